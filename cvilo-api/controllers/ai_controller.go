@@ -1,10 +1,9 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
-	"os"
 	"strconv"
 	"time"
 
@@ -15,157 +14,326 @@ import (
 )
 
 type AIController struct {
-	aiService           *services.AIService
-	githubModelsService *services.GitHubModelsService
-	useGitHubModels     bool
+	aiService *services.AIService
 }
 
 func NewAIController() *AIController {
-	// Check if GitHub Models should be used (for prototype testing)
-	useGitHubModels := os.Getenv("USE_GITHUB_MODELS") == "true"
-
 	return &AIController{
-		aiService:           services.NewAIService(),
-		githubModelsService: services.NewGitHubModelsService(),
-		useGitHubModels:     useGitHubModels,
+		aiService: services.NewAIService(),
+	}
+}
+
+// aiError answers a failed AI request, with 503 when no provider is configured
+func aiError(c *gin.Context, message string, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, services.ErrLLMNotConfigured) {
+		status = http.StatusServiceUnavailable
 	}
+	c.JSON(status, gin.H{"error": message + ": " + err.Error()})
 }
 
 // GenerateResumeFromPrompt creates a new resume using AI based on a text prompt
 func (ac *AIController) GenerateResumeFromPrompt(c *gin.Context) {
-	var request services.AIResumeRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	request, ok := bindGenerateRequest(c)
+	if !ok {
 		return
 	}
 
-	// Validate required fields
-	if request.Prompt == "" || request.UserID == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Prompt and user_id are required"})
+	// Generate resume using the configured AI providers
+	aiResponse, usedProvider, err := ac.aiService.GenerateResumeFromPrompt(c.Request.Context(), request)
+	if err != nil {
+		aiError(c, "Failed to generate resume", err)
 		return
 	}
 
-	// Check if user exists
-	var user models.UserModel
-	if err := user.GetUserByID(request.UserID); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "User not found"})
+	resume, err := ac.saveGeneratedResume(c, request, aiResponse, usedProvider)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save resume: " + err.Error()})
 		return
 	}
 
-	// Set default template and theme if not provided
-	if request.Template == "" {
-		request.Template = "modern"
+	utils.Success(c, "Resume generated successfully using AI", gin.H{
+		"resume":      resume,
+		"ai_response": aiResponse,
+		"provider":    usedProvider,
+	})
+}
+
+// GenerateResumeFromPromptStream works like GenerateResumeFromPrompt but streams the generation as server-sent
+// events: progress events, a section event for every part of the resume the model has written and finally the
+// saved resume, or an error event. Closing the connection cancels the request to the AI provider.
+func (ac *AIController) GenerateResumeFromPromptStream(c *gin.Context) {
+	request, ok := bindGenerateRequest(c)
+	if !ok {
+		return
 	}
-	if request.Theme == "" {
-		request.Theme = "blue"
+
+	events := newSSEWriter(c)
+	defer events.Close()
+	events.Send("progress", gin.H{"stage": "started"})
+
+	aiResponse, usedProvider, err := ac.aiService.GenerateResumeFromPromptStream(c.Request.Context(), request, events.AIStreamHandler())
+	if err != nil {
+		events.Error("Failed to generate resume", err)
+		return
 	}
 
-	// Generate resume title based on prompt and timestamp
-	title := "AI Generated Resume - " + time.Now().Format("2006-01-02 15:04")
+	events.Send("progress", gin.H{"stage": "saving", "provider": usedProvider})
+	resume, err := ac.saveGeneratedResume(c, request, aiResponse, usedProvider)
+	if err != nil {
+		events.Send("error", gin.H{"error": "Failed to save resume: " + err.Error()})
+		return
+	}
 
-	// Generate resume using AI (GitHub Models for prototype testing, OpenAI for production)
-	var aiResponse *services.AIResumeResponse
-	var err error
-	var usedProvider string
+	events.Send("resume", gin.H{
+		"resume":      resume,
+		"ai_response": aiResponse,
+		"provider":    usedProvider,
+	})
+}
 
-	if ac.useGitHubModels && ac.githubModelsService.IsConfigured() {
-		// Try GitHub Models first
-		aiResponse, err = ac.githubModelsService.GenerateResumeFromPrompt(request)
-		if err != nil {
-			log.Printf("GitHub Models failed, falling back to OpenAI: %v", err)
-			// Fallback to OpenAI if GitHub Models fails
-			if ac.aiService.IsConfigured() {
-				aiResponse, err = ac.aiService.GenerateResumeFromPrompt(request)
-				usedProvider = "openai (fallback)"
-				if err != nil {
-					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate resume with both providers: " + err.Error()})
-					return
-				}
-			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "GitHub Models failed and OpenAI is not configured: " + err.Error()})
-				return
-			}
-		} else {
-			usedProvider = "github_models"
-		}
-	} else {
-		// Use OpenAI directly
-		aiResponse, err = ac.aiService.GenerateResumeFromPrompt(request)
-		usedProvider = "openai"
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate resume: " + err.Error()})
-			return
-		}
+// UpdateResumeFromPrompt updates an existing resume using AI based on a text prompt
+func (ac *AIController) UpdateResumeFromPrompt(c *gin.Context) {
+	request, existingResume, _, ok := bindUpdateRequest(c)
+	if !ok {
+		return
 	}
 
-	// Convert AI response to ResumeModel
-	resume, err := ac.aiService.ConvertAIResponseToResume(aiResponse, request.UserID, title)
+	// Update resume using the configured AI providers
+	aiResponse, usedProvider, err := ac.aiService.UpdateResumeFromPrompt(c.Request.Context(), request, existingResume)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to convert AI response: " + err.Error()})
+		aiError(c, "Failed to update resume", err)
 		return
 	}
 
-	// Save the resume to database
-	if err := resume.Create(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save resume: " + err.Error()})
+	updatedResume, err := ac.saveUpdatedResume(c, request, &existingResume, aiResponse, usedProvider)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save updated resume: " + err.Error()})
 		return
 	}
 
-	// Save chat prompt history
-	responseSummary := fmt.Sprintf("Generated resume with %d experience entries, %d education entries, %d skills",
-		len(aiResponse.Experience), len(aiResponse.Education), len(aiResponse.Skills))
+	utils.Success(c, "Resume updated successfully using AI", gin.H{
+		"resume":      updatedResume,
+		"ai_response": aiResponse,
+		"provider":    usedProvider,
+	})
+}
 
-	if err := ac.aiService.SaveChatPromptHistory(
-		resume.ID,
-		request.UserID,
-		request.Prompt,
-		responseSummary,
-		usedProvider,
-		"success",
-	); err != nil {
-		log.Printf("Warning: Failed to save chat prompt history: %v", err)
-		// Continue even if history saving fails
+// UpdateResumeFromPromptStream works like UpdateResumeFromPrompt but streams the update as server-sent events,
+// see GenerateResumeFromPromptStream
+func (ac *AIController) UpdateResumeFromPromptStream(c *gin.Context) {
+	request, existingResume, _, ok := bindUpdateRequest(c)
+	if !ok {
+		return
 	}
 
-	utils.Success(c, "Resume generated successfully using AI", gin.H{
-		"resume":      resume,
+	events := newSSEWriter(c)
+	defer events.Close()
+	events.Send("progress", gin.H{"stage": "started"})
+
+	aiResponse, usedProvider, err := ac.aiService.UpdateResumeFromPromptStream(c.Request.Context(), request, existingResume, events.AIStreamHandler())
+	if err != nil {
+		events.Error("Failed to update resume", err)
+		return
+	}
+
+	events.Send("progress", gin.H{"stage": "saving", "provider": usedProvider})
+	updatedResume, err := ac.saveUpdatedResume(c, request, &existingResume, aiResponse, usedProvider)
+	if err != nil {
+		events.Send("error", gin.H{"error": "Failed to save updated resume: " + err.Error()})
+		return
+	}
+
+	events.Send("resume", gin.H{
+		"resume":      updatedResume,
 		"ai_response": aiResponse,
 		"provider":    usedProvider,
 	})
 }
 
-// UpdateResumeFromPrompt updates an existing resume using AI based on a text prompt
-func (ac *AIController) UpdateResumeFromPrompt(c *gin.Context) {
-	var request services.AIResumeRequest
+// PatchResumeFromPrompt asks the AI for the change a prompt requests as a JSON Patch against an existing resume and
+// previews it: the operations, the patched resume and the changed sections. Nothing is saved; the patch is applied
+// with ApplyResumePatch.
+func (ac *AIController) PatchResumeFromPrompt(c *gin.Context) {
+	request, existingResume, baseVersion, ok := bindUpdateRequest(c)
+	if !ok {
+		return
+	}
+
+	preview, usedProvider, err := ac.aiService.PatchResumeFromPrompt(c.Request.Context(), request, existingResume)
+	if err != nil {
+		aiError(c, "Failed to patch resume", err)
+		return
+	}
+
+	// The prompt is recorded here, so applying the patch links the version to it without trusting the client
+	responseSummary := preview.Explanation
+	if responseSummary == "" {
+		responseSummary = fmt.Sprintf("Proposed changes to %d sections", len(preview.Changes))
+	}
+	chatPrompt := ac.aiService.NewChatPromptHistory(request.UserID, request.Prompt, responseSummary, usedProvider)
+	chatPrompt.ResumeID = existingResume.ID
+	if err := chatPrompt.Create(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save chat prompt history: " + err.Error()})
+		return
+	}
+
+	utils.Success(c, "Resume patch generated using AI", gin.H{
+		"resume_id":      existingResume.ID,
+		"base_version":   baseVersion,
+		"chat_prompt_id": chatPrompt.ID,
+		"patch":          preview,
+		"provider":       usedProvider,
+	})
+}
+
+// applyPatchRequest is the body of ApplyResumePatch
+type applyPatchRequest struct {
+	UserID       uint                          `json:"user_id"`
+	ResumeID     uint                          `json:"resume_id" binding:"required"`
+	Operations   []services.JSONPatchOperation `json:"operations" binding:"required"`
+	BaseVersion  *int                          `json:"base_version" binding:"required"` // version the patch was previewed on
+	ChatPromptID uint                          `json:"chat_prompt_id,omitempty"`        // chat prompt history entry of the preview
+}
+
+// ApplyResumePatch validates a JSON Patch against the current content of a resume and saves the result as a new
+// version. Only the changed fields and sections are written. The patch is applied to the locked resume and refused
+// with 409 when the resume has a newer version than the one it was previewed on, so it never applies to content it
+// was not written for. A patch previewed with a prompt is linked to the chat prompt history entry of the preview.
+func (ac *AIController) ApplyResumePatch(c *gin.Context) {
+	var request applyPatchRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	userID := requestUserID(c, request.UserID)
 
-	// Validate required fields
-	if request.Prompt == "" || request.UserID == 0 || request.ResumeID == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Prompt, user_id, and resume_id are required"})
+	// Resumes of other users are reported as not found
+	var resume models.ResumeModel
+	if err := resume.GetResumeByID(request.ResumeID); err != nil || resume.UserID != userID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Resume not found"})
+		return
+	}
+
+	// A patch of a preview is an AI change, any other patch is a manual edit
+	change := models.ResumeChange{AuthorID: userID, Source: models.VersionSourceManual}
+	if request.ChatPromptID != 0 {
+		var chatPrompt models.ChatPromptHistory
+		if err := chatPrompt.GetByID(request.ChatPromptID); err != nil || chatPrompt.UserID != userID || chatPrompt.ResumeID != resume.ID {
+			utils.ValidationError(c, "Invalid chat prompt", []utils.ErrorDetail{
+				{Field: "chat_prompt_id", Message: "is not a prompt of this resume", Code: "invalid_chat_prompt"},
+			})
+			return
+		}
+		change.Source = models.VersionSourceAI
+		change.ChatPrompt = &chatPrompt
+	}
+
+	var preview *services.ResumePatchPreview
+	before := resume.AuditSummary()
+	version, err := resume.ApplyEdit(*request.BaseVersion, func(stored *models.ResumeSnapshot) (*models.ResumeSnapshot, error) {
+		var err error
+		preview, err = services.PreviewResumePatch(stored, request.Operations)
+		if err != nil {
+			return nil, err
+		}
+		return preview.Resume, nil
+	}, change)
+	if err != nil {
+		applyPatchError(c, err)
 		return
 	}
+	recordAIAudit(c, models.AuditActionAIResumePatched, before, &resume, change)
+
+	utils.Success(c, "Resume patch applied", gin.H{
+		"resume":  resume,
+		"version": version.Version,
+		"changes": preview.Changes,
+	})
+}
+
+// applyPatchError answers a patch that could not be applied: 409 when the resume has a newer version than the one
+// the patch was previewed on, 422 when the patch does not apply to the resume
+func applyPatchError(c *gin.Context, err error) {
+	var conflict *models.ResumeVersionConflictError
+	var patchErr *services.ResumePatchError
+	switch {
+	case errors.As(err, &conflict):
+		c.JSON(http.StatusConflict, gin.H{"error": "Resume has changed since the patch was previewed", "version": conflict.Latest})
+	case errors.As(err, &patchErr):
+		details := make([]utils.ErrorDetail, 0, len(patchErr.Problems))
+		for _, problem := range patchErr.Problems {
+			details = append(details, utils.ErrorDetail{Field: "operations", Message: problem, Code: "invalid_patch"})
+		}
+		utils.ValidationError(c, "Invalid patch", details)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save patched resume: " + err.Error()})
+	}
+}
+
+// bindGenerateRequest reads the request of the generate endpoints and answers it when it is invalid
+func bindGenerateRequest(c *gin.Context) (services.AIResumeRequest, bool) {
+	var request services.AIResumeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return request, false
+	}
+
+	request.UserID = requestUserID(c, request.UserID)
+
+	// Validate required fields
+	if request.Prompt == "" || request.UserID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Prompt is required"})
+		return request, false
+	}
 
 	// Check if user exists
 	var user models.UserModel
 	if err := user.GetUserByID(request.UserID); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "User not found"})
-		return
+		return request, false
 	}
 
-	// Get existing resume
+	// Set default template and theme if not provided
+	if request.Template == "" {
+		request.Template = "modern"
+	}
+	if request.Theme == "" {
+		request.Theme = "blue"
+	}
+	return request, true
+}
+
+// bindUpdateRequest reads the request of the update endpoints with the resume to update and the number of its latest
+// version, and answers it when it is invalid
+func bindUpdateRequest(c *gin.Context) (services.AIResumeRequest, models.ResumeModel, int, bool) {
+	var request services.AIResumeRequest
 	var existingResume models.ResumeModel
-	if err := existingResume.GetResumeByID(*request.ResumeID); err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Resume not found"})
-		return
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return request, existingResume, 0, false
 	}
 
-	// Verify that the resume belongs to the user
-	if existingResume.UserID != request.UserID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You can only update your own resumes"})
-		return
+	request.UserID = requestUserID(c, request.UserID)
+
+	// Validate required fields
+	if request.Prompt == "" || request.UserID == 0 || request.ResumeID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Prompt and resume_id are required"})
+		return request, existingResume, 0, false
+	}
+
+	// Check if user exists
+	var user models.UserModel
+	if err := user.GetUserByID(request.UserID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User not found"})
+		return request, existingResume, 0, false
+	}
+
+	// Get existing resume; resumes of other users are reported as not found
+	baseVersion, err := existingResume.GetResumeForEdit(*request.ResumeID)
+	if err != nil || existingResume.UserID != request.UserID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Resume not found"})
+		return request, existingResume, 0, false
 	}
 
 	// Set default template and theme if not provided
@@ -175,30 +343,43 @@ func (ac *AIController) UpdateResumeFromPrompt(c *gin.Context) {
 	if request.Theme == "" {
 		request.Theme = existingResume.Theme
 	}
+	return request, existingResume, baseVersion, true
+}
 
-	// Update resume using AI (GitHub Models for prototype testing, OpenAI for production)
-	var aiResponse *services.AIResumeResponse
-	var err error
+// saveGeneratedResume saves a resume generated from a prompt, with the prompt as the source of its first version
+func (ac *AIController) saveGeneratedResume(c *gin.Context, request services.AIResumeRequest, aiResponse *services.AIResumeResponse, usedProvider string) (*models.ResumeModel, error) {
+	// Generate resume title based on prompt and timestamp
+	title := "AI Generated Resume - " + time.Now().Format("2006-01-02 15:04")
 
-	if ac.useGitHubModels && ac.githubModelsService.IsConfigured() {
-		aiResponse, err = ac.githubModelsService.UpdateResumeFromPrompt(request, existingResume)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update resume with GitHub Models: " + err.Error()})
-			return
-		}
-	} else {
-		aiResponse, err = ac.aiService.UpdateResumeFromPrompt(request, existingResume)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update resume: " + err.Error()})
-			return
-		}
+	// Convert AI response to ResumeModel
+	resume, err := ac.aiService.ConvertAIResponseToResume(aiResponse, request.UserID, title)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert AI response: %v", err)
+	}
+
+	responseSummary := fmt.Sprintf("Generated resume with %d experience entries, %d education entries, %d skills",
+		len(aiResponse.Experience), len(aiResponse.Education), len(aiResponse.Skills))
+	change := models.ResumeChange{
+		AuthorID:   request.UserID,
+		Source:     models.VersionSourceAI,
+		ChatPrompt: ac.aiService.NewChatPromptHistory(request.UserID, request.Prompt, responseSummary, usedProvider),
 	}
+	if err := resume.CreateWithChange(change); err != nil {
+		return nil, err
+	}
+	recordAIAudit(c, models.AuditActionAIResumeGenerated, nil, resume, change)
+	return resume, nil
+}
+
+// saveUpdatedResume replaces the content of an existing resume with the AI response, saving the prompt with the
+// new version
+func (ac *AIController) saveUpdatedResume(c *gin.Context, request services.AIResumeRequest, existingResume *models.ResumeModel, aiResponse *services.AIResumeResponse, usedProvider string) (*models.ResumeModel, error) {
+	before := existingResume.AuditSummary()
 
 	// Convert AI response to ResumeModel
 	updatedResume, err := ac.aiService.ConvertAIResponseToResume(aiResponse, request.UserID, existingResume.Title)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to convert AI response: " + err.Error()})
-		return
+		return nil, fmt.Errorf("failed to convert AI response: %v", err)
 	}
 
 	// Update the existing resume
@@ -206,31 +387,18 @@ func (ac *AIController) UpdateResumeFromPrompt(c *gin.Context) {
 	updatedResume.CreatedAt = existingResume.CreatedAt
 	updatedResume.UpdatedAt = time.Now()
 
-	if err := existingResume.UpdateResume(*request.ResumeID, *updatedResume); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save updated resume: " + err.Error()})
-		return
-	}
-
-	// Save chat prompt history
 	responseSummary := fmt.Sprintf("Updated resume with %d experience entries, %d education entries, %d skills",
 		len(aiResponse.Experience), len(aiResponse.Education), len(aiResponse.Skills))
-
-	if err := ac.aiService.SaveChatPromptHistory(
-		*request.ResumeID,
-		request.UserID,
-		request.Prompt,
-		responseSummary,
-		"openai", // Default provider for updates
-		"success",
-	); err != nil {
-		log.Printf("Warning: Failed to save chat prompt history: %v", err)
-		// Continue even if history saving fails
+	change := models.ResumeChange{
+		AuthorID:   request.UserID,
+		Source:     models.VersionSourceAI,
+		ChatPrompt: ac.aiService.NewChatPromptHistory(request.UserID, request.Prompt, responseSummary, usedProvider),
 	}
-
-	utils.Success(c, "Resume updated successfully using AI", gin.H{
-		"resume":      updatedResume,
-		"ai_response": aiResponse,
-	})
+	if err := existingResume.UpdateResume(existingResume.ID, *updatedResume, change); err != nil {
+		return nil, err
+	}
+	recordAIAudit(c, models.AuditActionAIResumeUpdated, before, existingResume, change)
+	return updatedResume, nil
 }
 
 // GenerateResumeFromPromptWithID creates a new resume using AI based on a text prompt (alternative endpoint)
@@ -275,25 +443,15 @@ func (ac *AIController) GenerateResumeFromPromptWithID(c *gin.Context) {
 		aiRequest.Theme = "blue"
 	}
 
-	// Generate resume title based on prompt and timestamp
-	title := "AI Generated Resume - " + time.Now().Format("2006-01-02 15:04")
-
-	// Generate resume using AI
-	aiResponse, err := ac.aiService.GenerateResumeFromPrompt(aiRequest)
+	// Generate resume using the configured AI providers
+	aiResponse, usedProvider, err := ac.aiService.GenerateResumeFromPrompt(c.Request.Context(), aiRequest)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate resume: " + err.Error()})
+		aiError(c, "Failed to generate resume", err)
 		return
 	}
 
-	// Convert AI response to ResumeModel
-	resume, err := ac.aiService.ConvertAIResponseToResume(aiResponse, uint(userID), title)
+	resume, err := ac.saveGeneratedResume(c, aiRequest, aiResponse, usedProvider)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to convert AI response: " + err.Error()})
-		return
-	}
-
-	// Save the resume to database
-	if err := resume.Create(); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save resume: " + err.Error()})
 		return
 	}
@@ -301,6 +459,7 @@ func (ac *AIController) GenerateResumeFromPromptWithID(c *gin.Context) {
 	utils.Success(c, "Resume generated successfully using AI", gin.H{
 		"resume":      resume,
 		"ai_response": aiResponse,
+		"provider":    usedProvider,
 	})
 }
 
@@ -343,9 +502,9 @@ func (ac *AIController) UpdateResumeFromPromptWithID(c *gin.Context) {
 		return
 	}
 
-	// Verify that the resume belongs to the user
+	// The resume must belong to the user in the path
 	if existingResume.UserID != uint(userID) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You can only update your own resumes"})
+		c.JSON(http.StatusNotFound, gin.H{"error": "Resume not found"})
 		return
 	}
 
@@ -366,26 +525,15 @@ func (ac *AIController) UpdateResumeFromPromptWithID(c *gin.Context) {
 		aiRequest.Theme = existingResume.Theme
 	}
 
-	// Update resume using AI
-	aiResponse, err := ac.aiService.UpdateResumeFromPrompt(aiRequest, existingResume)
+	// Update resume using the configured AI providers
+	aiResponse, usedProvider, err := ac.aiService.UpdateResumeFromPrompt(c.Request.Context(), aiRequest, existingResume)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update resume: " + err.Error()})
+		aiError(c, "Failed to update resume", err)
 		return
 	}
 
-	// Convert AI response to ResumeModel
-	updatedResume, err := ac.aiService.ConvertAIResponseToResume(aiResponse, uint(userID), existingResume.Title)
+	updatedResume, err := ac.saveUpdatedResume(c, aiRequest, &existingResume, aiResponse, usedProvider)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to convert AI response: " + err.Error()})
-		return
-	}
-
-	// Update the existing resume
-	updatedResume.ID = existingResume.ID
-	updatedResume.CreatedAt = existingResume.CreatedAt
-	updatedResume.UpdatedAt = time.Now()
-
-	if err := existingResume.UpdateResume(uint(resumeID), *updatedResume); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save updated resume: " + err.Error()})
 		return
 	}
@@ -393,39 +541,56 @@ func (ac *AIController) UpdateResumeFromPromptWithID(c *gin.Context) {
 	utils.Success(c, "Resume updated successfully using AI", gin.H{
 		"resume":      updatedResume,
 		"ai_response": aiResponse,
+		"provider":    usedProvider,
+	})
+}
+
+// recordAIAudit writes the audit event of a resume generated or updated from a prompt. The prompt itself is kept
+// in the chat history the event links to.
+func recordAIAudit(c *gin.Context, action string, before map[string]interface{}, resume *models.ResumeModel, change models.ResumeChange) {
+	details := map[string]interface{}{}
+	if change.ChatPrompt != nil {
+		details["provider"] = change.ChatPrompt.Provider
+		details["chat_prompt_history_id"] = change.ChatPrompt.ID
+	}
+	recordAudit(c, services.AuditEvent{
+		Action:     action,
+		TargetType: models.AuditTargetResume,
+		TargetID:   auditID(resume.ID),
+		Before:     before,
+		After:      resume.AuditSummary(),
+		Details:    details,
 	})
 }
 
-// GetAIServiceStatus returns the status of the AI service
+// GetAIServiceStatus returns the status of the AI service and its provider chain
 func (ac *AIController) GetAIServiceStatus(c *gin.Context) {
+	chain := ac.aiService.Providers()
 	status := "disabled"
-	openaiConfigured := false
-	githubModelsConfigured := false
-	activeProvider := "none"
-
-	if ac.aiService != nil {
-		openaiConfigured = ac.aiService.IsConfigured()
-	}
-
-	if ac.githubModelsService != nil {
-		githubModelsConfigured = ac.githubModelsService.IsConfigured()
+	activeProvider := chain.Active()
+	if activeProvider != "" {
+		status = "enabled"
+	} else {
+		activeProvider = "none"
 	}
 
-	// Determine active provider
-	if ac.useGitHubModels && githubModelsConfigured {
-		status = "enabled"
-		activeProvider = "github_models"
-	} else if openaiConfigured {
-		status = "enabled"
-		activeProvider = "openai"
+	providers := chain.Status()
+	configured := func(name string) bool {
+		for _, provider := range providers {
+			if provider.Name == name {
+				return provider.Configured
+			}
+		}
+		return false
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"status":                   status,
 		"message":                  "AI service status",
-		"openai_configured":        openaiConfigured,
-		"github_models_configured": githubModelsConfigured,
+		"providers":                providers,
 		"active_provider":          activeProvider,
-		"use_github_models":        ac.useGitHubModels,
+		"openai_configured":        configured(services.LLMProviderOpenAI),
+		"github_models_configured": configured(services.LLMProviderGitHubModels),
+		"use_github_models":        configured(services.LLMProviderGitHubModels),
 	})
 }
@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/smhnaqvi/cvilo/models"
+	"github.com/smhnaqvi/cvilo/services"
+)
+
+func TestApplyPatchError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{"Version recorded between the preview and the apply", &models.ResumeVersionConflictError{Latest: 8}, http.StatusConflict},
+		{"Invalid patch", &services.ResumePatchError{Problems: []string{"/experience/3: no such entry"}}, http.StatusUnprocessableEntity},
+		{"Database error", errors.New("connection reset"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/ai/patch/apply", nil)
+			applyPatchError(c, tt.err)
+			if w.Code != tt.expected {
+				t.Errorf("applyPatchError(%v) = %d, want %d", tt.err, w.Code, tt.expected)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/ai/patch/apply", nil)
+	applyPatchError(c, &models.ResumeVersionConflictError{Latest: 8})
+	var body struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Version != 8 {
+		t.Errorf("conflict body = %s, want the latest version 8", w.Body.String())
+	}
+}
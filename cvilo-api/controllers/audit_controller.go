@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/smhnaqvi/cvilo/models"
+	"github.com/smhnaqvi/cvilo/services"
+	"github.com/smhnaqvi/cvilo/utils"
+)
+
+// auditExportBatchSize is the number of entries loaded at a time for a CSV export
+const auditExportBatchSize = 500
+
+type AuditController struct{}
+
+func NewAuditController() *AuditController {
+	return &AuditController{}
+}
+
+// recordAudit writes an audit event caused by the request. The authenticated user is the actor unless the event
+// names one; the IP address, user agent and request ID are taken from the request.
+func recordAudit(c *gin.Context, event services.AuditEvent) {
+	if event.ActorID == nil {
+		if userID := c.GetUint("user_id"); userID != 0 {
+			event.ActorID = &userID
+		}
+	}
+	event.Client = clientInfo(c)
+	services.RecordAudit(event)
+}
+
+// auditID formats the ID of an audit target
+func auditID(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// GetAuditLogs lists the audit log, newest first, filtered by actor_id, action (auth.* for all actions of an
+// area), target_type, target_id, ip, request_id, from and to (RFC 3339 or YYYY-MM-DD). With format=csv all
+// matching entries are exported as a CSV file instead of a page (admin only).
+func (ac *AuditController) GetAuditLogs(c *gin.Context) {
+	filter, details := parseAuditFilter(c)
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		details = append(details, utils.ErrorDetail{Field: "format", Message: "must be json or csv", Code: "invalid_format"})
+	}
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		details = append(details, utils.ErrorDetail{Field: "page", Message: "must be a positive number", Code: "invalid_page"})
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 500 {
+		details = append(details, utils.ErrorDetail{Field: "limit", Message: "must be between 1 and 500", Code: "invalid_limit"})
+	}
+	if len(details) > 0 {
+		utils.ValidationError(c, "Invalid audit log filter", details)
+		return
+	}
+
+	var auditLog models.AuditLogModel
+	if format == "csv" {
+		filename := fmt.Sprintf("audit-log-%s.csv", time.Now().UTC().Format("20060102-150405"))
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+		writer := services.NewAuditCSVWriter(c.Writer)
+		err := auditLog.EachBatch(filter, auditExportBatchSize, writer.Write)
+		if err == nil {
+			// An empty export still gets its header row
+			err = writer.Write(nil)
+		}
+		if err != nil {
+			// The status and part of the file may already be sent, so the error can only be logged
+			log.Printf("GetAuditLogs: CSV export failed: %v", err)
+			c.Abort()
+		}
+		return
+	}
+
+	filter.Offset = (page - 1) * limit
+	filter.Limit = limit
+	entries, total, err := auditLog.Search(filter)
+	if err != nil {
+		utils.InternalError(c, "Failed to retrieve audit log", err.Error())
+		return
+	}
+
+	utils.Paginated(c, "Audit log retrieved successfully", entries, utils.CreatePaginationInfo(page, limit, total))
+}
+
+// parseAuditFilter reads the audit log filter from the query string
+func parseAuditFilter(c *gin.Context) (models.AuditLogFilter, []utils.ErrorDetail) {
+	filter := models.AuditLogFilter{
+		Action:     strings.TrimSpace(c.Query("action")),
+		TargetType: strings.TrimSpace(c.Query("target_type")),
+		TargetID:   strings.TrimSpace(c.Query("target_id")),
+		IPAddress:  strings.TrimSpace(c.Query("ip")),
+		RequestID:  strings.TrimSpace(c.Query("request_id")),
+	}
+
+	var details []utils.ErrorDetail
+	if value := c.Query("actor_id"); value != "" {
+		actorID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			details = append(details, utils.ErrorDetail{Field: "actor_id", Message: "must be a user ID", Code: "invalid_actor_id"})
+		} else {
+			id := uint(actorID)
+			filter.ActorID = &id
+		}
+	}
+	for _, param := range []struct {
+		name  string
+		value **time.Time
+	}{
+		{"from", &filter.From},
+		{"to", &filter.To},
+	} {
+		value := c.Query(param.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := parseAuditTime(value)
+		if err != nil {
+			details = append(details, utils.ErrorDetail{Field: param.name, Message: "must be an RFC 3339 time or a YYYY-MM-DD date", Code: "invalid_time"})
+			continue
+		}
+		*param.value = &parsed
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		details = append(details, utils.ErrorDetail{Field: "to", Message: "must be after from", Code: "invalid_range"})
+	}
+	return filter, details
+}
+
+// parseAuditTime parses an RFC 3339 time or a date, which means midnight UTC
+func parseAuditTime(value string) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
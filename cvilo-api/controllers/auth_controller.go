@@ -1,7 +1,11 @@
 package controllers
 
 import (
+	"errors"
 	"log"
+	"math"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -37,19 +41,66 @@ func (ac *AuthController) Login(c *gin.Context) {
 		return
 	}
 
+	// Refuse attempts while the account or IP backs off from failed logins; unknown emails are throttled alike
+	client := clientInfo(c)
+	var throttled *services.LoginThrottledError
+	if err := ac.authService.CheckLoginAllowed(loginReq.Email, client); errors.As(err, &throttled) {
+		retryAfter := int(math.Ceil(throttled.RetryAfter.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		utils.Error(c, utils.CodeTooManyRequests, "Too many failed login attempts, please retry later", gin.H{
+			"retry_after": retryAfter,
+		})
+		return
+	} else if err != nil {
+		log.Printf("Login: failed to check login throttling: %v", err)
+	}
+
 	// Authenticate user
 	user, err := ac.authService.AuthenticateUser(loginReq.Email, loginReq.Password)
+	if errors.Is(err, services.ErrAccountDeactivated) {
+		utils.Forbidden(c, "Account is deactivated")
+		return
+	}
 	if err != nil {
-		utils.Unauthorized(c, "Authentication failed")
+		ac.authService.RecordLoginFailure(loginReq.Email, client)
+		recordAudit(c, services.AuditEvent{
+			Action:     models.AuditActionLoginFailed,
+			TargetType: models.AuditTargetAccount,
+			TargetID:   strings.ToLower(strings.TrimSpace(loginReq.Email)),
+		})
+		utils.Unauthorized(c, "Invalid email or password")
+		return
+	}
+
+	// With two-factor authentication the tokens are issued by /auth/2fa/verify, which also ends the failed logins
+	if user.TwoFactorEnabled {
+		challenge, err := ac.authService.NewMFAChallenge(*user)
+		if err != nil {
+			utils.InternalError(c, "Failed to start two-factor authentication", err.Error())
+			return
+		}
+		utils.Success(c, "Two-factor authentication required", gin.H{
+			"mfa_required": true,
+			"mfa_token":    challenge.Token,
+			"expires_at":   challenge.ExpiresAt,
+		})
 		return
 	}
+	ac.authService.RecordLoginSuccess(loginReq.Email)
 
 	// Generate token pair
-	tokenPair, err := ac.authService.GenerateTokenPair(*user)
+	tokenPair, err := ac.authService.GenerateTokenPair(*user, client)
 	if err != nil {
 		utils.InternalError(c, "Failed to generate tokens", err.Error())
 		return
 	}
+	recordAudit(c, services.AuditEvent{
+		ActorID:    &user.ID,
+		Action:     models.AuditActionLogin,
+		TargetType: models.AuditTargetUser,
+		TargetID:   auditID(user.ID),
+		Details:    map[string]interface{}{"method": "password"},
+	})
 
 	// Return token pair and user data
 
@@ -83,8 +134,21 @@ func (ac *AuthController) Register(c *gin.Context) {
 		return
 	}
 
+	// Registration succeeds even when the mail cannot be sent; the user can ask for it again
+	if err := ac.authService.SendVerificationEmail(*user); err != nil {
+		log.Printf("Register: failed to send verification email to user %d: %v", user.ID, err)
+	}
+
+	recordAudit(c, services.AuditEvent{
+		ActorID:    &user.ID,
+		Action:     models.AuditActionRegister,
+		TargetType: models.AuditTargetUser,
+		TargetID:   auditID(user.ID),
+		After:      user.AuditSummary(),
+	})
+
 	// Generate token pair
-	tokenPair, err := ac.authService.GenerateTokenPair(*user)
+	tokenPair, err := ac.authService.GenerateTokenPair(*user, clientInfo(c))
 	if err != nil {
 		utils.InternalError(c, "Failed to generate tokens", err.Error())
 		return
@@ -143,53 +207,165 @@ func (ac *AuthController) ChangePassword(c *gin.Context) {
 		utils.BadRequest(c, "Password change failed", err.Error())
 		return
 	}
+	recordAudit(c, services.AuditEvent{
+		Action:     models.AuditActionPasswordChanged,
+		TargetType: models.AuditTargetUser,
+		TargetID:   auditID(userID.(uint)),
+	})
 
 	utils.Success(c, "Password changed successfully", gin.H{"message": "Password changed successfully"})
 }
 
-// RefreshToken handles token refresh using refresh token
-func (ac *AuthController) RefreshToken(c *gin.Context) {
-	var refreshReq struct {
-		RefreshToken string `json:"refresh_token" validate:"required"`
+// ForgotPassword mails a password reset link. The response is the same, and is sent as fast, whether or not the
+// email belongs to an account.
+func (ac *AuthController) ForgotPassword(c *gin.Context) {
+	var forgotReq models.ForgotPasswordRequest
+
+	if err := c.ShouldBindJSON(&forgotReq); err != nil {
+		utils.BadRequest(c, "Invalid request body", err.Error())
+		return
 	}
 
-	if err := c.ShouldBindJSON(&refreshReq); err != nil {
+	// Validate request
+	if err := ac.validate.Struct(forgotReq); err != nil {
+		utils.BadRequest(c, "Validation failed", err.Error())
+		return
+	}
+
+	// Requested in the background: looking up the account and sending the email would make the response slower for
+	// registered emails than for unknown ones
+	go func(email string) {
+		if err := ac.authService.RequestPasswordReset(email); err != nil {
+			log.Printf("ForgotPassword: failed to send password reset email: %v", err)
+		}
+	}(forgotReq.Email)
+
+	utils.Success(c, "If an account exists for this email, a password reset link has been sent", nil)
+}
+
+// ResetPassword sets a new password with a token from a password reset email and ends all sessions of the user
+func (ac *AuthController) ResetPassword(c *gin.Context) {
+	var resetReq models.ResetPasswordRequest
+
+	if err := c.ShouldBindJSON(&resetReq); err != nil {
 		utils.BadRequest(c, "Invalid request body", err.Error())
 		return
 	}
 
 	// Validate request
-	if err := ac.validate.Struct(refreshReq); err != nil {
+	if err := ac.validate.Struct(resetReq); err != nil {
 		utils.BadRequest(c, "Validation failed", err.Error())
 		return
 	}
 
-	// Validate refresh token
-	claims, err := ac.authService.ValidateJWT(refreshReq.RefreshToken)
+	user, err := ac.authService.ResetPassword(resetReq.Token, resetReq.NewPassword)
+	if errors.Is(err, models.ErrUserTokenInvalid) {
+		utils.BadRequest(c, "Invalid or expired reset token", err.Error())
+		return
+	}
 	if err != nil {
-		utils.Unauthorized(c, "Invalid refresh token")
+		utils.InternalError(c, "Failed to reset password", err.Error())
+		return
+	}
+	recordAudit(c, services.AuditEvent{
+		ActorID:    &user.ID,
+		Action:     models.AuditActionPasswordReset,
+		TargetType: models.AuditTargetUser,
+		TargetID:   auditID(user.ID),
+		Details:    map[string]interface{}{"sessions_revoked": true},
+	})
+
+	utils.Success(c, "Password reset successfully, please log in with the new password", nil)
+}
+
+// VerifyEmail confirms the email address of a user with a token from a verification email
+func (ac *AuthController) VerifyEmail(c *gin.Context) {
+	var verifyReq models.VerifyEmailRequest
+
+	if err := c.ShouldBindJSON(&verifyReq); err != nil {
+		utils.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := ac.validate.Struct(verifyReq); err != nil {
+		utils.BadRequest(c, "Validation failed", err.Error())
 		return
 	}
 
-	// log claims
-	log.Println(claims)
+	user, err := ac.authService.VerifyEmail(verifyReq.Token)
+	if errors.Is(err, models.ErrUserTokenInvalid) {
+		utils.BadRequest(c, "Invalid or expired verification token", err.Error())
+		return
+	}
+	if err != nil {
+		utils.InternalError(c, "Failed to verify email", err.Error())
+		return
+	}
+	recordAudit(c, services.AuditEvent{
+		ActorID:    &user.ID,
+		Action:     models.AuditActionEmailVerified,
+		TargetType: models.AuditTargetUser,
+		TargetID:   auditID(user.ID),
+		Details:    map[string]interface{}{"email": user.Email},
+	})
 
-	// Get user from database
+	utils.Success(c, "Email verified successfully", user.ToUserResponse())
+}
+
+// ResendVerification mails a new verification link to the authenticated user
+func (ac *AuthController) ResendVerification(c *gin.Context) {
 	var user models.UserModel
-	if err := user.GetUserByID(claims.UserID); err != nil {
-		log.Printf("Error getting user by id: %v", err)
+	if err := user.GetUserByID(c.GetUint("user_id")); err != nil {
 		utils.NotFound(c, "User not found")
 		return
 	}
 
-	// Generate new token pair
-	tokenPair, err := ac.authService.GenerateTokenPair(user)
+	err := ac.authService.SendVerificationEmail(user)
+	if errors.Is(err, services.ErrEmailAlreadyVerified) {
+		utils.Conflict(c, "Email is already verified", err.Error())
+		return
+	}
 	if err != nil {
-		utils.InternalError(c, "Failed to generate tokens", err.Error())
+		utils.InternalError(c, "Failed to send verification email", err.Error())
 		return
 	}
 
-	// Generate new token pair
+	utils.Success(c, "Verification email sent", gin.H{"email": user.Email})
+}
+
+// RefreshToken exchanges a refresh token for a new token pair. Refresh tokens are single use; presenting one
+// twice revokes the session it belongs to.
+func (ac *AuthController) RefreshToken(c *gin.Context) {
+	var refreshReq struct {
+		RefreshToken string `json:"refresh_token" validate:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&refreshReq); err != nil {
+		utils.BadRequest(c, "Invalid request body", err.Error())
+		return
+	}
+
+	// Validate request
+	if err := ac.validate.Struct(refreshReq); err != nil {
+		utils.BadRequest(c, "Validation failed", err.Error())
+		return
+	}
+
+	tokenPair, user, err := ac.authService.RefreshTokens(refreshReq.RefreshToken, clientInfo(c))
+	if errors.Is(err, models.ErrRefreshTokenReused) {
+		log.Printf("RefreshToken: reused refresh token from %s, session revoked", c.ClientIP())
+		utils.Unauthorized(c, "Refresh token was already used, the session has been revoked")
+		return
+	}
+	if errors.Is(err, services.ErrInvalidRefreshToken) {
+		utils.Unauthorized(c, "Invalid refresh token")
+		return
+	}
+	if err != nil {
+		utils.InternalError(c, "Failed to refresh tokens", err.Error())
+		return
+	}
 
 	utils.Success(c, "Tokens refreshed successfully", gin.H{
 		"access_token":  tokenPair.AccessToken,
@@ -199,6 +375,87 @@ func (ac *AuthController) RefreshToken(c *gin.Context) {
 	})
 }
 
+// Logout ends the session of the access token, revoking its refresh token
+func (ac *AuthController) Logout(c *gin.Context) {
+	sessionID := c.GetString("session_id")
+	if sessionID == "" {
+		utils.BadRequest(c, "Token is not bound to a session", "use /auth/logout-all to end all sessions")
+		return
+	}
+
+	if _, err := ac.authService.Logout(c.GetUint("user_id"), sessionID); err != nil {
+		utils.InternalError(c, "Failed to log out", err.Error())
+		return
+	}
+	recordAudit(c, services.AuditEvent{
+		Action:     models.AuditActionLogout,
+		TargetType: models.AuditTargetSession,
+		TargetID:   sessionID,
+	})
+
+	utils.Success(c, "Logged out successfully", gin.H{"session_id": sessionID})
+}
+
+// LogoutAll ends every session of the authenticated user
+func (ac *AuthController) LogoutAll(c *gin.Context) {
+	revoked, err := ac.authService.LogoutAll(c.GetUint("user_id"))
+	if err != nil {
+		utils.InternalError(c, "Failed to log out", err.Error())
+		return
+	}
+	recordAudit(c, services.AuditEvent{
+		Action:     models.AuditActionLogoutAll,
+		TargetType: models.AuditTargetUser,
+		TargetID:   auditID(c.GetUint("user_id")),
+		Details:    map[string]interface{}{"revoked_sessions": revoked},
+	})
+
+	utils.Success(c, "Logged out of all sessions", gin.H{"revoked_sessions": revoked})
+}
+
+// GetSessions lists the open sessions of the authenticated user with their device and IP address
+func (ac *AuthController) GetSessions(c *gin.Context) {
+	sessions, err := ac.authService.GetSessions(c.GetUint("user_id"), c.GetString("session_id"))
+	if err != nil {
+		utils.InternalError(c, "Failed to retrieve sessions", err.Error())
+		return
+	}
+
+	utils.Success(c, "Sessions retrieved successfully", gin.H{
+		"sessions": sessions,
+		"count":    len(sessions),
+	})
+}
+
+// RevokeSession ends one session of the authenticated user, such as a lost device
+func (ac *AuthController) RevokeSession(c *gin.Context) {
+	revoked, err := ac.authService.Logout(c.GetUint("user_id"), c.Param("id"))
+	if err != nil {
+		utils.InternalError(c, "Failed to revoke session", err.Error())
+		return
+	}
+	if !revoked {
+		utils.NotFound(c, "Session not found")
+		return
+	}
+	recordAudit(c, services.AuditEvent{
+		Action:     models.AuditActionSessionRevoked,
+		TargetType: models.AuditTargetSession,
+		TargetID:   c.Param("id"),
+	})
+
+	utils.Success(c, "Session revoked successfully", gin.H{"session_id": c.Param("id")})
+}
+
+// clientInfo describes the client of a request for the session list and the audit log
+func clientInfo(c *gin.Context) services.ClientInfo {
+	return services.ClientInfo{
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+		RequestID: c.GetString("request_id"),
+	}
+}
+
 // VerifyToken verifies if a token is valid
 func (ac *AuthController) VerifyToken(c *gin.Context) {
 	authHeader := c.GetHeader("Authorization")
@@ -112,9 +112,11 @@ func (lc *LinkedInController) HandleCallback(c *gin.Context) {
 
 	log.Println("HandleCallback: Looking up existing user by email")
 	err = user.GetUserByEmail(email)
+	newUser := err != nil
 	if err != nil {
 		log.Printf("HandleCallback: User not found, creating new user with email: %s, error: %v", email, err)
-		// User doesn't exist, create new user
+		// User doesn't exist, create new user. LinkedIn only shares verified email addresses.
+		verifiedAt := time.Now()
 		user = &models.UserModel{
 			Name:     resume.FullName,
 			Email:    email,
@@ -123,6 +125,8 @@ func (lc *LinkedInController) HandleCallback(c *gin.Context) {
 			LinkedIn: resume.LinkedIn,
 			Step:     "profile",
 			IsActive: true,
+
+			EmailVerifiedAt: &verifiedAt,
 		}
 
 		if err := user.Create(); err != nil {
@@ -210,7 +214,7 @@ func (lc *LinkedInController) HandleCallback(c *gin.Context) {
 		log.Println("HandleCallback: Creating new resume from LinkedIn data")
 		resume.UserID = user.ID
 		resume.Title = "LinkedIn Profile - " + resume.FullName
-		if err := resume.Create(); err != nil {
+		if err := resume.CreateWithChange(models.ResumeChange{AuthorID: user.ID, Source: models.VersionSourceLinkedIn}); err != nil {
 			log.Printf("HandleCallback: ERROR - Failed to create resume from LinkedIn data: %v", err)
 			utils.InternalError(c, "Failed to create resume from LinkedIn data", err.Error())
 			return
@@ -250,7 +254,8 @@ func (lc *LinkedInController) HandleCallback(c *gin.Context) {
 			linkedInResume.Interests = resume.Interests
 			linkedInResume.References = resume.References
 
-			if err := linkedInResume.UpdateResume(linkedInResume.ID, *linkedInResume); err != nil {
+			change := models.ResumeChange{AuthorID: user.ID, Source: models.VersionSourceLinkedIn}
+			if err := linkedInResume.UpdateResume(linkedInResume.ID, *linkedInResume, change); err != nil {
 				log.Printf("HandleCallback: ERROR - Failed to update existing LinkedIn resume: %v", err)
 				utils.InternalError(c, "Failed to update existing LinkedIn resume", err.Error())
 				return
@@ -262,7 +267,7 @@ func (lc *LinkedInController) HandleCallback(c *gin.Context) {
 			log.Println("HandleCallback: Creating new LinkedIn resume (no existing LinkedIn resume found)")
 			resume.UserID = user.ID
 			resume.Title = "LinkedIn Profile - " + resume.FullName
-			if err := resume.Create(); err != nil {
+			if err := resume.CreateWithChange(models.ResumeChange{AuthorID: user.ID, Source: models.VersionSourceLinkedIn}); err != nil {
 				log.Printf("HandleCallback: ERROR - Failed to create resume from LinkedIn data: %v", err)
 				utils.InternalError(c, "Failed to create resume from LinkedIn data", err.Error())
 				return
@@ -302,14 +307,46 @@ func (lc *LinkedInController) HandleCallback(c *gin.Context) {
 		log.Println("HandleCallback: No user profile updates needed")
 	}
 
-	// generate jwt token pair
+	recordAudit(c, services.AuditEvent{
+		ActorID:    &user.ID,
+		Action:     models.AuditActionLinkedInConnected,
+		TargetType: models.AuditTargetUser,
+		TargetID:   auditID(user.ID),
+		Details: map[string]interface{}{
+			"linkedin_id": linkedInID,
+			"new_user":    newUser,
+			"resume_id":   resume.ID,
+		},
+	})
+
 	authService := services.NewAuthService()
-	tokenPair, err := authService.GenerateTokenPair(*user)
+
+	// users with two-factor authentication complete the login with /auth/2fa/verify
+	if user.TwoFactorEnabled {
+		challenge, err := authService.NewMFAChallenge(*user)
+		if err != nil {
+			log.Printf("HandleCallback: ERROR - Failed to start two-factor authentication: %v", err)
+			utils.InternalError(c, "Failed to start two-factor authentication", err.Error())
+			return
+		}
+		c.Redirect(http.StatusSeeOther, os.Getenv("REDIRECT_LINKEDIN_CLIENTAREA_URL")+"?mfa_token="+challenge.Token)
+		return
+	}
+
+	// generate jwt token pair
+	tokenPair, err := authService.GenerateTokenPair(*user, clientInfo(c))
 	if err != nil {
 		log.Printf("HandleCallback: ERROR - Failed to generate JWT tokens: %v", err)
 		utils.InternalError(c, "Failed to generate JWT tokens", err.Error())
 		return
 	}
+	recordAudit(c, services.AuditEvent{
+		ActorID:    &user.ID,
+		Action:     models.AuditActionLogin,
+		TargetType: models.AuditTargetUser,
+		TargetID:   auditID(user.ID),
+		Details:    map[string]interface{}{"method": "linkedin"},
+	})
 
 	// redirect user to react app with the jwt access token and refresh token
 	redirectURL := os.Getenv("REDIRECT_LINKEDIN_CLIENTAREA_URL") +
@@ -353,6 +390,14 @@ func (lc *LinkedInController) SyncProfile(c *gin.Context) {
 	latestResume := resumes[len(resumes)-1] // Get the most recent resume
 	log.Printf("SyncProfile: Found %d resumes, using latest with ID: %d", len(resumes), latestResume.ID)
 
+	recordAudit(c, services.AuditEvent{
+		Action:     models.AuditActionLinkedInSynced,
+		TargetType: models.AuditTargetResume,
+		TargetID:   auditID(latestResume.ID),
+		After:      latestResume.AuditSummary(),
+		Details:    map[string]interface{}{"user_id": userID},
+	})
+
 	utils.Success(c, "LinkedIn profile synced and resume created successfully", gin.H{
 		"resume": gin.H{
 			"id":         latestResume.ID,
@@ -434,5 +479,11 @@ func (lc *LinkedInController) DisconnectLinkedIn(c *gin.Context) {
 	}
 
 	log.Printf("DisconnectLinkedIn: Successfully disconnected LinkedIn for user ID: %d", userID)
+	recordAudit(c, services.AuditEvent{
+		Action:     models.AuditActionLinkedInDisconnected,
+		TargetType: models.AuditTargetUser,
+		TargetID:   auditID(uint(userID)),
+		Details:    map[string]interface{}{"linkedin_id": linkedInAuth.LinkedInID},
+	})
 	utils.Success(c, "LinkedIn disconnected successfully", nil)
 }
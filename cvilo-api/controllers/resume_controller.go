@@ -1,25 +1,51 @@
 package controllers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/smhnaqvi/cvilo/models"
+	"github.com/smhnaqvi/cvilo/pdfrender"
 	"github.com/smhnaqvi/cvilo/services"
 	"github.com/smhnaqvi/cvilo/utils"
+	"gorm.io/gorm"
 )
 
 type ResumeController struct {
-	pdfService *services.PDFService
+	pdfService    *services.PDFService
+	docxService   *services.DOCXService
+	exportService *services.ExportService
+	importService *services.ImportService
+	aiService     *services.AIService
+	jobFetcher    *services.JobDescriptionFetcher
+	renderer      *services.ResumeRenderer
 }
 
 func NewResumeController() *ResumeController {
+	renderer, err := services.NewResumeRenderer()
+	if err != nil {
+		log.Fatalf("Error initializing resume renderer: %v", err)
+	}
+
+	aiService := services.NewAIService()
+
 	return &ResumeController{
-		pdfService: services.NewPDFService(),
+		pdfService:    services.NewPDFService(),
+		docxService:   services.NewDOCXService(),
+		exportService: services.NewExportService(),
+		importService: services.NewImportService(aiService),
+		aiService:     aiService,
+		jobFetcher:    services.NewJobDescriptionFetcher(),
+		renderer:      renderer,
 	}
 }
 
@@ -31,6 +57,9 @@ func (rc *ResumeController) CreateResume(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	resume.UserID = requestUserID(c, resume.UserID)
+	// Only clones are linked to a source resume and a job description
+	resume.SourceResumeID, resume.JobDescriptionID = nil, nil
 
 	// Validate required fields
 	if resume.UserID == 0 || resume.Title == "" {
@@ -46,9 +75,20 @@ func (rc *ResumeController) CreateResume(c *gin.Context) {
 	}
 
 	if err := resume.Create(); err != nil {
+		var sectionErr *models.SectionError
+		if errors.As(err, &sectionErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": sectionErr.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create resume"})
 		return
 	}
+	recordAudit(c, services.AuditEvent{
+		Action:     models.AuditActionResumeCreated,
+		TargetType: models.AuditTargetResume,
+		TargetID:   auditID(resume.ID),
+		After:      resume.AuditSummary(),
+	})
 
 	c.JSON(http.StatusCreated, gin.H{
 		"success": true,
@@ -117,15 +157,31 @@ func (rc *ResumeController) GetResumesByUser(c *gin.Context) {
 	})
 }
 
-// GetAllResumes retrieves all resumes with pagination
+// GetOwnResumes retrieves the resumes of the authenticated user with pagination, optionally only those listing a skill
+func (rc *ResumeController) GetOwnResumes(c *gin.Context) {
+	rc.listResumes(c, models.OwnedBy(currentUserID(c)))
+}
+
+// GetAllResumes retrieves the resumes of all users with pagination, optionally only those listing a skill
 func (rc *ResumeController) GetAllResumes(c *gin.Context) {
+	rc.listResumes(c)
+}
 
+// listResumes responds with a page of the resumes selected by scopes
+func (rc *ResumeController) listResumes(c *gin.Context, scopes ...func(*gorm.DB) *gorm.DB) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 	offset := (page - 1) * limit
 
 	var resumeModel models.ResumeModel
-	resumes, total, err := resumeModel.GetAllResumes(offset, limit)
+	var resumes []models.ResumeModel
+	var total int64
+	var err error
+	if skill := c.Query("skill"); skill != "" {
+		resumes, total, err = resumeModel.GetResumesBySkill(skill, offset, limit, scopes...)
+	} else {
+		resumes, total, err = resumeModel.GetAllResumes(offset, limit, scopes...)
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve resumes"})
 		return
@@ -142,6 +198,79 @@ func (rc *ResumeController) GetAllResumes(c *gin.Context) {
 	})
 }
 
+// SearchResumes runs a full-text search over the resumes the authenticated user can access,
+// filtered by facets, with ranked results, highlighted snippets and facet counts
+func (rc *ResumeController) SearchResumes(c *gin.Context) {
+	userID := currentUserID(c)
+	if userID == 0 {
+		utils.Unauthorized(c, "User not authenticated")
+		return
+	}
+	role := c.GetString("user_role")
+
+	query := models.ResumeSearchQuery{
+		Query:    strings.TrimSpace(c.Query("q")),
+		Company:  strings.TrimSpace(c.Query("company")),
+		Location: strings.TrimSpace(c.Query("location")),
+		UserID:   userID,
+		AllUsers: role == models.RoleAdmin || role == models.RoleRecruiter,
+	}
+	for _, value := range c.QueryArray("skill") {
+		for _, skill := range strings.Split(value, ",") {
+			if skill = strings.TrimSpace(skill); skill != "" {
+				query.Skills = append(query.Skills, skill)
+			}
+		}
+	}
+
+	var details []utils.ErrorDetail
+	for _, param := range []struct {
+		name  string
+		value **float64
+	}{
+		{"min_years", &query.MinYears},
+		{"max_years", &query.MaxYears},
+	} {
+		value := c.Query(param.name)
+		if value == "" {
+			continue
+		}
+		years, err := strconv.ParseFloat(value, 64)
+		if err != nil || years < 0 {
+			details = append(details, utils.ErrorDetail{Field: param.name, Message: "must be a number of years", Code: "invalid_years"})
+			continue
+		}
+		*param.value = &years
+	}
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		details = append(details, utils.ErrorDetail{Field: "page", Message: "must be a positive number", Code: "invalid_page"})
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 100 {
+		details = append(details, utils.ErrorDetail{Field: "limit", Message: "must be between 1 and 100", Code: "invalid_limit"})
+	}
+	if len(details) > 0 {
+		utils.ValidationError(c, "Invalid search parameters", details)
+		return
+	}
+	query.Offset = (page - 1) * limit
+	query.Limit = limit
+
+	var resumeModel models.ResumeModel
+	results, total, facets, err := resumeModel.SearchResumes(query)
+	if err != nil {
+		utils.InternalError(c, "Failed to search resumes", err.Error())
+		return
+	}
+
+	utils.Success(c, "Resumes searched successfully", gin.H{
+		"results":    results,
+		"facets":     facets,
+		"pagination": utils.CreatePaginationInfo(page, limit, total),
+	})
+}
+
 // UpdateResume updates an existing resume
 func (rc *ResumeController) UpdateResume(c *gin.Context) {
 
@@ -165,11 +294,25 @@ func (rc *ResumeController) UpdateResume(c *gin.Context) {
 
 	// Don't allow changing the user ID
 	updateData.UserID = resume.UserID
-
-	if err := resume.UpdateResume(uint(id), updateData); err != nil {
+	before := resume.AuditSummary()
+
+	change := models.ResumeChange{AuthorID: currentUserID(c), Source: models.VersionSourceManual}
+	if err := resume.UpdateResume(uint(id), updateData, change); err != nil {
+		var sectionErr *models.SectionError
+		if errors.As(err, &sectionErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": sectionErr.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update resume"})
 		return
 	}
+	recordAudit(c, services.AuditEvent{
+		Action:     models.AuditActionResumeUpdated,
+		TargetType: models.AuditTargetResume,
+		TargetID:   auditID(resume.ID),
+		Before:     before,
+		After:      resume.AuditSummary(),
+	})
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Resume updated successfully",
@@ -177,6 +320,20 @@ func (rc *ResumeController) UpdateResume(c *gin.Context) {
 	})
 }
 
+// currentUserID returns the authenticated user set by AuthMiddleware
+func currentUserID(c *gin.Context) uint {
+	return c.GetUint("user_id")
+}
+
+// requestUserID returns the user a request acts for: the authenticated user, or the requested user
+// when an admin asks for one. IDs sent by other users are ignored.
+func requestUserID(c *gin.Context, requested uint) uint {
+	if requested != 0 && c.GetString("user_role") == models.RoleAdmin {
+		return requested
+	}
+	return currentUserID(c)
+}
+
 // DeleteResume deletes a resume by ID
 func (rc *ResumeController) DeleteResume(c *gin.Context) {
 
@@ -196,6 +353,12 @@ func (rc *ResumeController) DeleteResume(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete resume"})
 		return
 	}
+	recordAudit(c, services.AuditEvent{
+		Action:     models.AuditActionResumeDeleted,
+		TargetType: models.AuditTargetResume,
+		TargetID:   auditID(uint(id)),
+		Before:     resume.AuditSummary(),
+	})
 
 	c.JSON(http.StatusOK, gin.H{"message": "Resume deleted successfully"})
 }
@@ -219,13 +382,22 @@ func (rc *ResumeController) ToggleResumeStatus(c *gin.Context) {
 	}
 
 	// Toggle the active status
+	before := resume.AuditSummary()
 	resume.IsActive = !resume.IsActive
 
 	// Update the resume
-	if err := resume.UpdateResume(uint(id), resume); err != nil {
+	change := models.ResumeChange{AuthorID: currentUserID(c), Source: models.VersionSourceManual}
+	if err := resume.UpdateResume(uint(id), resume, change); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update resume status"})
 		return
 	}
+	recordAudit(c, services.AuditEvent{
+		Action:     models.AuditActionResumeStatusChanged,
+		TargetType: models.AuditTargetResume,
+		TargetID:   auditID(resume.ID),
+		Before:     before,
+		After:      resume.AuditSummary(),
+	})
 
 	// Return the updated resume
 	c.JSON(http.StatusOK, gin.H{
@@ -250,15 +422,20 @@ func (rc *ResumeController) CloneResume(c *gin.Context) {
 	}
 
 	// Create a copy
-	clonedResume := originalResume
-	clonedResume.ID = 0 // Reset ID for new record
-	clonedResume.Title = originalResume.Title + " (Copy)"
-	clonedResume.IsActive = false // Set clone as inactive by default
+	clonedResume := originalResume.NewClone(originalResume.Title + " (Copy)")
 
-	if err := clonedResume.Create(); err != nil {
+	change := models.ResumeChange{AuthorID: currentUserID(c), Source: models.VersionSourceManual}
+	if err := clonedResume.CreateWithChange(change); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clone resume"})
 		return
 	}
+	recordAudit(c, services.AuditEvent{
+		Action:     models.AuditActionResumeCloned,
+		TargetType: models.AuditTargetResume,
+		TargetID:   auditID(clonedResume.ID),
+		After:      clonedResume.AuditSummary(),
+		Details:    map[string]interface{}{"source_resume_id": originalResume.ID},
+	})
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message":       "Resume cloned successfully",
@@ -267,6 +444,107 @@ func (rc *ResumeController) CloneResume(c *gin.Context) {
 	})
 }
 
+// tailorResumeRequest is the body of TailorResume: the text of a job posting or the URL of its page
+type tailorResumeRequest struct {
+	JobDescription string `json:"job_description"`
+	URL            string `json:"url"`
+}
+
+// TailorResume clones a resume with its experience bullets and skills reordered and reworded toward a job
+// description by the AI. The clone is linked to the resume and to the saved job description, and the response
+// explains every change.
+func (rc *ResumeController) TailorResume(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid resume ID"})
+		return
+	}
+
+	var request tailorResumeRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	request.JobDescription = strings.TrimSpace(request.JobDescription)
+	request.URL = strings.TrimSpace(request.URL)
+	if (request.JobDescription == "") == (request.URL == "") {
+		utils.ValidationError(c, "Invalid job description", []utils.ErrorDetail{
+			{Field: "job_description", Message: "Give either the text of the job description or its url", Code: "required"},
+		})
+		return
+	}
+
+	var originalResume models.ResumeModel
+	if err := originalResume.GetResumeByID(uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve resume"})
+		return
+	}
+
+	jobDescription := &models.JobDescriptionModel{UserID: originalResume.UserID, Text: request.JobDescription}
+	if request.URL != "" {
+		fetched, err := rc.jobFetcher.Fetch(c.Request.Context(), request.URL)
+		switch {
+		case errors.Is(err, services.ErrInvalidJobDescriptionURL), errors.Is(err, services.ErrPrivateJobDescriptionURL):
+			utils.ValidationError(c, "Invalid job description", []utils.ErrorDetail{
+				{Field: "url", Message: err.Error(), Code: "invalid_url"},
+			})
+			return
+		case err != nil:
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+			return
+		}
+		jobDescription.URL, jobDescription.Text = fetched.URL, fetched.Text
+	}
+
+	tailoring, usedProvider, err := rc.aiService.TailorResume(c.Request.Context(), originalResume, jobDescription.Text)
+	if err != nil {
+		aiError(c, "Failed to tailor resume", err)
+		return
+	}
+	jobDescription.Title, jobDescription.Company = tailoring.JobTitle, tailoring.Company
+
+	// The clone gets the tailored content under a title naming the job
+	tailoredResume := originalResume.NewClone(originalResume.Title + " - " + tailoring.Target())
+	tailoring.Resume.Title = tailoredResume.Title
+	if err := tailoring.Resume.ApplyTo(tailoredResume); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to tailor resume: " + err.Error()})
+		return
+	}
+
+	userID := currentUserID(c)
+	change := models.ResumeChange{
+		AuthorID:   userID,
+		Source:     models.VersionSourceAI,
+		ChatPrompt: rc.aiService.NewChatPromptHistory(userID, "Tailor resume to "+tailoring.Target(), tailoring.Summary(), usedProvider),
+	}
+	if err := jobDescription.CreateWithResume(tailoredResume, change); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save tailored resume"})
+		return
+	}
+	recordAudit(c, services.AuditEvent{
+		Action:     models.AuditActionAIResumeTailored,
+		TargetType: models.AuditTargetResume,
+		TargetID:   auditID(tailoredResume.ID),
+		After:      tailoredResume.AuditSummary(),
+		Details: map[string]interface{}{
+			"source_resume_id":       originalResume.ID,
+			"job_description_id":     jobDescription.ID,
+			"provider":               usedProvider,
+			"chat_prompt_history_id": change.ChatPrompt.ID,
+		},
+	})
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":         "Resume tailored successfully",
+		"original_id":     originalResume.ID,
+		"tailored_resume": tailoredResume,
+		"job_description": jobDescription,
+		"changes":         tailoring.Changes,
+		"diff":            tailoring.Diff,
+		"provider":        usedProvider,
+	})
+}
+
 // Helper functions for JSON parsing
 func (rc *ResumeController) ParseExperience(c *gin.Context) {
 	var experiences []models.WorkExperience
@@ -325,7 +603,7 @@ func (rc *ResumeController) ParseSkills(c *gin.Context) {
 	})
 }
 
-// DownloadResumePDF generates a PDF from the resume preview and returns it for download
+// DownloadResumePDF renders the stored resume with its template and returns it as a PDF download
 func (rc *ResumeController) DownloadResumePDF(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
@@ -333,21 +611,41 @@ func (rc *ResumeController) DownloadResumePDF(c *gin.Context) {
 		return
 	}
 
-	// Get the resume to verify it exists
+	pdfOptions, err := pdfrender.ParsePDFOptions(c.Request.URL.Query(), pdfrender.DefaultPDFOptions())
+	if err != nil {
+		var details []utils.ErrorDetail
+		if optionsErr, ok := err.(*pdfrender.PDFOptionsError); ok {
+			for _, fe := range optionsErr.Errors {
+				details = append(details, utils.ErrorDetail{Field: fe.Field, Message: fe.Message, Code: fe.Code})
+			}
+		}
+		utils.ValidationError(c, "Invalid PDF options", details)
+		return
+	}
+
 	var resume models.ResumeModel
 	if err := resume.GetResumeByID(uint(id)); err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Resume not found"})
 		return
 	}
 
-	// Construct the preview URL with print mode
-
-	previewURL := fmt.Sprintf("http://localhost:3009/dashboard/resume/%d/preview?print=true", id)
-
-	log.Println("Preview URL:", previewURL)
+	// Render the resume from the database only, no frontend involved
+	htmlContent, err := rc.renderer.RenderHTML(&resume)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to render resume: %v", err)})
+		return
+	}
 
-	// Generate PDF from the preview URL with complete page loading detection
-	pdfBuffer, err := rc.pdfService.GeneratePDFFromURL(previewURL, fmt.Sprintf("resume_%d.pdf", id))
+	pdfBuffer, err := rc.pdfService.GeneratePDFFromHTML(c.Request.Context(), htmlContent, fmt.Sprintf("resume_%d.pdf", id), pdfOptions)
+	if errors.Is(err, context.Canceled) {
+		// The client went away, nobody is waiting for the PDF
+		return
+	}
+	if errors.Is(err, pdfrender.ErrRenderQueueFull) {
+		c.Header("Retry-After", "5")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "PDF renderer is busy, please try again shortly"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to generate PDF: %v", err)})
 		return
@@ -362,3 +660,414 @@ func (rc *ResumeController) DownloadResumePDF(c *gin.Context) {
 	// Return the PDF buffer
 	c.Data(http.StatusOK, "application/pdf", pdfBuffer)
 }
+
+// DownloadResumeDOCX generates an editable Word document from the stored resume
+func (rc *ResumeController) DownloadResumeDOCX(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid resume ID"})
+		return
+	}
+
+	var resume models.ResumeModel
+	if err := resume.GetResumeByID(uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Resume not found"})
+		return
+	}
+
+	docxBuffer, err := rc.docxService.GenerateResumeDOCX(&resume)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to generate DOCX: %v", err)})
+		return
+	}
+
+	// Set response headers for file download
+	filename := fmt.Sprintf("resume_%s.docx", resume.Title)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Content-Length", strconv.Itoa(len(docxBuffer)))
+
+	c.Data(http.StatusOK, "application/vnd.openxmlformats-officedocument.wordprocessingml.document", docxBuffer)
+}
+
+// ExportResume exports the resume as plain text or Markdown for pasting into job portals,
+// or as a JSON Resume document
+func (rc *ResumeController) ExportResume(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid resume ID"})
+		return
+	}
+
+	opts := services.ExportOptions{
+		Format: c.DefaultQuery("format", services.ExportFormatText),
+		Width:  services.DefaultExportWidth,
+	}
+	if value := c.Query("width"); value != "" {
+		width, err := strconv.Atoi(value)
+		if err != nil || (width != 0 && (width < services.MinExportWidth || width > services.MaxExportWidth)) {
+			utils.ValidationError(c, "Invalid export options", []utils.ErrorDetail{{
+				Field:   "width",
+				Message: fmt.Sprintf("must be 0 or between %d and %d", services.MinExportWidth, services.MaxExportWidth),
+				Code:    "invalid_width",
+			}})
+			return
+		}
+		opts.Width = width
+	}
+
+	var resume models.ResumeModel
+	if err := resume.GetResumeByID(uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Resume not found"})
+		return
+	}
+
+	exported, err := rc.exportService.Export(&resume, opts)
+	if errors.Is(err, services.ErrUnsupportedExportFormat) {
+		utils.ValidationError(c, "Invalid export options", []utils.ErrorDetail{{
+			Field:   "format",
+			Message: "must be one of txt, md or jsonresume",
+			Code:    "invalid_format",
+		}})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to export resume: %v", err)})
+		return
+	}
+
+	if c.Query("download") == "true" {
+		filename := fmt.Sprintf("resume_%s.%s", resume.Title, exported.Extension)
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	}
+	c.Data(http.StatusOK, exported.ContentType, exported.Content)
+}
+
+// ImportJSONResume creates a resume from a JSON Resume document sent as the request body.
+// Fields that have no place on the resume are returned in unmapped_fields instead of being dropped silently.
+func (rc *ResumeController) ImportJSONResume(c *gin.Context) {
+	requested, _ := strconv.ParseUint(c.Query("user_id"), 10, 32)
+	userID := requestUserID(c, uint(requested))
+
+	var user models.UserModel
+	if err := user.GetUserByID(userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	body, err := c.GetRawData()
+	if err != nil || len(body) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request body must be a JSON Resume document"})
+		return
+	}
+
+	resume, unmapped, err := services.ParseJSONResume(body, userID, c.Query("title"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := resume.Create(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create resume"})
+		return
+	}
+	recordAudit(c, services.AuditEvent{
+		Action:     models.AuditActionResumeImported,
+		TargetType: models.AuditTargetResume,
+		TargetID:   auditID(resume.ID),
+		After:      resume.AuditSummary(),
+		Details:    map[string]interface{}{"format": "jsonresume", "unmapped_fields": len(unmapped)},
+	})
+
+	if unmapped == nil {
+		unmapped = []services.UnmappedField{}
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data": gin.H{
+			"message":         "Resume imported successfully",
+			"resume":          resume,
+			"unmapped_fields": unmapped,
+		},
+	})
+}
+
+// ImportResume creates a resume from an uploaded PDF or DOCX file.
+// With dry_run=true the parsed resume is returned without being saved.
+func (rc *ResumeController) ImportResume(c *gin.Context) {
+	requested, _ := strconv.ParseUint(c.PostForm("user_id"), 10, 32)
+	userID := requestUserID(c, uint(requested))
+
+	dryRun := c.PostForm("dry_run") == "true" || c.Query("dry_run") == "true"
+	useAI := c.DefaultPostForm("use_ai", "true") != "false"
+
+	var user models.UserModel
+	if err := user.GetUserByID(userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A PDF or DOCX file is required in the file field"})
+		return
+	}
+	if fileHeader.Size > services.MaxImportFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("File must be at most %d MB", services.MaxImportFileSize>>20)})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, services.MaxImportFileSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+		return
+	}
+
+	imported, err := rc.importService.Import(c.Request.Context(), fileHeader.Filename, data, useAI)
+	if errors.Is(err, services.ErrUnsupportedImportFormat) || errors.Is(err, services.ErrNoResumeText) {
+		utils.ValidationError(c, "Invalid resume file", []utils.ErrorDetail{{
+			Field:   "file",
+			Message: err.Error(),
+			Code:    "invalid_file",
+		}})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	if dryRun {
+		utils.Success(c, "Resume parsed successfully", gin.H{
+			"dry_run": true,
+			"import":  imported,
+		})
+		return
+	}
+
+	title := c.PostForm("title")
+	if title == "" {
+		title = "Imported Resume - " + strings.TrimSuffix(fileHeader.Filename, filepath.Ext(fileHeader.Filename))
+	}
+
+	resume, err := rc.aiService.ConvertAIResponseToResume(imported.Resume, userID, title)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to convert parsed resume: " + err.Error()})
+		return
+	}
+
+	change := models.ResumeChange{AuthorID: currentUserID(c), Source: models.VersionSourceManual}
+	if imported.Method == services.ImportMethodAI {
+		change.Source = models.VersionSourceAI
+		responseSummary := fmt.Sprintf("Imported resume with %d experience entries, %d education entries, %d skills",
+			len(imported.Resume.Experience), len(imported.Resume.Education), len(imported.Resume.Skills))
+		change.ChatPrompt = rc.aiService.NewChatPromptHistory(userID, "Import "+fileHeader.Filename, responseSummary, imported.Provider)
+	}
+
+	if err := resume.CreateWithChange(change); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save resume: " + err.Error()})
+		return
+	}
+	recordAudit(c, services.AuditEvent{
+		Action:     models.AuditActionResumeImported,
+		TargetType: models.AuditTargetResume,
+		TargetID:   auditID(resume.ID),
+		After:      resume.AuditSummary(),
+		Details:    map[string]interface{}{"file": fileHeader.Filename, "method": imported.Method},
+	})
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data": gin.H{
+			"message": "Resume imported successfully",
+			"resume":  resume,
+			"import":  imported,
+		},
+	})
+}
+
+// GetResumeVersions lists the saved versions of a resume, newest first
+func (rc *ResumeController) GetResumeVersions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid resume ID"})
+		return
+	}
+
+	var resume models.ResumeModel
+	if err := resume.GetResumeByID(uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Resume not found"})
+		return
+	}
+
+	versions, err := resume.GetVersions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve resume versions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"resume_id": resume.ID,
+		"versions":  versions,
+		"total":     len(versions),
+	})
+}
+
+// GetResumeVersion returns a version of a resume with its snapshot
+func (rc *ResumeController) GetResumeVersion(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid resume ID"})
+		return
+	}
+	number, err := strconv.Atoi(c.Param("version"))
+	if err != nil || number < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version"})
+		return
+	}
+
+	var resume models.ResumeModel
+	if err := resume.GetResumeByID(uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Resume not found"})
+		return
+	}
+
+	version, ok := findResumeVersion(c, &resume, number)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"version": version})
+}
+
+// DiffResumeVersions compares two versions of a resume section by section.
+// to defaults to the latest version and from to the version before to.
+func (rc *ResumeController) DiffResumeVersions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid resume ID"})
+		return
+	}
+
+	var details []utils.ErrorDetail
+	numbers := map[string]int{}
+	for _, field := range []string{"from", "to"} {
+		value := c.Query(field)
+		if value == "" {
+			continue
+		}
+		number, err := strconv.Atoi(value)
+		if err != nil || number < 1 {
+			details = append(details, utils.ErrorDetail{Field: field, Message: "must be a version number", Code: "invalid_version"})
+			continue
+		}
+		numbers[field] = number
+	}
+	if len(details) > 0 {
+		utils.ValidationError(c, "Invalid versions", details)
+		return
+	}
+
+	var resume models.ResumeModel
+	if err := resume.GetResumeByID(uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Resume not found"})
+		return
+	}
+
+	to, ok := findResumeVersion(c, &resume, numbers["to"])
+	if !ok {
+		return
+	}
+	fromNumber := numbers["from"]
+	if fromNumber == 0 {
+		fromNumber = to.Version - 1
+	}
+	if fromNumber < 1 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Resume version not found"})
+		return
+	}
+	from, ok := findResumeVersion(c, &resume, fromNumber)
+	if !ok {
+		return
+	}
+
+	diff, err := models.DiffSnapshots(from.Snapshot, to.Snapshot)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to compare versions: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"resume_id": resume.ID,
+		"from":      from.Version,
+		"to":        to.Version,
+		"sections":  diff,
+	})
+}
+
+// RestoreResumeVersion replaces the content of a resume with one of its versions.
+// The restored content is recorded as a new version, so the restore itself can be undone.
+func (rc *ResumeController) RestoreResumeVersion(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid resume ID"})
+		return
+	}
+	number, err := strconv.Atoi(c.Param("version"))
+	if err != nil || number < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version"})
+		return
+	}
+
+	var resume models.ResumeModel
+	if err := resume.GetResumeByID(uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Resume not found"})
+		return
+	}
+
+	before := resume.AuditSummary()
+	change := models.ResumeChange{AuthorID: currentUserID(c), Source: models.VersionSourceManual}
+	version, err := resume.RestoreVersion(number, change)
+	if errors.Is(err, models.ErrResumeVersionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Resume version not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore resume version"})
+		return
+	}
+	recordAudit(c, services.AuditEvent{
+		Action:     models.AuditActionResumeRestored,
+		TargetType: models.AuditTargetResume,
+		TargetID:   auditID(resume.ID),
+		Before:     before,
+		After:      resume.AuditSummary(),
+		Details:    map[string]interface{}{"restored_version": number, "new_version": version.Version},
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Resume restored to version %d", number),
+		"resume":  resume,
+		"version": version,
+	})
+}
+
+// findResumeVersion loads a version of the resume, 0 meaning the latest, and writes the error response if it fails
+func findResumeVersion(c *gin.Context, resume *models.ResumeModel, number int) (*models.ResumeVersionModel, bool) {
+	version, err := resume.GetVersion(number)
+	if errors.Is(err, models.ErrResumeVersionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Resume version not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve resume version"})
+		return nil, false
+	}
+	return version, true
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/smhnaqvi/cvilo/services"
+)
+
+// sseHeartbeat is how often an idle event stream gets a comment, so proxies such as nginx (proxy_read_timeout 30s)
+// keep it open while the model has not started answering
+const sseHeartbeat = 15 * time.Second
+
+// sseWriter answers a request with server-sent events. Events are flushed as they are sent and a heartbeat
+// comment is written while the stream is idle.
+type sseWriter struct {
+	c    *gin.Context
+	mu   sync.Mutex
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newSSEWriter starts the event stream of the request
+func newSSEWriter(c *gin.Context) *sseWriter {
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // nginx must not buffer the stream
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	w := &sseWriter{c: c, stop: make(chan struct{})}
+	w.wg.Add(1)
+	go w.heartbeat()
+	return w
+}
+
+func (w *sseWriter) heartbeat() {
+	defer w.wg.Done()
+	ticker := time.NewTicker(sseHeartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-w.c.Request.Context().Done():
+			return
+		case <-ticker.C:
+			w.mu.Lock()
+			w.c.Writer.WriteString(": keep-alive\n\n")
+			w.c.Writer.Flush()
+			w.mu.Unlock()
+		}
+	}
+}
+
+// Send writes an event with data encoded as JSON. Events for a client that has gone are dropped.
+func (w *sseWriter) Send(event string, data interface{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.c.Request.Context().Err() != nil {
+		return
+	}
+	w.c.SSEvent(event, data)
+	w.c.Writer.Flush()
+}
+
+// Error sends the error event of a failed AI request. A request cancelled because the client has gone is only
+// logged.
+func (w *sseWriter) Error(message string, err error) {
+	if w.c.Request.Context().Err() != nil {
+		log.Printf("%s %s: client disconnected, AI request cancelled", w.c.Request.Method, w.c.Request.URL.Path)
+		return
+	}
+	status := http.StatusInternalServerError
+	if errors.Is(err, services.ErrLLMNotConfigured) {
+		status = http.StatusServiceUnavailable
+	}
+	w.Send("error", gin.H{"error": message + ": " + err.Error(), "status": status})
+}
+
+// AIStreamHandler reports the progress of an AI generation as events: a progress event for every provider asked
+// and a section event for every part of the resume written
+func (w *sseWriter) AIStreamHandler() services.AIStreamHandler {
+	attempt := 0
+	return services.AIStreamHandler{
+		OnProvider: func(provider string) {
+			attempt++
+			w.Send("progress", gin.H{"stage": "generating", "provider": provider, "attempt": attempt})
+		},
+		OnSection: func(name string, value json.RawMessage) {
+			w.Send("section", gin.H{"name": name, "data": value})
+		},
+	}
+}
+
+// Close stops the heartbeat; it must be called before the handler returns
+func (w *sseWriter) Close() {
+	close(w.stop)
+	w.wg.Wait()
+}
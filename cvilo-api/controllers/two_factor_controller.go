@@ -0,0 +1,207 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/smhnaqvi/cvilo/models"
+	"github.com/smhnaqvi/cvilo/services"
+	"github.com/smhnaqvi/cvilo/utils"
+)
+
+type TwoFactorController struct {
+	authService *services.AuthService
+	validate    *validator.Validate
+}
+
+func NewTwoFactorController() *TwoFactorController {
+	return &TwoFactorController{
+		authService: services.NewAuthService(),
+		validate:    validator.New(),
+	}
+}
+
+// bind reads and validates a JSON request body, answering 400 when it is invalid
+func (tc *TwoFactorController) bind(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		utils.BadRequest(c, "Invalid request body", err.Error())
+		return false
+	}
+	if err := tc.validate.Struct(req); err != nil {
+		utils.BadRequest(c, "Validation failed", err.Error())
+		return false
+	}
+	return true
+}
+
+// Setup starts a two-factor enrollment and returns the secret with its otpauth:// URI for a QR code
+func (tc *TwoFactorController) Setup(c *gin.Context) {
+	var user models.UserModel
+	if err := user.GetUserByID(c.GetUint("user_id")); err != nil {
+		utils.NotFound(c, "User not found")
+		return
+	}
+
+	setup, err := tc.authService.SetupTwoFactor(user)
+	if errors.Is(err, services.ErrTwoFactorAlreadyEnabled) {
+		utils.Conflict(c, "Two-factor authentication is already enabled", err.Error())
+		return
+	}
+	if err != nil {
+		utils.InternalError(c, "Failed to set up two-factor authentication", err.Error())
+		return
+	}
+
+	utils.Success(c, "Scan the QR code with an authenticator app, then confirm with a code", setup)
+}
+
+// Enable confirms the enrollment with a code from the authenticator app and returns the recovery codes
+func (tc *TwoFactorController) Enable(c *gin.Context) {
+	var req models.TwoFactorCodeRequest
+	if !tc.bind(c, &req) {
+		return
+	}
+
+	codes, err := tc.authService.EnableTwoFactor(c.GetUint("user_id"), req.Code)
+	switch {
+	case errors.Is(err, models.ErrTwoFactorNotFound):
+		utils.BadRequest(c, "Two-factor authentication is not set up", "call /auth/2fa/setup first")
+	case errors.Is(err, services.ErrTwoFactorAlreadyEnabled):
+		utils.Conflict(c, "Two-factor authentication is already enabled", err.Error())
+	case errors.Is(err, services.ErrInvalidMFACode):
+		utils.BadRequest(c, "Invalid authentication code", err.Error())
+	case err != nil:
+		utils.InternalError(c, "Failed to enable two-factor authentication", err.Error())
+	default:
+		recordAudit(c, services.AuditEvent{
+			Action:     models.AuditActionTwoFactorEnabled,
+			TargetType: models.AuditTargetUser,
+			TargetID:   auditID(c.GetUint("user_id")),
+		})
+		utils.Success(c, "Two-factor authentication enabled, store the recovery codes in a safe place", gin.H{
+			"recovery_codes": codes,
+		})
+	}
+}
+
+// Disable turns off two-factor authentication, confirmed by the password and a TOTP or recovery code
+func (tc *TwoFactorController) Disable(c *gin.Context) {
+	var req models.TwoFactorConfirmRequest
+	if !tc.bind(c, &req) {
+		return
+	}
+
+	err := tc.authService.DisableTwoFactor(c.GetUint("user_id"), req.Password, req.Code)
+	if tc.confirmationFailed(c, err) {
+		return
+	}
+	if err != nil {
+		utils.InternalError(c, "Failed to disable two-factor authentication", err.Error())
+		return
+	}
+	recordAudit(c, services.AuditEvent{
+		Action:     models.AuditActionTwoFactorDisabled,
+		TargetType: models.AuditTargetUser,
+		TargetID:   auditID(c.GetUint("user_id")),
+	})
+
+	utils.Success(c, "Two-factor authentication disabled", nil)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes, confirmed by the password and a TOTP code
+func (tc *TwoFactorController) RegenerateRecoveryCodes(c *gin.Context) {
+	var req models.TwoFactorConfirmRequest
+	if !tc.bind(c, &req) {
+		return
+	}
+
+	codes, err := tc.authService.RegenerateRecoveryCodes(c.GetUint("user_id"), req.Password, req.Code)
+	if tc.confirmationFailed(c, err) {
+		return
+	}
+	if err != nil {
+		utils.InternalError(c, "Failed to regenerate recovery codes", err.Error())
+		return
+	}
+	recordAudit(c, services.AuditEvent{
+		Action:     models.AuditActionRecoveryCodesReplaced,
+		TargetType: models.AuditTargetUser,
+		TargetID:   auditID(c.GetUint("user_id")),
+	})
+
+	utils.Success(c, "Recovery codes regenerated, the previous codes no longer work", gin.H{
+		"recovery_codes": codes,
+	})
+}
+
+// confirmationFailed answers the errors of a password and code confirmation
+func (tc *TwoFactorController) confirmationFailed(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrTwoFactorNotEnabled):
+		utils.Conflict(c, "Two-factor authentication is not enabled", err.Error())
+	case errors.Is(err, services.ErrIncorrectPassword):
+		utils.BadRequest(c, "Password is incorrect", err.Error())
+	case errors.Is(err, services.ErrInvalidMFACode):
+		utils.BadRequest(c, "Invalid authentication code", err.Error())
+	default:
+		return false
+	}
+	return true
+}
+
+// Verify completes a login of a user with two-factor authentication. It exchanges the mfa_token returned by Login
+// and a TOTP or recovery code for the token pair.
+func (tc *TwoFactorController) Verify(c *gin.Context) {
+	var req models.MFAVerifyRequest
+	if !tc.bind(c, &req) {
+		return
+	}
+
+	user, usedRecoveryCode, err := tc.authService.VerifyMFAChallenge(req.MFAToken, req.Code, clientInfo(c))
+	switch {
+	case errors.Is(err, models.ErrMFAChallengeInvalid):
+		utils.Unauthorized(c, "Invalid or expired MFA token, please log in again")
+		return
+	case errors.Is(err, services.ErrTooManyMFAAttempts):
+		utils.Unauthorized(c, "Too many invalid codes, please log in again")
+		return
+	case errors.Is(err, services.ErrInvalidMFACode):
+		utils.Unauthorized(c, "Invalid authentication code")
+		return
+	case err != nil:
+		utils.InternalError(c, "Failed to verify authentication code", err.Error())
+		return
+	}
+
+	tokenPair, err := tc.authService.GenerateTokenPair(*user, clientInfo(c))
+	if err != nil {
+		utils.InternalError(c, "Failed to generate tokens", err.Error())
+		return
+	}
+	method := "totp"
+	if usedRecoveryCode {
+		method = "recovery_code"
+	}
+	recordAudit(c, services.AuditEvent{
+		ActorID:    &user.ID,
+		Action:     models.AuditActionLogin,
+		TargetType: models.AuditTargetUser,
+		TargetID:   auditID(user.ID),
+		Details:    map[string]interface{}{"method": method},
+	})
+
+	response := gin.H{
+		"access_token":  tokenPair.AccessToken,
+		"refresh_token": tokenPair.RefreshToken,
+		"user":          user.ToUserResponse(),
+		"expires_at":    tokenPair.ExpiresAt,
+	}
+	if usedRecoveryCode {
+		remaining, err := tc.authService.RemainingRecoveryCodes(user.ID)
+		if err == nil {
+			response["recovery_codes_remaining"] = remaining
+		}
+	}
+	utils.Success(c, "Login successful", response)
+}
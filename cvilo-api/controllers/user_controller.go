@@ -1,11 +1,14 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/smhnaqvi/cvilo/models"
+	"github.com/smhnaqvi/cvilo/services"
 )
 
 type UserController struct{}
@@ -45,11 +48,22 @@ func (uc *UserController) CreateUser(c *gin.Context) {
 	user.Website = req.Website
 	user.LinkedIn = req.LinkedIn
 	user.GitHub = req.GitHub
+	user.Role = req.Role
+	if user.Role != "" && !models.ValidRole(user.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role must be one of " + strings.Join(models.Roles, ", ")})
+		return
+	}
 
 	if err := user.Create(); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
+	recordAudit(c, services.AuditEvent{
+		Action:     models.AuditActionUserCreated,
+		TargetType: models.AuditTargetUser,
+		TargetID:   auditID(user.ID),
+		After:      user.AuditSummary(),
+	})
 
 	// Convert to response
 	userResponse := models.UserResponse{
@@ -66,6 +80,7 @@ func (uc *UserController) CreateUser(c *gin.Context) {
 		GitHub:    user.GitHub,
 		Step:      user.Step,
 		IsActive:  user.IsActive,
+		Role:      user.Role,
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
@@ -134,6 +149,7 @@ func (uc *UserController) UpdateUser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	before := user.AuditSummary()
 
 	// Update only provided fields
 	if req.Name != "" {
@@ -172,7 +188,8 @@ func (uc *UserController) UpdateUser(c *gin.Context) {
 	if req.Step != "" {
 		user.Step = req.Step
 	}
-	if req.IsActive != nil {
+	// Only admins may (re)activate accounts
+	if req.IsActive != nil && c.GetString("user_role") == models.RoleAdmin {
 		user.IsActive = *req.IsActive
 	}
 
@@ -180,6 +197,13 @@ func (uc *UserController) UpdateUser(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
 		return
 	}
+	recordAudit(c, services.AuditEvent{
+		Action:     models.AuditActionUserUpdated,
+		TargetType: models.AuditTargetUser,
+		TargetID:   auditID(user.ID),
+		Before:     before,
+		After:      user.AuditSummary(),
+	})
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "User updated successfully",
@@ -201,6 +225,8 @@ func (uc *UserController) DeleteUser(c *gin.Context) {
 		return
 	}
 
+	before := user.AuditSummary()
+
 	// Delete associated resumes first
 	if err := user.DeleteUserResumes(uint(id)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user's resumes"})
@@ -212,6 +238,14 @@ func (uc *UserController) DeleteUser(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
 		return
 	}
+	recordAudit(c, services.AuditEvent{
+		Action:     models.AuditActionUserDeleted,
+		TargetType: models.AuditTargetUser,
+		TargetID:   auditID(uint(id)),
+		Before:     before,
+		After:      user.AuditSummary(),
+		Details:    map[string]interface{}{"resumes_deleted": true},
+	})
 
 	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
 }
@@ -247,14 +281,102 @@ func (uc *UserController) ToggleUserStatus(c *gin.Context) {
 		return
 	}
 
+	before := user.AuditSummary()
 	user.IsActive = !user.IsActive
 	if err := user.UpdateUser(uint(id)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user status"})
 		return
 	}
+	recordAudit(c, services.AuditEvent{
+		Action:     models.AuditActionUserStatusChanged,
+		TargetType: models.AuditTargetUser,
+		TargetID:   auditID(user.ID),
+		Before:     before,
+		After:      user.AuditSummary(),
+	})
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":   "User status updated successfully",
 		"is_active": user.IsActive,
 	})
 }
+
+// SetUserRole changes the role of a user. Admins cannot change their own role, so there is always an admin
+// left to undo a change.
+func (uc *UserController) SetUserRole(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role is required"})
+		return
+	}
+	if !models.ValidRole(req.Role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Role must be one of " + strings.Join(models.Roles, ", ")})
+		return
+	}
+	if uint(id) == c.GetUint("user_id") {
+		c.JSON(http.StatusConflict, gin.H{"error": "You cannot change your own role"})
+		return
+	}
+
+	var user models.UserModel
+	if err := user.GetUserByID(uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	before := user.AuditSummary()
+	if err := user.SetRole(req.Role); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user role"})
+		return
+	}
+	recordAudit(c, services.AuditEvent{
+		Action:     models.AuditActionUserRoleChanged,
+		TargetType: models.AuditTargetUser,
+		TargetID:   auditID(user.ID),
+		Before:     before,
+		After:      user.AuditSummary(),
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User role updated successfully",
+		"user":    user.ToUserResponse(),
+	})
+}
+
+// UnlockUser forgets the failed logins of a user, ending an account lock before it expires (admin only)
+func (uc *UserController) UnlockUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var user models.UserModel
+	if err := user.GetUserByID(uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	err = services.NewAuthService().UnlockAccount(user, c.GetUint("user_id"), clientInfo(c))
+	if errors.Is(err, services.ErrAccountNotLocked) {
+		c.JSON(http.StatusConflict, gin.H{"error": "User has no failed logins to clear"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User unlocked successfully",
+		"user":    user.ToUserResponse(),
+	})
+}
@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/smhnaqvi/cvilo/models"
+	"github.com/smhnaqvi/cvilo/utils"
+)
+
+// OwnershipStore looks up the user owning a resource and the stored role of a user
+type OwnershipStore interface {
+	ResumeOwner(resumeID uint) (uint, error)
+	ChatHistoryOwner(historyID uint) (uint, error)
+	UserRole(userID uint) (string, error)
+}
+
+// roleVerifiedKey marks a request whose role was checked against the stored role
+const roleVerifiedKey = "user_role_verified"
+
+// Authorizer checks that the authenticated user may access the resource named in the request path.
+// It must run after AuthMiddleware. Resources of other users are reported as not found, so their
+// existence is not revealed. Admins may access all resources.
+type Authorizer struct {
+	store OwnershipStore
+}
+
+// NewAuthorizer creates an authorizer looking up owners in store
+func NewAuthorizer(store OwnershipStore) *Authorizer {
+	return &Authorizer{store: store}
+}
+
+// CurrentUserID returns the authenticated user set by AuthMiddleware
+func CurrentUserID(c *gin.Context) (uint, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		return 0, false
+	}
+	id, ok := userID.(uint)
+	return id, ok && id != 0
+}
+
+// IsAdmin reports whether the authenticated user has the admin role
+func IsAdmin(c *gin.Context) bool {
+	return c.GetString("user_role") == models.RoleAdmin
+}
+
+// OwnsUser requires the user ID in the path parameter to be the authenticated user
+func (a *Authorizer) OwnsUser(param string) gin.HandlerFunc {
+	return a.owns(param, "User not found", func(id uint) (uint, error) {
+		return id, nil
+	})
+}
+
+// OwnsResume requires the resume ID in the path parameter to belong to the authenticated user
+func (a *Authorizer) OwnsResume(param string) gin.HandlerFunc {
+	return a.owns(param, "Resume not found", a.store.ResumeOwner)
+}
+
+// OwnsChatHistory requires the chat history entry ID in the path parameter to belong to the authenticated user
+func (a *Authorizer) OwnsChatHistory(param string) gin.HandlerFunc {
+	return a.owns(param, "Chat history not found", a.store.ChatHistoryOwner)
+}
+
+// RequireRole allows the request only when the authenticated user has one of the roles.
+// It must run after AuthMiddleware; the role is the stored role, so a role change applies before the token expires.
+func (a *Authorizer) RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !a.verifyRole(c) {
+			utils.Unauthorized(c, "User not authenticated")
+			c.Abort()
+			return
+		}
+
+		role := c.GetString("user_role")
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+		utils.Forbidden(c, "Insufficient role")
+		c.Abort()
+	}
+}
+
+// VerifyRole replaces the role of the token with the stored role, for handlers that read it.
+// It must run after AuthMiddleware.
+func (a *Authorizer) VerifyRole() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !a.verifyRole(c) {
+			utils.Unauthorized(c, "User not authenticated")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// verifyRole checks the role of the token claims against the stored role of the user and keeps the stored one.
+// Only privileged claims are looked up: a demotion applies at once, a promotion with the next token.
+func (a *Authorizer) verifyRole(c *gin.Context) bool {
+	if c.GetBool(roleVerifiedKey) {
+		return true
+	}
+	userID, ok := CurrentUserID(c)
+	if !ok {
+		return false
+	}
+	if role := c.GetString("user_role"); role != "" && role != models.RoleUser {
+		stored, err := a.store.UserRole(userID)
+		if err != nil {
+			return false
+		}
+		c.Set("user_role", stored)
+	}
+	c.Set(roleVerifiedKey, true)
+	return true
+}
+
+// owns builds a middleware resolving the owner of the resource named by param.
+// Malformed IDs are left to the handler, which reports them as bad requests.
+func (a *Authorizer) owns(param string, notFound string, owner func(id uint) (uint, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := CurrentUserID(c)
+		if !ok {
+			utils.Unauthorized(c, "User not authenticated")
+			c.Abort()
+			return
+		}
+
+		id, err := strconv.ParseUint(c.Param(param), 10, 32)
+		if err != nil {
+			c.Next()
+			return
+		}
+		if !a.verifyRole(c) {
+			utils.Unauthorized(c, "User not authenticated")
+			c.Abort()
+			return
+		}
+		if IsAdmin(c) {
+			c.Next()
+			return
+		}
+
+		ownerID, err := owner(uint(id))
+		if err != nil || ownerID != userID {
+			utils.NotFound(c, notFound)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// dbOwnershipStore looks up owners in the database
+type dbOwnershipStore struct{}
+
+// NewOwnershipStore creates an ownership store backed by the database
+func NewOwnershipStore() OwnershipStore {
+	return dbOwnershipStore{}
+}
+
+func (dbOwnershipStore) ResumeOwner(resumeID uint) (uint, error) {
+	var resume models.ResumeModel
+	return resume.GetOwnerID(resumeID)
+}
+
+func (dbOwnershipStore) ChatHistoryOwner(historyID uint) (uint, error) {
+	var history models.ChatPromptHistory
+	return history.GetOwnerID(historyID)
+}
+
+func (dbOwnershipStore) UserRole(userID uint) (string, error) {
+	var user models.UserModel
+	return user.GetRole(userID)
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/smhnaqvi/cvilo/models"
+)
+
+type stubOwnershipStore map[uint]uint
+
+func (s stubOwnershipStore) ResumeOwner(resumeID uint) (uint, error) {
+	if owner, ok := s[resumeID]; ok {
+		return owner, nil
+	}
+	return 0, errors.New("resume not found")
+}
+
+func (s stubOwnershipStore) ChatHistoryOwner(historyID uint) (uint, error) {
+	return s.ResumeOwner(historyID)
+}
+
+// stubRoles are the stored roles of the users: user 4 was an admin and has been demoted
+var stubRoles = map[uint]string{1: models.RoleAdmin, 2: models.RoleUser, 3: models.RoleRecruiter, 4: models.RoleUser}
+
+func (s stubOwnershipStore) UserRole(userID uint) (string, error) {
+	if role, ok := stubRoles[userID]; ok {
+		return role, nil
+	}
+	return "", errors.New("user not found")
+}
+
+func TestAuthorizer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authz := NewAuthorizer(stubOwnershipStore{10: 1, 20: 2})
+
+	tests := []struct {
+		name     string
+		userID   uint
+		role     string
+		check    gin.HandlerFunc
+		url      string
+		expected int
+	}{
+		{"Own user", 1, "user", authz.OwnsUser("id"), "/1", http.StatusOK},
+		{"Other user", 1, "user", authz.OwnsUser("id"), "/2", http.StatusNotFound},
+		{"Own resume", 1, "user", authz.OwnsResume("id"), "/10", http.StatusOK},
+		{"Other user's resume", 1, "user", authz.OwnsResume("id"), "/20", http.StatusNotFound},
+		{"Missing resume", 1, "user", authz.OwnsResume("id"), "/30", http.StatusNotFound},
+		{"Own chat history", 2, "user", authz.OwnsChatHistory("id"), "/20", http.StatusOK},
+		{"Other user's chat history", 2, "user", authz.OwnsChatHistory("id"), "/10", http.StatusNotFound},
+		{"Malformed ID left to the handler", 1, "user", authz.OwnsResume("id"), "/abc", http.StatusOK},
+		{"Admin bypasses ownership", 1, models.RoleAdmin, authz.OwnsResume("id"), "/20", http.StatusOK},
+		{"Recruiter does not bypass ownership", 3, models.RoleRecruiter, authz.OwnsResume("id"), "/20", http.StatusNotFound},
+		{"Demoted admin does not bypass ownership", 4, models.RoleAdmin, authz.OwnsResume("id"), "/20", http.StatusNotFound},
+		{"Admin role as admin", 1, models.RoleAdmin, authz.RequireRole(models.RoleAdmin), "/1", http.StatusOK},
+		{"Admin role as user", 2, models.RoleUser, authz.RequireRole(models.RoleAdmin), "/1", http.StatusForbidden},
+		{"Admin role as demoted admin", 4, models.RoleAdmin, authz.RequireRole(models.RoleAdmin), "/1", http.StatusForbidden},
+		{"Admin role as deleted user", 5, models.RoleAdmin, authz.RequireRole(models.RoleAdmin), "/1", http.StatusUnauthorized},
+		{"One of several roles", 3, models.RoleRecruiter, authz.RequireRole(models.RoleAdmin, models.RoleRecruiter), "/1", http.StatusOK},
+		{"Role without authentication", 0, "", authz.RequireRole(models.RoleAdmin), "/1", http.StatusUnauthorized},
+		{"Unauthenticated", 0, "", authz.OwnsUser("id"), "/1", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/:id", func(c *gin.Context) {
+				if tt.userID != 0 {
+					c.Set("user_id", tt.userID)
+					c.Set("user_role", tt.role)
+				}
+				c.Next()
+			}, tt.check, func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.url, nil))
+			if w.Code != tt.expected {
+				t.Errorf("GET %s = %d, want %d", tt.url, w.Code, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,306 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/smhnaqvi/cvilo/services"
+	"github.com/smhnaqvi/cvilo/utils"
+)
+
+// RateLimitPolicy is a token bucket holding Requests tokens, refilled at Requests per Per. Each request takes a
+// token, so a client can burst up to Requests and then sustain the refill rate.
+type RateLimitPolicy struct {
+	Name     string
+	Requests int
+	Per      time.Duration
+	// FailClosed rejects the requests with 503 Service Unavailable when the store fails, instead of letting them
+	// through unlimited
+	FailClosed bool
+}
+
+// refillRate returns the tokens added per second
+func (p RateLimitPolicy) refillRate() float64 {
+	return float64(p.Requests) / p.Per.Seconds()
+}
+
+// RateLimitResult is the state of a bucket after taking a token
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // until the next token, when not allowed
+	Reset      time.Duration // until the bucket is full again
+}
+
+// newRateLimitResult derives the result from the tokens left in a bucket of the policy
+func newRateLimitResult(policy RateLimitPolicy, allowed bool, tokens float64) RateLimitResult {
+	rate := policy.refillRate()
+	result := RateLimitResult{
+		Allowed:   allowed,
+		Limit:     policy.Requests,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(policy.Requests) - tokens) / rate * float64(time.Second)),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) / rate * float64(time.Second))
+	}
+	return result
+}
+
+// RateLimitStore keeps the token buckets
+type RateLimitStore interface {
+	// Take takes a token from the bucket of key, creating a full bucket for unknown keys
+	Take(ctx context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error)
+}
+
+// memoryBucket is a token bucket of MemoryRateLimitStore
+type memoryBucket struct {
+	tokens  float64
+	updated time.Time
+	fullAt  time.Time // when the bucket is full again and can be forgotten
+}
+
+// MemoryRateLimitStore keeps the buckets in process memory. Each API instance then limits on its own.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+// NewMemoryRateLimitStore creates an empty in-memory store
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets: make(map[string]*memoryBucket),
+		now:     time.Now,
+	}
+}
+
+// Take takes a token from the bucket of key
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	capacity := float64(policy.Requests)
+	rate := policy.refillRate()
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &memoryBucket{tokens: capacity, updated: now}
+		s.buckets[key] = bucket
+	}
+
+	bucket.tokens = math.Min(capacity, bucket.tokens+now.Sub(bucket.updated).Seconds()*rate)
+	bucket.updated = now
+	allowed := bucket.tokens >= 1
+	if allowed {
+		bucket.tokens--
+	}
+	bucket.fullAt = now.Add(time.Duration((capacity - bucket.tokens) / rate * float64(time.Second)))
+
+	return newRateLimitResult(policy, allowed, bucket.tokens), nil
+}
+
+// sweep forgets the buckets that refilled completely, at most once a minute
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for key, bucket := range s.buckets {
+		if now.After(bucket.fullAt) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// rateLimitRoute applies a policy to the routes starting with a path prefix. A * in the prefix matches one path
+// segment, such as the resume ID in /api/v1/resumes/*/tailor.
+type rateLimitRoute struct {
+	prefix string
+	policy RateLimitPolicy
+}
+
+// Rate limit policies. Logins and AI generation are expensive or attractive to abuse, so they are strict. Logins
+// fail closed: an outage of the store must not open the door to password guessing.
+var (
+	LoginRateLimit   = RateLimitPolicy{Name: "login", Requests: 5, Per: time.Minute, FailClosed: true}
+	AccountRateLimit = RateLimitPolicy{Name: "account", Requests: 10, Per: time.Minute}
+	AIRateLimit      = RateLimitPolicy{Name: "ai", Requests: 10, Per: time.Minute}
+	DefaultRateLimit = RateLimitPolicy{Name: "default", Requests: 120, Per: time.Minute}
+)
+
+// defaultRateLimitRoutes maps route prefixes to policies; the first matching prefix applies
+var defaultRateLimitRoutes = []rateLimitRoute{
+	{"/api/v1/auth/login", LoginRateLimit},
+	{"/api/v1/auth/2fa/verify", LoginRateLimit},
+	{"/api/v1/auth/register", AccountRateLimit},
+	{"/api/v1/auth/forgot-password", AccountRateLimit},
+	{"/api/v1/auth/reset-password", AccountRateLimit},
+	{"/api/v1/auth/verify-email", AccountRateLimit},
+	{"/api/v1/auth/resend-verification", AccountRateLimit},
+	{"/api/v1/ai/", AIRateLimit},
+	{"/api/v1/resumes/*/tailor", AIRateLimit},
+}
+
+// RateLimiter limits the requests of each client with token buckets. Authenticated requests are counted per user,
+// others per client IP.
+type RateLimiter struct {
+	store         RateLimitStore
+	routes        []rateLimitRoute
+	defaultPolicy RateLimitPolicy
+	authService   *services.AuthService
+}
+
+// NewRateLimiter creates a rate limiter with the default policies
+func NewRateLimiter(store RateLimitStore) *RateLimiter {
+	return &RateLimiter{
+		store:         store,
+		routes:        defaultRateLimitRoutes,
+		defaultPolicy: DefaultRateLimit,
+		authService:   services.NewAuthService(),
+	}
+}
+
+// NewRateLimiterFromEnv creates the rate limiter configured by the environment: RATE_LIMIT_STORE=redis shares the
+// buckets between instances through REDIS_URL, otherwise they are kept in memory. RATE_LIMIT_ENABLED=false turns
+// rate limiting off and returns nil. RATE_LIMIT_LOGIN_FAIL_OPEN=true lets logins through when the store fails.
+func NewRateLimiterFromEnv() *RateLimiter {
+	if enabled, err := strconv.ParseBool(os.Getenv("RATE_LIMIT_ENABLED")); err == nil && !enabled {
+		log.Println("RateLimiter: rate limiting is disabled")
+		return nil
+	}
+
+	var store RateLimitStore = NewMemoryRateLimitStore()
+	if os.Getenv("RATE_LIMIT_STORE") == "redis" {
+		redisStore, err := NewRedisRateLimitStore(os.Getenv("REDIS_URL"))
+		if err == nil {
+			store = redisStore
+		} else {
+			log.Printf("RateLimiter: %v, falling back to the in-memory store", err)
+		}
+	}
+
+	limiter := NewRateLimiter(store)
+	if failOpen, err := strconv.ParseBool(os.Getenv("RATE_LIMIT_LOGIN_FAIL_OPEN")); err == nil {
+		limiter.setFailClosed(LoginRateLimit.Name, !failOpen)
+	}
+	return limiter
+}
+
+// setFailClosed changes whether the policy with a name rejects requests when the store fails
+func (rl *RateLimiter) setFailClosed(name string, failClosed bool) {
+	routes := make([]rateLimitRoute, len(rl.routes))
+	for i, route := range rl.routes {
+		if route.policy.Name == name {
+			route.policy.FailClosed = failClosed
+		}
+		routes[i] = route
+	}
+	rl.routes = routes
+	if rl.defaultPolicy.Name == name {
+		rl.defaultPolicy.FailClosed = failClosed
+	}
+}
+
+// policyFor returns the policy of a request path
+func (rl *RateLimiter) policyFor(path string) RateLimitPolicy {
+	for _, route := range rl.routes {
+		if matchRoutePrefix(route.prefix, path) {
+			return route.policy
+		}
+	}
+	return rl.defaultPolicy
+}
+
+// matchRoutePrefix reports whether a path starts with a route prefix, where * matches any one segment
+func matchRoutePrefix(prefix, path string) bool {
+	if !strings.Contains(prefix, "*") {
+		return strings.HasPrefix(path, prefix)
+	}
+	prefixSegments := strings.Split(prefix, "/")
+	pathSegments := strings.Split(path, "/")
+	if len(pathSegments) < len(prefixSegments) {
+		return false
+	}
+	last := len(prefixSegments) - 1
+	for i, segment := range prefixSegments[:last] {
+		if segment != "*" && segment != pathSegments[i] {
+			return false
+		}
+	}
+	return prefixSegments[last] == "*" || strings.HasPrefix(pathSegments[last], prefixSegments[last])
+}
+
+// subject identifies the client of a request: the user of a valid bearer token, otherwise the client IP.
+// The limiter runs before AuthMiddleware, so it reads the token itself.
+func (rl *RateLimiter) subject(c *gin.Context) string {
+	if tokenString, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+		if claims, err := rl.authService.ValidateJWT(tokenString); err == nil {
+			return fmt.Sprintf("user:%d", claims.UserID)
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// RateLimiting takes a token for each request and answers 429 Too Many Requests when the bucket is empty. The
+// X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset (seconds until the bucket is full) headers are set
+// on every response, Retry-After on 429. A nil RateLimiter does not limit. When the store fails, requests of
+// policies that fail closed are answered 503 Service Unavailable and the others are let through.
+func (rl *RateLimiter) RateLimiting() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rl == nil || c.Request.Method == http.MethodOptions {
+			c.Next()
+			return
+		}
+
+		policy := rl.policyFor(c.Request.URL.Path)
+		result, err := rl.store.Take(c.Request.Context(), policy.Name+":"+rl.subject(c), policy)
+		if err != nil && policy.FailClosed {
+			log.Printf("RateLimiter: store error, rejecting %s request: %v", policy.Name, err)
+			utils.Error(c, utils.CodeServiceUnavailable, "Rate limiting is unavailable, please retry later", gin.H{
+				"policy": policy.Name,
+			})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			log.Printf("RateLimiter: store error, not limiting: %v", err)
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		if !result.Allowed {
+			retryAfter := ceilSeconds(result.RetryAfter)
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			utils.Error(c, utils.CodeTooManyRequests, "Too many requests, please retry later", gin.H{
+				"policy":      policy.Name,
+				"retry_after": retryAfter,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ceilSeconds rounds a duration up to whole seconds
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,272 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tokenBucketScript takes a token from the bucket hash KEYS[1] atomically. ARGV are the capacity and the refill
+// rate in tokens per millisecond; the time comes from the Redis server so all API instances share one clock. It
+// returns whether a token was taken and the tokens left.
+const tokenBucketScript = `
+-- Needed by Redis before 5 to write after reading the time, a no-op since Redis 5
+redis.replicate_commands()
+
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+  tokens = capacity
+  ts = now
+end
+
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((capacity - tokens) / rate) + 1000)
+return {allowed, tostring(tokens)}
+`
+
+var tokenBucketScriptSHA = func() string {
+	sum := sha1.Sum([]byte(tokenBucketScript))
+	return hex.EncodeToString(sum[:])
+}()
+
+// redisError is an error reply of the Redis server
+type redisError string
+
+func (e redisError) Error() string { return string(e) }
+
+// RedisRateLimitStore keeps the buckets in Redis, shared by all API instances. It speaks the Redis protocol over a
+// small pool of connections.
+type RedisRateLimitStore struct {
+	addr      string
+	password  string
+	db        int
+	timeout   time.Duration
+	keyPrefix string
+	conns     chan *redisConn
+}
+
+// NewRedisRateLimitStore creates a store for a redis://[:password@]host[:port][/db] URL and checks that the server
+// answers
+func NewRedisRateLimitStore(redisURL string) (*RedisRateLimitStore, error) {
+	if redisURL == "" {
+		return nil, errors.New("REDIS_URL is not set")
+	}
+	parsed, err := url.Parse(redisURL)
+	if err != nil || parsed.Scheme != "redis" || parsed.Hostname() == "" {
+		return nil, fmt.Errorf("invalid REDIS_URL %q, expected redis://[:password@]host[:port][/db]", redisURL)
+	}
+
+	store := &RedisRateLimitStore{
+		addr:      parsed.Host,
+		timeout:   500 * time.Millisecond,
+		keyPrefix: "cvilo:ratelimit:",
+		conns:     make(chan *redisConn, 8),
+	}
+	if parsed.Port() == "" {
+		store.addr = net.JoinHostPort(parsed.Hostname(), "6379")
+	}
+	if password, ok := parsed.User.Password(); ok {
+		store.password = password
+	}
+	if db := strings.TrimPrefix(parsed.Path, "/"); db != "" {
+		if store.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("invalid Redis database %q", db)
+		}
+	}
+
+	conn, err := store.dial()
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis at %s: %v", store.addr, err)
+	}
+	store.release(conn)
+	return store, nil
+}
+
+// Take takes a token from the bucket of key
+func (s *RedisRateLimitStore) Take(ctx context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error) {
+	capacity := strconv.Itoa(policy.Requests)
+	ratePerMs := strconv.FormatFloat(policy.refillRate()/1000, 'g', -1, 64)
+	args := []string{tokenBucketScriptSHA, "1", s.keyPrefix + key, capacity, ratePerMs}
+
+	reply, err := s.do(ctx, append([]string{"EVALSHA"}, args...)...)
+	var replyErr redisError
+	if errors.As(err, &replyErr) && strings.HasPrefix(string(replyErr), "NOSCRIPT") {
+		args[0] = tokenBucketScript
+		reply, err = s.do(ctx, append([]string{"EVAL"}, args...)...)
+	}
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 2 {
+		return RateLimitResult{}, fmt.Errorf("unexpected Redis reply %v", reply)
+	}
+	allowed, _ := values[0].(int64)
+	tokensText, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(tokensText, 64)
+	if err != nil {
+		return RateLimitResult{}, fmt.Errorf("unexpected Redis reply %v", reply)
+	}
+	return newRateLimitResult(policy, allowed == 1, tokens), nil
+}
+
+// do runs a command on a pooled connection
+func (s *RedisRateLimitStore) do(ctx context.Context, args ...string) (interface{}, error) {
+	conn, err := s.acquire()
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(s.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	reply, err := conn.do(deadline, args...)
+
+	var replyErr redisError
+	if err != nil && !errors.As(err, &replyErr) {
+		// The connection is in an unknown state after a network error
+		conn.Close()
+		return nil, err
+	}
+	s.release(conn)
+	return reply, err
+}
+
+func (s *RedisRateLimitStore) acquire() (*redisConn, error) {
+	select {
+	case conn := <-s.conns:
+		return conn, nil
+	default:
+		return s.dial()
+	}
+}
+
+func (s *RedisRateLimitStore) release(conn *redisConn) {
+	select {
+	case s.conns <- conn:
+	default:
+		conn.Close()
+	}
+}
+
+// dial opens a connection, authenticating and selecting the database
+func (s *RedisRateLimitStore) dial() (*redisConn, error) {
+	netConn, err := net.DialTimeout("tcp", s.addr, s.timeout)
+	if err != nil {
+		return nil, err
+	}
+	conn := &redisConn{Conn: netConn, reader: bufio.NewReader(netConn)}
+
+	deadline := time.Now().Add(s.timeout)
+	if s.password != "" {
+		if _, err := conn.do(deadline, "AUTH", s.password); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if s.db != 0 {
+		if _, err := conn.do(deadline, "SELECT", strconv.Itoa(s.db)); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if _, err := conn.do(deadline, "PING"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// redisConn is a connection speaking RESP, the Redis protocol
+type redisConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// do sends a command and reads its reply
+func (c *redisConn) do(deadline time.Time, args ...string) (interface{}, error) {
+	if err := c.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	var command strings.Builder
+	fmt.Fprintf(&command, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&command, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c, command.String()); err != nil {
+		return nil, err
+	}
+	return readRedisReply(c.reader)
+}
+
+// readRedisReply reads a reply: simple strings and bulk strings as string, integers as int64, arrays as
+// []interface{} and nil bulk strings as nil. Error replies are returned as redisError.
+func readRedisReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty Redis reply")
+	}
+
+	payload := line[1:]
+	switch line[0] {
+	case '+':
+		return payload, nil
+	case '-':
+		return nil, redisError(payload)
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case '$':
+		size, err := strconv.Atoi(payload)
+		if err != nil || size < 0 {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:size]), nil
+	case '*':
+		count, err := strconv.Atoi(payload)
+		if err != nil || count < 0 {
+			return nil, err
+		}
+		values := make([]interface{}, count)
+		for i := range values {
+			if values[i], err = readRedisReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("unexpected Redis reply %q", line)
+}
@@ -0,0 +1,317 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis is a Redis server speaking enough RESP for RedisRateLimitStore: AUTH, SELECT, PING, and EVALSHA and
+// EVAL of the token bucket script, which it runs without refilling. Queued replies are sent instead of the normal
+// answer to the next commands; an empty one closes the connection.
+type fakeRedis struct {
+	listener net.Listener
+	password string
+
+	mu       sync.Mutex
+	dials    int
+	commands [][]string
+	replies  []string
+	loaded   bool // the script was run with EVAL, so EVALSHA finds it
+	tokens   map[string]float64
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	f := &fakeRedis{listener: listener, password: password, tokens: make(map[string]float64)}
+	go f.serve()
+	return f
+}
+
+func (f *fakeRedis) url(path string) string {
+	return "redis://:" + f.password + "@" + f.listener.Addr().String() + path
+}
+
+func (f *fakeRedis) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.dials++
+		f.mu.Unlock()
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		request, err := readRedisReply(reader)
+		if err != nil {
+			return
+		}
+		values, _ := request.([]interface{})
+		args := make([]string, len(values))
+		for i, value := range values {
+			args[i], _ = value.(string)
+		}
+		reply := f.answer(args)
+		if reply == "" {
+			return
+		}
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) answer(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.commands = append(f.commands, args)
+	if len(f.replies) > 0 {
+		reply := f.replies[0]
+		f.replies = f.replies[1:]
+		return reply
+	}
+
+	switch args[0] {
+	case "AUTH":
+		if len(args) != 2 || args[1] != f.password {
+			return "-WRONGPASS invalid username-password pair or user is disabled.\r\n"
+		}
+		return "+OK\r\n"
+	case "SELECT":
+		return "+OK\r\n"
+	case "PING":
+		return "+PONG\r\n"
+	case "EVALSHA":
+		if !f.loaded || args[1] != tokenBucketScriptSHA {
+			return "-NOSCRIPT No matching script. Please use EVAL.\r\n"
+		}
+		return f.takeToken(args)
+	case "EVAL":
+		if args[1] != tokenBucketScript {
+			return "-ERR unexpected script\r\n"
+		}
+		f.loaded = true
+		return f.takeToken(args)
+	}
+	return fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+}
+
+// takeToken runs the token bucket script for EVAL and EVALSHA arguments: script, 1, key, capacity and rate
+func (f *fakeRedis) takeToken(args []string) string {
+	if len(args) != 6 || args[2] != "1" {
+		return "-ERR wrong number of arguments\r\n"
+	}
+	tokens, ok := f.tokens[args[3]]
+	if !ok {
+		tokens, _ = strconv.ParseFloat(args[4], 64)
+	}
+	allowed := 0
+	if tokens >= 1 {
+		tokens--
+		allowed = 1
+	}
+	f.tokens[args[3]] = tokens
+	text := strconv.FormatFloat(tokens, 'f', -1, 64)
+	return fmt.Sprintf("*2\r\n:%d\r\n$%d\r\n%s\r\n", allowed, len(text), text)
+}
+
+// takeCommands returns the names of the commands received since the last call
+func (f *fakeRedis) takeCommands() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	names := make([]string, 0, len(f.commands))
+	for _, command := range f.commands {
+		names = append(names, command[0])
+	}
+	f.commands = nil
+	return names
+}
+
+func (f *fakeRedis) lastCommand() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.commands) == 0 {
+		return nil
+	}
+	return f.commands[len(f.commands)-1]
+}
+
+func (f *fakeRedis) queueReply(reply string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.replies = append(f.replies, reply)
+}
+
+func (f *fakeRedis) dialCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.dials
+}
+
+func TestRedisRateLimitStore(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+	store, err := NewRedisRateLimitStore(server.url("/2"))
+	if err != nil {
+		t.Fatalf("NewRedisRateLimitStore() error: %v", err)
+	}
+	if commands := server.takeCommands(); !reflect.DeepEqual(commands, []string{"AUTH", "SELECT", "PING"}) {
+		t.Errorf("commands on connect = %v, want [AUTH SELECT PING]", commands)
+	}
+
+	ctx := context.Background()
+	policy := RateLimitPolicy{Name: "test", Requests: 2, Per: time.Minute}
+	tests := []struct {
+		name      string
+		commands  []string
+		allowed   bool
+		remaining int
+	}{
+		{"Script not loaded yet", []string{"EVALSHA", "EVAL"}, true, 1},
+		{"Script loaded", []string{"EVALSHA"}, true, 0},
+		{"Empty bucket", []string{"EVALSHA"}, false, 0},
+	}
+
+	for _, tt := range tests {
+		result, err := store.Take(ctx, "test:ip:192.0.2.1", policy)
+		if err != nil {
+			t.Fatalf("%s: Take() error: %v", tt.name, err)
+		}
+		if result.Allowed != tt.allowed || result.Remaining != tt.remaining {
+			t.Errorf("%s: Take() = %+v, want allowed %v with %d remaining", tt.name, result, tt.allowed, tt.remaining)
+		}
+		if !tt.allowed && result.RetryAfter != 30*time.Second {
+			t.Errorf("%s: RetryAfter = %s, want 30s", tt.name, result.RetryAfter)
+		}
+		if commands := server.takeCommands(); !reflect.DeepEqual(commands, tt.commands) {
+			t.Errorf("%s: commands = %v, want %v", tt.name, commands, tt.commands)
+		}
+	}
+
+	store.Take(ctx, "test:ip:192.0.2.2", policy)
+	command := server.lastCommand()
+	expected := []string{"EVALSHA", tokenBucketScriptSHA, "1", "cvilo:ratelimit:test:ip:192.0.2.2", "2", strconv.FormatFloat(2.0/60/1000, 'g', -1, 64)}
+	if !reflect.DeepEqual(command, expected) {
+		t.Errorf("EVALSHA arguments = %q, want %q", command, expected)
+	}
+	if dials := server.dialCount(); dials != 1 {
+		t.Errorf("connections = %d, want 1 reused from the pool", dials)
+	}
+}
+
+func TestRedisRateLimitStoreErrors(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+	store, err := NewRedisRateLimitStore(server.url(""))
+	if err != nil {
+		t.Fatalf("NewRedisRateLimitStore() error: %v", err)
+	}
+	ctx := context.Background()
+	policy := RateLimitPolicy{Name: "test", Requests: 2, Per: time.Minute}
+
+	server.queueReply("-ERR OOM command not allowed when used memory > 'maxmemory'.\r\n")
+	_, err = store.Take(ctx, "key", policy)
+	var replyErr redisError
+	if !errors.As(err, &replyErr) || !strings.HasPrefix(string(replyErr), "ERR OOM") {
+		t.Errorf("Take() on an error reply error = %v, want the Redis error", err)
+	}
+	if dials := server.dialCount(); dials != 1 {
+		t.Errorf("connections after an error reply = %d, want 1, the connection is still usable", dials)
+	}
+
+	server.queueReply("+OK\r\n")
+	if _, err := store.Take(ctx, "key", policy); err == nil || !strings.Contains(err.Error(), "unexpected Redis reply") {
+		t.Errorf("Take() on an unexpected reply error = %v, want an unexpected reply error", err)
+	}
+
+	server.queueReply("")
+	if _, err := store.Take(ctx, "key", policy); err == nil {
+		t.Errorf("Take() on a closed connection error = nil, want an error")
+	}
+	if result, err := store.Take(ctx, "key", policy); err != nil || !result.Allowed {
+		t.Errorf("Take() after a closed connection = %+v, %v, want allowed on a new connection", result, err)
+	}
+	if dials := server.dialCount(); dials != 2 {
+		t.Errorf("connections after a closed connection = %d, want 2", dials)
+	}
+
+	// Pooled connections stay open, so start from an empty pool
+	server.listener.Close()
+	store.conns = make(chan *redisConn, 8)
+	if _, err := store.Take(ctx, "key", policy); err == nil {
+		t.Errorf("Take() with the server down error = nil, want an error")
+	}
+}
+
+func TestNewRedisRateLimitStore(t *testing.T) {
+	server := newFakeRedis(t, "secret")
+
+	tests := []struct {
+		name     string
+		redisURL string
+	}{
+		{"Not set", ""},
+		{"Other scheme", "http://localhost:6379"},
+		{"No host", "redis:///0"},
+		{"Invalid database", "redis://localhost:6379/cache"},
+		{"Wrong password", "redis://:wrong@" + server.listener.Addr().String()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewRedisRateLimitStore(tt.redisURL); err == nil {
+				t.Errorf("NewRedisRateLimitStore(%q) error = nil, want an error", tt.redisURL)
+			}
+		})
+	}
+}
+
+func TestReadRedisReply(t *testing.T) {
+	tests := []struct {
+		name     string
+		reply    string
+		expected interface{}
+	}{
+		{"Simple string", "+PONG\r\n", "PONG"},
+		{"Integer", ":1\r\n", int64(1)},
+		{"Bulk string", "$4\r\n3.25\r\n", "3.25"},
+		{"Nil bulk string", "$-1\r\n", nil},
+		{"Token bucket result", "*2\r\n:0\r\n$18\r\n0.0833333333333333\r\n", []interface{}{int64(0), "0.0833333333333333"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := readRedisReply(bufio.NewReader(strings.NewReader(tt.reply)))
+			if err != nil {
+				t.Fatalf("readRedisReply() error: %v", err)
+			}
+			if !reflect.DeepEqual(value, tt.expected) {
+				t.Errorf("readRedisReply(%q) = %#v, want %#v", tt.reply, value, tt.expected)
+			}
+		})
+	}
+
+	_, err := readRedisReply(bufio.NewReader(strings.NewReader("-NOSCRIPT No matching script\r\n")))
+	if err == nil || err.Error() != "NOSCRIPT No matching script" {
+		t.Errorf("readRedisReply(error reply) error = %v, want the Redis error", err)
+	}
+}
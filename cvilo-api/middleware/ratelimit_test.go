@@ -0,0 +1,211 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/smhnaqvi/cvilo/models"
+	"github.com/smhnaqvi/cvilo/services"
+)
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	now := time.Unix(1700000000, 0)
+	store.now = func() time.Time { return now }
+	policy := RateLimitPolicy{Name: "test", Requests: 5, Per: time.Minute} // one token every 12 seconds
+
+	for i := 0; i < 5; i++ {
+		result, _ := store.Take(context.Background(), "key", policy)
+		if !result.Allowed || result.Remaining != 4-i {
+			t.Fatalf("Take() #%d = %+v, want allowed with %d remaining", i+1, result, 4-i)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		advance    time.Duration
+		allowed    bool
+		retryAfter time.Duration
+		reset      time.Duration
+	}{
+		{"Empty bucket", 0, false, 12 * time.Second, time.Minute},
+		{"Partly refilled", 6 * time.Second, false, 6 * time.Second, 54 * time.Second},
+		{"One token refilled", 6 * time.Second, true, 0, time.Minute},
+		{"Full again after idling", 2 * time.Minute, true, 0, 12 * time.Second},
+	}
+
+	for _, tt := range tests {
+		now = now.Add(tt.advance)
+		result, _ := store.Take(context.Background(), "key", policy)
+		if result.Allowed != tt.allowed || result.RetryAfter != tt.retryAfter || result.Reset != tt.reset {
+			t.Errorf("%s: Take() = %+v, want allowed %v, retry after %s, reset %s", tt.name, result, tt.allowed, tt.retryAfter, tt.reset)
+		}
+	}
+
+	if result, _ := store.Take(context.Background(), "other", policy); !result.Allowed || result.Remaining != 4 {
+		t.Errorf("Take(other) = %+v, want a bucket of its own", result)
+	}
+}
+
+func TestRateLimiting(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(NewRateLimiter(NewMemoryRateLimitStore()).RateLimiting())
+	router.POST("/api/v1/auth/login", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/api/v1/resumes", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	token, err := services.NewAuthService().GenerateJWT(models.UserModel{ID: 7, Email: "jane@example.com"})
+	if err != nil {
+		t.Fatalf("GenerateJWT() error: %v", err)
+	}
+
+	request := func(method, path, ip, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = ip + ":40000"
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < LoginRateLimit.Requests; i++ {
+		if w := request(http.MethodPost, "/api/v1/auth/login", "192.0.2.1", ""); w.Code != http.StatusOK {
+			t.Fatalf("login #%d = %d, want %d", i+1, w.Code, http.StatusOK)
+		}
+	}
+
+	w := request(http.MethodPost, "/api/v1/auth/login", "192.0.2.1", "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("login over the limit = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	expectedHeaders := map[string]string{
+		"X-RateLimit-Limit":     "5",
+		"X-RateLimit-Remaining": "0",
+		"X-RateLimit-Reset":     "60",
+		"Retry-After":           "12",
+	}
+	for header, expected := range expectedHeaders {
+		if value := w.Header().Get(header); value != expected {
+			t.Errorf("%s = %q, want %q", header, value, expected)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		ip            string
+		authorization string
+		expected      int
+	}{
+		{"Other IP", http.MethodPost, "/api/v1/auth/login", "192.0.2.2", "", http.StatusOK},
+		{"Authenticated user counted apart from the IP", http.MethodPost, "/api/v1/auth/login", "192.0.2.1", "Bearer " + token, http.StatusOK},
+		{"Invalid token counted by IP", http.MethodPost, "/api/v1/auth/login", "192.0.2.1", "Bearer invalid", http.StatusTooManyRequests},
+		{"Other policy", http.MethodGet, "/api/v1/resumes", "192.0.2.1", "", http.StatusOK},
+		{"Preflight not counted", http.MethodOptions, "/api/v1/auth/login", "192.0.2.1", "", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := request(tt.method, tt.path, tt.ip, tt.authorization); w.Code != tt.expected {
+				t.Errorf("%s %s = %d, want %d", tt.method, tt.path, w.Code, tt.expected)
+			}
+		})
+	}
+}
+
+// failingRateLimitStore is a store that is down
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Take(context.Context, string, RateLimitPolicy) (RateLimitResult, error) {
+	return RateLimitResult{}, errors.New("connection refused")
+}
+
+func TestRateLimitingStoreFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		loginFailOpen bool
+		path          string
+		expected      int
+	}{
+		{"Login fails closed", false, "/api/v1/auth/login", http.StatusServiceUnavailable},
+		{"Second factor fails closed", false, "/api/v1/auth/2fa/verify", http.StatusServiceUnavailable},
+		{"Login configured to fail open", true, "/api/v1/auth/login", http.StatusOK},
+		{"Other policies fail open", false, "/api/v1/resumes", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewRateLimiter(failingRateLimitStore{})
+			if tt.loginFailOpen {
+				limiter.setFailClosed(LoginRateLimit.Name, false)
+			}
+			router := gin.New()
+			router.Use(limiter.RateLimiting())
+			router.Any("/*path", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.path, nil))
+			if w.Code != tt.expected {
+				t.Errorf("POST %s = %d, want %d", tt.path, w.Code, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNewRateLimiterFromEnvLoginFailOpen(t *testing.T) {
+	tests := []struct {
+		value      string
+		failClosed bool
+	}{
+		{"", true},
+		{"false", true},
+		{"true", false},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("RATE_LIMIT_LOGIN_FAIL_OPEN", tt.value)
+		limiter := NewRateLimiterFromEnv()
+		if policy := limiter.policyFor("/api/v1/auth/login"); policy.FailClosed != tt.failClosed {
+			t.Errorf("RATE_LIMIT_LOGIN_FAIL_OPEN=%q: FailClosed = %v, want %v", tt.value, policy.FailClosed, tt.failClosed)
+		}
+	}
+	if !LoginRateLimit.FailClosed {
+		t.Errorf("LoginRateLimit.FailClosed changed by the environment")
+	}
+}
+
+func TestRateLimitPolicies(t *testing.T) {
+	limiter := NewRateLimiter(NewMemoryRateLimitStore())
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/api/v1/auth/login", "login"},
+		{"/api/v1/auth/2fa/verify", "login"},
+		{"/api/v1/auth/forgot-password", "account"},
+		{"/api/v1/ai/generate", "ai"},
+		{"/api/v1/ai/users/1/history", "ai"},
+		{"/api/v1/auth/me", "default"},
+		{"/api/v1/resumes/1", "default"},
+		{"/api/v1/resumes/1/tailor", "ai"},
+		{"/api/v1/resumes/1/clone", "default"},
+		{"/api/v1/resumes/tailor", "default"},
+	}
+
+	for _, tt := range tests {
+		if policy := limiter.policyFor(tt.path); policy.Name != tt.expected {
+			t.Errorf("policyFor(%s) = %s, want %s", tt.path, policy.Name, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package migration
+
+import (
+	"gorm.io/gorm"
+)
+
+// auditLogTriggerSQL makes audit_logs append-only in the database, so entries cannot be changed or removed by
+// raw SQL or code skipping the GORM hooks either. TRUNCATE is refused too; archiving the log takes dropping the
+// trigger on purpose.
+const auditLogTriggerSQL = `
+CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_logs is append-only, % is not allowed', TG_OP;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_logs_no_update_delete ON audit_logs;
+CREATE TRIGGER audit_logs_no_update_delete BEFORE UPDATE OR DELETE ON audit_logs
+	FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only();
+
+DROP TRIGGER IF EXISTS audit_logs_no_truncate ON audit_logs;
+CREATE TRIGGER audit_logs_no_truncate BEFORE TRUNCATE ON audit_logs
+	FOR EACH STATEMENT EXECUTE FUNCTION audit_logs_append_only();
+`
+
+// MigrateAuditLogs installs the triggers keeping the audit log append-only. It is safe to rerun.
+func MigrateAuditLogs(db *gorm.DB) error {
+	return db.Exec(auditLogTriggerSQL).Error
+}
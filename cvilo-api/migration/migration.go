@@ -10,10 +10,31 @@ import (
 // Auto-migrate the schemas
 func AutoMigrate() error {
 	db := database.GetPostgresDB()
-	err := db.AutoMigrate(&models.UserModel{}, &models.ResumeModel{}, &models.LinkedInAuthModel{}, &models.ChatPromptHistory{})
+	err := db.AutoMigrate(&models.UserModel{}, &models.JobDescriptionModel{}, &models.ResumeModel{}, &models.LinkedInAuthModel{}, &models.ChatPromptHistory{}, &models.RefreshTokenModel{}, &models.UserTokenModel{}, &models.TwoFactorModel{}, &models.RecoveryCodeModel{}, &models.LoginThrottleModel{}, &models.AuditLogModel{})
 	if err != nil {
 		return err
 	}
+	if err := MigrateAuditLogs(db); err != nil {
+		return err
+	}
+
+	// Section tables reference resumes, so they are migrated after it
+	if err := db.AutoMigrate(models.ResumeSectionModels()...); err != nil {
+		return err
+	}
+	if err := MigrateResumeSections(db); err != nil {
+		return err
+	}
+
+	if err := db.AutoMigrate(&models.ResumeVersionModel{}); err != nil {
+		return err
+	}
+	if err := MigrateResumeVersions(db); err != nil {
+		return err
+	}
+	if err := MigrateResumeSearchIndex(db); err != nil {
+		return err
+	}
 
 	log.Println("PostgreSQL database connected and migrated successfully")
 	return nil
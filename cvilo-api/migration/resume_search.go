@@ -0,0 +1,36 @@
+package migration
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/smhnaqvi/cvilo/models"
+	"gorm.io/gorm"
+)
+
+// MigrateResumeSearchIndex builds the full-text index of resumes that do not have one yet.
+// Saves keep the index up to date afterwards, so only resumes from before search existed are indexed.
+func MigrateResumeSearchIndex(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		indexed := 0
+		var batch []models.ResumeModel
+		result := tx.Where("search_vector IS NULL").
+			FindInBatches(&batch, 100, func(batchTx *gorm.DB, _ int) error {
+				for i := range batch {
+					if err := batch[i].UpdateSearchIndex(tx); err != nil {
+						return fmt.Errorf("failed to index resume %d: %w", batch[i].ID, err)
+					}
+					indexed++
+				}
+				return nil
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if indexed > 0 {
+			log.Printf("Built the search index of %d existing resumes", indexed)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,83 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/smhnaqvi/cvilo/models"
+	"gorm.io/gorm"
+)
+
+// legacyResumeSections holds the JSON section columns resumes had before sections moved to child tables
+type legacyResumeSections struct {
+	ID             uint
+	Experience     string
+	Education      string
+	Skills         string
+	Languages      string
+	Certifications string
+	Projects       string
+}
+
+// MigrateResumeSections copies the JSON section columns of existing resumes into the section tables.
+// The old columns are renamed to legacy_<section> rather than dropped, so sections that could not be
+// decoded can still be recovered by hand. The migration runs in a single transaction and is skipped
+// once the old columns are gone.
+func MigrateResumeSections(db *gorm.DB) error {
+	if !db.Migrator().HasColumn("resumes", models.SectionExperience) {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		columns := []string{"id"}
+		for _, name := range models.ResumeSectionNames {
+			columns = append(columns, fmt.Sprintf("COALESCE(%s, '') AS %s", name, name))
+		}
+
+		migrated, skipped := 0, 0
+		var batch []legacyResumeSections
+		result := tx.Table("resumes").Select(strings.Join(columns, ", ")).
+			FindInBatches(&batch, 100, func(batchTx *gorm.DB, _ int) error {
+				for _, row := range batch {
+					resume := models.ResumeModel{
+						ID:             row.ID,
+						Experience:     row.Experience,
+						Education:      row.Education,
+						Skills:         row.Skills,
+						Languages:      row.Languages,
+						Certifications: row.Certifications,
+						Projects:       row.Projects,
+					}
+					// Sections are saved one by one so a malformed section does not lose the others
+					for _, name := range models.ResumeSectionNames {
+						err := resume.SaveSections(tx, name)
+						var sectionErr *models.SectionError
+						if errors.As(err, &sectionErr) {
+							log.Printf("Warning: resume %d: %v, kept in legacy_%s", row.ID, err, name)
+							skipped++
+							continue
+						}
+						if err != nil {
+							return fmt.Errorf("failed to migrate %s of resume %d: %w", name, row.ID, err)
+						}
+					}
+					migrated++
+				}
+				return nil
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		for _, name := range models.ResumeSectionNames {
+			if err := tx.Migrator().RenameColumn("resumes", name, "legacy_"+name); err != nil {
+				return fmt.Errorf("failed to rename resumes.%s: %w", name, err)
+			}
+		}
+
+		log.Printf("Migrated sections of %d resumes to section tables (%d malformed sections skipped)", migrated, skipped)
+		return nil
+	})
+}
@@ -0,0 +1,38 @@
+package migration
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/smhnaqvi/cvilo/models"
+	"gorm.io/gorm"
+)
+
+// MigrateResumeVersions records a first version for resumes saved before versions existed,
+// so their current content can be compared with and restored after the next save.
+// Resumes that already have a version are left alone, which makes the migration safe to rerun.
+func MigrateResumeVersions(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		recorded := 0
+		var batch []models.ResumeModel
+		result := tx.Where("id NOT IN (?)", tx.Model(&models.ResumeVersionModel{}).Select("resume_id")).
+			FindInBatches(&batch, 100, func(batchTx *gorm.DB, _ int) error {
+				for i := range batch {
+					change := models.ResumeChange{AuthorID: batch[i].UserID, Source: models.VersionSourceManual}
+					if _, err := batch[i].RecordVersion(tx, change); err != nil {
+						return fmt.Errorf("failed to record the first version of resume %d: %w", batch[i].ID, err)
+					}
+					recorded++
+				}
+				return nil
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if recorded > 0 {
+			log.Printf("Recorded a first version for %d existing resumes", recorded)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,221 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/smhnaqvi/cvilo/database"
+	"gorm.io/gorm"
+)
+
+// Audit actions, named <area>.<event>
+const (
+	AuditActionLogin                 = "auth.login"
+	AuditActionLoginFailed           = "auth.login_failed"
+	AuditActionRegister              = "auth.register"
+	AuditActionLogout                = "auth.logout"
+	AuditActionLogoutAll             = "auth.logout_all"
+	AuditActionSessionRevoked        = "auth.session_revoked"
+	AuditActionPasswordChanged       = "auth.password_changed"
+	AuditActionPasswordReset         = "auth.password_reset"
+	AuditActionEmailVerified         = "auth.email_verified"
+	AuditActionTwoFactorEnabled      = "auth.2fa_enabled"
+	AuditActionTwoFactorDisabled     = "auth.2fa_disabled"
+	AuditActionRecoveryCodesReplaced = "auth.recovery_codes_replaced"
+	AuditActionAccountLocked         = "auth.account_locked"
+	AuditActionAccountUnlocked       = "auth.account_unlocked"
+	AuditActionIPBlocked             = "auth.ip_blocked"
+
+	AuditActionUserCreated       = "user.created"
+	AuditActionUserUpdated       = "user.updated"
+	AuditActionUserDeleted       = "user.deleted"
+	AuditActionUserStatusChanged = "user.status_changed"
+	AuditActionUserRoleChanged   = "user.role_changed"
+
+	AuditActionResumeCreated       = "resume.created"
+	AuditActionResumeUpdated       = "resume.updated"
+	AuditActionResumeDeleted       = "resume.deleted"
+	AuditActionResumeCloned        = "resume.cloned"
+	AuditActionResumeStatusChanged = "resume.status_changed"
+	AuditActionResumeImported      = "resume.imported"
+	AuditActionResumeRestored      = "resume.version_restored"
+
+	AuditActionLinkedInConnected    = "linkedin.connected"
+	AuditActionLinkedInSynced       = "linkedin.synced"
+	AuditActionLinkedInDisconnected = "linkedin.disconnected"
+
+	AuditActionAIResumeGenerated = "ai.resume_generated"
+	AuditActionAIResumeUpdated   = "ai.resume_updated"
+	AuditActionAIResumePatched   = "ai.resume_patched"
+	AuditActionAIResumeTailored  = "ai.resume_tailored"
+)
+
+// Audit target types
+const (
+	AuditTargetUser    = "user"
+	AuditTargetResume  = "resume"
+	AuditTargetSession = "session"
+	AuditTargetAccount = "account" // an email without a user, such as a locked unknown account
+	AuditTargetIP      = "ip"
+)
+
+// ErrAuditLogImmutable is returned when changing or deleting an audit log entry
+var ErrAuditLogImmutable = errors.New("audit log entries cannot be changed or deleted")
+
+// AuditLogModel records a security relevant event. Entries are append-only: the hooks below refuse updates and
+// deletes through GORM and a trigger installed by the migration refuses them in the database.
+type AuditLogModel struct {
+	ID         uint   `json:"id" gorm:"primarykey"`
+	ActorID    *uint  `json:"actor_id,omitempty" gorm:"index"` // user who caused the event, nil for anonymous requests
+	Action     string `json:"action" gorm:"type:varchar(64);not null;index"`
+	TargetType string `json:"target_type" gorm:"type:varchar(32);index:idx_audit_logs_target"`
+	TargetID   string `json:"target_id" gorm:"type:varchar(255);index:idx_audit_logs_target"`
+	IPAddress  string `json:"ip_address" gorm:"type:varchar(45)"`
+	UserAgent  string `json:"user_agent" gorm:"type:text"`
+	RequestID  string `json:"request_id,omitempty" gorm:"type:varchar(64);index"`
+
+	// Before and After summarize the target around a change, see UserModel.AuditSummary and
+	// ResumeModel.AuditSummary
+	Before  map[string]interface{} `json:"before,omitempty" gorm:"type:jsonb;serializer:json"`
+	After   map[string]interface{} `json:"after,omitempty" gorm:"type:jsonb;serializer:json"`
+	Details map[string]interface{} `json:"details,omitempty" gorm:"type:jsonb;serializer:json"`
+
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// TableName overrides the table name used by AuditLogModel to `audit_logs`
+func (AuditLogModel) TableName() string {
+	return "audit_logs"
+}
+
+// BeforeUpdate keeps the audit log append-only
+func (a *AuditLogModel) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
+
+// BeforeDelete keeps the audit log append-only
+func (a *AuditLogModel) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
+
+// Create stores the event
+func (a *AuditLogModel) Create() error {
+	db := database.GetPostgresDB()
+	return db.Create(a).Error
+}
+
+// AuditLogFilter selects audit log entries; zero fields do not filter
+type AuditLogFilter struct {
+	ActorID    *uint
+	Action     string // an action, or a prefix ending in * such as auth.*
+	TargetType string
+	TargetID   string
+	IPAddress  string
+	RequestID  string
+	From       *time.Time // inclusive
+	To         *time.Time // exclusive
+
+	Offset int
+	Limit  int
+}
+
+// apply adds the conditions of the filter to a query
+func (f AuditLogFilter) apply(db *gorm.DB) *gorm.DB {
+	if f.ActorID != nil {
+		db = db.Where("actor_id = ?", *f.ActorID)
+	}
+	if prefix, ok := strings.CutSuffix(f.Action, "*"); ok {
+		db = db.Where("action LIKE ?", prefixPattern(prefix))
+	} else if f.Action != "" {
+		db = db.Where("action = ?", f.Action)
+	}
+	if f.TargetType != "" {
+		db = db.Where("target_type = ?", f.TargetType)
+	}
+	if f.TargetID != "" {
+		db = db.Where("target_id = ?", f.TargetID)
+	}
+	if f.IPAddress != "" {
+		db = db.Where("ip_address = ?", f.IPAddress)
+	}
+	if f.RequestID != "" {
+		db = db.Where("request_id = ?", f.RequestID)
+	}
+	if f.From != nil {
+		db = db.Where("created_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		db = db.Where("created_at < ?", *f.To)
+	}
+	return db
+}
+
+// prefixPattern builds a LIKE pattern matching values starting with prefix, with its wildcards escaped
+func prefixPattern(prefix string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(prefix) + "%"
+}
+
+// Search returns a page of the matching entries, newest first, and the number of matches
+func (a *AuditLogModel) Search(filter AuditLogFilter) ([]AuditLogModel, int64, error) {
+	db := database.GetPostgresDB()
+	var entries []AuditLogModel
+	var total int64
+
+	query := filter.apply(db.Model(&AuditLogModel{}))
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Order("created_at DESC, id DESC").Offset(filter.Offset).Limit(filter.Limit).Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+// EachBatch calls fn with the matching entries in batches of batchSize, oldest first, so exports do not load
+// the whole log into memory. The offset and limit of the filter are ignored.
+func (a *AuditLogModel) EachBatch(filter AuditLogFilter, batchSize int, fn func([]AuditLogModel) error) error {
+	db := database.GetPostgresDB()
+	var batch []AuditLogModel
+	return filter.apply(db.Model(&AuditLogModel{})).
+		FindInBatches(&batch, batchSize, func(_ *gorm.DB, _ int) error {
+			return fn(batch)
+		}).Error
+}
+
+// AuditSummary describes a user for the before and after of an audit event, without personal details beyond
+// the email
+func (u *UserModel) AuditSummary() map[string]interface{} {
+	return map[string]interface{}{
+		"name":               u.Name,
+		"email":              u.Email,
+		"role":               u.Role,
+		"is_active":          u.IsActive,
+		"email_verified":     u.EmailVerifiedAt != nil,
+		"two_factor_enabled": u.TwoFactorEnabled,
+	}
+}
+
+// AuditSummary describes a resume for the before and after of an audit event: its metadata and the number of
+// entries per section rather than the content, which the resume versions keep
+func (r *ResumeModel) AuditSummary() map[string]interface{} {
+	summary := map[string]interface{}{
+		"user_id":   r.UserID,
+		"title":     r.Title,
+		"full_name": r.FullName,
+		"is_active": r.IsActive,
+		"template":  r.Template,
+		"theme":     r.Theme,
+	}
+	if snapshot, err := NewResumeSnapshot(r); err == nil {
+		summary["sections"] = map[string]int{
+			SectionExperience:     len(snapshot.Experience),
+			SectionEducation:      len(snapshot.Education),
+			SectionSkills:         len(snapshot.Skills),
+			SectionLanguages:      len(snapshot.Languages),
+			SectionCertifications: len(snapshot.Certifications),
+			SectionProjects:       len(snapshot.Projects),
+		}
+	}
+	return summary
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPrefixPattern(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{value: "auth.", expected: "auth.%"},
+		{value: "auth.login_", expected: `auth.login\_%`},
+		{value: "", expected: "%"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if result := prefixPattern(tt.value); result != tt.expected {
+				t.Errorf("prefixPattern(%s) = %s, want %s", tt.value, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResumeAuditSummary(t *testing.T) {
+	resume := &ResumeModel{
+		UserID:     3,
+		Title:      "Backend Engineer",
+		FullName:   "Jane Doe",
+		IsActive:   true,
+		Template:   "modern",
+		Theme:      "blue",
+		Experience: `[{"company": "Acme"}, {"company": "Initech"}]`,
+		Skills:     `[{"name": "Go"}]`,
+	}
+
+	expected := map[string]interface{}{
+		"user_id":   uint(3),
+		"title":     "Backend Engineer",
+		"full_name": "Jane Doe",
+		"is_active": true,
+		"template":  "modern",
+		"theme":     "blue",
+		"sections": map[string]int{
+			SectionExperience:     2,
+			SectionEducation:      0,
+			SectionSkills:         1,
+			SectionLanguages:      0,
+			SectionCertifications: 0,
+			SectionProjects:       0,
+		},
+	}
+	if summary := resume.AuditSummary(); !reflect.DeepEqual(summary, expected) {
+		t.Errorf("AuditSummary() = %v, want %v", summary, expected)
+	}
+}
@@ -28,9 +28,10 @@ type AuthResponse struct {
 
 // JWTClaims represents the JWT token claims
 type JWTClaims struct {
-	UserID uint   `json:"user_id"`
-	Email  string `json:"email"`
-	Role   string `json:"role"`
+	UserID    uint   `json:"user_id"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid,omitempty"` // refresh token family the access token was issued for
 	jwt.RegisteredClaims
 }
 
@@ -50,3 +51,26 @@ type ResetPasswordRequest struct {
 	Token       string `json:"token" validate:"required"`
 	NewPassword string `json:"new_password" validate:"required,min=6"`
 }
+
+// VerifyEmailRequest represents the request structure for verifying an email address
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// TwoFactorCodeRequest represents the request structure for confirming a two-factor enrollment
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// TwoFactorConfirmRequest represents the request structure for changing two-factor settings, confirmed by the
+// password and a current code
+type TwoFactorConfirmRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+// MFAVerifyRequest represents the request structure for the second step of a login with two-factor authentication
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"` // TOTP code or recovery code
+}
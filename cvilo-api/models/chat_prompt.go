@@ -16,7 +16,7 @@ type ChatPromptHistory struct {
 	UserID    uint      `json:"user_id" gorm:"not null"`
 	Prompt    string    `json:"prompt" gorm:"type:text;not null"`
 	Response  string    `json:"response" gorm:"type:text"`        // AI response summary or metadata
-	Provider  string    `json:"provider" gorm:"default:'openai'"` // AI provider used (openai, github_models, etc.)
+	Provider  string    `json:"provider" gorm:"default:'openai'"` // AI provider that answered (openai, github_models, openai_compatible, anthropic)
 	Status    string    `json:"status" gorm:"default:'success'"`  // success, failed, partial
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
@@ -48,6 +48,19 @@ func (cph *ChatPromptHistory) GetByID(id uint) error {
 	return errors.New("chat prompt history not found")
 }
 
+// GetOwnerID returns the ID of the user owning a chat prompt history record
+func (cph *ChatPromptHistory) GetOwnerID(id uint) (uint, error) {
+	db := database.GetPostgresDB()
+	var owners []uint
+	if err := db.Model(&ChatPromptHistory{}).Where("id = ?", id).Pluck("user_id", &owners).Error; err != nil {
+		return 0, err
+	}
+	if len(owners) == 0 {
+		return 0, errors.New("chat prompt history not found")
+	}
+	return owners[0], nil
+}
+
 // GetByResumeID retrieves all chat prompt history for a specific resume
 func (cph *ChatPromptHistory) GetByResumeID(resumeID uint) ([]ChatPromptHistory, error) {
 	db := database.GetPostgresDB()
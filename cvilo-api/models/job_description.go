@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/smhnaqvi/cvilo/database"
+	"gorm.io/gorm"
+)
+
+// JobDescriptionModel is a job posting a resume was tailored to, see ResumeModel.JobDescriptionID
+type JobDescriptionModel struct {
+	ID      uint      `json:"id" gorm:"primarykey"`
+	UserID  uint      `json:"user_id" gorm:"not null;index"`
+	User    UserModel `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	URL     string    `json:"url,omitempty" gorm:"type:text"` // page the text was fetched from, empty when pasted
+	Title   string    `json:"title"`                          // advertised position
+	Company string    `json:"company"`
+	Text    string    `json:"text" gorm:"type:text;not null"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName overrides the table name used by JobDescriptionModel to `job_descriptions`
+func (JobDescriptionModel) TableName() string {
+	return "job_descriptions"
+}
+
+// CreateWithResume saves the job description together with a resume tailored to it, which is linked to the job
+// description and created like CreateWithChange
+func (jd *JobDescriptionModel) CreateWithResume(resume *ResumeModel, change ResumeChange) error {
+	db := database.GetPostgresDB()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("User").Create(jd).Error; err != nil {
+			return err
+		}
+		resume.JobDescriptionID = &jd.ID
+		return resume.createWithChange(tx, change)
+	})
+	if err != nil {
+		return err
+	}
+
+	resume.reload(db)
+	return nil
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"github.com/smhnaqvi/cvilo/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LoginThrottleModel counts the failed logins of an account or a client IP. Accounts are keyed by email, whether
+// or not a user has it, so throttling does not reveal which emails exist.
+type LoginThrottleModel struct {
+	ID            uint       `json:"id" gorm:"primarykey"`
+	Key           string     `json:"key" gorm:"type:varchar(320);not null;uniqueIndex"` // account:<email> or ip:<address>
+	Failures      int        `json:"failures" gorm:"not null;default:0"`
+	LastFailureAt *time.Time `json:"last_failure_at,omitempty"`
+	BlockedUntil  *time.Time `json:"blocked_until,omitempty"`              // no login attempt is checked before
+	Locked        bool       `json:"locked" gorm:"not null;default:false"` // blocked for reaching the lock threshold rather than backing off
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// TableName overrides the table name used by LoginThrottleModel to `login_throttles`
+func (LoginThrottleModel) TableName() string {
+	return "login_throttles"
+}
+
+// Blocked reports how long logins with the key are still refused
+func (t *LoginThrottleModel) Blocked(now time.Time) (time.Duration, bool) {
+	if t.BlockedUntil == nil || !now.Before(*t.BlockedUntil) {
+		return 0, false
+	}
+	return t.BlockedUntil.Sub(now), true
+}
+
+// GetByKeys loads the throttles of the keys that had failures
+func (t *LoginThrottleModel) GetByKeys(keys ...string) ([]LoginThrottleModel, error) {
+	db := database.GetPostgresDB()
+	var throttles []LoginThrottleModel
+	err := db.Where("key IN ?", keys).Find(&throttles).Error
+	return throttles, err
+}
+
+// RecordFailure loads the throttle of the key, creating it, lets update count the failure and saves the result.
+// The row is locked meanwhile, so concurrent failures are all counted.
+func (t *LoginThrottleModel) RecordFailure(key string, update func(*LoginThrottleModel)) error {
+	db := database.GetPostgresDB()
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&LoginThrottleModel{Key: key}).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", key).First(t).Error; err != nil {
+			return err
+		}
+		update(t)
+		return tx.Save(t).Error
+	})
+}
+
+// Clear forgets the failures of the key
+func (t *LoginThrottleModel) Clear(key string) error {
+	db := database.GetPostgresDB()
+	return db.Where("key = ?", key).Delete(&LoginThrottleModel{}).Error
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/smhnaqvi/cvilo/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrRefreshTokenNotFound is returned for refresh tokens that were never issued
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	// ErrRefreshTokenReused is returned when a refresh token that was already rotated is presented again
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+)
+
+// RefreshTokenModel is an issued refresh token, stored as a SHA-256 hash. Tokens are single use: refreshing revokes
+// the token and issues its successor in the same family. A family is one login session, from a login until logout.
+type RefreshTokenModel struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	User      UserModel `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	FamilyID  string    `json:"family_id" gorm:"type:varchar(64);not null;index"`
+	TokenHash string    `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+
+	// Client the session was opened or last refreshed from
+	UserAgent string `json:"user_agent" gorm:"type:text"`
+	IPAddress string `json:"ip_address" gorm:"type:varchar(45)"`
+
+	SessionStartedAt time.Time  `json:"session_started_at" gorm:"not null"` // login time of the family
+	ExpiresAt        time.Time  `json:"expires_at" gorm:"not null;index"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+	ReplacedByID     *uint      `json:"replaced_by_id,omitempty"` // set when revoked by rotation
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// TableName overrides the table name used by RefreshTokenModel to `refresh_tokens`
+func (RefreshTokenModel) TableName() string {
+	return "refresh_tokens"
+}
+
+// Active reports whether the token can still be used
+func (t *RefreshTokenModel) Active(now time.Time) bool {
+	return t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
+
+// Create stores a new refresh token, first pruning the expired tokens of the user
+func (t *RefreshTokenModel) Create() error {
+	db := database.GetPostgresDB()
+	if err := db.Where("user_id = ? AND expires_at < ?", t.UserID, time.Now()).Delete(&RefreshTokenModel{}).Error; err != nil {
+		return err
+	}
+	return db.Omit(clause.Associations).Create(t).Error
+}
+
+// GetByHash loads the refresh token with the hash
+func (t *RefreshTokenModel) GetByHash(hash string) error {
+	db := database.GetPostgresDB()
+	err := db.Where("token_hash = ?", hash).First(t).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrRefreshTokenNotFound
+	}
+	return err
+}
+
+// Rotate revokes the token and stores next as its successor. When the token was revoked in the meantime, by a
+// concurrent refresh or a logout, nothing is stored and ErrRefreshTokenReused is returned.
+func (t *RefreshTokenModel) Rotate(next *RefreshTokenModel) error {
+	db := database.GetPostgresDB()
+	return db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		revoked := tx.Model(&RefreshTokenModel{}).
+			Where("id = ? AND revoked_at IS NULL", t.ID).
+			Update("revoked_at", now)
+		if revoked.Error != nil {
+			return revoked.Error
+		}
+		if revoked.RowsAffected == 0 {
+			return ErrRefreshTokenReused
+		}
+
+		if err := tx.Omit(clause.Associations).Create(next).Error; err != nil {
+			return err
+		}
+		t.RevokedAt = &now
+		t.ReplacedByID = &next.ID
+		return tx.Model(&RefreshTokenModel{}).Where("id = ?", t.ID).Update("replaced_by_id", next.ID).Error
+	})
+}
+
+// RevokeFamily revokes every token of a session
+func (t *RefreshTokenModel) RevokeFamily(userID uint, familyID string) (int64, error) {
+	db := database.GetPostgresDB()
+	result := db.Model(&RefreshTokenModel{}).
+		Where("user_id = ? AND family_id = ? AND revoked_at IS NULL", userID, familyID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// RevokeAllForUser revokes every token of a user, ending all sessions
+func (t *RefreshTokenModel) RevokeAllForUser(userID uint) (int64, error) {
+	db := database.GetPostgresDB()
+	result := db.Model(&RefreshTokenModel{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// GetActiveSessions returns the usable token of each open session of a user, most recently used first
+func (t *RefreshTokenModel) GetActiveSessions(userID uint) ([]RefreshTokenModel, error) {
+	db := database.GetPostgresDB()
+	var tokens []RefreshTokenModel
+	err := db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("created_at DESC").
+		Find(&tokens).Error
+	return tokens, err
+}
@@ -30,27 +30,33 @@ type ResumeModel struct {
 	Summary   string `json:"summary"`
 	Objective string `json:"objective"`
 
-	// Experience (JSON array as string for simplicity)
-	Experience string `json:"experience"` // JSON string of WorkExperience array
-
-	// Education (JSON array as string)
-	Education string `json:"education"` // JSON string of Education array
-
-	// Skills (JSON array as string)
-	Skills string `json:"skills"` // JSON string of skills array
+	// Sections are stored in child tables (see resume_section_models.go) and exposed
+	// as JSON array strings, loaded after every find and saved on create and update
+	Experience     string `json:"experience" gorm:"-"`     // JSON string of WorkExperience array
+	Education      string `json:"education" gorm:"-"`      // JSON string of Education array
+	Skills         string `json:"skills" gorm:"-"`         // JSON string of skills array
+	Languages      string `json:"languages" gorm:"-"`      // JSON string of Language array
+	Certifications string `json:"certifications" gorm:"-"` // JSON string of Certification array
+	Projects       string `json:"projects" gorm:"-"`       // JSON string of Project array
 
 	// Additional sections
-	Languages      string `json:"languages"`
-	Certifications string `json:"certifications"`
-	Projects       string `json:"projects"`
-	Awards         string `json:"awards"`
-	Interests      string `json:"interests"`
-	References     string `json:"references"`
+	Awards     string `json:"awards"`
+	Interests  string `json:"interests"`
+	References string `json:"references"`
 
 	// Template and styling
 	Template string `json:"template" gorm:"default:'modern'"`
 	Theme    string `json:"theme" gorm:"default:'blue'"`
 
+	// Clones link to the resume they were copied from and tailored resumes to their job description.
+	// Both are set on create only, see NewClone.
+	SourceResumeID   *uint `json:"source_resume_id,omitempty" gorm:"index;<-:create"`
+	JobDescriptionID *uint `json:"job_description_id,omitempty" gorm:"index;<-:create"`
+
+	// Full-text search index, written by UpdateSearchIndex only (see resume_search.go)
+	SearchText   string `json:"-" gorm:"type:text;->:false;<-:false"`
+	SearchVector string `json:"-" gorm:"type:tsvector;index:idx_resumes_search_vector,type:gin;->:false;<-:false"`
+
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index"`
@@ -67,7 +73,7 @@ type WorkExperience struct {
 	Position     string     `json:"position"`
 	Location     string     `json:"location"`
 	StartDate    time.Time  `json:"start_date"`
-	EndDate      *time.Time `json:"end_date,omitempty"` // nil for current job
+	EndDate      *time.Time `json:"end_date,omitempty" jsonschema_description:"null for the current job"`
 	IsCurrent    bool       `json:"is_current"`
 	Description  string     `json:"description"`
 	Technologies []string   `json:"technologies,omitempty"`
@@ -86,8 +92,8 @@ type Education struct {
 
 type Skill struct {
 	Name     string `json:"name"`
-	Category string `json:"category"` // e.g., "Technical", "Languages", "Soft Skills"
-	Level    int    `json:"level"`    // 1-5 proficiency level
+	Category string `json:"category"`                               // e.g., "Technical", "Languages", "Soft Skills"
+	Level    int    `json:"level" jsonschema:"minimum=1,maximum=5"` // 1-5 proficiency level
 	YearsExp int    `json:"years_experience,omitempty"`
 }
 
@@ -115,54 +121,121 @@ type Project struct {
 	GitHub       string     `json:"github,omitempty"`
 }
 
+// Create creates the resume with a first version authored manually by its owner
 func (r *ResumeModel) Create() error {
-	db := database.GetPostgresDB()
-	if err := db.Create(&r).Error; err != nil {
-		return err
-	}
-
-	// Load the user relationship
-	db.Preload("User").First(&r, r.ID)
+	return r.CreateWithChange(ResumeChange{AuthorID: r.UserID, Source: VersionSourceManual})
+}
 
-	return nil
+// NewClone returns an unsaved, inactive copy of the resume with a new title, linked to the resume as its source
+func (r *ResumeModel) NewClone(title string) *ResumeModel {
+	sourceID := r.ID
+	clone := *r
+	clone.ID = 0 // Reset ID for new record
+	clone.Title = title
+	clone.IsActive = false // Set clone as inactive by default
+	clone.SourceResumeID = &sourceID
+	clone.CreatedAt, clone.UpdatedAt = time.Time{}, time.Time{}
+	return &clone
 }
 
 func (r *ResumeModel) GetResumeByID(id uint) error {
 	db := database.GetPostgresDB()
-	if err := db.First(&r, id).Error; err == nil {
-		return nil
+	if err := db.First(&r, id).Error; err != nil {
+		return errors.New("resume not found")
 	}
-	return errors.New("resume not found")
+	return r.loadSections(db)
 }
 
 func (r *ResumeModel) GetResumesByUserID(userID uint) ([]ResumeModel, error) {
 	db := database.GetPostgresDB()
 	var resumes []ResumeModel
-	if err := db.Where("user_id = ?", userID).Preload("User").Find(&resumes).Error; err == nil {
-		return resumes, nil
+	if err := db.Where("user_id = ?", userID).Preload("User").Find(&resumes).Error; err != nil {
+		return nil, errors.New("resumes not found")
+	}
+	if err := loadResumeListSections(db, resumes); err != nil {
+		return nil, err
 	}
-	return nil, errors.New("resumes not found")
+	return resumes, nil
 }
 
-func (r *ResumeModel) GetAllResumes(offset int, limit int) ([]ResumeModel, int64, error) {
+// GetOwnerID returns the ID of the user owning a resume
+func (r *ResumeModel) GetOwnerID(id uint) (uint, error) {
 	db := database.GetPostgresDB()
+	var owners []uint
+	if err := db.Model(&ResumeModel{}).Where("id = ?", id).Pluck("user_id", &owners).Error; err != nil {
+		return 0, err
+	}
+	if len(owners) == 0 {
+		return 0, errors.New("resume not found")
+	}
+	return owners[0], nil
+}
+
+// OwnedBy restricts a resume query to the resumes of a user
+func OwnedBy(userID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("resumes.user_id = ?", userID)
+	}
+}
+
+// GetAllResumes returns a page of resumes, narrowed down by the optional scopes
+func (r *ResumeModel) GetAllResumes(offset int, limit int, scopes ...func(*gorm.DB) *gorm.DB) ([]ResumeModel, int64, error) {
+	db := database.GetPostgresDB().Scopes(scopes...)
 	var resumes []ResumeModel
 	var total int64
 	db.Model(&ResumeModel{}).Count(&total)
-	if err := db.Limit(limit).Offset(offset).Preload("User").Find(&resumes).Error; err == nil {
-		return resumes, total, nil
+	if err := db.Limit(limit).Offset(offset).Preload("User").Find(&resumes).Error; err != nil {
+		return nil, 0, errors.New("resumes not found")
+	}
+	if err := loadResumeListSections(db, resumes); err != nil {
+		return nil, 0, err
+	}
+	return resumes, total, nil
+}
+
+// GetResumesBySkill returns the resumes listing a skill, matched case-insensitively
+func (r *ResumeModel) GetResumesBySkill(skill string, offset int, limit int, scopes ...func(*gorm.DB) *gorm.DB) ([]ResumeModel, int64, error) {
+	db := database.GetPostgresDB()
+	var resumes []ResumeModel
+	var total int64
+	withSkill := db.Model(&ResumeModel{}).Scopes(scopes...).
+		Where("id IN (?)", db.Model(&ResumeSkillModel{}).Select("resume_id").Where("LOWER(name) = LOWER(?)", skill)).
+		Session(&gorm.Session{})
+	if err := withSkill.Count(&total).Error; err != nil {
+		return nil, 0, err
 	}
-	return nil, 0, errors.New("resumes not found")
+	if err := withSkill.Limit(limit).Offset(offset).Preload("User").Find(&resumes).Error; err != nil {
+		return nil, 0, errors.New("resumes not found")
+	}
+	if err := loadResumeListSections(db, resumes); err != nil {
+		return nil, 0, err
+	}
+	return resumes, total, nil
 }
 
-func (r *ResumeModel) UpdateResume(id uint, updateData ResumeModel) error {
+// UpdateResume updates the non-empty fields of updateData and records the result as a version.
+// Sections with a value replace the stored section, empty sections are left unchanged.
+func (r *ResumeModel) UpdateResume(id uint, updateData ResumeModel, change ResumeChange) error {
 	db := database.GetPostgresDB()
-	if err := db.Model(&r).Updates(updateData).Error; err != nil {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&r).Updates(updateData).Error; err != nil {
+			return err
+		}
+		updateData.ID = r.ID
+		if err := updateData.SaveSections(tx, updateData.providedSections()...); err != nil {
+			return err
+		}
+		if err := r.UpdateSearchIndex(tx); err != nil {
+			return err
+		}
+		_, err := r.RecordVersion(tx, change)
+		return err
+	})
+	if err != nil {
 		return err
 	}
 
-	// Load the user relationship
-	db.Preload("User").First(&r, r.ID)
+	r.reload(db)
 	return nil
 }
 
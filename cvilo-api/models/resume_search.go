@@ -0,0 +1,334 @@
+package models
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/smhnaqvi/cvilo/database"
+	"gorm.io/gorm"
+)
+
+// searchConfig is the PostgreSQL text search configuration used to index and query resumes
+const searchConfig = "english"
+
+// Sentinels from the Unicode private use area delimit matches and fragments in the snippets returned by
+// ts_headline, which does not escape the resume text. highlightFragments escapes the text and turns them into markup.
+const (
+	headlineStartSel    = "\ue000"
+	headlineStopSel     = "\ue001"
+	headlineFragmentSep = "\ue002"
+)
+
+// searchHeadlineOptions marks matches in the snippets returned by ts_headline
+const searchHeadlineOptions = "StartSel=" + headlineStartSel + ", StopSel=" + headlineStopSel +
+	", MaxFragments=3, MaxWords=25, MinWords=8, FragmentDelimiter=" + headlineFragmentSep
+
+// experienceYearsSQL totals the years of the dated positions of a resume; overlapping positions are counted twice
+const experienceYearsSQL = `(SELECT COALESCE(SUM(EXTRACT(EPOCH FROM (CASE WHEN e.is_current OR e.end_date IS NULL THEN NOW() ELSE e.end_date END) - e.start_date)), 0) / 31557600
+	FROM resume_experiences e WHERE e.resume_id = resumes.id AND e.start_date > '0001-01-01')`
+
+// experienceYearBuckets are the ranges of the years of experience facet
+var experienceYearBuckets = []struct {
+	label string
+	max   float64
+}{
+	{"0-2", 2},
+	{"2-5", 5},
+	{"5-10", 10},
+	{"10+", 0},
+}
+
+// maxFacetValues limits the values returned per facet
+const maxFacetValues = 10
+
+// ResumeSearchQuery holds the full-text query and facet filters of a resume search
+type ResumeSearchQuery struct {
+	Query    string   // websearch syntax: words, "quoted phrases", -excluded, or
+	Skills   []string // all must be listed, matched case-insensitively
+	Company  string   // substring of a company worked at
+	Location string   // substring of the address or of a position location
+	MinYears *float64
+	MaxYears *float64
+
+	// Access: only resumes of UserID unless AllUsers is set, which adds the active resumes of the other active users
+	UserID   uint
+	AllUsers bool
+
+	Offset int
+	Limit  int
+}
+
+// ResumeSearchResult is a matching resume with its rank and highlighted snippets
+type ResumeSearchResult struct {
+	Resume          ResumeModel `json:"resume"`
+	Rank            float64     `json:"rank"`
+	Highlights      []string    `json:"highlights,omitempty"`
+	ExperienceYears float64     `json:"experience_years"`
+}
+
+// FacetCount is a facet value with the number of matching resumes
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// ResumeSearchFacets counts the matching resumes per skill, company, location and years of experience
+type ResumeSearchFacets struct {
+	Skills          []FacetCount `json:"skills"`
+	Companies       []FacetCount `json:"companies"`
+	Locations       []FacetCount `json:"locations"`
+	ExperienceYears []FacetCount `json:"experience_years"`
+}
+
+// SearchResumes runs a full-text and faceted search. Results are ranked by relevance when there is a
+// text query and by last update otherwise. Facets are counted over all matches, not only the returned page.
+func (r *ResumeModel) SearchResumes(query ResumeSearchQuery) ([]ResumeSearchResult, int64, *ResumeSearchFacets, error) {
+	db := database.GetPostgresDB()
+	matches := query.filter(db).Session(&gorm.Session{})
+
+	var total int64
+	if err := matches.Count(&total).Error; err != nil {
+		return nil, 0, nil, err
+	}
+
+	type rankedRow struct {
+		ID              uint
+		Rank            float64
+		Headline        string
+		ExperienceYears float64
+	}
+	columns := []string{"resumes.id", experienceYearsSQL + " AS experience_years"}
+	var args []interface{}
+	order := "resumes.updated_at DESC, resumes.id DESC"
+	if query.Query != "" {
+		columns = append(columns,
+			fmt.Sprintf("ts_rank_cd(resumes.search_vector, websearch_to_tsquery('%s', ?)) AS rank", searchConfig),
+			fmt.Sprintf("ts_headline('%s', COALESCE(resumes.search_text, ''), websearch_to_tsquery('%s', ?), '%s') AS headline",
+				searchConfig, searchConfig, searchHeadlineOptions),
+		)
+		args = append(args, query.Query, query.Query)
+		order = "rank DESC, " + order
+	}
+	var rows []rankedRow
+	if err := matches.Select(strings.Join(columns, ", "), args...).Order(order).
+		Offset(query.Offset).Limit(query.Limit).Scan(&rows).Error; err != nil {
+		return nil, 0, nil, err
+	}
+
+	ids := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+	var resumes []ResumeModel
+	if len(ids) > 0 {
+		if err := db.Preload("User").Find(&resumes, ids).Error; err != nil {
+			return nil, 0, nil, err
+		}
+		if err := loadResumeListSections(db, resumes); err != nil {
+			return nil, 0, nil, err
+		}
+	}
+	byID := make(map[uint]ResumeModel, len(resumes))
+	for _, resume := range resumes {
+		byID[resume.ID] = resume
+	}
+
+	results := make([]ResumeSearchResult, 0, len(rows))
+	for _, row := range rows {
+		resume, ok := byID[row.ID]
+		if !ok {
+			continue
+		}
+		result := ResumeSearchResult{Resume: resume, Rank: row.Rank, ExperienceYears: row.ExperienceYears}
+		result.Highlights = highlightFragments(row.Headline)
+		results = append(results, result)
+	}
+
+	facets, err := searchFacets(db, matches)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	return results, total, facets, nil
+}
+
+// highlightFragments turns a ts_headline result into HTML snippets: the resume text is escaped and the matches are
+// wrapped in <mark>. A headline without matches gives no snippets.
+func highlightFragments(headline string) []string {
+	if !strings.Contains(headline, headlineStartSel) {
+		return nil
+	}
+	marks := strings.NewReplacer(headlineStartSel, "<mark>", headlineStopSel, "</mark>")
+	fragments := strings.Split(headline, headlineFragmentSep)
+	for i, fragment := range fragments {
+		fragments[i] = marks.Replace(html.EscapeString(strings.TrimSpace(fragment)))
+	}
+	return fragments
+}
+
+// filter returns the resumes matching the query and the access restriction
+func (q ResumeSearchQuery) filter(db *gorm.DB) *gorm.DB {
+	tx := db.Model(&ResumeModel{})
+	if q.AllUsers {
+		// Resumes of other users are only found once published: drafts, the inactive copies made by clone and
+		// tailor, and the resumes of deactivated users stay private
+		tx = tx.Where("(resumes.user_id = ? OR (resumes.is_active = ? AND resumes.user_id IN (?)))", q.UserID, true,
+			db.Model(&UserModel{}).Select("id").Where("is_active = ?", true))
+	} else {
+		tx = tx.Where("resumes.user_id = ?", q.UserID)
+	}
+	if q.Query != "" {
+		tx = tx.Where(fmt.Sprintf("resumes.search_vector @@ websearch_to_tsquery('%s', ?)", searchConfig), q.Query)
+	}
+	for _, skill := range q.Skills {
+		tx = tx.Where("resumes.id IN (?)", db.Model(&ResumeSkillModel{}).Select("resume_id").Where("LOWER(name) = LOWER(?)", skill))
+	}
+	if q.Company != "" {
+		tx = tx.Where("resumes.id IN (?)", db.Model(&ResumeExperienceModel{}).Select("resume_id").
+			Where("company ILIKE ?", containsPattern(q.Company)))
+	}
+	if q.Location != "" {
+		pattern := containsPattern(q.Location)
+		tx = tx.Where("(resumes.address ILIKE ? OR resumes.id IN (?))", pattern,
+			db.Model(&ResumeExperienceModel{}).Select("resume_id").Where("location ILIKE ?", pattern))
+	}
+	if q.MinYears != nil {
+		tx = tx.Where(experienceYearsSQL+" >= ?", *q.MinYears)
+	}
+	if q.MaxYears != nil {
+		tx = tx.Where(experienceYearsSQL+" <= ?", *q.MaxYears)
+	}
+	return tx
+}
+
+// containsPattern builds an ILIKE pattern matching value anywhere, with its wildcards escaped
+func containsPattern(value string) string {
+	return "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value) + "%"
+}
+
+// searchFacets counts the matching resumes per facet value
+func searchFacets(db *gorm.DB, matches *gorm.DB) (*ResumeSearchFacets, error) {
+	facets := &ResumeSearchFacets{}
+	matchingIDs := matches.Select("resumes.id")
+
+	if err := db.Model(&ResumeSkillModel{}).
+		Select("MIN(name) AS value, COUNT(DISTINCT resume_id) AS count").
+		Where("resume_id IN (?) AND name <> ''", matchingIDs).
+		Group("LOWER(name)").Order("count DESC, value").Limit(maxFacetValues).
+		Scan(&facets.Skills).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Model(&ResumeExperienceModel{}).
+		Select("MIN(company) AS value, COUNT(DISTINCT resume_id) AS count").
+		Where("resume_id IN (?) AND company <> ''", matchingIDs).
+		Group("LOWER(company)").Order("count DESC, value").Limit(maxFacetValues).
+		Scan(&facets.Companies).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Model(&ResumeModel{}).
+		Select("MIN(address) AS value, COUNT(*) AS count").
+		Where("id IN (?) AND address <> ''", matchingIDs).
+		Group("LOWER(address)").Order("count DESC, value").Limit(maxFacetValues).
+		Scan(&facets.Locations).Error; err != nil {
+		return nil, err
+	}
+
+	var years []float64
+	if err := matches.Select(experienceYearsSQL).Scan(&years).Error; err != nil {
+		return nil, err
+	}
+	facets.ExperienceYears = experienceYearFacets(years)
+	return facets, nil
+}
+
+// experienceYearFacets counts years of experience per bucket, leaving out empty buckets
+func experienceYearFacets(years []float64) []FacetCount {
+	counts := make([]int64, len(experienceYearBuckets))
+	for _, value := range years {
+		i := sort.Search(len(experienceYearBuckets)-1, func(i int) bool {
+			return value < experienceYearBuckets[i].max
+		})
+		counts[i]++
+	}
+
+	facets := []FacetCount{}
+	for i, bucket := range experienceYearBuckets {
+		if counts[i] > 0 {
+			facets = append(facets, FacetCount{Value: bucket.label, Count: counts[i]})
+		}
+	}
+	return facets
+}
+
+// searchDocument is the indexed text of a resume, by weight
+type searchDocument struct {
+	primary   []string // title, skills (weight A)
+	secondary []string // summary, objective, experience (weight B)
+	tertiary  []string // projects (weight C)
+}
+
+// newSearchDocument collects the searchable text of a resume snapshot
+func newSearchDocument(s *ResumeSnapshot) *searchDocument {
+	doc := &searchDocument{}
+	add := func(parts *[]string, values ...string) {
+		for _, value := range values {
+			if value = strings.TrimSpace(value); value != "" {
+				*parts = append(*parts, value)
+			}
+		}
+	}
+
+	add(&doc.primary, s.Title)
+	skills := make([]string, 0, len(s.Skills))
+	for _, skill := range s.Skills {
+		skills = append(skills, skill.Name)
+	}
+	add(&doc.primary, strings.Join(skills, ", "))
+
+	add(&doc.secondary, s.Summary, s.Objective)
+	for _, exp := range s.Experience {
+		role := exp.Position
+		if role != "" && exp.Company != "" {
+			role += " at "
+		}
+		add(&doc.secondary, role+exp.Company, exp.Description, strings.Join(exp.Technologies, ", "))
+	}
+
+	for _, project := range s.Projects {
+		add(&doc.tertiary, project.Name, project.Description, strings.Join(project.Technologies, ", "))
+	}
+	return doc
+}
+
+// text is the whole document, which the snippets are cut from. The headline sentinels are removed, so the resume
+// text cannot forge matches.
+func (d *searchDocument) text() string {
+	var parts []string
+	for _, group := range [][]string{d.primary, d.secondary, d.tertiary} {
+		parts = append(parts, group...)
+	}
+	return strings.NewReplacer(headlineStartSel, "", headlineStopSel, "", headlineFragmentSep, "").Replace(strings.Join(parts, "\n"))
+}
+
+// UpdateSearchIndex rebuilds the full-text index columns of the stored resume
+func (r *ResumeModel) UpdateSearchIndex(tx *gorm.DB) error {
+	var current ResumeModel
+	if err := tx.First(&current, r.ID).Error; err != nil {
+		return err
+	}
+	if err := current.loadSections(tx); err != nil {
+		return err
+	}
+	snapshot, err := NewResumeSnapshot(&current)
+	if err != nil {
+		return err
+	}
+
+	doc := newSearchDocument(snapshot)
+	return tx.Exec(fmt.Sprintf(`UPDATE resumes SET search_text = ?, search_vector =
+		setweight(to_tsvector('%[1]s', ?), 'A') || setweight(to_tsvector('%[1]s', ?), 'B') || setweight(to_tsvector('%[1]s', ?), 'C')
+		WHERE id = ?`, searchConfig),
+		doc.text(), strings.Join(doc.primary, "\n"), strings.Join(doc.secondary, "\n"), strings.Join(doc.tertiary, "\n"), r.ID,
+	).Error
+}
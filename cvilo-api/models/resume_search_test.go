@@ -0,0 +1,153 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestNewSearchDocument(t *testing.T) {
+	snapshot := &ResumeSnapshot{
+		Title:   "Backend Engineer",
+		Summary: "Builds payment systems",
+		Skills:  []Skill{{Name: "Go"}, {Name: "PostgreSQL"}},
+		Experience: []WorkExperience{
+			{Position: "Senior Engineer", Company: "Acme", Description: "Led the Go migration", Technologies: []string{"Go", "Kafka"}},
+			{Company: "Initech"},
+		},
+		Projects: []Project{{Name: "Cvilo", Description: "Resume builder"}},
+	}
+
+	doc := newSearchDocument(snapshot)
+
+	tests := []struct {
+		name     string
+		got      []string
+		expected []string
+	}{
+		{name: "Primary", got: doc.primary, expected: []string{"Backend Engineer", "Go, PostgreSQL"}},
+		{name: "Secondary", got: doc.secondary, expected: []string{"Builds payment systems", "Senior Engineer at Acme", "Led the Go migration", "Go, Kafka", "Initech"}},
+		{name: "Tertiary", got: doc.tertiary, expected: []string{"Cvilo", "Resume builder"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.expected) {
+				t.Errorf("newSearchDocument() %s = %q, want %q", tt.name, tt.got, tt.expected)
+			}
+		})
+	}
+
+	if text := doc.text(); text != "Backend Engineer\nGo, PostgreSQL\nBuilds payment systems\nSenior Engineer at Acme\nLed the Go migration\nGo, Kafka\nInitech\nCvilo\nResume builder" {
+		t.Errorf("searchDocument.text() = %q", text)
+	}
+}
+
+func TestExperienceYearFacets(t *testing.T) {
+	result := experienceYearFacets([]float64{0, 1.9, 2, 4.5, 12, 30})
+	expected := []FacetCount{
+		{Value: "0-2", Count: 2},
+		{Value: "2-5", Count: 2},
+		{Value: "10+", Count: 2},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("experienceYearFacets() = %+v, want %+v", result, expected)
+	}
+}
+
+func TestContainsPattern(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{value: "Acme", expected: "%Acme%"},
+		{value: "100%_sure", expected: `%100\%\_sure%`},
+		{value: `C:\dev`, expected: `%C:\\dev%`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if result := containsPattern(tt.value); result != tt.expected {
+				t.Errorf("containsPattern(%s) = %s, want %s", tt.value, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHighlightFragments(t *testing.T) {
+	tests := []struct {
+		name     string
+		headline string
+		expected []string
+	}{
+		{
+			name:     "Matches",
+			headline: "Led the \ue000Go\ue001 migration\ue002Owned \ue000Go\ue001 services",
+			expected: []string{"Led the <mark>Go</mark> migration", "Owned <mark>Go</mark> services"},
+		},
+		{
+			name:     "Markup in the resume is escaped",
+			headline: "<script>alert(\"x\")</script> \ue000Go\ue001 & <mark>SQL</mark>",
+			expected: []string{`&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; <mark>Go</mark> &amp; &lt;mark&gt;SQL&lt;/mark&gt;`},
+		},
+		{
+			name:     "No match",
+			headline: "Backend engineer <b>",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := highlightFragments(tt.headline); !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("highlightFragments(%q) = %q, want %q", tt.headline, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSearchDocumentTextDropsSentinels(t *testing.T) {
+	doc := &searchDocument{primary: []string{"\ue000Go\ue001 forged\ue002"}}
+	if text := doc.text(); text != "Go forged" {
+		t.Errorf("text() = %q, want the headline sentinels removed", text)
+	}
+}
+
+func TestResumeSearchQueryAccess(t *testing.T) {
+	// DryRun renders the SQL without connecting
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("gorm.Open() error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		query    ResumeSearchQuery
+		expected string
+	}{
+		{
+			name:     "User searches their own resumes",
+			query:    ResumeSearchQuery{UserID: 9},
+			expected: "WHERE resumes.user_id = 9 AND",
+		},
+		{
+			name:  "Recruiter finds only active resumes of active users besides their own",
+			query: ResumeSearchQuery{UserID: 9, AllUsers: true},
+			expected: `WHERE ((resumes.user_id = 9 OR (resumes.is_active = true AND resumes.user_id IN ` +
+				`(SELECT "id" FROM "users" WHERE is_active = true AND "users"."deleted_at" IS NULL)))) AND`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+				var resumes []ResumeModel
+				return tt.query.filter(tx).Find(&resumes)
+			})
+			if !strings.Contains(sql, tt.expected) {
+				t.Errorf("filter() = %s, want it to contain %s", sql, tt.expected)
+			}
+		})
+	}
+}
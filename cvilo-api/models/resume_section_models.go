@@ -0,0 +1,464 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Names of the resume sections stored in child tables
+const (
+	SectionExperience     = "experience"
+	SectionEducation      = "education"
+	SectionSkills         = "skills"
+	SectionLanguages      = "languages"
+	SectionCertifications = "certifications"
+	SectionProjects       = "projects"
+)
+
+// ResumeSectionNames lists the sections stored in child tables, in display order
+var ResumeSectionNames = []string{
+	SectionExperience,
+	SectionEducation,
+	SectionSkills,
+	SectionLanguages,
+	SectionCertifications,
+	SectionProjects,
+}
+
+// SectionError reports a resume section whose JSON could not be decoded
+type SectionError struct {
+	Section string
+	Err     error
+}
+
+func (e *SectionError) Error() string {
+	return fmt.Sprintf("invalid %s section: %v", e.Section, e.Err)
+}
+
+func (e *SectionError) Unwrap() error {
+	return e.Err
+}
+
+// ResumeExperienceModel is a work experience entry of a resume
+type ResumeExperienceModel struct {
+	ID           uint        `gorm:"primarykey"`
+	ResumeID     uint        `gorm:"not null;index"`
+	Resume       ResumeModel `gorm:"foreignKey:ResumeID;constraint:OnDelete:CASCADE"`
+	SortOrder    int         `gorm:"not null;default:0"`
+	Company      string
+	Position     string
+	Location     string
+	StartDate    time.Time
+	EndDate      *time.Time
+	IsCurrent    bool
+	Description  string   `gorm:"type:text"`
+	Technologies []string `gorm:"serializer:json"`
+}
+
+func (ResumeExperienceModel) TableName() string {
+	return "resume_experiences"
+}
+
+// ResumeEducationModel is an education entry of a resume
+type ResumeEducationModel struct {
+	ID           uint        `gorm:"primarykey"`
+	ResumeID     uint        `gorm:"not null;index"`
+	Resume       ResumeModel `gorm:"foreignKey:ResumeID;constraint:OnDelete:CASCADE"`
+	SortOrder    int         `gorm:"not null;default:0"`
+	Institution  string
+	Degree       string
+	FieldOfStudy string
+	Location     string
+	StartDate    time.Time
+	EndDate      *time.Time
+	GPA          string
+	Description  string `gorm:"type:text"`
+}
+
+func (ResumeEducationModel) TableName() string {
+	return "resume_educations"
+}
+
+// ResumeSkillModel is a skill of a resume
+type ResumeSkillModel struct {
+	ID        uint        `gorm:"primarykey"`
+	ResumeID  uint        `gorm:"not null;index"`
+	Resume    ResumeModel `gorm:"foreignKey:ResumeID;constraint:OnDelete:CASCADE"`
+	SortOrder int         `gorm:"not null;default:0"`
+	Name      string      `gorm:"index"`
+	Category  string
+	Level     int
+	YearsExp  int
+}
+
+func (ResumeSkillModel) TableName() string {
+	return "resume_skills"
+}
+
+// ResumeLanguageModel is a spoken language of a resume
+type ResumeLanguageModel struct {
+	ID          uint        `gorm:"primarykey"`
+	ResumeID    uint        `gorm:"not null;index"`
+	Resume      ResumeModel `gorm:"foreignKey:ResumeID;constraint:OnDelete:CASCADE"`
+	SortOrder   int         `gorm:"not null;default:0"`
+	Name        string
+	Proficiency string
+}
+
+func (ResumeLanguageModel) TableName() string {
+	return "resume_languages"
+}
+
+// ResumeCertificationModel is a certification of a resume
+type ResumeCertificationModel struct {
+	ID           uint        `gorm:"primarykey"`
+	ResumeID     uint        `gorm:"not null;index"`
+	Resume       ResumeModel `gorm:"foreignKey:ResumeID;constraint:OnDelete:CASCADE"`
+	SortOrder    int         `gorm:"not null;default:0"`
+	Name         string
+	Issuer       string
+	IssueDate    time.Time
+	ExpiryDate   *time.Time
+	CredentialID string
+	URL          string
+}
+
+func (ResumeCertificationModel) TableName() string {
+	return "resume_certifications"
+}
+
+// ResumeProjectModel is a project of a resume
+type ResumeProjectModel struct {
+	ID           uint        `gorm:"primarykey"`
+	ResumeID     uint        `gorm:"not null;index"`
+	Resume       ResumeModel `gorm:"foreignKey:ResumeID;constraint:OnDelete:CASCADE"`
+	SortOrder    int         `gorm:"not null;default:0"`
+	Name         string
+	Description  string   `gorm:"type:text"`
+	Technologies []string `gorm:"serializer:json"`
+	StartDate    time.Time
+	EndDate      *time.Time
+	URL          string
+	GitHub       string
+}
+
+func (ResumeProjectModel) TableName() string {
+	return "resume_projects"
+}
+
+// ResumeSectionModels lists the child table models, for migrations
+func ResumeSectionModels() []interface{} {
+	return []interface{}{
+		&ResumeExperienceModel{},
+		&ResumeEducationModel{},
+		&ResumeSkillModel{},
+		&ResumeLanguageModel{},
+		&ResumeCertificationModel{},
+		&ResumeProjectModel{},
+	}
+}
+
+// AfterCreate stores the JSON sections of a new resume in the child tables
+func (r *ResumeModel) AfterCreate(tx *gorm.DB) error {
+	return r.SaveSections(tx.Session(&gorm.Session{NewDB: true}), ResumeSectionNames...)
+}
+
+// providedSections returns the sections that have a value, the ones an update replaces
+func (r *ResumeModel) providedSections() []string {
+	var names []string
+	for _, name := range ResumeSectionNames {
+		if *r.sectionField(name) != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// sectionField returns the JSON field holding a section
+func (r *ResumeModel) sectionField(name string) *string {
+	switch name {
+	case SectionExperience:
+		return &r.Experience
+	case SectionEducation:
+		return &r.Education
+	case SectionSkills:
+		return &r.Skills
+	case SectionLanguages:
+		return &r.Languages
+	case SectionCertifications:
+		return &r.Certifications
+	case SectionProjects:
+		return &r.Projects
+	}
+	panic("unknown resume section " + name)
+}
+
+// loadSections reads the sections of the resume from the child tables into its JSON fields
+func (r *ResumeModel) loadSections(db *gorm.DB) error {
+	return loadResumeSections(db, []*ResumeModel{r})
+}
+
+// reload reads the stored resume again with its user and sections
+func (r *ResumeModel) reload(db *gorm.DB) error {
+	if err := db.Preload("User").First(r, r.ID).Error; err != nil {
+		return err
+	}
+	return r.loadSections(db)
+}
+
+// loadResumeListSections reads the sections of a list of resumes, see loadResumeSections
+func loadResumeListSections(db *gorm.DB, resumes []ResumeModel) error {
+	pointers := make([]*ResumeModel, len(resumes))
+	for i := range resumes {
+		pointers[i] = &resumes[i]
+	}
+	return loadResumeSections(db, pointers)
+}
+
+// loadResumeSections reads the child tables in order and encodes them into the JSON fields of the resumes, with
+// one query per section whatever the number of resumes. Empty sections are encoded as "[]".
+func loadResumeSections(db *gorm.DB, resumes []*ResumeModel) error {
+	if len(resumes) == 0 {
+		return nil
+	}
+	db = db.Session(&gorm.Session{NewDB: true})
+
+	ids := make([]uint, 0, len(resumes))
+	byResume := make(map[uint]*ResumeSections, len(resumes))
+	for _, r := range resumes {
+		if _, ok := byResume[r.ID]; ok {
+			continue
+		}
+		ids = append(ids, r.ID)
+		byResume[r.ID] = &ResumeSections{
+			Experience:     []WorkExperience{},
+			Education:      []Education{},
+			Skills:         []Skill{},
+			Languages:      []Language{},
+			Certifications: []Certification{},
+			Projects:       []Project{},
+		}
+	}
+
+	var (
+		experience     []ResumeExperienceModel
+		education      []ResumeEducationModel
+		skills         []ResumeSkillModel
+		languages      []ResumeLanguageModel
+		certifications []ResumeCertificationModel
+		projects       []ResumeProjectModel
+	)
+	for _, rows := range []interface{}{&experience, &education, &skills, &languages, &certifications, &projects} {
+		if err := db.Where("resume_id IN ?", ids).Order("resume_id, sort_order, id").Find(rows).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, row := range experience {
+		sections := byResume[row.ResumeID]
+		sections.Experience = append(sections.Experience, WorkExperience{
+			Company: row.Company, Position: row.Position, Location: row.Location, StartDate: row.StartDate,
+			EndDate: row.EndDate, IsCurrent: row.IsCurrent, Description: row.Description, Technologies: row.Technologies,
+		})
+	}
+	for _, row := range education {
+		sections := byResume[row.ResumeID]
+		sections.Education = append(sections.Education, Education{
+			Institution: row.Institution, Degree: row.Degree, FieldOfStudy: row.FieldOfStudy, Location: row.Location,
+			StartDate: row.StartDate, EndDate: row.EndDate, GPA: row.GPA, Description: row.Description,
+		})
+	}
+	for _, row := range skills {
+		sections := byResume[row.ResumeID]
+		sections.Skills = append(sections.Skills, Skill{
+			Name: row.Name, Category: row.Category, Level: row.Level, YearsExp: row.YearsExp,
+		})
+	}
+	for _, row := range languages {
+		sections := byResume[row.ResumeID]
+		sections.Languages = append(sections.Languages, Language{Name: row.Name, Proficiency: row.Proficiency})
+	}
+	for _, row := range certifications {
+		sections := byResume[row.ResumeID]
+		sections.Certifications = append(sections.Certifications, Certification{
+			Name: row.Name, Issuer: row.Issuer, IssueDate: row.IssueDate, ExpiryDate: row.ExpiryDate,
+			CredentialID: row.CredentialID, URL: row.URL,
+		})
+	}
+	for _, row := range projects {
+		sections := byResume[row.ResumeID]
+		sections.Projects = append(sections.Projects, Project{
+			Name: row.Name, Description: row.Description, Technologies: row.Technologies, StartDate: row.StartDate,
+			EndDate: row.EndDate, URL: row.URL, GitHub: row.GitHub,
+		})
+	}
+
+	for _, r := range resumes {
+		if err := r.encodeSections(byResume[r.ID]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeSections stores the sections in the JSON fields
+func (r *ResumeModel) encodeSections(sections *ResumeSections) error {
+	for _, section := range []struct {
+		name  string
+		value interface{}
+	}{
+		{SectionExperience, sections.Experience},
+		{SectionEducation, sections.Education},
+		{SectionSkills, sections.Skills},
+		{SectionLanguages, sections.Languages},
+		{SectionCertifications, sections.Certifications},
+		{SectionProjects, sections.Projects},
+	} {
+		encoded, err := json.Marshal(section.value)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s section: %v", section.name, err)
+		}
+		*r.sectionField(section.name) = string(encoded)
+	}
+	return nil
+}
+
+// SaveSections replaces the named sections in the child tables with the entries decoded from the JSON fields.
+// A section that cannot be decoded is returned as a *SectionError before anything is written.
+func (r *ResumeModel) SaveSections(db *gorm.DB, names ...string) error {
+	type decodedSection struct {
+		name  string
+		rows  interface{}
+		count int
+	}
+	decoded := make([]decodedSection, 0, len(names))
+	for _, name := range names {
+		rows, count, err := r.sectionRows(name)
+		if err != nil {
+			return err
+		}
+		decoded = append(decoded, decodedSection{name: name, rows: rows, count: count})
+	}
+
+	for _, section := range decoded {
+		if err := db.Where("resume_id = ?", r.ID).Delete(sectionModel(section.name)).Error; err != nil {
+			return err
+		}
+		if section.count == 0 {
+			continue
+		}
+		if err := db.Omit(clause.Associations).Create(section.rows).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sectionModel returns an empty child table model for a section
+func sectionModel(name string) interface{} {
+	switch name {
+	case SectionExperience:
+		return &ResumeExperienceModel{}
+	case SectionEducation:
+		return &ResumeEducationModel{}
+	case SectionSkills:
+		return &ResumeSkillModel{}
+	case SectionLanguages:
+		return &ResumeLanguageModel{}
+	case SectionCertifications:
+		return &ResumeCertificationModel{}
+	case SectionProjects:
+		return &ResumeProjectModel{}
+	}
+	panic("unknown resume section " + name)
+}
+
+// sectionRows decodes a JSON section into child table rows numbered in order, and returns their count
+func (r *ResumeModel) sectionRows(name string) (interface{}, int, error) {
+	decode := func(v interface{}) error {
+		return decodeSection(name, *r.sectionField(name), v)
+	}
+
+	switch name {
+	case SectionExperience:
+		var entries []WorkExperience
+		if err := decode(&entries); err != nil {
+			return nil, 0, err
+		}
+		rows := make([]ResumeExperienceModel, 0, len(entries))
+		for i, e := range entries {
+			rows = append(rows, ResumeExperienceModel{
+				ResumeID: r.ID, SortOrder: i, Company: e.Company, Position: e.Position, Location: e.Location,
+				StartDate: e.StartDate, EndDate: e.EndDate, IsCurrent: e.IsCurrent, Description: e.Description,
+				Technologies: e.Technologies,
+			})
+		}
+		return &rows, len(rows), nil
+	case SectionEducation:
+		var entries []Education
+		if err := decode(&entries); err != nil {
+			return nil, 0, err
+		}
+		rows := make([]ResumeEducationModel, 0, len(entries))
+		for i, e := range entries {
+			rows = append(rows, ResumeEducationModel{
+				ResumeID: r.ID, SortOrder: i, Institution: e.Institution, Degree: e.Degree, FieldOfStudy: e.FieldOfStudy,
+				Location: e.Location, StartDate: e.StartDate, EndDate: e.EndDate, GPA: e.GPA, Description: e.Description,
+			})
+		}
+		return &rows, len(rows), nil
+	case SectionSkills:
+		var entries []Skill
+		if err := decode(&entries); err != nil {
+			return nil, 0, err
+		}
+		rows := make([]ResumeSkillModel, 0, len(entries))
+		for i, e := range entries {
+			rows = append(rows, ResumeSkillModel{
+				ResumeID: r.ID, SortOrder: i, Name: e.Name, Category: e.Category, Level: e.Level, YearsExp: e.YearsExp,
+			})
+		}
+		return &rows, len(rows), nil
+	case SectionLanguages:
+		var entries []Language
+		if err := decode(&entries); err != nil {
+			return nil, 0, err
+		}
+		rows := make([]ResumeLanguageModel, 0, len(entries))
+		for i, e := range entries {
+			rows = append(rows, ResumeLanguageModel{ResumeID: r.ID, SortOrder: i, Name: e.Name, Proficiency: e.Proficiency})
+		}
+		return &rows, len(rows), nil
+	case SectionCertifications:
+		var entries []Certification
+		if err := decode(&entries); err != nil {
+			return nil, 0, err
+		}
+		rows := make([]ResumeCertificationModel, 0, len(entries))
+		for i, e := range entries {
+			rows = append(rows, ResumeCertificationModel{
+				ResumeID: r.ID, SortOrder: i, Name: e.Name, Issuer: e.Issuer, IssueDate: e.IssueDate,
+				ExpiryDate: e.ExpiryDate, CredentialID: e.CredentialID, URL: e.URL,
+			})
+		}
+		return &rows, len(rows), nil
+	case SectionProjects:
+		var entries []Project
+		if err := decode(&entries); err != nil {
+			return nil, 0, err
+		}
+		rows := make([]ResumeProjectModel, 0, len(entries))
+		for i, e := range entries {
+			rows = append(rows, ResumeProjectModel{
+				ResumeID: r.ID, SortOrder: i, Name: e.Name, Description: e.Description, Technologies: e.Technologies,
+				StartDate: e.StartDate, EndDate: e.EndDate, URL: e.URL, GitHub: e.GitHub,
+			})
+		}
+		return &rows, len(rows), nil
+	}
+	panic("unknown resume section " + name)
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestResumeSectionRows(t *testing.T) {
+	resume := &ResumeModel{
+		ID:     3,
+		Skills: `[{"name":"Go","category":"Backend","level":5},{"name":"SQL"}]`,
+	}
+
+	rows, count, err := resume.sectionRows(SectionSkills)
+	if err != nil {
+		t.Fatalf("sectionRows(skills) error: %v", err)
+	}
+	expected := &[]ResumeSkillModel{
+		{ResumeID: 3, SortOrder: 0, Name: "Go", Category: "Backend", Level: 5},
+		{ResumeID: 3, SortOrder: 1, Name: "SQL"},
+	}
+	if count != 2 || !reflect.DeepEqual(rows, expected) {
+		t.Errorf("sectionRows(skills) = %+v (%d), want %+v", rows, count, expected)
+	}
+}
+
+func TestResumeSectionRowsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		resume  ResumeModel
+		section string
+		invalid bool
+	}{
+		{name: "Empty section", resume: ResumeModel{}, section: SectionExperience},
+		{name: "Null section", resume: ResumeModel{Projects: "null"}, section: SectionProjects},
+		{name: "Malformed section", resume: ResumeModel{Education: `[{"institution":`}, section: SectionEducation, invalid: true},
+		{name: "Wrong type", resume: ResumeModel{Languages: `{"name":"German"}`}, section: SectionLanguages, invalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, count, err := tt.resume.sectionRows(tt.section)
+			var sectionErr *SectionError
+			if errors.As(err, &sectionErr) != tt.invalid {
+				t.Fatalf("sectionRows(%s) error = %v, want invalid %t", tt.section, err, tt.invalid)
+			}
+			if tt.invalid && sectionErr.Section != tt.section {
+				t.Errorf("sectionRows(%s) error section = %s, want %s", tt.section, sectionErr.Section, tt.section)
+			}
+			if !tt.invalid && count != 0 {
+				t.Errorf("sectionRows(%s) count = %d, want 0", tt.section, count)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Award is a structured award entry, as imported from LinkedIn honors
+type Award struct {
+	Name        string     `json:"name"`
+	Issuer      string     `json:"issuer"`
+	Description string     `json:"description,omitempty"`
+	IssueDate   *time.Time `json:"issue_date,omitempty"`
+}
+
+// ResumeSections holds the decoded JSON sections of a resume
+type ResumeSections struct {
+	Experience     []WorkExperience
+	Education      []Education
+	Skills         []Skill
+	Languages      []Language
+	Certifications []Certification
+	Projects       []Project
+	Awards         []Award
+}
+
+// DecodeSections decodes the JSON-encoded sections stored on the resume.
+// Empty sections decode to nil slices; awards stored as free text become one award per line.
+func (r *ResumeModel) DecodeSections() (*ResumeSections, error) {
+	sections := &ResumeSections{}
+
+	if err := decodeSection("experience", r.Experience, &sections.Experience); err != nil {
+		return nil, err
+	}
+	if err := decodeSection("education", r.Education, &sections.Education); err != nil {
+		return nil, err
+	}
+	if err := decodeSection("skills", r.Skills, &sections.Skills); err != nil {
+		return nil, err
+	}
+	if err := decodeSection("languages", r.Languages, &sections.Languages); err != nil {
+		return nil, err
+	}
+	if err := decodeSection("certifications", r.Certifications, &sections.Certifications); err != nil {
+		return nil, err
+	}
+	if err := decodeSection("projects", r.Projects, &sections.Projects); err != nil {
+		return nil, err
+	}
+
+	// Awards are free text unless they were imported from LinkedIn
+	if err := decodeSection("awards", r.Awards, &sections.Awards); err != nil {
+		sections.Awards = nil
+		for _, line := range strings.Split(r.Awards, "\n") {
+			line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "•-*"))
+			if line != "" {
+				sections.Awards = append(sections.Awards, Award{Name: line})
+			}
+		}
+	}
+
+	return sections, nil
+}
+
+// decodeSection unmarshals a single JSON section, treating empty values as an empty section
+func decodeSection(name string, value string, v interface{}) error {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "null" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(value), v); err != nil {
+		return &SectionError{Section: name, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,444 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/smhnaqvi/cvilo/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Sources of a resume version
+const (
+	VersionSourceManual   = "manual"
+	VersionSourceAI       = "ai"
+	VersionSourceLinkedIn = "linkedin"
+)
+
+var (
+	ErrResumeVersionImmutable = errors.New("resume versions cannot be changed or deleted")
+	ErrResumeVersionNotFound  = errors.New("resume version not found")
+)
+
+// ResumeVersionConflictError is returned when an edit was made on an older version than the latest
+type ResumeVersionConflictError struct {
+	Latest int
+}
+
+func (e *ResumeVersionConflictError) Error() string {
+	return fmt.Sprintf("resume has changed, the latest version is %d", e.Latest)
+}
+
+// ResumeVersionModel is an immutable snapshot of a resume, recorded on every save that changes its content
+type ResumeVersionModel struct {
+	ID       uint        `json:"id" gorm:"primarykey"`
+	ResumeID uint        `json:"resume_id" gorm:"not null;uniqueIndex:idx_resume_versions_resume_version"`
+	Resume   ResumeModel `json:"-" gorm:"foreignKey:ResumeID;constraint:OnDelete:CASCADE"`
+	Version  int         `json:"version" gorm:"not null;uniqueIndex:idx_resume_versions_resume_version"`
+
+	AuthorID            uint   `json:"author_id" gorm:"not null"`
+	Source              string `json:"source" gorm:"not null"` // manual, ai or linkedin
+	ChatPromptHistoryID *uint  `json:"chat_prompt_history_id,omitempty"`
+	RestoredFrom        *int   `json:"restored_from,omitempty"` // version this one restored
+
+	Snapshot *ResumeSnapshot `json:"snapshot,omitempty" gorm:"type:jsonb;serializer:json;not null"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName overrides the table name used by ResumeVersionModel to `resume_versions`
+func (ResumeVersionModel) TableName() string {
+	return "resume_versions"
+}
+
+// BeforeUpdate keeps versions immutable
+func (v *ResumeVersionModel) BeforeUpdate(tx *gorm.DB) error {
+	return ErrResumeVersionImmutable
+}
+
+// BeforeDelete keeps versions immutable; they are only removed with their resume
+func (v *ResumeVersionModel) BeforeDelete(tx *gorm.DB) error {
+	return ErrResumeVersionImmutable
+}
+
+// ResumeChange describes who made a save and how
+type ResumeChange struct {
+	AuthorID uint
+	Source   string
+	// ChatPrompt is the prompt that produced the change. It is saved with the version,
+	// so its ResumeID does not need to be known in advance.
+	ChatPrompt   *ChatPromptHistory
+	RestoredFrom *int
+}
+
+// ResumeSnapshot is the content of a resume at one version. The active flag is not part of
+// the content, so toggling it does not record a version.
+type ResumeSnapshot struct {
+	Title     string `json:"title"`
+	Template  string `json:"template"`
+	Theme     string `json:"theme"`
+	FullName  string `json:"full_name"`
+	Email     string `json:"email"`
+	Phone     string `json:"phone"`
+	Address   string `json:"address"`
+	Website   string `json:"website"`
+	LinkedIn  string `json:"linkedin"`
+	GitHub    string `json:"github"`
+	Summary   string `json:"summary"`
+	Objective string `json:"objective"`
+
+	Experience     []WorkExperience `json:"experience"`
+	Education      []Education      `json:"education"`
+	Skills         []Skill          `json:"skills"`
+	Languages      []Language       `json:"languages"`
+	Certifications []Certification  `json:"certifications"`
+	Projects       []Project        `json:"projects"`
+
+	Awards     string `json:"awards"`
+	Interests  string `json:"interests"`
+	References string `json:"references"`
+}
+
+// resumeSnapshotColumns are the resume columns a snapshot restores
+var resumeSnapshotColumns = []string{
+	"title", "template", "theme", "full_name", "email", "phone", "address", "website", "linkedin", "github",
+	"summary", "objective", "awards", "interests", "references",
+}
+
+// NewResumeSnapshot captures the content of a resume
+func NewResumeSnapshot(r *ResumeModel) (*ResumeSnapshot, error) {
+	snapshot := &ResumeSnapshot{
+		Title: r.Title, Template: r.Template, Theme: r.Theme,
+		FullName: r.FullName, Email: r.Email, Phone: r.Phone, Address: r.Address,
+		Website: r.Website, LinkedIn: r.LinkedIn, GitHub: r.GitHub,
+		Summary: r.Summary, Objective: r.Objective,
+		Awards: r.Awards, Interests: r.Interests, References: r.References,
+		Experience: []WorkExperience{}, Education: []Education{}, Skills: []Skill{},
+		Languages: []Language{}, Certifications: []Certification{}, Projects: []Project{},
+	}
+	for _, name := range ResumeSectionNames {
+		if err := decodeSection(name, *r.sectionField(name), snapshot.section(name)); err != nil {
+			return nil, err
+		}
+	}
+	return snapshot, nil
+}
+
+// ApplyTo copies the snapshot content onto a resume
+func (s *ResumeSnapshot) ApplyTo(r *ResumeModel) error {
+	r.Title, r.Template, r.Theme = s.Title, s.Template, s.Theme
+	r.FullName, r.Email, r.Phone, r.Address = s.FullName, s.Email, s.Phone, s.Address
+	r.Website, r.LinkedIn, r.GitHub = s.Website, s.LinkedIn, s.GitHub
+	r.Summary, r.Objective = s.Summary, s.Objective
+	r.Awards, r.Interests, r.References = s.Awards, s.Interests, s.References
+	for _, name := range ResumeSectionNames {
+		encoded, err := json.Marshal(s.section(name))
+		if err != nil {
+			return fmt.Errorf("failed to encode %s section: %v", name, err)
+		}
+		*r.sectionField(name) = string(encoded)
+	}
+	return nil
+}
+
+// changedContent lists the resume columns and sections that differ in other
+func (s *ResumeSnapshot) changedContent(other *ResumeSnapshot) (columns, sections []string, err error) {
+	for _, column := range resumeSnapshotColumns {
+		value := snapshotFieldValue(column)
+		if value(s) != value(other) {
+			columns = append(columns, column)
+		}
+	}
+	for _, name := range ResumeSectionNames {
+		a, err := json.Marshal(s.section(name))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encode %s section: %v", name, err)
+		}
+		b, err := json.Marshal(other.section(name))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encode %s section: %v", name, err)
+		}
+		if string(a) != string(b) {
+			sections = append(sections, name)
+		}
+	}
+	return columns, sections, nil
+}
+
+// section returns a pointer to the slice holding a section
+func (s *ResumeSnapshot) section(name string) interface{} {
+	switch name {
+	case SectionExperience:
+		return &s.Experience
+	case SectionEducation:
+		return &s.Education
+	case SectionSkills:
+		return &s.Skills
+	case SectionLanguages:
+		return &s.Languages
+	case SectionCertifications:
+		return &s.Certifications
+	case SectionProjects:
+		return &s.Projects
+	}
+	panic("unknown resume section " + name)
+}
+
+// equal reports whether two snapshots hold the same content
+func (s *ResumeSnapshot) equal(other *ResumeSnapshot) bool {
+	a, errA := json.Marshal(s)
+	b, errB := json.Marshal(other)
+	return errA == nil && errB == nil && string(a) == string(b)
+}
+
+// CreateWithChange creates the resume and records its first version
+func (r *ResumeModel) CreateWithChange(change ResumeChange) error {
+	db := database.GetPostgresDB()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return r.createWithChange(tx, change)
+	})
+	if err != nil {
+		return err
+	}
+
+	r.reload(db)
+	return nil
+}
+
+func (r *ResumeModel) createWithChange(tx *gorm.DB, change ResumeChange) error {
+	if err := tx.Create(&r).Error; err != nil {
+		return err
+	}
+	if err := r.UpdateSearchIndex(tx); err != nil {
+		return err
+	}
+	_, err := r.RecordVersion(tx, change)
+	return err
+}
+
+// RecordVersion snapshots the stored resume as a new version. Nothing is recorded when the
+// content equals the latest version, which is returned instead.
+func (r *ResumeModel) RecordVersion(tx *gorm.DB, change ResumeChange) (*ResumeVersionModel, error) {
+	var current ResumeModel
+	if err := tx.First(&current, r.ID).Error; err != nil {
+		return nil, err
+	}
+	if err := current.loadSections(tx); err != nil {
+		return nil, err
+	}
+	snapshot, err := NewResumeSnapshot(&current)
+	if err != nil {
+		return nil, err
+	}
+
+	if change.AuthorID == 0 {
+		change.AuthorID = current.UserID
+	}
+	// The prompt is kept even when it did not change the content
+	if prompt := change.ChatPrompt; prompt != nil && prompt.ID == 0 {
+		prompt.ResumeID = r.ID
+		if prompt.UserID == 0 {
+			prompt.UserID = change.AuthorID
+		}
+		if err := tx.Create(prompt).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	var latest ResumeVersionModel
+	err = tx.Where("resume_id = ?", r.ID).Order("version DESC").Limit(1).Find(&latest).Error
+	if err != nil {
+		return nil, err
+	}
+	if latest.ID != 0 && latest.Snapshot.equal(snapshot) {
+		return &latest, nil
+	}
+
+	if change.Source == "" {
+		change.Source = VersionSourceManual
+	}
+	version := &ResumeVersionModel{
+		ResumeID:     r.ID,
+		Version:      latest.Version + 1,
+		AuthorID:     change.AuthorID,
+		Source:       change.Source,
+		RestoredFrom: change.RestoredFrom,
+		Snapshot:     snapshot,
+	}
+	if change.ChatPrompt != nil {
+		version.ChatPromptHistoryID = &change.ChatPrompt.ID
+	}
+	if err := tx.Omit(clause.Associations).Create(version).Error; err != nil {
+		return nil, err
+	}
+	return version, nil
+}
+
+// GetVersions lists the versions of the resume, newest first, without their snapshots
+func (r *ResumeModel) GetVersions() ([]ResumeVersionModel, error) {
+	db := database.GetPostgresDB()
+	var versions []ResumeVersionModel
+	if err := db.Omit("snapshot").Where("resume_id = ?", r.ID).Order("version DESC").Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// GetVersion returns a version of the resume with its snapshot; version 0 is the latest
+func (r *ResumeModel) GetVersion(version int) (*ResumeVersionModel, error) {
+	db := database.GetPostgresDB()
+	query := db.Where("resume_id = ?", r.ID)
+	if version > 0 {
+		query = query.Where("version = ?", version)
+	}
+	var result ResumeVersionModel
+	if err := query.Order("version DESC").Limit(1).Find(&result).Error; err != nil {
+		return nil, err
+	}
+	if result.ID == 0 {
+		return nil, ErrResumeVersionNotFound
+	}
+	return &result, nil
+}
+
+// GetResumeForEdit loads a resume with the number of its latest version, the base version of an edit made on the
+// loaded content. The version is read first, so a version recorded while the resume loads makes the edit conflict
+// instead of applying it to content it was not written for.
+func (r *ResumeModel) GetResumeForEdit(id uint) (int, error) {
+	latest, err := latestVersionNumber(database.GetPostgresDB(), id)
+	if err != nil {
+		return 0, err
+	}
+	if err := r.GetResumeByID(id); err != nil {
+		return 0, err
+	}
+	return latest, nil
+}
+
+// latestVersionNumber returns the number of the latest version of a resume, 0 when none was recorded
+func latestVersionNumber(db *gorm.DB, resumeID uint) (int, error) {
+	var latest int
+	err := db.Model(&ResumeVersionModel{}).Where("resume_id = ?", resumeID).
+		Select("COALESCE(MAX(version), 0)").Scan(&latest).Error
+	return latest, err
+}
+
+// checkBaseVersion returns a *ResumeVersionConflictError when versions were recorded after baseVersion
+func checkBaseVersion(baseVersion, latest int) error {
+	if latest != baseVersion {
+		return &ResumeVersionConflictError{Latest: latest}
+	}
+	return nil
+}
+
+// ApplyEdit replaces the resume content with the snapshot edit makes of the stored content and records the result
+// as a new version. The resume row is locked for the transaction and edit only runs when the latest version is still
+// baseVersion (0 when none was recorded), otherwise a *ResumeVersionConflictError is returned. Only the columns and
+// sections that differ from the stored content are written, so everything else stays exactly as it was.
+func (r *ResumeModel) ApplyEdit(baseVersion int, edit func(stored *ResumeSnapshot) (*ResumeSnapshot, error), change ResumeChange) (*ResumeVersionModel, error) {
+	db := database.GetPostgresDB()
+	var applied *ResumeVersionModel
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var current ResumeModel
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, r.ID).Error; err != nil {
+			return err
+		}
+		if err := current.loadSections(tx); err != nil {
+			return err
+		}
+		latest, err := latestVersionNumber(tx, r.ID)
+		if err != nil {
+			return err
+		}
+		if err := checkBaseVersion(baseVersion, latest); err != nil {
+			return err
+		}
+
+		stored, err := NewResumeSnapshot(&current)
+		if err != nil {
+			return err
+		}
+		snapshot, err := edit(stored)
+		if err != nil {
+			return err
+		}
+		columns, sections, err := stored.changedContent(snapshot)
+		if err != nil {
+			return err
+		}
+
+		content := ResumeModel{ID: r.ID}
+		if err := snapshot.ApplyTo(&content); err != nil {
+			return err
+		}
+		// Select updates the empty fields too, so emptied fields are saved
+		if len(columns) > 0 {
+			if err := tx.Model(&r).Select(columns).Updates(&content).Error; err != nil {
+				return err
+			}
+		}
+		if err := content.SaveSections(tx, sections...); err != nil {
+			return err
+		}
+		if len(columns) > 0 || len(sections) > 0 {
+			if err := r.UpdateSearchIndex(tx); err != nil {
+				return err
+			}
+		}
+
+		applied, err = r.RecordVersion(tx, change)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.reload(db)
+	return applied, nil
+}
+
+// RestoreVersion replaces the resume content with a version and records the result as a new version.
+// Restoring the content of the latest version records nothing and returns the latest version.
+func (r *ResumeModel) RestoreVersion(version int, change ResumeChange) (*ResumeVersionModel, error) {
+	db := database.GetPostgresDB()
+	var restored *ResumeVersionModel
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var source ResumeVersionModel
+		if err := tx.Where("resume_id = ? AND version = ?", r.ID, version).Limit(1).Find(&source).Error; err != nil {
+			return err
+		}
+		if source.ID == 0 {
+			return ErrResumeVersionNotFound
+		}
+
+		content := ResumeModel{ID: r.ID}
+		if err := source.Snapshot.ApplyTo(&content); err != nil {
+			return err
+		}
+		// Select updates the empty fields too, so the content matches the version exactly
+		if err := tx.Model(&r).Select(resumeSnapshotColumns).Updates(&content).Error; err != nil {
+			return err
+		}
+		if err := content.SaveSections(tx, ResumeSectionNames...); err != nil {
+			return err
+		}
+
+		if err := r.UpdateSearchIndex(tx); err != nil {
+			return err
+		}
+
+		change.RestoredFrom = &source.Version
+		var err error
+		restored, err = r.RecordVersion(tx, change)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.reload(db)
+	return restored, nil
+}
@@ -0,0 +1,162 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SectionDiff describes how one section of a resume changed between two versions.
+// Field sections list their changed fields, list sections their added and removed entries.
+type SectionDiff struct {
+	Section   string            `json:"section"`
+	Changes   []FieldChange     `json:"changes,omitempty"`
+	Added     []json.RawMessage `json:"added,omitempty"`
+	Removed   []json.RawMessage `json:"removed,omitempty"`
+	Reordered bool              `json:"reordered,omitempty"` // same entries in a different order
+}
+
+// FieldChange is a changed field of a resume
+type FieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// snapshotField is a text field of a snapshot
+type snapshotField struct {
+	name  string
+	value func(s *ResumeSnapshot) string
+}
+
+// snapshotFieldSections groups the text fields of a snapshot into sections
+var snapshotFieldSections = map[string][]snapshotField{
+	"details": {
+		{"title", func(s *ResumeSnapshot) string { return s.Title }},
+		{"template", func(s *ResumeSnapshot) string { return s.Template }},
+		{"theme", func(s *ResumeSnapshot) string { return s.Theme }},
+	},
+	"personal": {
+		{"full_name", func(s *ResumeSnapshot) string { return s.FullName }},
+		{"email", func(s *ResumeSnapshot) string { return s.Email }},
+		{"phone", func(s *ResumeSnapshot) string { return s.Phone }},
+		{"address", func(s *ResumeSnapshot) string { return s.Address }},
+		{"website", func(s *ResumeSnapshot) string { return s.Website }},
+		{"linkedin", func(s *ResumeSnapshot) string { return s.LinkedIn }},
+		{"github", func(s *ResumeSnapshot) string { return s.GitHub }},
+	},
+	"summary": {
+		{"summary", func(s *ResumeSnapshot) string { return s.Summary }},
+		{"objective", func(s *ResumeSnapshot) string { return s.Objective }},
+	},
+	"awards":     {{"awards", func(s *ResumeSnapshot) string { return s.Awards }}},
+	"interests":  {{"interests", func(s *ResumeSnapshot) string { return s.Interests }}},
+	"references": {{"references", func(s *ResumeSnapshot) string { return s.References }}},
+}
+
+// snapshotFieldValue returns the accessor of a text field of a snapshot by name
+func snapshotFieldValue(name string) func(s *ResumeSnapshot) string {
+	for _, fields := range snapshotFieldSections {
+		for _, field := range fields {
+			if field.name == name {
+				return field.value
+			}
+		}
+	}
+	panic("unknown resume snapshot field " + name)
+}
+
+// versionDiffSections lists the sections of a version diff in display order
+var versionDiffSections = append(append([]string{"details", "personal", "summary"}, ResumeSectionNames...),
+	"awards", "interests", "references")
+
+// DiffSnapshots compares two snapshots section by section and returns the changed sections in display order
+func DiffSnapshots(from, to *ResumeSnapshot) ([]SectionDiff, error) {
+	var diffs []SectionDiff
+	for _, name := range versionDiffSections {
+		fields, isFieldSection := snapshotFieldSections[name]
+		if !isFieldSection {
+			diff, err := diffEntries(name, from.section(name), to.section(name))
+			if err != nil {
+				return nil, err
+			}
+			if diff != nil {
+				diffs = append(diffs, *diff)
+			}
+			continue
+		}
+
+		diff := SectionDiff{Section: name}
+		for _, field := range fields {
+			if a, b := field.value(from), field.value(to); a != b {
+				diff.Changes = append(diff.Changes, FieldChange{Field: field.name, From: a, To: b})
+			}
+		}
+		if len(diff.Changes) > 0 {
+			diffs = append(diffs, diff)
+		}
+	}
+	return diffs, nil
+}
+
+// diffEntries compares the entries of a list section by their JSON encoding.
+// An edited entry shows up as the old entry removed and the new one added.
+func diffEntries(name string, from, to interface{}) (*SectionDiff, error) {
+	a, err := encodeEntries(from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s section: %v", name, err)
+	}
+	b, err := encodeEntries(to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s section: %v", name, err)
+	}
+
+	diff := &SectionDiff{Section: name}
+	remaining := make(map[string]int, len(b))
+	for _, entry := range b {
+		remaining[string(entry)]++
+	}
+	for _, entry := range a {
+		if remaining[string(entry)] > 0 {
+			remaining[string(entry)]--
+			continue
+		}
+		diff.Removed = append(diff.Removed, entry)
+	}
+	previous := make(map[string]int, len(a))
+	for _, entry := range a {
+		previous[string(entry)]++
+	}
+	for _, entry := range b {
+		if previous[string(entry)] > 0 {
+			previous[string(entry)]--
+			continue
+		}
+		diff.Added = append(diff.Added, entry)
+	}
+
+	if len(diff.Added) == 0 && len(diff.Removed) == 0 {
+		for i := range a {
+			if string(a[i]) != string(b[i]) {
+				diff.Reordered = true
+				break
+			}
+		}
+		if !diff.Reordered {
+			return nil, nil
+		}
+	}
+	return diff, nil
+}
+
+// encodeEntries encodes each entry of a section slice
+func encodeEntries(section interface{}) ([]json.RawMessage, error) {
+	data, err := json.Marshal(section)
+	if err != nil {
+		return nil, err
+	}
+	var entries []json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
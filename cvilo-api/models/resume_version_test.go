@@ -0,0 +1,194 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestResumeSnapshotRoundTrip(t *testing.T) {
+	resume := &ResumeModel{
+		Title:    "Backend Engineer",
+		FullName: "Jane Doe",
+		IsActive: true,
+		Skills:   `[{"name":"Go","category":"Backend","level":5}]`,
+		Awards:   "Hackathon winner",
+	}
+
+	snapshot, err := NewResumeSnapshot(resume)
+	if err != nil {
+		t.Fatalf("NewResumeSnapshot() error: %v", err)
+	}
+	if snapshot.Experience == nil || len(snapshot.Experience) != 0 {
+		t.Errorf("NewResumeSnapshot() experience = %#v, want an empty section", snapshot.Experience)
+	}
+
+	var restored ResumeModel
+	if err := snapshot.ApplyTo(&restored); err != nil {
+		t.Fatalf("ApplyTo() error: %v", err)
+	}
+	if restored.Title != resume.Title || restored.Awards != resume.Awards || restored.IsActive {
+		t.Errorf("ApplyTo() = %q/%q/%v, want the content without the active flag", restored.Title, restored.Awards, restored.IsActive)
+	}
+	if restored.Skills != `[{"name":"Go","category":"Backend","level":5}]` || restored.Projects != "[]" {
+		t.Errorf("ApplyTo() sections = %s/%s", restored.Skills, restored.Projects)
+	}
+
+	// A snapshot read back from the database must compare equal to a fresh one
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatalf("json.Marshal() error: %v", err)
+	}
+	var stored ResumeSnapshot
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatalf("json.Unmarshal() error: %v", err)
+	}
+	again, _ := NewResumeSnapshot(&restored)
+	if !stored.equal(again) {
+		t.Errorf("snapshot of a restored resume differs from the stored snapshot")
+	}
+
+	var sectionErr *SectionError
+	if _, err := NewResumeSnapshot(&ResumeModel{Skills: "{"}); !errors.As(err, &sectionErr) {
+		t.Errorf("NewResumeSnapshot() of a malformed section error = %v, want a *SectionError", err)
+	}
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	base := ResumeSnapshot{
+		Title:    "Backend Engineer",
+		FullName: "Jane Doe",
+		Email:    "jane@example.com",
+		Skills:   []Skill{{Name: "Go"}, {Name: "SQL"}},
+		Projects: []Project{{Name: "Cvilo"}},
+	}
+
+	tests := []struct {
+		name     string
+		change   func(s *ResumeSnapshot)
+		expected []SectionDiff
+	}{
+		{
+			name:     "Unchanged",
+			change:   func(s *ResumeSnapshot) {},
+			expected: nil,
+		},
+		{
+			name: "Field changes",
+			change: func(s *ResumeSnapshot) {
+				s.Email = "jane@doe.dev"
+				s.Phone = "+49 30 1234567"
+				s.Interests = "Climbing"
+			},
+			expected: []SectionDiff{
+				{Section: "personal", Changes: []FieldChange{
+					{Field: "email", From: "jane@example.com", To: "jane@doe.dev"},
+					{Field: "phone", From: "", To: "+49 30 1234567"},
+				}},
+				{Section: "interests", Changes: []FieldChange{{Field: "interests", From: "", To: "Climbing"}}},
+			},
+		},
+		{
+			name: "Entries added and removed",
+			change: func(s *ResumeSnapshot) {
+				s.Skills = []Skill{{Name: "Go"}, {Name: "Kubernetes"}}
+				s.Projects = nil
+			},
+			expected: []SectionDiff{
+				{
+					Section: SectionSkills,
+					Added:   []json.RawMessage{json.RawMessage(`{"name":"Kubernetes","category":"","level":0}`)},
+					Removed: []json.RawMessage{json.RawMessage(`{"name":"SQL","category":"","level":0}`)},
+				},
+				{
+					Section: SectionProjects,
+					Removed: []json.RawMessage{json.RawMessage(`{"name":"Cvilo","description":"","technologies":null,"start_date":"0001-01-01T00:00:00Z"}`)},
+				},
+			},
+		},
+		{
+			name: "Entries reordered",
+			change: func(s *ResumeSnapshot) {
+				s.Skills = []Skill{{Name: "SQL"}, {Name: "Go"}}
+			},
+			expected: []SectionDiff{{Section: SectionSkills, Reordered: true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from := base
+			to := base
+			tt.change(&to)
+
+			result, err := DiffSnapshots(&from, &to)
+			if err != nil {
+				t.Fatalf("DiffSnapshots() error: %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				got, _ := json.Marshal(result)
+				want, _ := json.Marshal(tt.expected)
+				t.Errorf("DiffSnapshots() = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestResumeSnapshotChangedContent(t *testing.T) {
+	base := ResumeSnapshot{
+		Title:      "Backend Engineer",
+		Summary:    "Go developer",
+		Objective:  "Lead a team",
+		Experience: []WorkExperience{{Company: "Acme", Position: "Engineer"}},
+		Skills:     []Skill{{Name: "Go", Level: 5}},
+	}
+
+	edited := base
+	edited.Summary = "Go developer with 8 years of experience"
+	edited.Objective = ""
+	edited.Skills = []Skill{{Name: "Go", Level: 5}, {Name: "SQL", Level: 4}}
+
+	columns, sections, err := base.changedContent(&edited)
+	if err != nil {
+		t.Fatalf("changedContent() error: %v", err)
+	}
+	if !reflect.DeepEqual(columns, []string{"summary", "objective"}) || !reflect.DeepEqual(sections, []string{SectionSkills}) {
+		t.Errorf("changedContent() = %v, %v, want [summary objective], [skills]", columns, sections)
+	}
+
+	columns, sections, _ = base.changedContent(&base)
+	if len(columns) != 0 || len(sections) != 0 {
+		t.Errorf("changedContent() of equal snapshots = %v, %v, want nothing", columns, sections)
+	}
+}
+
+func TestCheckBaseVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		baseVersion int // latest version when the patch was previewed
+		latest      int // latest version when it is applied
+		conflict    bool
+	}{
+		{"No version recorded since the preview", 7, 7, false},
+		{"Resume without versions", 0, 0, false},
+		{"Version recorded between the preview and the apply", 7, 8, true},
+		{"First version recorded after the preview", 0, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkBaseVersion(tt.baseVersion, tt.latest)
+			var conflict *ResumeVersionConflictError
+			if !tt.conflict {
+				if err != nil {
+					t.Errorf("checkBaseVersion(%d, %d) error: %v", tt.baseVersion, tt.latest, err)
+				}
+				return
+			}
+			if !errors.As(err, &conflict) || conflict.Latest != tt.latest {
+				t.Errorf("checkBaseVersion(%d, %d) error = %v, want a conflict at version %d", tt.baseVersion, tt.latest, err, tt.latest)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/smhnaqvi/cvilo/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrTwoFactorNotFound is returned for users who never started a two-factor enrollment
+	ErrTwoFactorNotFound = errors.New("two-factor authentication is not set up")
+	// ErrMFAChallengeInvalid is returned for login challenges that were completed or replaced by a newer login
+	ErrMFAChallengeInvalid = errors.New("invalid or expired MFA challenge")
+	// ErrRecoveryCodeInvalid is returned for recovery codes that do not exist or were used
+	ErrRecoveryCodeInvalid = errors.New("invalid recovery code")
+)
+
+// TwoFactorModel holds the TOTP secret of a user. It is created by the enrollment and only protects logins once
+// EnabledAt is set, after the user proved their authenticator works.
+type TwoFactorModel struct {
+	ID     uint      `json:"id" gorm:"primarykey"`
+	UserID uint      `json:"user_id" gorm:"not null;uniqueIndex"`
+	User   UserModel `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Secret string    `json:"-" gorm:"type:text;not null"` // encrypted base32 TOTP secret
+
+	EnabledAt    *time.Time `json:"enabled_at,omitempty"`
+	LastUsedStep int64      `json:"-" gorm:"not null;default:0"` // time step of the last accepted code, which cannot be replayed
+
+	// Login challenge awaiting the second factor; only the latest challenge of a user is valid
+	ChallengeID    string `json:"-" gorm:"type:varchar(64)"`
+	FailedAttempts int    `json:"-" gorm:"not null;default:0"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName overrides the table name used by TwoFactorModel to `two_factor_settings`
+func (TwoFactorModel) TableName() string {
+	return "two_factor_settings"
+}
+
+// RecoveryCodeModel is a one-time code replacing the TOTP code when the authenticator is lost, stored as a SHA-256
+// hash
+type RecoveryCodeModel struct {
+	ID        uint       `json:"id" gorm:"primarykey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	User      UserModel  `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	CodeHash  string     `json:"-" gorm:"type:varchar(64);not null"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName overrides the table name used by RecoveryCodeModel to `recovery_codes`
+func (RecoveryCodeModel) TableName() string {
+	return "recovery_codes"
+}
+
+// Enabled reports whether logins of the user require the second factor
+func (t *TwoFactorModel) Enabled() bool {
+	return t.EnabledAt != nil
+}
+
+// GetByUserID loads the two-factor settings of the user
+func (t *TwoFactorModel) GetByUserID(userID uint) error {
+	db := database.GetPostgresDB()
+	err := db.Where("user_id = ?", userID).First(t).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrTwoFactorNotFound
+	}
+	return err
+}
+
+// StartEnrollment stores a new secret for the user, replacing an enrollment that was not completed
+func (t *TwoFactorModel) StartEnrollment() error {
+	db := database.GetPostgresDB()
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND enabled_at IS NULL", t.UserID).Delete(&TwoFactorModel{}).Error; err != nil {
+			return err
+		}
+		return tx.Omit(clause.Associations).Create(t).Error
+	})
+}
+
+// Enable turns on the second factor after a first valid code at step, replacing the recovery codes
+func (t *TwoFactorModel) Enable(step int64, recoveryCodeHashes []string) error {
+	db := database.GetPostgresDB()
+	return db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(t).Updates(map[string]interface{}{"enabled_at": now, "last_used_step": step}).Error; err != nil {
+			return err
+		}
+		if err := replaceRecoveryCodes(tx, t.UserID, recoveryCodeHashes); err != nil {
+			return err
+		}
+		if err := tx.Model(&UserModel{}).Where("id = ?", t.UserID).Update("two_factor_enabled", true).Error; err != nil {
+			return err
+		}
+		t.EnabledAt = &now
+		t.LastUsedStep = step
+		return nil
+	})
+}
+
+// Disable removes the secret and recovery codes of the user
+func (t *TwoFactorModel) Disable() error {
+	db := database.GetPostgresDB()
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", t.UserID).Delete(&RecoveryCodeModel{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", t.UserID).Delete(&TwoFactorModel{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&UserModel{}).Where("id = ?", t.UserID).Update("two_factor_enabled", false).Error
+	})
+}
+
+// MarkStepUsed records an accepted code at step. It fails when a code of that step or a later one was accepted in
+// the meantime, so a code works once.
+func (t *TwoFactorModel) MarkStepUsed(step int64) error {
+	db := database.GetPostgresDB()
+	result := db.Model(&TwoFactorModel{}).
+		Where("id = ? AND last_used_step < ?", t.ID, step).
+		Update("last_used_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrMFAChallengeInvalid
+	}
+	t.LastUsedStep = step
+	return nil
+}
+
+// StartChallenge makes challengeID the only valid login challenge of the user, with no failed attempts
+func (t *TwoFactorModel) StartChallenge(challengeID string) error {
+	db := database.GetPostgresDB()
+	return db.Model(t).Updates(map[string]interface{}{"challenge_id": challengeID, "failed_attempts": 0}).Error
+}
+
+// ReserveAttempt counts an attempt at the challenge before its code is checked, in a single update so concurrent
+// attempts cannot exceed maxAttempts. It reports false when the challenge has no attempt left or is no longer
+// valid. A right code resets the count with CompleteChallenge.
+func (t *TwoFactorModel) ReserveAttempt(challengeID string, maxAttempts int) (bool, error) {
+	db := database.GetPostgresDB()
+	result := db.Model(&TwoFactorModel{}).
+		Where("id = ? AND challenge_id = ? AND failed_attempts < ?", t.ID, challengeID, maxAttempts).
+		Update("failed_attempts", gorm.Expr("failed_attempts + 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// CompleteChallenge ends the challenge after a valid code, so it cannot be completed twice
+func (t *TwoFactorModel) CompleteChallenge(challengeID string) error {
+	db := database.GetPostgresDB()
+	result := db.Model(&TwoFactorModel{}).
+		Where("id = ? AND challenge_id = ?", t.ID, challengeID).
+		Updates(map[string]interface{}{"challenge_id": "", "failed_attempts": 0})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrMFAChallengeInvalid
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes discards the recovery codes of the user and stores new ones
+func (t *TwoFactorModel) ReplaceRecoveryCodes(recoveryCodeHashes []string) error {
+	db := database.GetPostgresDB()
+	return db.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, t.UserID, recoveryCodeHashes)
+	})
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uint, hashes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCodeModel{}).Error; err != nil {
+		return err
+	}
+	codes := make([]RecoveryCodeModel, 0, len(hashes))
+	for _, hash := range hashes {
+		codes = append(codes, RecoveryCodeModel{UserID: userID, CodeHash: hash})
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+	return tx.Omit(clause.Associations).Create(&codes).Error
+}
+
+// UseRecoveryCode marks the unused recovery code of the user with the hash as used
+func (t *TwoFactorModel) UseRecoveryCode(hash string) error {
+	db := database.GetPostgresDB()
+	result := db.Model(&RecoveryCodeModel{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", t.UserID, hash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecoveryCodeInvalid
+	}
+	return nil
+}
+
+// RemainingRecoveryCodes counts the unused recovery codes of the user
+func (t *TwoFactorModel) RemainingRecoveryCodes() (int64, error) {
+	db := database.GetPostgresDB()
+	var count int64
+	err := db.Model(&RecoveryCodeModel{}).Where("user_id = ? AND used_at IS NULL", t.UserID).Count(&count).Error
+	return count, err
+}
@@ -2,12 +2,34 @@ package models
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/smhnaqvi/cvilo/database"
 	"gorm.io/gorm"
 )
 
+// User roles
+const (
+	RoleUser      = "user"      // manages their own resumes
+	RoleRecruiter = "recruiter" // also searches the resumes of all users
+	RoleAdmin     = "admin"     // manages all users and resumes
+)
+
+// Roles lists the valid user roles
+var Roles = []string{RoleUser, RoleRecruiter, RoleAdmin}
+
+// ValidRole reports whether role is one of Roles
+func ValidRole(role string) bool {
+	for _, r := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
 type UserModel struct {
 	ID        uint           `json:"id" gorm:"primarykey"`
 	CreatedAt time.Time      `json:"created_at"`
@@ -37,6 +59,10 @@ type UserModel struct {
 	// System Fields
 	Step     string `json:"step" gorm:"default:'profile'"`
 	IsActive bool   `json:"is_active" gorm:"default:true"`
+	Role     string `json:"role" gorm:"type:varchar(20);not null;default:'user';index"`
+
+	EmailVerifiedAt  *time.Time `json:"email_verified_at,omitempty"`                      // nil until the verification link is opened
+	TwoFactorEnabled bool       `json:"two_factor_enabled" gorm:"not null;default:false"` // login asks for a TOTP code, see TwoFactorModel
 
 	// Relationships
 	Resumes []ResumeModel `json:"resumes,omitempty" gorm:"foreignKey:UserID"`
@@ -52,6 +78,9 @@ func (u *UserModel) BeforeCreate(tx *gorm.DB) error {
 	if u.Step == "" {
 		u.Step = "profile"
 	}
+	if u.Role == "" {
+		u.Role = RoleUser
+	}
 	return nil
 }
 
@@ -66,6 +95,7 @@ type UserCreateRequest struct {
 	Website  string `json:"website"`
 	LinkedIn string `json:"linkedin"`
 	GitHub   string `json:"github"`
+	Role     string `json:"role"` // defaults to user
 }
 
 // UserUpdateRequest represents the request structure for updating a user
@@ -104,6 +134,10 @@ type UserResponse struct {
 	GitHub     string    `json:"github"`
 	Step       string    `json:"step"`
 	IsActive   bool      `json:"is_active"`
+	Role       string    `json:"role"`
+
+	EmailVerified    bool `json:"email_verified"`
+	TwoFactorEnabled bool `json:"two_factor_enabled"`
 }
 
 func (u *UserModel) Create() error {
@@ -147,10 +181,18 @@ func (u *UserModel) UpdateUser(id uint) error {
 		return err
 	}
 
-	db.Preload("Resumes").First(&u, u.ID)
+	u.reload(db)
 	return nil
 }
 
+// reload reads the stored user again with their resumes and the sections of the resumes
+func (u *UserModel) reload(db *gorm.DB) error {
+	if err := db.Preload("Resumes").First(u, u.ID).Error; err != nil {
+		return err
+	}
+	return loadResumeListSections(db, u.Resumes)
+}
+
 func (u *UserModel) DeleteUserResumes(id uint) error {
 	db := database.GetPostgresDB()
 	if err := db.Where("user_id = ?", id).Delete(&ResumeModel{}).Error; err != nil {
@@ -164,10 +206,50 @@ func (u *UserModel) DeactivateUser(id uint) error {
 	if err := db.Model(&u).Where("id = ?", id).Update("is_active", false).Error; err != nil {
 		return err
 	}
-	db.Preload("Resumes").First(&u, u.ID)
+	u.reload(db)
 	return nil
 }
 
+// MarkEmailVerified records that the user proved to own their email address
+func (u *UserModel) MarkEmailVerified() error {
+	now := time.Now()
+	db := database.GetPostgresDB()
+	if err := db.Model(&u).Update("email_verified_at", now).Error; err != nil {
+		return err
+	}
+	u.EmailVerifiedAt = &now
+	return nil
+}
+
+// SetRole changes the role of the user
+func (u *UserModel) SetRole(role string) error {
+	if !ValidRole(role) {
+		return fmt.Errorf("invalid role %q, must be one of %s", role, strings.Join(Roles, ", "))
+	}
+	db := database.GetPostgresDB()
+	if err := db.Model(&u).Update("role", role).Error; err != nil {
+		return err
+	}
+	u.Role = role
+	return nil
+}
+
+// GetRole returns the stored role of a user, users without a stored role being plain users
+func (u *UserModel) GetRole(id uint) (string, error) {
+	db := database.GetPostgresDB()
+	var roles []string
+	if err := db.Model(&UserModel{}).Where("id = ?", id).Pluck("role", &roles).Error; err != nil {
+		return "", err
+	}
+	if len(roles) == 0 {
+		return "", errors.New("user not found")
+	}
+	if roles[0] == "" {
+		return RoleUser, nil
+	}
+	return roles[0], nil
+}
+
 func (u *UserModel) GetUserByChatID(chatID int64) error {
 	db := database.GetPostgresDB()
 	if err := db.Where("chat_id = ?", chatID).First(&u).Error; err == nil {
@@ -196,5 +278,9 @@ func (u *UserModel) ToUserResponse() UserResponse {
 		GitHub:     u.GitHub,
 		Step:       u.Step,
 		IsActive:   u.IsActive,
+		Role:       u.Role,
+
+		EmailVerified:    u.EmailVerifiedAt != nil,
+		TwoFactorEnabled: u.TwoFactorEnabled,
 	}
 }
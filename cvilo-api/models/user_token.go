@@ -0,0 +1,68 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/smhnaqvi/cvilo/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// User token purposes
+const (
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailVerification = "email_verification"
+)
+
+// ErrUserTokenInvalid is returned for tokens that are unknown, expired, already used or issued for another purpose
+var ErrUserTokenInvalid = errors.New("invalid or expired token")
+
+// UserTokenModel is a single-use token mailed to a user, stored as a SHA-256 hash. Issuing a token invalidates the
+// unused tokens of the same purpose, so only the most recent mail works.
+type UserTokenModel struct {
+	ID        uint       `json:"id" gorm:"primarykey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	User      UserModel  `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Purpose   string     `json:"purpose" gorm:"type:varchar(32);not null;index"`
+	TokenHash string     `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName overrides the table name used by UserTokenModel to `user_tokens`
+func (UserTokenModel) TableName() string {
+	return "user_tokens"
+}
+
+// Create stores a new token, first discarding the unused and expired tokens of the user for the purpose
+func (t *UserTokenModel) Create() error {
+	db := database.GetPostgresDB()
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND purpose = ? AND (used_at IS NULL OR expires_at < ?)", t.UserID, t.Purpose, time.Now()).
+			Delete(&UserTokenModel{}).Error; err != nil {
+			return err
+		}
+		return tx.Omit(clause.Associations).Create(t).Error
+	})
+}
+
+// Consume marks the token with the hash as used and loads it. It fails with ErrUserTokenInvalid unless the token
+// exists for the purpose, has not expired and was not used before; of two concurrent calls only one succeeds.
+func (t *UserTokenModel) Consume(hash, purpose string) error {
+	db := database.GetPostgresDB()
+	return db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		used := tx.Model(&UserTokenModel{}).
+			Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", hash, purpose, now).
+			Update("used_at", now)
+		if used.Error != nil {
+			return used.Error
+		}
+		if used.RowsAffected == 0 {
+			return ErrUserTokenInvalid
+		}
+		return tx.Where("token_hash = ?", hash).First(t).Error
+	})
+}
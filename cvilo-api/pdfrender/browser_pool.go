@@ -0,0 +1,194 @@
+// Package pdfrender renders pages to PDF in a shared headless Chrome. It is used by the API and by the export
+// service.
+package pdfrender
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// ErrRenderQueueFull is returned when too many PDF renders are already waiting for a tab
+var ErrRenderQueueFull = errors.New("PDF render queue is full")
+
+// readyScript resolves once the page has finished loading its fonts and images.
+// Pages that need more time can set window.__READY__ = false and flip it to true when done.
+const readyScript = `(async () => {
+	if (document.fonts && document.fonts.ready) {
+		await document.fonts.ready;
+	}
+	await Promise.all(Array.from(document.images).map(img => {
+		if (img.complete) return Promise.resolve();
+		return new Promise(resolve => {
+			img.addEventListener('load', resolve, { once: true });
+			img.addEventListener('error', resolve, { once: true });
+		});
+	}));
+	if ('__READY__' in window) {
+		while (!window.__READY__) {
+			await new Promise(resolve => setTimeout(resolve, 50));
+		}
+	}
+	return true;
+})()`
+
+// BrowserPool keeps a single headless Chrome process alive and hands out a bounded number of tabs
+type BrowserPool struct {
+	maxTabs       int
+	maxQueue      int32
+	renderTimeout time.Duration
+
+	slots   chan struct{}
+	waiting int32
+
+	mu            sync.Mutex
+	allocCancel   context.CancelFunc
+	browserCtx    context.Context
+	browserCancel context.CancelFunc
+}
+
+// NewBrowserPool creates a pool allowing maxTabs concurrent renders and maxQueue waiting requests.
+// Chrome is started lazily on the first render.
+func NewBrowserPool(maxTabs int, maxQueue int, renderTimeout time.Duration) *BrowserPool {
+	if maxTabs < 1 {
+		maxTabs = 1
+	}
+	return &BrowserPool{
+		maxTabs:       maxTabs,
+		maxQueue:      int32(maxQueue),
+		renderTimeout: renderTimeout,
+		slots:         make(chan struct{}, maxTabs),
+	}
+}
+
+// browser returns the shared browser context, (re)starting Chrome if it is not running
+func (bp *BrowserPool) browser() (context.Context, error) {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+
+	if bp.browserCtx != nil && bp.browserCtx.Err() == nil {
+		return bp.browserCtx, nil
+	}
+
+	if bp.allocCancel != nil {
+		log.Println("BrowserPool: Chrome is not running, restarting")
+		bp.allocCancel()
+	}
+
+	opts := chromedp.DefaultExecAllocatorOptions[:]
+	if chromePath := os.Getenv("CHROME_BIN"); chromePath != "" {
+		if _, err := os.Stat(chromePath); err == nil {
+			opts = append(opts, chromedp.ExecPath(chromePath))
+		}
+	}
+
+	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), opts...)
+	browserCtx, browserCancel := chromedp.NewContext(allocCtx)
+
+	// Running an empty action list starts the browser
+	if err := chromedp.Run(browserCtx); err != nil {
+		browserCancel()
+		allocCancel()
+		return nil, fmt.Errorf("failed to start Chrome: %v", err)
+	}
+
+	bp.allocCancel = allocCancel
+	bp.browserCtx = browserCtx
+	bp.browserCancel = browserCancel
+	log.Printf("BrowserPool: Chrome started with %d tab slots", bp.maxTabs)
+	return browserCtx, nil
+}
+
+// acquire waits for a free tab slot, honouring the queue limit and ctx cancellation
+func (bp *BrowserPool) acquire(ctx context.Context) error {
+	select {
+	case bp.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if atomic.AddInt32(&bp.waiting, 1) > bp.maxQueue {
+		atomic.AddInt32(&bp.waiting, -1)
+		return ErrRenderQueueFull
+	}
+	defer atomic.AddInt32(&bp.waiting, -1)
+
+	select {
+	case bp.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees a tab slot
+func (bp *BrowserPool) release() {
+	<-bp.slots
+}
+
+// Run opens a new tab in the shared browser and runs actions in it.
+// The tab is closed when the actions finish, when ctx is cancelled or when the render timeout expires.
+func (bp *BrowserPool) Run(ctx context.Context, actions ...chromedp.Action) error {
+	if err := bp.acquire(ctx); err != nil {
+		return err
+	}
+	defer bp.release()
+
+	browserCtx, err := bp.browser()
+	if err != nil {
+		return err
+	}
+
+	tabCtx, cancelTab := chromedp.NewContext(browserCtx)
+	defer cancelTab()
+
+	// Close the tab as soon as the caller goes away
+	stop := context.AfterFunc(ctx, cancelTab)
+	defer stop()
+
+	tabCtx, cancelTimeout := context.WithTimeout(tabCtx, bp.renderTimeout)
+	defer cancelTimeout()
+
+	if err := chromedp.Run(tabCtx, actions...); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return nil
+}
+
+// Stats returns the number of busy tabs and the number of queued requests
+func (bp *BrowserPool) Stats() (busy int, waiting int) {
+	return len(bp.slots), int(atomic.LoadInt32(&bp.waiting))
+}
+
+// Close shuts down the shared Chrome process
+func (bp *BrowserPool) Close() {
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+
+	if bp.browserCancel != nil {
+		bp.browserCancel()
+	}
+	if bp.allocCancel != nil {
+		bp.allocCancel()
+	}
+	bp.browserCtx = nil
+}
+
+// WaitUntilReady waits for fonts, images and the optional window.__READY__ flag
+func WaitUntilReady() chromedp.Action {
+	var ready bool
+	return chromedp.Evaluate(readyScript, &ready, func(p *runtime.EvaluateParams) *runtime.EvaluateParams {
+		return p.WithAwaitPromise(true)
+	})
+}
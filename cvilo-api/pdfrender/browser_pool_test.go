@@ -0,0 +1,49 @@
+package pdfrender
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBrowserPoolAcquire(t *testing.T) {
+	pool := NewBrowserPool(1, 1, time.Second)
+
+	// The single tab slot is free
+	if err := pool.acquire(context.Background()); err != nil {
+		t.Fatalf("acquire() error: %v", err)
+	}
+
+	// A second request waits in the queue until its context is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	waitErr := make(chan error)
+	go func() { waitErr <- pool.acquire(ctx) }()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, waiting := pool.Stats(); waiting == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("request was not queued")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// The queue holds one request, a third one is rejected
+	if err := pool.acquire(context.Background()); !errors.Is(err, ErrRenderQueueFull) {
+		t.Errorf("acquire() = %v, want %v", err, ErrRenderQueueFull)
+	}
+
+	cancel()
+	if err := <-waitErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("acquire() = %v, want %v", err, context.Canceled)
+	}
+
+	// Releasing the slot lets the next request in
+	pool.release()
+	if err := pool.acquire(context.Background()); err != nil {
+		t.Errorf("acquire() after release error: %v", err)
+	}
+}
@@ -0,0 +1,295 @@
+package pdfrender
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/chromedp/cdproto/page"
+)
+
+// Paper sizes in inches (width x height, portrait)
+var paperSizes = map[string][2]float64{
+	"a4":     {8.27, 11.69},
+	"letter": {8.5, 11},
+	"legal":  {8.5, 14},
+}
+
+const (
+	// Chrome only draws headers and footers inside the page margins
+	minHeaderFooterMargin = 0.35
+	// Minimum printable area left after margins, in inches
+	minContentSize        = 1.0
+	maxHeaderFooterLength = 2000
+	minScale              = 0.1
+	maxScale              = 2.0
+)
+
+var (
+	marginPattern     = regexp.MustCompile(`^([0-9]*\.?[0-9]+)\s*(in|mm|cm)?$`)
+	pageRangesPattern = regexp.MustCompile(`^\d+(-\d+)?(,\d+(-\d+)?)*$`)
+)
+
+// defaultPageNumberFooter prints "page / total" centred at the bottom of every page
+const defaultPageNumberFooter = `<div style="font-size:9px;width:100%;text-align:center;color:#555;"><span class="pageNumber"></span> / <span class="totalPages"></span></div>`
+
+// PDFOptions controls the page layout of generated PDFs. Sizes are in inches.
+type PDFOptions struct {
+	PaperSize      string  `json:"paper_size"`
+	MarginTop      float64 `json:"margin_top"`
+	MarginBottom   float64 `json:"margin_bottom"`
+	MarginLeft     float64 `json:"margin_left"`
+	MarginRight    float64 `json:"margin_right"`
+	Scale          float64 `json:"scale"`
+	Landscape      bool    `json:"landscape"`
+	HeaderTemplate string  `json:"header_template,omitempty"`
+	FooterTemplate string  `json:"footer_template,omitempty"`
+	PageNumbers    bool    `json:"page_numbers"`
+	PageRanges     string  `json:"page_ranges,omitempty"`
+}
+
+// PDFOptionError describes a single invalid PDF option
+type PDFOptionError struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+// PDFOptionsError lists every invalid PDF option of a request
+type PDFOptionsError struct {
+	Errors []PDFOptionError
+}
+
+func (e *PDFOptionsError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		messages = append(messages, fe.Field+": "+fe.Message)
+	}
+	return "invalid PDF options: " + strings.Join(messages, "; ")
+}
+
+func (e *PDFOptionsError) add(field string, code string, message string) {
+	e.Errors = append(e.Errors, PDFOptionError{Field: field, Code: code, Message: message})
+}
+
+// DefaultPDFOptions returns A4 portrait with 10mm margins
+func DefaultPDFOptions() PDFOptions {
+	return PDFOptions{
+		PaperSize:    "a4",
+		MarginTop:    0.4,
+		MarginBottom: 0.4,
+		MarginLeft:   0.4,
+		MarginRight:  0.4,
+		Scale:        1,
+	}
+}
+
+// ParsePDFOptions reads PDF options from query parameters on top of defaults.
+// Margins accept "in", "mm" or "cm" units and default to inches.
+// Invalid values and combinations are reported together as a *PDFOptionsError.
+func ParsePDFOptions(query url.Values, defaults PDFOptions) (PDFOptions, error) {
+	opts := defaults
+	errs := &PDFOptionsError{}
+
+	if value := query.Get("paper_size"); value != "" {
+		opts.PaperSize = strings.ToLower(value)
+	}
+	if _, ok := paperSizes[opts.PaperSize]; !ok {
+		errs.add("paper_size", "invalid_paper_size", "must be one of A4, Letter or Legal")
+	}
+
+	if value := query.Get("margin"); value != "" {
+		if margin, err := parseMargin(value); err != nil {
+			errs.add("margin", "invalid_margin", err.Error())
+		} else {
+			opts.MarginTop, opts.MarginBottom, opts.MarginLeft, opts.MarginRight = margin, margin, margin, margin
+		}
+	}
+	for _, margin := range []struct {
+		field  string
+		target *float64
+	}{
+		{"margin_top", &opts.MarginTop},
+		{"margin_bottom", &opts.MarginBottom},
+		{"margin_left", &opts.MarginLeft},
+		{"margin_right", &opts.MarginRight},
+	} {
+		if value := query.Get(margin.field); value != "" {
+			if parsed, err := parseMargin(value); err != nil {
+				errs.add(margin.field, "invalid_margin", err.Error())
+			} else {
+				*margin.target = parsed
+			}
+		}
+	}
+
+	if value := query.Get("scale"); value != "" {
+		scale, err := strconv.ParseFloat(value, 64)
+		if err != nil || scale < minScale || scale > maxScale {
+			errs.add("scale", "invalid_scale", fmt.Sprintf("must be a number between %.1f and %.1f", minScale, maxScale))
+		} else {
+			opts.Scale = scale
+		}
+	}
+
+	for _, flag := range []struct {
+		field  string
+		target *bool
+	}{
+		{"landscape", &opts.Landscape},
+		{"page_numbers", &opts.PageNumbers},
+	} {
+		if value := query.Get(flag.field); value != "" {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				errs.add(flag.field, "invalid_boolean", "must be true or false")
+			} else {
+				*flag.target = parsed
+			}
+		}
+	}
+
+	if value, ok := query["header_template"]; ok {
+		opts.HeaderTemplate = value[0]
+	}
+	if value, ok := query["footer_template"]; ok {
+		opts.FooterTemplate = value[0]
+	}
+	if len(opts.HeaderTemplate) > maxHeaderFooterLength {
+		errs.add("header_template", "too_long", fmt.Sprintf("must be at most %d characters", maxHeaderFooterLength))
+	}
+	if len(opts.FooterTemplate) > maxHeaderFooterLength {
+		errs.add("footer_template", "too_long", fmt.Sprintf("must be at most %d characters", maxHeaderFooterLength))
+	}
+
+	if value := query.Get("page_ranges"); value != "" {
+		opts.PageRanges = strings.ReplaceAll(value, " ", "")
+	}
+	if value := query.Get("max_pages"); value != "" {
+		maxPages, err := strconv.Atoi(value)
+		switch {
+		case err != nil || maxPages < 1:
+			errs.add("max_pages", "invalid_max_pages", "must be a positive integer")
+		case query.Get("page_ranges") != "":
+			errs.add("max_pages", "invalid_combination", "cannot be combined with page_ranges")
+		default:
+			opts.PageRanges = fmt.Sprintf("1-%d", maxPages)
+		}
+	}
+	if opts.PageRanges != "" {
+		if err := validatePageRanges(opts.PageRanges); err != nil {
+			errs.add("page_ranges", "invalid_page_ranges", err.Error())
+		}
+	}
+
+	// Combinations
+	if opts.PageNumbers && opts.FooterTemplate != "" {
+		errs.add("page_numbers", "invalid_combination", "cannot be combined with footer_template, use the pageNumber and totalPages classes in the footer instead")
+	}
+	if opts.HeaderTemplate != "" && opts.MarginTop < minHeaderFooterMargin {
+		errs.add("header_template", "invalid_combination", fmt.Sprintf("requires margin_top of at least %.2fin", minHeaderFooterMargin))
+	}
+	if (opts.FooterTemplate != "" || opts.PageNumbers) && opts.MarginBottom < minHeaderFooterMargin {
+		errs.add("margin_bottom", "invalid_combination", fmt.Sprintf("must be at least %.2fin to show a footer or page numbers", minHeaderFooterMargin))
+	}
+	if size, ok := paperSizes[opts.PaperSize]; ok {
+		width, height := opts.pageSize(size)
+		if width-opts.MarginLeft-opts.MarginRight < minContentSize {
+			errs.add("margin_left", "invalid_combination", "left and right margins leave no room for content")
+		}
+		if height-opts.MarginTop-opts.MarginBottom < minContentSize {
+			errs.add("margin_top", "invalid_combination", "top and bottom margins leave no room for content")
+		}
+	}
+
+	if len(errs.Errors) > 0 {
+		return opts, errs
+	}
+	return opts, nil
+}
+
+// parseMargin converts a margin such as "10mm", "1cm" or "0.5" (inches) to inches
+func parseMargin(value string) (float64, error) {
+	match := marginPattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(value)))
+	if match == nil {
+		return 0, fmt.Errorf("must be a non-negative length such as 0.5in, 10mm or 1cm")
+	}
+	margin, _ := strconv.ParseFloat(match[1], 64)
+	switch match[2] {
+	case "mm":
+		margin /= 25.4
+	case "cm":
+		margin /= 2.54
+	}
+	return margin, nil
+}
+
+// validatePageRanges checks a page range list such as "1-2,4"
+func validatePageRanges(ranges string) error {
+	if !pageRangesPattern.MatchString(ranges) {
+		return fmt.Errorf("must be a list of pages or ranges such as 1-2,4")
+	}
+	for _, part := range strings.Split(ranges, ",") {
+		bounds := strings.SplitN(part, "-", 2)
+		start, _ := strconv.Atoi(bounds[0])
+		end := start
+		if len(bounds) == 2 {
+			end, _ = strconv.Atoi(bounds[1])
+		}
+		if start < 1 || end < start {
+			return fmt.Errorf("range %s is invalid, pages start at 1 and ranges must be ascending", part)
+		}
+	}
+	return nil
+}
+
+// pageSize returns the page width and height in inches, taking the orientation into account
+func (o PDFOptions) pageSize(size [2]float64) (float64, float64) {
+	if o.Landscape {
+		return size[1], size[0]
+	}
+	return size[0], size[1]
+}
+
+// PrintParams builds the Chrome print parameters for the options
+func (o PDFOptions) PrintParams() *page.PrintToPDFParams {
+	size, ok := paperSizes[o.PaperSize]
+	if !ok {
+		size = paperSizes["a4"]
+	}
+
+	params := page.PrintToPDF().
+		WithPrintBackground(true).
+		WithPaperWidth(size[0]).
+		WithPaperHeight(size[1]).
+		WithLandscape(o.Landscape).
+		WithMarginTop(o.MarginTop).
+		WithMarginBottom(o.MarginBottom).
+		WithMarginLeft(o.MarginLeft).
+		WithMarginRight(o.MarginRight).
+		WithScale(o.Scale).
+		WithPageRanges(o.PageRanges)
+
+	footer := o.FooterTemplate
+	if o.PageNumbers {
+		footer = defaultPageNumberFooter
+	}
+	if o.HeaderTemplate != "" || footer != "" {
+		// Chrome prints the title and date when a template is left empty
+		header := o.HeaderTemplate
+		if header == "" {
+			header = "<span></span>"
+		}
+		if footer == "" {
+			footer = "<span></span>"
+		}
+		params = params.
+			WithDisplayHeaderFooter(true).
+			WithHeaderTemplate(header).
+			WithFooterTemplate(footer)
+	}
+
+	return params
+}
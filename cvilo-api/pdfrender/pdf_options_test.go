@@ -0,0 +1,88 @@
+package pdfrender
+
+import (
+	"math"
+	"net/url"
+	"testing"
+)
+
+func TestParsePDFOptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantFields []string
+		check      func(t *testing.T, opts PDFOptions)
+	}{
+		{
+			name:  "Defaults",
+			query: "",
+			check: func(t *testing.T, opts PDFOptions) {
+				if opts.PaperSize != "a4" || opts.MarginTop != 0.4 || opts.Scale != 1 {
+					t.Errorf("ParsePDFOptions() = %+v, want A4 defaults", opts)
+				}
+			},
+		},
+		{
+			name:  "Letter with millimetre margins",
+			query: "paper_size=Letter&margin=10mm&margin_left=1in",
+			check: func(t *testing.T, opts PDFOptions) {
+				if opts.PaperSize != "letter" {
+					t.Errorf("PaperSize = %s, want letter", opts.PaperSize)
+				}
+				if math.Abs(opts.MarginTop-10/25.4) > 1e-9 || opts.MarginLeft != 1 {
+					t.Errorf("margins = %v/%v, want %v/1", opts.MarginTop, opts.MarginLeft, 10/25.4)
+				}
+			},
+		},
+		{
+			name:  "Page limit",
+			query: "max_pages=2",
+			check: func(t *testing.T, opts PDFOptions) {
+				if opts.PageRanges != "1-2" {
+					t.Errorf("PageRanges = %s, want 1-2", opts.PageRanges)
+				}
+			},
+		},
+		{
+			name:  "Page numbers with footer margin",
+			query: "page_numbers=true&margin_bottom=15mm&landscape=true&scale=0.8",
+		},
+		{name: "Unknown paper size", query: "paper_size=A3", wantFields: []string{"paper_size"}},
+		{name: "Negative margin", query: "margin=-1in", wantFields: []string{"margin"}},
+		{name: "Scale out of range", query: "scale=3", wantFields: []string{"scale"}},
+		{name: "Descending page range", query: "page_ranges=3-1", wantFields: []string{"page_ranges"}},
+		{name: "Page range and limit", query: "page_ranges=1&max_pages=2", wantFields: []string{"max_pages"}},
+		{name: "Page numbers without footer margin", query: "page_numbers=true&margin=0", wantFields: []string{"margin_bottom"}},
+		{name: "Page numbers and footer template", query: "page_numbers=true&footer_template=x", wantFields: []string{"page_numbers"}},
+		{name: "Margins larger than the page", query: "landscape=true&margin_top=4in&margin_bottom=4in", wantFields: []string{"margin_top"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			opts, err := ParsePDFOptions(query, DefaultPDFOptions())
+
+			if len(tt.wantFields) == 0 {
+				if err != nil {
+					t.Fatalf("ParsePDFOptions(%s) error: %v", tt.query, err)
+				}
+				if tt.check != nil {
+					tt.check(t, opts)
+				}
+				return
+			}
+
+			optionsErr, ok := err.(*PDFOptionsError)
+			if !ok {
+				t.Fatalf("ParsePDFOptions(%s) error = %v, want *PDFOptionsError", tt.query, err)
+			}
+			var fields []string
+			for _, fe := range optionsErr.Errors {
+				fields = append(fields, fe.Field)
+			}
+			if len(fields) != len(tt.wantFields) || fields[0] != tt.wantFields[0] {
+				t.Errorf("ParsePDFOptions(%s) fields = %v, want %v", tt.query, fields, tt.wantFields)
+			}
+		})
+	}
+}
@@ -0,0 +1,441 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/smhnaqvi/cvilo/controllers"
+	"github.com/smhnaqvi/cvilo/middleware"
+	"github.com/smhnaqvi/cvilo/models"
+	"github.com/smhnaqvi/cvilo/utils"
+)
+
+// setupRouter registers all routes. Routes acting on a user, resume or chat history entry run
+// AuthMiddleware followed by the ownership check of authz; roles are checked against the stored role with authz.
+// Every request goes through limiter; a nil limiter does not limit.
+func setupRouter(authz *middleware.Authorizer, limiter *middleware.RateLimiter) *gin.Engine {
+	// Initialize controllers (no database parameters needed)
+	authController := controllers.NewAuthController()
+	twoFactorController := controllers.NewTwoFactorController()
+	userController := controllers.NewUserController()
+	resumeController := controllers.NewResumeController()
+	linkedInController := controllers.NewLinkedInController()
+	aiController := controllers.NewAIController()
+	chatHistoryController := controllers.NewChatHistoryController()
+	auditController := controllers.NewAuditController()
+
+	// Initialize router
+	router := gin.Default()
+
+	// Set production mode
+	if os.Getenv("GIN_MODE") == "release" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	// Only trust X-Forwarded-For from the reverse proxy, so clients cannot choose the IP they are rate limited by
+	if err := router.SetTrustedProxies(trustedProxies()); err != nil {
+		log.Printf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
+	// Tag every request with an ID, returned in X-Request-ID and recorded in the audit log
+	router.Use(utils.ResponseMiddleware())
+
+	// Add security middleware
+	router.Use(middleware.SecurityHeaders())
+
+	// Add CORS middleware
+	router.Use(func(c *gin.Context) {
+		c.Header("Access-Control-Allow-Origin", "*")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID")
+		c.Header("Access-Control-Expose-Headers", "X-Request-ID, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, Retry-After")
+
+		if c.Request.Method == "OPTIONS" {
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		c.Next()
+	})
+
+	// Add rate limiting, after CORS so preflight requests are not counted
+	router.Use(limiter.RateLimiting())
+
+	// Health check endpoint
+	router.GET("/ping", func(c *gin.Context) {
+		// Get build info from environment or build-time variables
+		buildVersion := os.Getenv("BUILD_VERSION")
+		if buildVersion == "" {
+			buildVersion = BuildVersion
+		}
+		if buildVersion == "" {
+			buildVersion = "dev"
+		}
+
+		buildDate := os.Getenv("BUILD_DATE")
+		if buildDate == "" {
+			buildDate = BuildDate
+		}
+		if buildDate == "" {
+			buildDate = "unknown"
+		}
+
+		apiBaseURL := os.Getenv("API_BASE_URL")
+		if apiBaseURL == "" {
+			apiBaseURL = APIBaseURL
+		}
+		if apiBaseURL == "" {
+			apiBaseURL = "http://localhost:8081"
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"message":      "Cvilo API is running!",
+			"version":      buildVersion,
+			"build_date":   buildDate,
+			"api_base_url": apiBaseURL,
+			"database":     "Connected",
+			"environment":  os.Getenv("GIN_MODE"),
+		})
+	})
+
+	// API version 1 routes
+	v1 := router.Group("/api/v1")
+	{
+		// Auth routes
+		auth := v1.Group("/auth")
+		{
+			auth.POST("/login", authController.Login)                    // User login
+			auth.POST("/register", authController.Register)              // User registration
+			auth.POST("/refresh", authController.RefreshToken)           // Refresh token
+			auth.GET("/verify", authController.VerifyToken)              // Verify token
+			auth.POST("/forgot-password", authController.ForgotPassword) // Mail a password reset link
+			auth.POST("/reset-password", authController.ResetPassword)   // Reset password with a mailed token
+			auth.POST("/verify-email", authController.VerifyEmail)       // Verify email with a mailed token
+			auth.POST("/2fa/verify", twoFactorController.Verify)         // Complete a login with a TOTP or recovery code
+		}
+
+		// Protected routes (require authentication)
+		protected := v1.Group("")
+		protected.Use(middleware.AuthMiddleware())
+		{
+			protected.GET("/auth/me", authController.Me)                                            // Get current user
+			protected.POST("/auth/change-password", authController.ChangePassword)                  // Change password
+			protected.POST("/auth/logout", authController.Logout)                                   // End the current session
+			protected.POST("/auth/logout-all", authController.LogoutAll)                            // End all sessions
+			protected.GET("/auth/sessions", authController.GetSessions)                             // List open sessions
+			protected.DELETE("/auth/sessions/:id", authController.RevokeSession)                    // End a session
+			protected.POST("/auth/resend-verification", authController.ResendVerification)          // Mail a new verification link
+			protected.POST("/auth/2fa/setup", twoFactorController.Setup)                            // Start a TOTP enrollment
+			protected.POST("/auth/2fa/enable", twoFactorController.Enable)                          // Confirm the enrollment, returns recovery codes
+			protected.POST("/auth/2fa/disable", twoFactorController.Disable)                        // Turn off two-factor authentication
+			protected.POST("/auth/2fa/recovery-codes", twoFactorController.RegenerateRecoveryCodes) // Replace recovery codes
+		}
+
+		// User routes
+		users := v1.Group("/users")
+		users.Use(middleware.AuthMiddleware(), authz.VerifyRole())
+		{
+			users.GET("/:id", authz.OwnsUser("id"), userController.GetUser)                    // Get user by ID
+			users.PUT("/:id", authz.OwnsUser("id"), userController.UpdateUser)                 // Update user
+			users.GET("/:id/resumes", authz.OwnsUser("id"), resumeController.GetResumesByUser) // Get all resumes for a user
+		}
+
+		// Admin routes (require the admin role)
+		admin := v1.Group("/admin")
+		admin.Use(middleware.AuthMiddleware(), authz.RequireRole(models.RoleAdmin))
+		{
+			admin.POST("/users", userController.CreateUser)                        // Create user
+			admin.GET("/users", userController.GetUsers)                           // Get all users (with pagination)
+			admin.GET("/users/search", userController.GetUserByEmail)              // Get user by email
+			admin.DELETE("/users/:id", userController.DeleteUser)                  // Delete user
+			admin.PUT("/users/:id/toggle-status", userController.ToggleUserStatus) // Toggle user status
+			admin.PUT("/users/:id/role", userController.SetUserRole)               // Change user role
+			admin.POST("/users/:id/unlock", userController.UnlockUser)             // Clear failed logins and end an account lock
+			admin.GET("/resumes", resumeController.GetAllResumes)                  // Get resumes of all users (with pagination)
+		}
+
+		// Audit log (requires the admin role)
+		audit := v1.Group("/audit")
+		audit.Use(middleware.AuthMiddleware(), authz.RequireRole(models.RoleAdmin))
+		{
+			audit.GET("", auditController.GetAuditLogs) // List or export the audit log
+		}
+
+		// Resume routes
+		resumes := v1.Group("/resumes")
+		resumes.Use(middleware.AuthMiddleware(), authz.VerifyRole())
+		{
+			resumes.POST("", resumeController.CreateResume)                                                               // Create resume
+			resumes.POST("/import", resumeController.ImportResume)                                                        // Import resume from a PDF or DOCX upload
+			resumes.POST("/import/jsonresume", resumeController.ImportJSONResume)                                         // Import resume from JSON Resume
+			resumes.GET("", resumeController.GetOwnResumes)                                                               // Get own resumes (with pagination)
+			resumes.GET("/search", resumeController.SearchResumes)                                                        // Full-text search with facets
+			resumes.GET("/:id", authz.OwnsResume("id"), resumeController.GetResume)                                       // Get resume by ID
+			resumes.PUT("/:id", authz.OwnsResume("id"), resumeController.UpdateResume)                                    // Update resume
+			resumes.DELETE("/:id", authz.OwnsResume("id"), resumeController.DeleteResume)                                 // Delete resume
+			resumes.POST("/:id/clone", authz.OwnsResume("id"), resumeController.CloneResume)                              // Clone resume
+			resumes.POST("/:id/tailor", authz.OwnsResume("id"), resumeController.TailorResume)                            // Clone resume tailored to a job description by AI
+			resumes.PUT("/:id/toggle-status", authz.OwnsResume("id"), resumeController.ToggleResumeStatus)                // Toggle active status
+			resumes.GET("/:id/download-pdf", authz.OwnsResume("id"), resumeController.DownloadResumePDF)                  // Download resume as PDF
+			resumes.GET("/:id/download-docx", authz.OwnsResume("id"), resumeController.DownloadResumeDOCX)                // Download resume as Word document
+			resumes.GET("/:id/export", authz.OwnsResume("id"), resumeController.ExportResume)                             // Export resume as plain text, Markdown or JSON Resume
+			resumes.GET("/:id/versions", authz.OwnsResume("id"), resumeController.GetResumeVersions)                      // List resume versions
+			resumes.GET("/:id/versions/diff", authz.OwnsResume("id"), resumeController.DiffResumeVersions)                // Compare two resume versions
+			resumes.GET("/:id/versions/:version", authz.OwnsResume("id"), resumeController.GetResumeVersion)              // Get a resume version with its snapshot
+			resumes.POST("/:id/versions/:version/restore", authz.OwnsResume("id"), resumeController.RestoreResumeVersion) // Restore a resume version
+		}
+
+		// Helper routes for parsing complex JSON fields
+		helpers := v1.Group("/helpers")
+		{
+			helpers.POST("/parse-experience", resumeController.ParseExperience) // Parse experience JSON
+			helpers.POST("/parse-education", resumeController.ParseEducation)   // Parse education JSON
+			helpers.POST("/parse-skills", resumeController.ParseSkills)         // Parse skills JSON
+		}
+
+		// Sample data endpoint
+		v1.GET("/sample-data", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{
+				"message": "Sample data structures for reference",
+				"data":    utils.GetSampleData(),
+			})
+		})
+
+		// LinkedIn OAuth routes
+		linkedin := v1.Group("/linkedin")
+		{
+			linkedin.GET("/auth-url", linkedInController.GetAuthURL)                                                                     // Get LinkedIn OAuth URL
+			linkedin.GET("/callback", linkedInController.HandleCallback)                                                                 // Handle OAuth callback
+			linkedin.GET("/profile/:id", middleware.AuthMiddleware(), authz.OwnsUser("id"), linkedInController.GetLinkedInProfile)       // Get LinkedIn profile data
+			linkedin.POST("/sync/:id", middleware.AuthMiddleware(), authz.OwnsUser("id"), linkedInController.SyncProfile)                // Sync LinkedIn profile data
+			linkedin.DELETE("/disconnect/:id", middleware.AuthMiddleware(), authz.OwnsUser("id"), linkedInController.DisconnectLinkedIn) // Disconnect LinkedIn
+		}
+
+		// AI Resume Builder routes
+		ai := v1.Group("/ai")
+		{
+			ai.GET("/status", aiController.GetAIServiceStatus) // Get AI service status
+		}
+		aiProtected := ai.Group("")
+		aiProtected.Use(middleware.AuthMiddleware(), authz.VerifyRole())
+		{
+			aiProtected.POST("/generate", aiController.GenerateResumeFromPrompt)                                                                                               // Generate new resume from prompt
+			aiProtected.POST("/generate/stream", aiController.GenerateResumeFromPromptStream)                                                                                  // Generate new resume from prompt, streamed as server-sent events
+			aiProtected.POST("/update", aiController.UpdateResumeFromPrompt)                                                                                                   // Update existing resume from prompt
+			aiProtected.POST("/update/stream", aiController.UpdateResumeFromPromptStream)                                                                                      // Update existing resume from prompt, streamed as server-sent events
+			aiProtected.POST("/patch", aiController.PatchResumeFromPrompt)                                                                                                     // Preview the change a prompt requests as a JSON Patch
+			aiProtected.POST("/patch/apply", aiController.ApplyResumePatch)                                                                                                    // Apply a previewed JSON Patch to a resume
+			aiProtected.POST("/users/:user_id/generate", authz.OwnsUser("user_id"), aiController.GenerateResumeFromPromptWithID)                                               // Generate resume for specific user
+			aiProtected.POST("/users/:user_id/resumes/:resume_id/update", authz.OwnsUser("user_id"), authz.OwnsResume("resume_id"), aiController.UpdateResumeFromPromptWithID) // Update specific resume
+		}
+
+		// Chat History routes
+		chatHistory := v1.Group("/chat-history")
+		chatHistory.Use(middleware.AuthMiddleware())
+		{
+			chatHistory.GET("/resumes/:resume_id", authz.OwnsResume("resume_id"), chatHistoryController.GetChatHistoryByResume)       // Get chat history for a resume
+			chatHistory.GET("/resumes/:resume_id/recent", authz.OwnsResume("resume_id"), chatHistoryController.GetRecentChatHistory)  // Get recent chat history for a resume
+			chatHistory.GET("/users/:user_id", authz.OwnsUser("user_id"), chatHistoryController.GetChatHistoryByUser)                 // Get all chat history for a user
+			chatHistory.GET("/users/:user_id/stats", authz.OwnsUser("user_id"), chatHistoryController.GetChatHistoryStats)            // Get chat history statistics
+			chatHistory.DELETE("/:id", authz.OwnsChatHistory("id"), chatHistoryController.DeleteChatHistory)                          // Delete specific chat history entry
+			chatHistory.DELETE("/resumes/:resume_id", authz.OwnsResume("resume_id"), chatHistoryController.DeleteChatHistoryByResume) // Delete all chat history for a resume
+		}
+	}
+
+	// API documentation endpoint
+	router.GET("/api/docs", func(c *gin.Context) {
+		docs := gin.H{
+			"title":       "Cvilo REST API Documentation",
+			"version":     "2.0.0",
+			"description": "A REST API for managing users and their resumes/CVs with global database connection",
+			"base_url":    "http://localhost:8081/api/v1",
+			"features": gin.H{
+				"global_db_access": "Uses global database.GetSqliteDB() for clean, centralized database access",
+				"controllers":      "Organized with controller-based architecture",
+				"validation":       "Enhanced input validation and error handling",
+				"relationships":    "Proper user-resume relationships with cascading operations",
+				"linkedin_oauth":   "LinkedIn OAuth integration for profile data import",
+				"authorization":    "Bearer token required on user, resume, AI and chat history routes; other users' resources return 404",
+				"roles":            "user, recruiter (searches all resumes) and admin (/admin routes, access to all resources)",
+				"rate_limiting":    "Token buckets per user or IP: 5/min on login, 10/min on /ai/*, 120/min elsewhere; 429 with Retry-After",
+				"request_ids":      "Every response carries an X-Request-ID header, taken from the request when sent, recorded in the audit log",
+				"audit_log":        "Append-only log of auth, user, resume, LinkedIn and AI changes with actor, IP, request ID and before/after summaries",
+				"ai_providers":     "OpenAI, GitHub Models, OpenAI compatible servers (Ollama, llama.cpp) and Anthropic in a fallback chain set by LLM_PROVIDERS, with per-provider timeouts",
+			},
+			"endpoints": gin.H{
+				"auth": gin.H{
+					"POST /auth/login":               "Log in; returns an access token (15 minutes) and a single-use refresh token (7 days), or an mfa_token with two-factor authentication",
+					"POST /auth/register":            "Register and log in",
+					"POST /auth/refresh":             "Exchange a refresh token for a new pair; reusing a refresh token revokes its session",
+					"GET /auth/verify":               "Verify an access token",
+					"GET /auth/me":                   "Get the authenticated user",
+					"POST /auth/change-password":     "Change password",
+					"POST /auth/logout":              "End the current session",
+					"POST /auth/logout-all":          "End all sessions of the user",
+					"GET /auth/sessions":             "List open sessions with device, IP address and last use",
+					"DELETE /auth/sessions/:id":      "End one session",
+					"POST /auth/forgot-password":     "Mail a password reset link valid for 1 hour; the response does not reveal whether the email exists",
+					"POST /auth/reset-password":      "Set a new password with a reset token; ends all sessions",
+					"POST /auth/verify-email":        "Verify the email address with the token mailed on registration",
+					"POST /auth/resend-verification": "Mail a new email verification link",
+					"POST /auth/2fa/setup":           "Start a TOTP enrollment; returns the secret and an otpauth:// URI for a QR code",
+					"POST /auth/2fa/enable":          "Confirm the enrollment with a code; returns 10 one-time recovery codes",
+					"POST /auth/2fa/disable":         "Turn off two-factor authentication with the password and a code",
+					"POST /auth/2fa/recovery-codes":  "Replace the recovery codes with the password and a code",
+					"POST /auth/2fa/verify":          "Exchange the mfa_token returned by login and a TOTP or recovery code for the tokens",
+				},
+				"users": gin.H{
+					"GET /users/:id":         "Get user by ID (includes resumes)",
+					"PUT /users/:id":         "Update user (partial updates supported, is_active for admins only)",
+					"GET /users/:id/resumes": "Get all resumes for a user",
+				},
+				"admin": gin.H{
+					"POST /admin/users":                  "Create a new user (optional role: user, recruiter or admin)",
+					"GET /admin/users":                   "Get all users (with pagination)",
+					"GET /admin/users/search?email=":     "Get user by email",
+					"DELETE /admin/users/:id":            "Delete user (cascades to resumes)",
+					"PUT /admin/users/:id/toggle-status": "Toggle user active status",
+					"PUT /admin/users/:id/role":          "Change the role of a user ({\"role\": \"admin\"})",
+					"POST /admin/users/:id/unlock":       "Clear the failed logins of a user, ending an account lock",
+					"GET /admin/resumes":                 "Get resumes of all users (with pagination, ?skill= to filter by skill)",
+				},
+				"audit": gin.H{
+					"GET /audit": "List the audit log, newest first (admin only; ?actor_id=&action=auth.*&target_type=&target_id=&ip=&request_id=&from=&to=&page=&limit=, format=csv exports all matches)",
+				},
+				"resumes": gin.H{
+					"POST /resumes":                               "Create a new resume",
+					"GET /resumes":                                "Get own resumes (with pagination, ?skill= to filter by skill)",
+					"GET /resumes/search":                         "Full-text search over accessible resumes (requires auth; ?q=&skill=&company=&location=&min_years=&max_years=&page=&limit=)",
+					"GET /resumes/:id":                            "Get resume by ID",
+					"PUT /resumes/:id":                            "Update resume",
+					"DELETE /resumes/:id":                         "Delete resume",
+					"POST /resumes/:id/clone":                     "Clone resume",
+					"POST /resumes/:id/tailor":                    "Clone resume with experience bullets and skills tailored to a job description by AI (job_description text or url; explains each change)",
+					"PUT /resumes/:id/toggle-status":              "Toggle resume active status",
+					"GET /resumes/:id/download-pdf":               "Download resume as PDF",
+					"GET /resumes/:id/download-docx":              "Download resume as Word document",
+					"GET /resumes/:id/export":                     "Export resume as plain text, Markdown or JSON Resume (?format=txt|md|jsonresume&width=80)",
+					"POST /resumes/import/jsonresume":             "Import a JSON Resume document for the authenticated user (?title=, ?user_id= for admins), reports unmapped fields",
+					"POST /resumes/import":                        "Import a PDF or DOCX resume for the authenticated user (multipart: file, title, use_ai, dry_run; user_id for admins)",
+					"GET /resumes/:id/versions":                   "List the versions recorded on every save (author, source, linked chat prompt)",
+					"GET /resumes/:id/versions/diff":              "Section-level diff between two versions (?from=&to=, defaults to the latest change)",
+					"GET /resumes/:id/versions/:version":          "Get a resume version with its snapshot",
+					"POST /resumes/:id/versions/:version/restore": "Restore a resume version, recorded as a new version",
+				},
+				"linkedin": gin.H{
+					"GET /linkedin/auth-url":          "Get LinkedIn OAuth authorization URL",
+					"POST /linkedin/callback":         "Handle LinkedIn OAuth callback and create resume",
+					"GET /linkedin/profile/:id":       "Get latest resume created from LinkedIn for user",
+					"POST /linkedin/sync/:id":         "Sync LinkedIn profile and create new resume",
+					"DELETE /linkedin/disconnect/:id": "Disconnect LinkedIn for user",
+				},
+				"helpers": gin.H{
+					"POST /helpers/parse-experience": "Parse experience data to JSON",
+					"POST /helpers/parse-education":  "Parse education data to JSON",
+					"POST /helpers/parse-skills":     "Parse skills data to JSON",
+					"GET /sample-data":               "Get sample data structures",
+				},
+				"ai": gin.H{
+					"GET /ai/status":                                    "Get AI service status and the provider chain",
+					"POST /ai/generate":                                 "Generate new resume from prompt",
+					"POST /ai/generate/stream":                          "Generate new resume from prompt, streamed as server-sent events (progress, section, resume, error)",
+					"POST /ai/update":                                   "Update existing resume from prompt",
+					"POST /ai/update/stream":                            "Update existing resume from prompt, streamed as server-sent events",
+					"POST /ai/patch":                                    "Preview the change a prompt requests as a JSON Patch (RFC 6902) with the changed sections, saving only the prompt",
+					"POST /ai/patch/apply":                              "Apply a JSON Patch to a resume, writing only the changed sections (base_version required, 409 when the resume changed since)",
+					"POST /ai/users/:user_id/generate":                  "Generate resume for specific user",
+					"POST /ai/users/:user_id/resumes/:resume_id/update": "Update specific resume",
+				},
+				"chat_history": gin.H{
+					"GET /chat-history/resumes/:resume_id":        "Get chat history for a resume",
+					"GET /chat-history/resumes/:resume_id/recent": "Get recent chat history for a resume",
+					"GET /chat-history/users/:user_id":            "Get all chat history for a user",
+					"GET /chat-history/users/:user_id/stats":      "Get chat history statistics",
+					"DELETE /chat-history/:id":                    "Delete specific chat history entry",
+					"DELETE /chat-history/resumes/:resume_id":     "Delete all chat history for a resume",
+				},
+			},
+			"sample_requests": gin.H{
+				"create_user": gin.H{
+					"url": "POST /api/v1/admin/users",
+					"body": gin.H{
+						"name":     "John Doe",
+						"email":    "john@example.com",
+						"phone":    "+1234567890",
+						"summary":  "Software Developer with 5 years of experience",
+						"location": "San Francisco, CA",
+						"website":  "https://johndoe.dev",
+						"linkedin": "https://linkedin.com/in/johndoe",
+						"github":   "https://github.com/johndoe",
+					},
+				},
+				"update_user": gin.H{
+					"url": "PUT /api/v1/users/1",
+					"body": gin.H{
+						"summary":   "Updated summary",
+						"skills":    "Go, JavaScript, React, Docker",
+						"location":  "New York, NY",
+						"is_active": true,
+					},
+				},
+				"create_resume": gin.H{
+					"url": "POST /api/v1/resumes",
+					"body": gin.H{
+						"user_id":   1,
+						"title":     "Software Developer Resume",
+						"full_name": "John Doe",
+						"email":     "john@example.com",
+						"phone":     "+1234567890",
+						"summary":   "Experienced software developer...",
+						"skills":    `[{"name": "Go", "category": "Programming", "level": 4}]`,
+					},
+				},
+				"linkedin_oauth": gin.H{
+					"get_auth_url": "GET /api/v1/linkedin/auth-url?user_id=1",
+					"callback": gin.H{
+						"url": "POST /api/v1/linkedin/callback",
+						"body": gin.H{
+							"code":  "authorization_code_from_linkedin",
+							"state": "optional_state_parameter",
+						},
+						"response": gin.H{
+							"user": gin.H{
+								"id":    1,
+								"name":  "John Doe",
+								"email": "john@example.com",
+							},
+							"resume": gin.H{
+								"id":        1,
+								"title":     "LinkedIn Profile Resume",
+								"full_name": "John Doe",
+								"summary":   "Experienced software developer...",
+								"linkedin":  "https://linkedin.com/in/johndoe",
+							},
+						},
+					},
+				},
+			},
+			"setup": gin.H{
+				"seed_database": "Run `go run main.go --seed` to populate database with sample data",
+				"start_server":  "Run `go run main.go` to start the API server",
+				"promote_admin": "Run `go run main.go --promote <email> [role]` to give a user the admin (or another) role",
+				"architecture":  "Uses global database connection for clean, centralized access",
+			},
+		}
+		c.JSON(http.StatusOK, docs)
+	})
+
+	return router
+}
+
+// trustedProxies returns the proxies allowed to set X-Forwarded-For: TRUSTED_PROXIES as a comma-separated list of
+// IPs and CIDRs, by default the loopback and private networks a reverse proxy like the nginx container runs in
+func trustedProxies() []string {
+	if proxies := os.Getenv("TRUSTED_PROXIES"); proxies != "" {
+		return strings.Split(proxies, ",")
+	}
+	return []string{"127.0.0.1/8", "::1/128", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7"}
+}
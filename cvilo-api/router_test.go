@@ -0,0 +1,301 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/smhnaqvi/cvilo/middleware"
+	"github.com/smhnaqvi/cvilo/models"
+	"github.com/smhnaqvi/cvilo/services"
+)
+
+// Access policies of the routes
+const (
+	public        = "public"          // no token
+	authenticated = "authenticated"   // any valid token
+	adminOnly     = "admin"           // admin token
+	ownsUser      = "owns user"       // the user in the path
+	ownsResume    = "owns resume"     // a resume of the user
+	ownsHistory   = "owns chat entry" // a chat history entry of the user
+)
+
+// routePolicies lists every registered route with its access policy. A route missing here fails the test,
+// so new routes must be classified.
+var routePolicies = map[string]string{
+	"GET /ping":     public,
+	"GET /api/docs": public,
+
+	"POST /api/v1/auth/login":               public,
+	"POST /api/v1/auth/register":            public,
+	"POST /api/v1/auth/refresh":             public,
+	"GET /api/v1/auth/verify":               public,
+	"POST /api/v1/auth/forgot-password":     public,
+	"POST /api/v1/auth/reset-password":      public,
+	"POST /api/v1/auth/verify-email":        public,
+	"POST /api/v1/auth/2fa/verify":          public,
+	"GET /api/v1/auth/me":                   authenticated,
+	"POST /api/v1/auth/change-password":     authenticated,
+	"POST /api/v1/auth/logout":              authenticated,
+	"POST /api/v1/auth/logout-all":          authenticated,
+	"GET /api/v1/auth/sessions":             authenticated,
+	"DELETE /api/v1/auth/sessions/:id":      authenticated,
+	"POST /api/v1/auth/resend-verification": authenticated,
+	"POST /api/v1/auth/2fa/setup":           authenticated,
+	"POST /api/v1/auth/2fa/enable":          authenticated,
+	"POST /api/v1/auth/2fa/disable":         authenticated,
+	"POST /api/v1/auth/2fa/recovery-codes":  authenticated,
+
+	"GET /api/v1/users/:id":         ownsUser,
+	"PUT /api/v1/users/:id":         ownsUser,
+	"GET /api/v1/users/:id/resumes": ownsUser,
+
+	"POST /api/v1/admin/users":                  adminOnly,
+	"GET /api/v1/admin/users":                   adminOnly,
+	"GET /api/v1/admin/users/search":            adminOnly,
+	"DELETE /api/v1/admin/users/:id":            adminOnly,
+	"PUT /api/v1/admin/users/:id/toggle-status": adminOnly,
+	"PUT /api/v1/admin/users/:id/role":          adminOnly,
+	"POST /api/v1/admin/users/:id/unlock":       adminOnly,
+	"GET /api/v1/admin/resumes":                 adminOnly,
+	"GET /api/v1/audit":                         adminOnly,
+
+	"POST /api/v1/resumes":                               authenticated,
+	"POST /api/v1/resumes/import":                        authenticated,
+	"POST /api/v1/resumes/import/jsonresume":             authenticated,
+	"GET /api/v1/resumes":                                authenticated,
+	"GET /api/v1/resumes/search":                         authenticated,
+	"GET /api/v1/resumes/:id":                            ownsResume,
+	"PUT /api/v1/resumes/:id":                            ownsResume,
+	"DELETE /api/v1/resumes/:id":                         ownsResume,
+	"POST /api/v1/resumes/:id/clone":                     ownsResume,
+	"POST /api/v1/resumes/:id/tailor":                    ownsResume,
+	"PUT /api/v1/resumes/:id/toggle-status":              ownsResume,
+	"GET /api/v1/resumes/:id/download-pdf":               ownsResume,
+	"GET /api/v1/resumes/:id/download-docx":              ownsResume,
+	"GET /api/v1/resumes/:id/export":                     ownsResume,
+	"GET /api/v1/resumes/:id/versions":                   ownsResume,
+	"GET /api/v1/resumes/:id/versions/diff":              ownsResume,
+	"GET /api/v1/resumes/:id/versions/:version":          ownsResume,
+	"POST /api/v1/resumes/:id/versions/:version/restore": ownsResume,
+
+	"POST /api/v1/helpers/parse-experience": public,
+	"POST /api/v1/helpers/parse-education":  public,
+	"POST /api/v1/helpers/parse-skills":     public,
+	"GET /api/v1/sample-data":               public,
+
+	"GET /api/v1/linkedin/auth-url":          public,
+	"GET /api/v1/linkedin/callback":          public,
+	"GET /api/v1/linkedin/profile/:id":       ownsUser,
+	"POST /api/v1/linkedin/sync/:id":         ownsUser,
+	"DELETE /api/v1/linkedin/disconnect/:id": ownsUser,
+
+	"GET /api/v1/ai/status":                                    public,
+	"POST /api/v1/ai/generate":                                 authenticated,
+	"POST /api/v1/ai/generate/stream":                          authenticated,
+	"POST /api/v1/ai/update":                                   authenticated,
+	"POST /api/v1/ai/update/stream":                            authenticated,
+	"POST /api/v1/ai/patch":                                    authenticated,
+	"POST /api/v1/ai/patch/apply":                              authenticated,
+	"POST /api/v1/ai/users/:user_id/generate":                  ownsUser,
+	"POST /api/v1/ai/users/:user_id/resumes/:resume_id/update": ownsUser,
+
+	"GET /api/v1/chat-history/resumes/:resume_id":        ownsResume,
+	"GET /api/v1/chat-history/resumes/:resume_id/recent": ownsResume,
+	"GET /api/v1/chat-history/users/:user_id":            ownsUser,
+	"GET /api/v1/chat-history/users/:user_id/stats":      ownsUser,
+	"DELETE /api/v1/chat-history/:id":                    ownsHistory,
+	"DELETE /api/v1/chat-history/resumes/:resume_id":     ownsResume,
+}
+
+// Resources of the fake store: everything belongs to owner, missingID does not exist
+const (
+	owner     uint = 1
+	otherUser uint = 2
+	missingID      = "999"
+)
+
+type fakeOwnershipStore struct{}
+
+func (fakeOwnershipStore) ResumeOwner(resumeID uint) (uint, error) {
+	if resumeID == 999 {
+		return 0, errors.New("resume not found")
+	}
+	return owner, nil
+}
+
+func (fakeOwnershipStore) ChatHistoryOwner(historyID uint) (uint, error) {
+	if historyID == 999 {
+		return 0, errors.New("chat prompt history not found")
+	}
+	return owner, nil
+}
+
+func (fakeOwnershipStore) UserRole(userID uint) (string, error) {
+	return models.RoleUser, nil
+}
+
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	t.Setenv("LINKEDIN_CLIENT_ID", "test-client")
+	t.Setenv("LINKEDIN_CLIENT_SECRET", "test-secret")
+	t.Setenv("LINKEDIN_REDIRECT_URL", "http://localhost/callback")
+	return setupRouter(middleware.NewAuthorizer(fakeOwnershipStore{}), middleware.NewRateLimiter(middleware.NewMemoryRateLimitStore()))
+}
+
+func bearerToken(t *testing.T, userID uint) string {
+	t.Helper()
+	token, err := services.NewAuthService().GenerateJWT(models.UserModel{ID: userID, Email: "user@example.com"})
+	if err != nil {
+		t.Fatalf("GenerateJWT() error: %v", err)
+	}
+	return "Bearer " + token
+}
+
+// routeURL fills the path parameters of a route: IDs with id, other parameters with 1
+func routeURL(path string, id string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		switch {
+		case segment == ":version":
+			segments[i] = "1"
+		case strings.HasPrefix(segment, ":"):
+			segments[i] = id
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func serve(router *gin.Engine, method string, url string, authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, url, nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRoutePoliciesCoverAllRoutes(t *testing.T) {
+	router := newTestRouter(t)
+
+	registered := map[string]bool{}
+	for _, route := range router.Routes() {
+		key := route.Method + " " + route.Path
+		registered[key] = true
+		if _, ok := routePolicies[key]; !ok {
+			t.Errorf("route %s has no access policy", key)
+		}
+	}
+
+	var stale []string
+	for key := range routePolicies {
+		if !registered[key] {
+			stale = append(stale, key)
+		}
+	}
+	sort.Strings(stale)
+	for _, key := range stale {
+		t.Errorf("policy for %s, which is not registered", key)
+	}
+}
+
+func TestProtectedRoutesRequireToken(t *testing.T) {
+	for key, policy := range routePolicies {
+		if policy == public {
+			continue
+		}
+		method, path, _ := strings.Cut(key, " ")
+		t.Run(key, func(t *testing.T) {
+			// A router per route, so the requests stay within the rate limit of their route group
+			router := newTestRouter(t)
+			for _, authorization := range []string{"", "Bearer not-a-token"} {
+				w := serve(router, method, routeURL(path, "1"), authorization)
+				if w.Code != http.StatusUnauthorized {
+					t.Errorf("%s with authorization %q = %d, want %d", key, authorization, w.Code, http.StatusUnauthorized)
+				}
+			}
+		})
+	}
+}
+
+func TestAdminRoutesForbidUsers(t *testing.T) {
+	router := newTestRouter(t)
+	token := bearerToken(t, otherUser)
+
+	for key, policy := range routePolicies {
+		if policy != adminOnly {
+			continue
+		}
+		method, path, _ := strings.Cut(key, " ")
+		t.Run(key, func(t *testing.T) {
+			w := serve(router, method, routeURL(path, "1"), token)
+			if w.Code != http.StatusForbidden {
+				t.Errorf("%s = %d, want %d", key, w.Code, http.StatusForbidden)
+			}
+		})
+	}
+}
+
+func TestOwnedRoutesHideOtherUsersResources(t *testing.T) {
+	router := newTestRouter(t)
+	token := bearerToken(t, otherUser)
+
+	for key, policy := range routePolicies {
+		if policy != ownsUser && policy != ownsResume && policy != ownsHistory {
+			continue
+		}
+		method, path, _ := strings.Cut(key, " ")
+		t.Run(key, func(t *testing.T) {
+			// A resource of another user and a missing one must be indistinguishable
+			for _, id := range []string{"1", missingID} {
+				w := serve(router, method, routeURL(path, id), token)
+				if w.Code != http.StatusNotFound {
+					t.Errorf("%s with ID %s = %d, want %d", key, id, w.Code, http.StatusNotFound)
+				}
+			}
+		})
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	router := newTestRouter(t)
+
+	tests := []struct {
+		name     string
+		sent     string
+		expected string // empty when a new ID must be generated
+	}{
+		{"Generated", "", ""},
+		{"Kept from the client", "req-42.abc_DEF", "req-42.abc_DEF"},
+		{"Unsafe characters replaced", "abc\"; drop", ""},
+		{"Too long replaced", strings.Repeat("a", 65), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			if tt.sent != "" {
+				req.Header.Set("X-Request-ID", tt.sent)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			id := w.Header().Get("X-Request-ID")
+			if tt.expected != "" && id != tt.expected {
+				t.Errorf("X-Request-ID = %q, want %q", id, tt.expected)
+			}
+			if tt.expected == "" && (len(id) != 32 || id == tt.sent) {
+				t.Errorf("X-Request-ID = %q, want a new 32 character ID", id)
+			}
+			if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "application/json") {
+				t.Errorf("Content-Type = %q, want JSON", contentType)
+			}
+		})
+	}
+}
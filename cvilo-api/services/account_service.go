@@ -0,0 +1,148 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/smhnaqvi/cvilo/models"
+)
+
+const (
+	passwordResetTTL     = time.Hour
+	emailVerificationTTL = 48 * time.Hour
+)
+
+// ErrEmailAlreadyVerified is returned when a verification email is requested for a verified address
+var ErrEmailAlreadyVerified = errors.New("email is already verified")
+
+// issueUserToken stores a new single-use token of the purpose for the user and returns it
+func (s *AuthService) issueUserToken(userID uint, purpose string, ttl time.Duration) (string, error) {
+	token, err := newOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	record := &models.UserTokenModel{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := record.Create(); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// clientAreaLink returns the URL of a client area page carrying token, based on CLIENTAREA_URL
+func clientAreaLink(path, token string) string {
+	base := os.Getenv("CLIENTAREA_URL")
+	if base == "" {
+		base = "http://localhost:3009"
+	}
+	return strings.TrimRight(base, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+// RequestPasswordReset mails a password reset link valid for one hour. Unknown and deactivated accounts are ignored
+// without an error, so callers cannot tell whether an account exists.
+func (s *AuthService) RequestPasswordReset(email string) error {
+	var user models.UserModel
+	if err := user.GetUserByEmail(email); err != nil || !user.IsActive {
+		return nil
+	}
+
+	token, err := s.issueUserToken(user.ID, models.TokenPurposePasswordReset, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(Mail{
+		To:      user.Email,
+		Subject: "Reset your Cvilo password",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"We received a request to reset the password of your Cvilo account. Open this link to choose a new one:\n\n"+
+			"%s\n\n"+
+			"The link expires in 1 hour and works once. If you did not ask for a reset, ignore this email; your "+
+			"password stays unchanged.\n",
+			user.Name, clientAreaLink("/auth/reset-password", token)),
+	})
+}
+
+// ResetPassword sets a new password with a token from RequestPasswordReset and ends all sessions of the user.
+// Opening the mailed link also proves the email address, so it is marked verified.
+func (s *AuthService) ResetPassword(token, newPassword string) (*models.UserModel, error) {
+	var record models.UserTokenModel
+	if err := record.Consume(hashToken(token), models.TokenPurposePasswordReset); err != nil {
+		return nil, err
+	}
+
+	var user models.UserModel
+	if err := user.GetUserByID(record.UserID); err != nil || !user.IsActive {
+		return nil, models.ErrUserTokenInvalid
+	}
+
+	hashedPassword, err := s.HashPassword(newPassword)
+	if err != nil {
+		return nil, err
+	}
+	user.Password = hashedPassword
+	if err := user.UpdateUser(user.ID); err != nil {
+		return nil, err
+	}
+	if user.EmailVerifiedAt == nil {
+		if err := user.MarkEmailVerified(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Whoever knew the old password may still hold a session
+	var tokens models.RefreshTokenModel
+	if _, err := tokens.RevokeAllForUser(user.ID); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SendVerificationEmail mails a link confirming the email address of the user, valid for 48 hours
+func (s *AuthService) SendVerificationEmail(user models.UserModel) error {
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+
+	token, err := s.issueUserToken(user.ID, models.TokenPurposeEmailVerification, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(Mail{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Welcome to Cvilo! Please confirm your email address by opening this link:\n\n"+
+			"%s\n\n"+
+			"The link expires in 48 hours. If you did not create a Cvilo account, ignore this email.\n",
+			user.Name, clientAreaLink("/auth/verify-email", token)),
+	})
+}
+
+// VerifyEmail marks the email address of the user of a token from SendVerificationEmail as verified
+func (s *AuthService) VerifyEmail(token string) (*models.UserModel, error) {
+	var record models.UserTokenModel
+	if err := record.Consume(hashToken(token), models.TokenPurposeEmailVerification); err != nil {
+		return nil, err
+	}
+
+	var user models.UserModel
+	if err := user.GetUserByID(record.UserID); err != nil {
+		return nil, models.ErrUserTokenInvalid
+	}
+	if user.EmailVerifiedAt == nil {
+		if err := user.MarkEmailVerified(); err != nil {
+			return nil, err
+		}
+	}
+	return &user, nil
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/smhnaqvi/cvilo/models"
+)
+
+// AIResumePatch is the answer of the model to a section-scoped edit: a JSON Patch against the resume document
+type AIResumePatch struct {
+	Operations  []JSONPatchOperation `json:"operations"`
+	Explanation string               `json:"explanation" jsonschema_description:"What was changed and why, in one or two sentences"`
+}
+
+// ResumePatchPreview is a validated patch with the resume it results in and the changed sections, in the format
+// of the version diff
+type ResumePatchPreview struct {
+	Operations  []JSONPatchOperation   `json:"operations"`
+	Explanation string                 `json:"explanation,omitempty"`
+	Resume      *models.ResumeSnapshot `json:"resume"`
+	Changes     []models.SectionDiff   `json:"changes"`
+}
+
+// ResumePatchError lists why a patch cannot be applied to a resume
+type ResumePatchError struct {
+	Problems []string
+}
+
+func (e *ResumePatchError) Error() string {
+	return "invalid patch: " + strings.Join(e.Problems, "; ")
+}
+
+var (
+	// resumePatchSchema is the JSON schema of AIResumePatch
+	resumePatchSchema = newResumePatchSchema()
+	// resumeDocumentSchema is the JSON schema of the document patches apply to
+	resumeDocumentSchema = NewJSONSchema(models.ResumeSnapshot{})
+)
+
+func newResumePatchSchema() *JSONSchema {
+	schema := NewJSONSchema(AIResumePatch{})
+	schema.Title = "resume_patch"
+	schema.Description = "A JSON Patch (RFC 6902) against the resume document"
+	return schema
+}
+
+// PreviewResumePatch applies a JSON Patch to the content of a resume without saving it. The patched resume must
+// still be a valid resume; the content the patch does not touch is unchanged by construction.
+func PreviewResumePatch(current *models.ResumeSnapshot, operations []JSONPatchOperation) (*ResumePatchPreview, error) {
+	document, err := json.Marshal(current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode resume: %v", err)
+	}
+	patched, err := ApplyJSONPatch(document, operations)
+	if err != nil {
+		return nil, &ResumePatchError{Problems: []string{err.Error()}}
+	}
+	if problems := patchProblems(document, patched); len(problems) > 0 {
+		return nil, &ResumePatchError{Problems: problems}
+	}
+
+	var resume models.ResumeSnapshot
+	if err := json.Unmarshal(patched, &resume); err != nil {
+		return nil, &ResumePatchError{Problems: []string{err.Error()}}
+	}
+	changes, err := models.DiffSnapshots(current, &resume)
+	if err != nil {
+		return nil, err
+	}
+	if operations == nil {
+		operations = []JSONPatchOperation{}
+	}
+	return &ResumePatchPreview{Operations: operations, Resume: &resume, Changes: changes}, nil
+}
+
+// patchProblems validates a patched resume document. Problems the resume already had, like a skill level of 0
+// from an import, are not caused by the patch and do not reject it.
+func patchProblems(document, patched []byte) []string {
+	var violation *SchemaViolationError
+	if !errors.As(resumeDocumentSchema.Validate(patched), &violation) {
+		return nil
+	}
+	known := map[string]bool{}
+	var existing *SchemaViolationError
+	if errors.As(resumeDocumentSchema.Validate(document), &existing) {
+		for _, problem := range existing.Problems {
+			known[problem] = true
+		}
+	}
+
+	var problems []string
+	for _, problem := range violation.Problems {
+		if !known[problem] {
+			problems = append(problems, problem)
+		}
+	}
+	return problems
+}
+
+// PatchResumeFromPrompt asks for the change the prompt requests as a JSON Patch against the existing resume and
+// returns the validated preview with the name of the provider that wrote it. Nothing is saved. A patch that does
+// not apply is sent back to the model for correction.
+func (ai *AIService) PatchResumeFromPrompt(ctx context.Context, request AIResumeRequest, existingResume models.ResumeModel) (*ResumePatchPreview, string, error) {
+	current, err := models.NewResumeSnapshot(&existingResume)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read resume: %v", err)
+	}
+	document, err := json.Marshal(current)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode resume: %v", err)
+	}
+
+	// Get chat prompt history for this resume
+	chatHistory, err := ai.GetChatPromptHistory(existingResume.ID, 5) // Get last 5 prompts
+	if err != nil {
+		chatHistory = "" // Continue without history if there's an error
+	}
+
+	systemPrompt := `You are an expert resume editor. Change only what the user asks for in their resume, which is given as a JSON document, and answer with a JSON Patch (RFC 6902) against that document.
+
+The response should be a valid JSON object matching this JSON schema:
+` + resumePatchSchema.String() + `
+
+Important guidelines:
+1. Only touch the fields and entries the request is about, never rewrite, reorder or drop anything else
+2. Replace the smallest value that needs to change, e.g. /summary or /experience/1/description, rather than whole sections
+3. Array indexes refer to the document as given; operations apply in order, so account for earlier additions and removals
+4. Append an entry with "add" to a path ending in /-, giving all fields of the entry
+5. Ensure all dates are in ISO 8601 format (YYYY-MM-DDTHH:MM:SSZ), "end_date" is null for current positions
+6. Answer with an empty list of operations when the request needs no change
+7. Consider previous conversation history to maintain context and consistency`
+
+	userPrompt := fmt.Sprintf(`Change this resume as requested: "%s"
+
+Resume document:
+%s
+
+%s`, request.Prompt, document, chatHistory)
+
+	return ai.patchResume(ctx, current, LLMRequest{
+		System:      systemPrompt,
+		Prompt:      userPrompt,
+		Temperature: 0.3,
+		MaxTokens:   2000,
+		Schema:      resumePatchSchema,
+	})
+}
+
+// patchResume sends a patch request through the provider chain and previews the patch of the answer against the
+// current content
+func (ai *AIService) patchResume(ctx context.Context, current *models.ResumeSnapshot, request LLMRequest) (*ResumePatchPreview, string, error) {
+	var preview *ResumePatchPreview
+	provider, err := ai.llm.Complete(ctx, request, func(content string) error {
+		var patch AIResumePatch
+		if err := json.Unmarshal([]byte(extractJSON(content)), &patch); err != nil {
+			return fmt.Errorf("failed to parse AI response: %v", err)
+		}
+		result, err := PreviewResumePatch(current, patch.Operations)
+		var patchErr *ResumePatchError
+		if errors.As(err, &patchErr) {
+			// A patch that does not apply is corrected like an answer that does not match the schema
+			return &SchemaViolationError{Problems: patchErr.Problems}
+		}
+		if err != nil {
+			return err
+		}
+		result.Explanation = patch.Explanation
+		preview = result
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return preview, provider, nil
+}
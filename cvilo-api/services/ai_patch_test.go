@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smhnaqvi/cvilo/models"
+)
+
+func testResumeSnapshot() *models.ResumeSnapshot {
+	return &models.ResumeSnapshot{
+		Title:   "Backend Engineer",
+		Summary: "Go developer who has built payment systems, search engines and a lot of internal tooling",
+		Experience: []models.WorkExperience{
+			{Company: "Acme", Position: "Engineer", StartDate: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), IsCurrent: true},
+			{Company: "Initech", Position: "Intern", StartDate: time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		Education: []models.Education{},
+		// A level of 0 violates the schema but was already there
+		Skills:         []models.Skill{{Name: "Go", Level: 0}},
+		Languages:      []models.Language{},
+		Certifications: []models.Certification{},
+		Projects:       []models.Project{},
+	}
+}
+
+func TestPreviewResumePatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		patch    string
+		sections []string
+		problem  string
+	}{
+		{"shorter summary", `[{"op": "replace", "path": "/summary", "value": "Go developer"}]`, []string{"summary"}, ""},
+		{"new entry", `[{"op": "add", "path": "/experience/-", "value": {"company": "Globex", "position": "Lead", "location": "", "start_date": "2024-01-01T00:00:00Z", "end_date": null, "is_current": true, "description": ""}}]`, []string{models.SectionExperience}, ""},
+		{"no change", `[]`, nil, ""},
+		{"missing entry", `[{"op": "remove", "path": "/experience/5"}]`, nil, "index 5 is out of range"},
+		{"wrong type", `[{"op": "replace", "path": "/skills", "value": "Go, SQL"}]`, nil, "skills: expected an array"},
+		{"unknown field", `[{"op": "add", "path": "/hobbies", "value": "Chess"}]`, nil, "hobbies: unknown property"},
+		{"bad date", `[{"op": "replace", "path": "/experience/0/start_date", "value": "January 2020"}]`, nil, "experience[0].start_date"},
+	}
+
+	for _, tt := range tests {
+		var operations []JSONPatchOperation
+		json.Unmarshal([]byte(tt.patch), &operations)
+		current := testResumeSnapshot()
+
+		preview, err := PreviewResumePatch(current, operations)
+		if tt.problem != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.problem) {
+				t.Errorf("%s: error = %v, want %s", tt.name, err, tt.problem)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+
+		var sections []string
+		for _, change := range preview.Changes {
+			sections = append(sections, change.Section)
+		}
+		if strings.Join(sections, ",") != strings.Join(tt.sections, ",") {
+			t.Errorf("%s: changed sections = %v, want %v", tt.name, sections, tt.sections)
+		}
+		// Untouched content is unchanged, and the resume the patch was previewed on too
+		if tt.name == "shorter summary" {
+			unchanged, _ := json.Marshal(testResumeSnapshot().Experience)
+			patched, _ := json.Marshal(preview.Resume.Experience)
+			if string(patched) != string(unchanged) || current.Summary != testResumeSnapshot().Summary {
+				t.Errorf("%s: experience = %s, summary = %q, want them unchanged", tt.name, patched, current.Summary)
+			}
+		}
+	}
+}
+
+func TestPatchResumeRepairsPatch(t *testing.T) {
+	badIndex := `{"operations": [{"op": "replace", "path": "/experience/2/position", "value": "Senior Engineer"}], "explanation": "Promoted"}`
+	fixed := `{"operations": [{"op": "replace", "path": "/experience/0/position", "value": "Senior Engineer"}], "explanation": "Promoted"}`
+	llm := &stubLLM{name: "a", configured: true, answers: []string{badIndex}, content: fixed}
+	ai := NewAIServiceWithChain(NewLLMChain(time.Second, llm))
+
+	preview, provider, err := ai.patchResume(context.Background(), testResumeSnapshot(), LLMRequest{Prompt: "I was promoted", Schema: resumePatchSchema})
+	if err != nil {
+		t.Fatalf("patchResume() error = %v", err)
+	}
+	if provider != "a" || llm.calls != 2 || !strings.Contains(llm.requests[1].Prompt, "index 2 is out of range") {
+		t.Errorf("provider = %s, calls = %d, want a corrected patch from a", provider, llm.calls)
+	}
+	if preview.Resume.Experience[0].Position != "Senior Engineer" || preview.Explanation != "Promoted" || len(preview.Changes) != 1 {
+		t.Errorf("preview = %+v, want the promotion", preview)
+	}
+}
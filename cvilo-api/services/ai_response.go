@@ -0,0 +1,26 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// resumeSchema is the JSON schema of AIResumeResponse, sent with every request for a resume
+var resumeSchema = newResumeSchema()
+
+func newResumeSchema() *JSONSchema {
+	schema := NewJSONSchema(AIResumeResponse{})
+	schema.Title = "resume"
+	schema.Description = "A complete resume"
+	return schema
+}
+
+// parseAIResumeContent parses the JSON answer of a model into a resume. Text around the JSON object, like markdown
+// code fences, is removed; the chain has validated the object against resumeSchema.
+func parseAIResumeContent(content string) (*AIResumeResponse, error) {
+	var response AIResumeResponse
+	if err := json.Unmarshal([]byte(extractJSON(content)), &response); err != nil {
+		return nil, fmt.Errorf("failed to parse AI response: %v", err)
+	}
+	return &response, nil
+}
@@ -4,16 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
-	"os"
-	"strings"
 
-	"github.com/sashabaranov/go-openai"
 	"github.com/smhnaqvi/cvilo/models"
 )
 
+// AIService generates and parses resumes with the configured LLM providers
 type AIService struct {
-	client *openai.Client
+	llm *LLMChain
 }
 
 type AIResumeRequest struct {
@@ -44,26 +41,35 @@ type AIResumeResponse struct {
 	Awards         string                  `json:"awards"`
 	Interests      string                  `json:"interests"`
 	References     string                  `json:"references"`
-	Template       string                  `json:"template"`
-	Theme          string                  `json:"theme"`
+	Template       string                  `json:"template" jsonschema_description:"Resume template, e.g. modern"`
+	Theme          string                  `json:"theme" jsonschema_description:"Color theme, e.g. blue"`
 }
 
+// NewAIService creates the AI service with the provider chain configured by the environment, see
+// NewLLMChainFromEnv
 func NewAIService() *AIService {
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	if apiKey == "" {
-		log.Println("Warning: OPENAI_API_KEY not set")
-		return &AIService{client: nil}
-	}
+	return &AIService{llm: NewLLMChainFromEnv()}
+}
 
-	client := openai.NewClient(apiKey)
-	return &AIService{client: client}
+// NewAIServiceWithChain creates the AI service with the given provider chain
+func NewAIServiceWithChain(llm *LLMChain) *AIService {
+	return &AIService{llm: llm}
 }
 
-func (ai *AIService) GenerateResumeFromPrompt(request AIResumeRequest) (*AIResumeResponse, error) {
-	if ai.client == nil {
-		return nil, fmt.Errorf("OpenAI client not initialized - check OPENAI_API_KEY")
-	}
+// GenerateResumeFromPrompt creates a resume from the prompt and returns it with the name of the provider that
+// wrote it
+func (ai *AIService) GenerateResumeFromPrompt(ctx context.Context, request AIResumeRequest) (*AIResumeResponse, string, error) {
+	return ai.complete(ctx, ai.generateRequest(request), "modern", "blue")
+}
+
+// GenerateResumeFromPromptStream works like GenerateResumeFromPrompt, reporting the sections of the resume to
+// handler as the model writes them
+func (ai *AIService) GenerateResumeFromPromptStream(ctx context.Context, request AIResumeRequest, handler AIStreamHandler) (*AIResumeResponse, string, error) {
+	return ai.stream(ctx, ai.generateRequest(request), handler, "modern", "blue")
+}
 
+// generateRequest builds the LLM request creating a resume from a prompt
+func (ai *AIService) generateRequest(request AIResumeRequest) LLMRequest {
 	// Get chat prompt history if resume ID is provided
 	var chatHistory string
 	if request.ResumeID != nil {
@@ -76,88 +82,14 @@ func (ai *AIService) GenerateResumeFromPrompt(request AIResumeRequest) (*AIResum
 	// Create the system prompt
 	systemPrompt := `You are an expert resume builder. Based on the user's prompt, create a comprehensive resume in JSON format. 
 
-The response should be a valid JSON object with the following structure:
-{
-  "full_name": "string",
-  "email": "string", 
-  "phone": "string",
-  "address": "string",
-  "website": "string",
-  "linkedin": "string",
-  "github": "string",
-  "summary": "string",
-  "objective": "string",
-  "experience": [
-    {
-      "company": "string",
-      "position": "string", 
-      "location": "string",
-      "start_date": "2020-01-01T00:00:00Z",
-      "end_date": "2023-01-01T00:00:00Z",
-      "is_current": false,
-      "description": "string",
-      "technologies": ["string"]
-    }
-  ],
-  "education": [
-    {
-      "institution": "string",
-      "degree": "string",
-      "field_of_study": "string", 
-      "location": "string",
-      "start_date": "2020-01-01T00:00:00Z",
-      "end_date": "2023-01-01T00:00:00Z",
-      "gpa": "string",
-      "description": "string"
-    }
-  ],
-  "skills": [
-    {
-      "name": "string",
-      "category": "string",
-      "level": 5,
-      "years_experience": 3
-    }
-  ],
-  "languages": [
-    {
-      "name": "string",
-      "proficiency": "string"
-    }
-  ],
-  "certifications": [
-    {
-      "name": "string",
-      "issuer": "string",
-      "issue_date": "2020-01-01T00:00:00Z",
-      "expiry_date": "2023-01-01T00:00:00Z",
-      "credential_id": "string",
-      "url": "string"
-    }
-  ],
-  "projects": [
-    {
-      "name": "string",
-      "description": "string",
-      "technologies": ["string"],
-      "start_date": "2020-01-01T00:00:00Z",
-      "end_date": "2023-01-01T00:00:00Z",
-      "url": "string",
-      "github": "string"
-    }
-  ],
-  "awards": "string",
-  "interests": "string",
-  "references": "string",
-  "template": "modern",
-  "theme": "blue"
-}
+The response should be a valid JSON object matching this JSON schema:
+` + resumeSchema.String() + `
 
 Important guidelines:
 1. Use realistic but professional information
 2. Ensure all dates are in ISO 8601 format (YYYY-MM-DDTHH:MM:SSZ)
-3. For current positions, set "is_current": true and omit "end_date"
-4. For ongoing education, omit "end_date"
+3. For current positions, set "is_current": true and "end_date": null
+4. For ongoing education, set "end_date": null
 5. Skills should have levels 1-5 (1=beginner, 5=expert)
 6. Use appropriate categories for skills (Technical, Languages, Soft Skills, etc.)
 7. Make the resume comprehensive and professional
@@ -181,69 +113,29 @@ Please generate a complete, professional resume in the exact JSON format specifi
 		request.UserID,
 		chatHistory)
 
-	// Make the API call
-	resp, err := ai.client.CreateChatCompletion(
-		context.Background(),
-		openai.ChatCompletionRequest{
-			Model: openai.GPT4,
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleSystem,
-					Content: systemPrompt,
-				},
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: userPrompt,
-				},
-			},
-			Temperature: 0.7,
-			MaxTokens:   4000,
-		},
-	)
-
-	if err != nil {
-		return nil, fmt.Errorf("OpenAI API error: %v", err)
-	}
-
-	if len(resp.Choices) == 0 {
-		return nil, fmt.Errorf("no response from OpenAI")
-	}
-
-	// Extract the JSON response
-	content := resp.Choices[0].Message.Content
-
-	// Clean up the response - remove markdown formatting if present
-	content = strings.TrimSpace(content)
-	if strings.HasPrefix(content, "```json") {
-		content = strings.TrimPrefix(content, "```json")
-	}
-	if strings.HasSuffix(content, "```") {
-		content = strings.TrimSuffix(content, "```")
-	}
-	content = strings.TrimSpace(content)
-
-	// Parse the JSON response
-	var aiResponse AIResumeResponse
-	if err := json.Unmarshal([]byte(content), &aiResponse); err != nil {
-		return nil, fmt.Errorf("failed to parse AI response: %v\nResponse: %s", err, content)
-	}
-
-	// Set default template and theme if not provided
-	if aiResponse.Template == "" {
-		aiResponse.Template = "modern"
-	}
-	if aiResponse.Theme == "" {
-		aiResponse.Theme = "blue"
+	return LLMRequest{
+		System:      systemPrompt,
+		Prompt:      userPrompt,
+		Temperature: 0.7,
+		MaxTokens:   4000,
+		Schema:      resumeSchema,
 	}
+}
 
-	return &aiResponse, nil
+// UpdateResumeFromPrompt rewrites an existing resume according to the prompt and returns it with the name of the
+// provider that wrote it
+func (ai *AIService) UpdateResumeFromPrompt(ctx context.Context, request AIResumeRequest, existingResume models.ResumeModel) (*AIResumeResponse, string, error) {
+	return ai.complete(ctx, ai.updateRequest(request, existingResume), request.Template, request.Theme)
 }
 
-func (ai *AIService) UpdateResumeFromPrompt(request AIResumeRequest, existingResume models.ResumeModel) (*AIResumeResponse, error) {
-	if ai.client == nil {
-		return nil, fmt.Errorf("OpenAI client not initialized - check OPENAI_API_KEY")
-	}
+// UpdateResumeFromPromptStream works like UpdateResumeFromPrompt, reporting the sections of the resume to handler
+// as the model writes them
+func (ai *AIService) UpdateResumeFromPromptStream(ctx context.Context, request AIResumeRequest, existingResume models.ResumeModel, handler AIStreamHandler) (*AIResumeResponse, string, error) {
+	return ai.stream(ctx, ai.updateRequest(request, existingResume), handler, request.Template, request.Theme)
+}
 
+// updateRequest builds the LLM request rewriting an existing resume according to a prompt
+func (ai *AIService) updateRequest(request AIResumeRequest, existingResume models.ResumeModel) LLMRequest {
 	// Get chat prompt history for this resume
 	chatHistory, err := ai.GetChatPromptHistory(existingResume.ID, 5) // Get last 5 prompts
 	if err != nil {
@@ -253,7 +145,10 @@ func (ai *AIService) UpdateResumeFromPrompt(request AIResumeRequest, existingRes
 	// Create the system prompt for updating
 	systemPrompt := `You are an expert resume builder. Based on the user's prompt and the existing resume, update the resume in JSON format.
 
-The response should be a valid JSON object with the same structure as before, but you should:
+The response should be a valid JSON object matching this JSON schema:
+` + resumeSchema.String() + `
+
+You should:
 1. Keep relevant existing information that doesn't conflict with the new prompt
 2. Update or add information based on the user's prompt
 3. Maintain the professional quality and consistency
@@ -286,51 +181,95 @@ Please update the resume according to the prompt while maintaining professional
 		existingResume.Skills,
 		chatHistory)
 
-	// Make the API call
-	resp, err := ai.client.CreateChatCompletion(
-		context.Background(),
-		openai.ChatCompletionRequest{
-			Model: openai.GPT4,
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleSystem,
-					Content: systemPrompt,
-				},
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: userPrompt,
-				},
-			},
-			Temperature: 0.7,
-			MaxTokens:   4000,
-		},
-	)
+	return LLMRequest{
+		System:      systemPrompt,
+		Prompt:      userPrompt,
+		Temperature: 0.7,
+		MaxTokens:   4000,
+		Schema:      resumeSchema,
+	}
+}
+
+// ParseResumeText structures the text extracted from an uploaded resume and returns it with the name of the
+// provider that parsed it. Unlike prompt generation the model must only use information found in the text.
+func (ai *AIService) ParseResumeText(ctx context.Context, text string) (*AIResumeResponse, string, error) {
+	systemPrompt := `You are an expert resume parser. Convert the text of an existing resume into JSON.
 
+The response should be a valid JSON object matching this JSON schema:
+` + resumeSchema.String() + `
+
+Important guidelines:
+1. Only use information found in the resume text, never invent or embellish details
+2. Leave fields empty (an empty string, array or null) when the text does not contain them
+3. Ensure all dates are in ISO 8601 format (YYYY-MM-DDTHH:MM:SSZ), use the first day of the month when only a month is given
+4. For current positions, set "is_current": true and "end_date": null
+5. Keep the wording of descriptions, one achievement per line
+6. Ensure all JSON is valid and properly formatted`
+
+	return ai.complete(ctx, LLMRequest{
+		System:      systemPrompt,
+		Prompt:      "Resume text:\n\n" + text,
+		Temperature: 0,
+		MaxTokens:   4000,
+		Schema:      resumeSchema,
+	}, "modern", "blue")
+}
+
+// complete sends the request through the provider chain and parses the answer. An answer that is not a resume
+// falls back to the next provider. The template and theme default to the given ones when the model leaves them out.
+func (ai *AIService) complete(ctx context.Context, request LLMRequest, template, theme string) (*AIResumeResponse, string, error) {
+	var aiResponse *AIResumeResponse
+	provider, err := ai.llm.Complete(ctx, request, acceptResume(&aiResponse))
 	if err != nil {
-		return nil, fmt.Errorf("OpenAI API error: %v", err)
+		return nil, "", err
 	}
+	aiResponse.applyDefaults(template, theme)
+	return aiResponse, provider, nil
+}
 
-	if len(resp.Choices) == 0 {
-		return nil, fmt.Errorf("no response from OpenAI")
+// stream works like complete, streaming the answer through a section scanner to handler
+func (ai *AIService) stream(ctx context.Context, request LLMRequest, handler AIStreamHandler, template, theme string) (*AIResumeResponse, string, error) {
+	var scanner *sectionScanner
+	var aiResponse *AIResumeResponse
+	provider, err := ai.llm.Stream(ctx, request, LLMStreamHandler{
+		OnAttempt: func(provider string) {
+			scanner = newSectionScanner(handler.OnSection)
+			if handler.OnProvider != nil {
+				handler.OnProvider(provider)
+			}
+		},
+		OnDelta: func(delta string) {
+			scanner.Write(delta)
+		},
+	}, acceptResume(&aiResponse))
+	if err != nil {
+		return nil, "", err
 	}
+	aiResponse.applyDefaults(template, theme)
+	return aiResponse, provider, nil
+}
 
-	// Extract and parse the JSON response
-	content := resp.Choices[0].Message.Content
-	content = strings.TrimSpace(content)
-	if strings.HasPrefix(content, "```json") {
-		content = strings.TrimPrefix(content, "```json")
-	}
-	if strings.HasSuffix(content, "```") {
-		content = strings.TrimSuffix(content, "```")
+// acceptResume returns a chain accept function that parses the answer into target and rejects answers that are
+// not a resume
+func acceptResume(target **AIResumeResponse) func(content string) error {
+	return func(content string) error {
+		parsed, err := parseAIResumeContent(content)
+		if err != nil {
+			return err
+		}
+		*target = parsed
+		return nil
 	}
-	content = strings.TrimSpace(content)
+}
 
-	var aiResponse AIResumeResponse
-	if err := json.Unmarshal([]byte(content), &aiResponse); err != nil {
-		return nil, fmt.Errorf("failed to parse AI response: %v\nResponse: %s", err, content)
+// applyDefaults sets the template and theme the model left out
+func (r *AIResumeResponse) applyDefaults(template, theme string) {
+	if r.Template == "" {
+		r.Template = template
+	}
+	if r.Theme == "" {
+		r.Theme = theme
 	}
-
-	return &aiResponse, nil
 }
 
 // Helper function to convert AI response to ResumeModel
@@ -395,17 +334,16 @@ func (ai *AIService) ConvertAIResponseToResume(aiResponse *AIResumeResponse, use
 	return resume, nil
 }
 
-// SaveChatPromptHistory saves the chat prompt history for a resume
-func (ai *AIService) SaveChatPromptHistory(resumeID uint, userID uint, prompt string, response string, provider string, status string) error {
-	history := &models.ChatPromptHistory{
-		ResumeID: resumeID,
+// NewChatPromptHistory builds the history entry of a successful prompt.
+// It is saved with the resume version the prompt produced, see models.ResumeChange.
+func (ai *AIService) NewChatPromptHistory(userID uint, prompt string, response string, provider string) *models.ChatPromptHistory {
+	return &models.ChatPromptHistory{
 		UserID:   userID,
 		Prompt:   prompt,
 		Response: response,
 		Provider: provider,
-		Status:   status,
+		Status:   "success",
 	}
-	return history.Create()
 }
 
 // GetChatPromptHistory retrieves recent chat prompt history for a resume
@@ -414,7 +352,12 @@ func (ai *AIService) GetChatPromptHistory(resumeID uint, maxHistory int) (string
 	return history.GetPromptHistoryForAI(resumeID, maxHistory)
 }
 
-// IsConfigured returns true if the AI service is properly configured
+// IsConfigured returns true if at least one AI provider is configured
 func (ai *AIService) IsConfigured() bool {
-	return ai.client != nil
+	return ai.llm.IsConfigured()
+}
+
+// Providers returns the provider chain requests are sent through
+func (ai *AIService) Providers() *LLMChain {
+	return ai.llm
 }
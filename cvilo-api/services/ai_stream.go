@@ -0,0 +1,98 @@
+package services
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// AIStreamHandler receives the progress of a streamed resume generation
+type AIStreamHandler struct {
+	// OnProvider is called when a provider starts answering. Sections reported before came from a provider that
+	// failed and are replaced by the ones that follow.
+	OnProvider func(provider string)
+	// OnSection is called with every top-level field of the resume, like "summary" or "experience", as soon as
+	// the model has written it completely
+	OnSection func(name string, value json.RawMessage)
+}
+
+// sectionScanner follows a JSON object written piece by piece and reports each of its members once complete.
+// Text before the opening brace, like a markdown code fence, is skipped.
+type sectionScanner struct {
+	onSection func(name string, value json.RawMessage)
+	member    []byte // the current member, from its key on
+	started   bool
+	done      bool
+	depth     int
+	inString  bool
+	escaped   bool
+}
+
+func newSectionScanner(onSection func(name string, value json.RawMessage)) *sectionScanner {
+	return &sectionScanner{onSection: onSection}
+}
+
+// Write scans the next piece of the answer
+func (s *sectionScanner) Write(chunk string) {
+	for i := 0; i < len(chunk); i++ {
+		b := chunk[i]
+		if s.done {
+			return
+		}
+		if !s.started {
+			if b == '{' {
+				s.started = true
+				s.depth = 1
+			}
+			continue
+		}
+
+		if s.inString {
+			s.member = append(s.member, b)
+			switch {
+			case s.escaped:
+				s.escaped = false
+			case b == '\\':
+				s.escaped = true
+			case b == '"':
+				s.inString = false
+			}
+			continue
+		}
+
+		switch b {
+		case '"':
+			s.inString = true
+		case '{', '[':
+			s.depth++
+		case '}', ']':
+			s.depth--
+			if s.depth == 0 {
+				s.flush()
+				s.done = true
+				continue
+			}
+		case ',':
+			if s.depth == 1 {
+				s.flush()
+				continue
+			}
+		}
+		s.member = append(s.member, b)
+	}
+}
+
+// flush reports the completed member
+func (s *sectionScanner) flush() {
+	member := strings.TrimSpace(string(s.member))
+	s.member = s.member[:0]
+	if member == "" || s.onSection == nil {
+		return
+	}
+	var section map[string]json.RawMessage
+	if err := json.Unmarshal([]byte("{"+member+"}"), &section); err != nil {
+		return
+	}
+	for name, value := range section {
+		s.onSection(name, value)
+	}
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSectionScanner(t *testing.T) {
+	answer := "```json\n" + `{
+  "full_name": "Jane \"JD\" Doe",
+  "summary": "Builds APIs, {fast} and [safe]",
+  "experience": [{"company": "Acme, Inc.", "technologies": ["Go", "SQL"]}],
+  "skills": []
+}` + "\n```"
+
+	expected := []struct {
+		name  string
+		value string
+	}{
+		{"full_name", `"Jane \"JD\" Doe"`},
+		{"summary", `"Builds APIs, {fast} and [safe]"`},
+		{"experience", `[{"company": "Acme, Inc.", "technologies": ["Go", "SQL"]}]`},
+		{"skills", `[]`},
+	}
+
+	// The sections must not depend on how the answer is split into pieces
+	for _, size := range []int{1, 3, 7, len(answer)} {
+		var names, values []string
+		scanner := newSectionScanner(func(name string, value json.RawMessage) {
+			names = append(names, name)
+			values = append(values, string(value))
+		})
+		for i := 0; i < len(answer); i += size {
+			scanner.Write(answer[i:min(i+size, len(answer))])
+		}
+
+		if len(names) != len(expected) {
+			t.Errorf("pieces of %d: sections = %s, want %d sections", size, strings.Join(names, ", "), len(expected))
+			continue
+		}
+		for i, want := range expected {
+			if names[i] != want.name || values[i] != want.value {
+				t.Errorf("pieces of %d: section %d = %s %s, want %s %s", size, i, names[i], values[i], want.name, want.value)
+			}
+		}
+	}
+}
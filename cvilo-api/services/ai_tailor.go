@@ -0,0 +1,258 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/smhnaqvi/cvilo/models"
+)
+
+// AIResumeTailoring is the answer of the model to a tailoring request. It only reorders and rewords: experience
+// entries keep their order and their facts, skills are the skills of the resume.
+type AIResumeTailoring struct {
+	JobTitle   string               `json:"job_title" jsonschema_description:"Title of the advertised position"`
+	Company    string               `json:"company" jsonschema_description:"Hiring company, empty when the job description does not name it"`
+	Experience []TailoredExperience `json:"experience" jsonschema_description:"One entry per experience entry of the resume, in the same order"`
+	Skills     []TailoredSkill      `json:"skills" jsonschema_description:"Every skill of the resume once, most relevant to the job first"`
+	Changes    []TailoringChange    `json:"changes" jsonschema_description:"Every change made, with the reason for it"`
+}
+
+// TailoredExperience is the description of an experience entry, rewritten for the job
+type TailoredExperience struct {
+	Bullets []string `json:"bullets" jsonschema_description:"The points of the description, reworded toward the job and most relevant first, without bullet characters"`
+}
+
+// TailoredSkill is a skill of the resume, possibly named as in the job description
+type TailoredSkill struct {
+	Original string `json:"original" jsonschema_description:"Name of the skill in the resume"`
+	Name     string `json:"name" jsonschema_description:"Name to show, e.g. the wording of the job description; the original name if unchanged"`
+}
+
+// TailoringChange explains a change of a tailored resume
+type TailoringChange struct {
+	Section string `json:"section" jsonschema:"enum=experience|skills"`
+	Entry   string `json:"entry" jsonschema_description:"Position and company of the changed experience entry, empty for skills"`
+	Change  string `json:"change" jsonschema_description:"What was changed"`
+	Reason  string `json:"reason" jsonschema_description:"Why, referring to the job description"`
+}
+
+// ResumeTailoring is a resume tailored to a job description: the tailored content, the explanation of the model
+// and the changed sections in the format of the version diff
+type ResumeTailoring struct {
+	JobTitle string                 `json:"job_title"`
+	Company  string                 `json:"company"`
+	Resume   *models.ResumeSnapshot `json:"resume"`
+	Changes  []TailoringChange      `json:"changes"`
+	Diff     []models.SectionDiff   `json:"diff"`
+}
+
+// Summary describes the changes in a few lines, for the chat prompt history
+func (t *ResumeTailoring) Summary() string {
+	lines := []string{fmt.Sprintf("Tailored to %s", t.Target())}
+	for _, change := range t.Changes {
+		line := change.Change
+		if change.Entry != "" {
+			line = change.Entry + ": " + line
+		}
+		if change.Reason != "" {
+			line += " (" + change.Reason + ")"
+		}
+		lines = append(lines, "- "+line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Target names the job, e.g. "Backend Engineer at Acme"
+func (t *ResumeTailoring) Target() string {
+	target := t.JobTitle
+	if target == "" {
+		target = "the job description"
+	}
+	if t.Company != "" {
+		target += " at " + t.Company
+	}
+	return target
+}
+
+// resumeTailoringSchema is the JSON schema of AIResumeTailoring
+var resumeTailoringSchema = newResumeTailoringSchema()
+
+func newResumeTailoringSchema() *JSONSchema {
+	schema := NewJSONSchema(AIResumeTailoring{})
+	schema.Title = "resume_tailoring"
+	schema.Description = "Experience bullets and skills of a resume, reordered and reworded for a job description"
+	return schema
+}
+
+// tailoringDocument is the part of a resume the model tailors, with the summary for context
+type tailoringDocument struct {
+	Title      string                `json:"title"`
+	Summary    string                `json:"summary"`
+	Experience []tailoringExperience `json:"experience"`
+	Skills     []string              `json:"skills"`
+}
+
+type tailoringExperience struct {
+	Position string   `json:"position"`
+	Company  string   `json:"company"`
+	Bullets  []string `json:"bullets"`
+}
+
+func newTailoringDocument(resume *models.ResumeSnapshot) tailoringDocument {
+	document := tailoringDocument{
+		Title:      resume.Title,
+		Summary:    resume.Summary,
+		Experience: make([]tailoringExperience, 0, len(resume.Experience)),
+		Skills:     make([]string, 0, len(resume.Skills)),
+	}
+	for _, exp := range resume.Experience {
+		document.Experience = append(document.Experience, tailoringExperience{
+			Position: exp.Position,
+			Company:  exp.Company,
+			Bullets:  splitResumePoints(exp.Description),
+		})
+	}
+	for _, skill := range resume.Skills {
+		document.Skills = append(document.Skills, skill.Name)
+	}
+	return document
+}
+
+// TailorResume asks for the experience bullets and skills of a resume reordered and reworded toward a job
+// description and returns the tailored content with the name of the provider that wrote it. Nothing is saved.
+func (ai *AIService) TailorResume(ctx context.Context, resume models.ResumeModel, jobDescription string) (*ResumeTailoring, string, error) {
+	current, err := models.NewResumeSnapshot(&resume)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read resume: %v", err)
+	}
+	document, err := json.Marshal(newTailoringDocument(current))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode resume: %v", err)
+	}
+
+	systemPrompt := `You are an expert resume writer. Tailor the experience bullets and the skills of a resume to a job description, so the experience the job asks for stands out.
+
+The response should be a valid JSON object matching this JSON schema:
+` + resumeTailoringSchema.String() + `
+
+Important guidelines:
+1. Never invent experience, responsibilities, numbers, technologies or skills; every bullet must state what the original bullets state
+2. Reword bullets with the terms of the job description where they describe the same thing, and put the most relevant bullets first
+3. Keep every bullet, shorten or merge them only when they repeat each other
+4. Answer with one experience entry per entry of the resume, in the same order, even when nothing changes
+5. List every skill of the resume exactly once, most relevant to the job first, naming it as the job description does when it is the same skill
+6. Explain every change in "changes", with the requirement of the job description it answers`
+
+	userPrompt := fmt.Sprintf(`Tailor this resume to the job description.
+
+Job description:
+"""
+%s
+"""
+
+Resume:
+%s`, truncateJobDescription(jobDescription), document)
+
+	return ai.tailorResume(ctx, current, LLMRequest{
+		System:      systemPrompt,
+		Prompt:      userPrompt,
+		Temperature: 0.4,
+		MaxTokens:   4000,
+		Schema:      resumeTailoringSchema,
+	})
+}
+
+// tailorResume sends a tailoring request through the provider chain and applies the answer to the current content
+func (ai *AIService) tailorResume(ctx context.Context, current *models.ResumeSnapshot, request LLMRequest) (*ResumeTailoring, string, error) {
+	var tailoring *ResumeTailoring
+	provider, err := ai.llm.Complete(ctx, request, func(content string) error {
+		var answer AIResumeTailoring
+		if err := json.Unmarshal([]byte(extractJSON(content)), &answer); err != nil {
+			return fmt.Errorf("failed to parse AI response: %v", err)
+		}
+		result, err := applyResumeTailoring(current, &answer)
+		if err != nil {
+			return err
+		}
+		tailoring = result
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return tailoring, provider, nil
+}
+
+// applyResumeTailoring applies the answer of the model to a copy of the resume. An answer that drops or invents
+// entries is a *SchemaViolationError, so the model corrects it; skills it left out are kept at the end.
+func applyResumeTailoring(current *models.ResumeSnapshot, answer *AIResumeTailoring) (*ResumeTailoring, error) {
+	var problems []string
+	if len(answer.Experience) != len(current.Experience) {
+		problems = append(problems, fmt.Sprintf("experience: expected %d entries, one per experience entry of the resume, got %d",
+			len(current.Experience), len(answer.Experience)))
+	}
+
+	tailored := *current
+	tailored.Experience = make([]models.WorkExperience, len(current.Experience))
+	copy(tailored.Experience, current.Experience)
+	for i := range tailored.Experience {
+		if i >= len(answer.Experience) {
+			break
+		}
+		bullets := splitResumePoints(strings.Join(answer.Experience[i].Bullets, "\n"))
+		if len(bullets) == 0 && strings.TrimSpace(current.Experience[i].Description) != "" {
+			problems = append(problems, fmt.Sprintf("experience[%d].bullets: the entry has a description, keep its points", i))
+			continue
+		}
+		tailored.Experience[i].Description = strings.Join(bullets, "\n")
+	}
+
+	remaining := make(map[string][]models.Skill, len(current.Skills))
+	for _, skill := range current.Skills {
+		key := strings.ToLower(strings.TrimSpace(skill.Name))
+		remaining[key] = append(remaining[key], skill)
+	}
+	tailored.Skills = make([]models.Skill, 0, len(current.Skills))
+	for i, answered := range answer.Skills {
+		key := strings.ToLower(strings.TrimSpace(answered.Original))
+		matches := remaining[key]
+		if len(matches) == 0 {
+			problems = append(problems, fmt.Sprintf("skills[%d].original: %q is not a skill of the resume or is listed twice", i, answered.Original))
+			continue
+		}
+		skill := matches[0]
+		remaining[key] = matches[1:]
+		if name := strings.TrimSpace(answered.Name); name != "" {
+			skill.Name = name
+		}
+		tailored.Skills = append(tailored.Skills, skill)
+	}
+	for _, skill := range current.Skills {
+		key := strings.ToLower(strings.TrimSpace(skill.Name))
+		if len(remaining[key]) > 0 {
+			tailored.Skills = append(tailored.Skills, remaining[key][0])
+			remaining[key] = remaining[key][1:]
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, &SchemaViolationError{Problems: problems}
+	}
+	diff, err := models.DiffSnapshots(current, &tailored)
+	if err != nil {
+		return nil, err
+	}
+	changes := answer.Changes
+	if changes == nil {
+		changes = []TailoringChange{}
+	}
+	return &ResumeTailoring{
+		JobTitle: strings.TrimSpace(answer.JobTitle),
+		Company:  strings.TrimSpace(answer.Company),
+		Resume:   &tailored,
+		Changes:  changes,
+		Diff:     diff,
+	}, nil
+}
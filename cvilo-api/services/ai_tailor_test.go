@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smhnaqvi/cvilo/models"
+)
+
+func testTailoringResume() *models.ResumeSnapshot {
+	resume := testResumeSnapshot()
+	resume.Experience[0].Description = "• Built the billing service\n• Ran the on-call rotation"
+	resume.Skills = []models.Skill{
+		{Name: "Python", Level: 3},
+		{Name: "Go", Level: 5, Category: "Technical"},
+		{Name: "Postgres", Level: 4},
+	}
+	return resume
+}
+
+func TestApplyResumeTailoring(t *testing.T) {
+	answer := AIResumeTailoring{
+		JobTitle: "Payments Engineer",
+		Company:  "Globex",
+		Experience: []TailoredExperience{
+			{Bullets: []string{"Built the billing service for payments", "- Ran the on-call rotation"}},
+			{Bullets: []string{}},
+		},
+		Skills: []TailoredSkill{{Original: "go", Name: "Golang"}, {Original: "Postgres", Name: "PostgreSQL"}},
+		Changes: []TailoringChange{
+			{Section: "experience", Entry: "Engineer at Acme", Change: "Mentioned payments", Reason: "The job is about payments"},
+		},
+	}
+	current := testTailoringResume()
+
+	tailoring, err := applyResumeTailoring(current, &answer)
+	if err != nil {
+		t.Fatalf("applyResumeTailoring() error = %v", err)
+	}
+	if description := tailoring.Resume.Experience[0].Description; description != "Built the billing service for payments\nRan the on-call rotation" {
+		t.Errorf("experience[0].description = %q, want the tailored bullets", description)
+	}
+	if tailoring.Resume.Experience[1].Company != "Initech" || tailoring.Resume.Experience[1].Description != "" {
+		t.Errorf("experience[1] = %+v, want it unchanged", tailoring.Resume.Experience[1])
+	}
+	var skills []string
+	for _, skill := range tailoring.Resume.Skills {
+		skills = append(skills, skill.Name)
+	}
+	// Python was left out by the model and is kept at the end
+	if strings.Join(skills, ",") != "Golang,PostgreSQL,Python" || tailoring.Resume.Skills[0].Level != 5 || tailoring.Resume.Skills[0].Category != "Technical" {
+		t.Errorf("skills = %+v, want Golang, PostgreSQL, Python with their levels", tailoring.Resume.Skills)
+	}
+	if current.Skills[0].Name != "Python" || current.Experience[0].Description != testTailoringResume().Experience[0].Description {
+		t.Errorf("current resume changed: %+v", current)
+	}
+
+	var sections []string
+	for _, diff := range tailoring.Diff {
+		sections = append(sections, diff.Section)
+	}
+	if strings.Join(sections, ",") != "experience,skills" {
+		t.Errorf("diff sections = %v, want experience, skills", sections)
+	}
+	if tailoring.Target() != "Payments Engineer at Globex" || !strings.Contains(tailoring.Summary(), "Engineer at Acme: Mentioned payments (The job is about payments)") {
+		t.Errorf("summary = %q", tailoring.Summary())
+	}
+}
+
+func TestApplyResumeTailoringProblems(t *testing.T) {
+	tests := []struct {
+		name    string
+		answer  AIResumeTailoring
+		problem string
+	}{
+		{
+			"dropped entry",
+			AIResumeTailoring{Experience: []TailoredExperience{{Bullets: []string{"Built billing"}}}},
+			"experience: expected 2 entries",
+		},
+		{
+			"emptied description",
+			AIResumeTailoring{Experience: []TailoredExperience{{}, {}}},
+			"experience[0].bullets",
+		},
+		{
+			"invented skill",
+			AIResumeTailoring{
+				Experience: []TailoredExperience{{Bullets: []string{"Built billing"}}, {}},
+				Skills:     []TailoredSkill{{Original: "Kubernetes", Name: "Kubernetes"}},
+			},
+			`skills[0].original: "Kubernetes" is not a skill of the resume`,
+		},
+		{
+			"repeated skill",
+			AIResumeTailoring{
+				Experience: []TailoredExperience{{Bullets: []string{"Built billing"}}, {}},
+				Skills:     []TailoredSkill{{Original: "Go"}, {Original: "Go"}},
+			},
+			`skills[1].original: "Go"`,
+		},
+	}
+
+	for _, tt := range tests {
+		_, err := applyResumeTailoring(testTailoringResume(), &tt.answer)
+		var violation *SchemaViolationError
+		if !errors.As(err, &violation) || !strings.Contains(err.Error(), tt.problem) {
+			t.Errorf("%s: error = %v, want a schema violation with %s", tt.name, err, tt.problem)
+		}
+	}
+}
+
+func TestTailorResumeRepairsAnswer(t *testing.T) {
+	dropped := `{"job_title": "Payments Engineer", "company": "", "experience": [{"bullets": ["Built billing"]}], "skills": [], "changes": []}`
+	fixed := `{"job_title": "Payments Engineer", "company": "", "experience": [{"bullets": ["Built billing for payments"]}, {"bullets": []}], "skills": [{"original": "Go", "name": "Go"}], "changes": [{"section": "experience", "entry": "Engineer at Acme", "change": "Mentioned payments", "reason": "Payments role"}]}`
+	llm := &stubLLM{name: "a", configured: true, answers: []string{dropped}, content: fixed}
+	ai := NewAIServiceWithChain(NewLLMChain(time.Second, llm))
+
+	tailoring, provider, err := ai.tailorResume(context.Background(), testTailoringResume(), LLMRequest{Prompt: "Tailor", Schema: resumeTailoringSchema})
+	if err != nil {
+		t.Fatalf("tailorResume() error = %v", err)
+	}
+	if provider != "a" || llm.calls != 2 || !strings.Contains(llm.requests[1].Prompt, "expected 2 entries") {
+		t.Errorf("provider = %s, calls = %d, want a corrected answer from a", provider, llm.calls)
+	}
+	if tailoring.Resume.Experience[0].Description != "Built billing for payments" || tailoring.Resume.Skills[0].Name != "Go" || len(tailoring.Changes) != 1 {
+		t.Errorf("tailoring = %+v, want the corrected answer", tailoring)
+	}
+}
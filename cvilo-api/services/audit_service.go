@@ -0,0 +1,117 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/smhnaqvi/cvilo/models"
+)
+
+// AuditEvent describes an event for the audit log
+type AuditEvent struct {
+	ActorID    *uint
+	Action     string
+	TargetType string
+	TargetID   string
+	Client     ClientInfo
+	Before     map[string]interface{} // summary of the target before a change, nil for creations
+	After      map[string]interface{} // summary of the target after a change, nil for deletions
+	Details    map[string]interface{}
+}
+
+// RecordAudit writes an event to the audit log. Failing to write it is logged but does not fail the request
+// that caused the event.
+func RecordAudit(event AuditEvent) {
+	entry := models.AuditLogModel{
+		ActorID:    event.ActorID,
+		Action:     event.Action,
+		TargetType: event.TargetType,
+		TargetID:   event.TargetID,
+		IPAddress:  event.Client.IPAddress,
+		UserAgent:  event.Client.UserAgent,
+		RequestID:  event.Client.RequestID,
+		Before:     event.Before,
+		After:      event.After,
+		Details:    event.Details,
+	}
+	if err := entry.Create(); err != nil {
+		log.Printf("Audit: failed to record %s on %s %s: %v", event.Action, event.TargetType, event.TargetID, err)
+	}
+}
+
+// auditCSVHeader are the columns of an audit log export; before, after and details hold JSON
+var auditCSVHeader = []string{
+	"id", "created_at", "actor_id", "action", "target_type", "target_id",
+	"ip_address", "user_agent", "request_id", "before", "after", "details",
+}
+
+// AuditCSVWriter writes audit log entries as CSV, one row per entry after a header row
+type AuditCSVWriter struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+// NewAuditCSVWriter creates a CSV writer for audit log entries
+func NewAuditCSVWriter(w io.Writer) *AuditCSVWriter {
+	return &AuditCSVWriter{w: csv.NewWriter(w)}
+}
+
+// Write writes entries, preceded by the header on the first call
+func (a *AuditCSVWriter) Write(entries []models.AuditLogModel) error {
+	if !a.headerWritten {
+		if err := a.w.Write(auditCSVHeader); err != nil {
+			return err
+		}
+		a.headerWritten = true
+	}
+	for _, entry := range entries {
+		actorID := ""
+		if entry.ActorID != nil {
+			actorID = strconv.FormatUint(uint64(*entry.ActorID), 10)
+		}
+		row := []string{
+			strconv.FormatUint(uint64(entry.ID), 10),
+			entry.CreatedAt.UTC().Format(time.RFC3339),
+			actorID,
+			entry.Action,
+			entry.TargetType,
+			csvSafe(entry.TargetID),
+			entry.IPAddress,
+			csvSafe(entry.UserAgent),
+			entry.RequestID,
+			auditJSON(entry.Before),
+			auditJSON(entry.After),
+			auditJSON(entry.Details),
+		}
+		if err := a.w.Write(row); err != nil {
+			return err
+		}
+	}
+	a.w.Flush()
+	return a.w.Error()
+}
+
+// auditJSON encodes a summary for a CSV cell, empty when there is none
+func auditJSON(value map[string]interface{}) string {
+	if len(value) == 0 {
+		return ""
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(encoded)
+}
+
+// csvSafe keeps values chosen by clients, like user agents, from being run as formulas when the export is opened
+// in a spreadsheet
+func csvSafe(value string) string {
+	if value != "" && (value[0] == '=' || value[0] == '+' || value[0] == '-' || value[0] == '@') {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/smhnaqvi/cvilo/models"
+)
+
+func TestAuditCSVWriter(t *testing.T) {
+	adminID := uint(1)
+	entries := []models.AuditLogModel{
+		{
+			ID:         7,
+			ActorID:    &adminID,
+			Action:     models.AuditActionUserRoleChanged,
+			TargetType: models.AuditTargetUser,
+			TargetID:   "42",
+			IPAddress:  "192.0.2.1",
+			UserAgent:  "curl/8.0",
+			RequestID:  "req-1",
+			Before:     map[string]interface{}{"role": "user"},
+			After:      map[string]interface{}{"role": "admin"},
+			CreatedAt:  time.Date(2024, 3, 1, 12, 30, 0, 0, time.FixedZone("CET", 3600)),
+		},
+		{
+			ID:         8,
+			Action:     models.AuditActionLoginFailed,
+			TargetType: models.AuditTargetAccount,
+			TargetID:   "=HYPERLINK(\"http://example.com\")",
+			UserAgent:  "Mozilla/5.0, \"quoted\"",
+			CreatedAt:  time.Date(2024, 3, 1, 13, 0, 0, 0, time.UTC),
+		},
+	}
+
+	var buf bytes.Buffer
+	writer := NewAuditCSVWriter(&buf)
+	if err := writer.Write(entries[:1]); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	if err := writer.Write(entries[1:]); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("reading the CSV failed: %v", err)
+	}
+	expected := [][]string{
+		auditCSVHeader,
+		{"7", "2024-03-01T11:30:00Z", "1", "user.role_changed", "user", "42", "192.0.2.1", "curl/8.0", "req-1", `{"role":"user"}`, `{"role":"admin"}`, ""},
+		{"8", "2024-03-01T13:00:00Z", "", "auth.login_failed", "account", "'=HYPERLINK(\"http://example.com\")", "", "Mozilla/5.0, \"quoted\"", "", "", "", ""},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("CSV rows = %q, want %q", rows, expected)
+	}
+}
+
+func TestAuditCSVWriterEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewAuditCSVWriter(&buf).Write(nil); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	if buf.String() != "id,created_at,actor_id,action,target_type,target_id,ip_address,user_agent,request_id,before,after,details\n" {
+		t.Errorf("empty export = %q, want the header row", buf.String())
+	}
+}
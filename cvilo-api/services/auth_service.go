@@ -11,10 +11,12 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-type AuthService struct{}
+type AuthService struct {
+	mailer Mailer
+}
 
 func NewAuthService() *AuthService {
-	return &AuthService{}
+	return &AuthService{mailer: DefaultMailer()}
 }
 
 // HashPassword hashes a password using bcrypt
@@ -36,87 +38,53 @@ type TokenPair struct {
 	ExpiresAt    time.Time `json:"expires_at"`
 }
 
-// GenerateJWT generates a JWT token for a user
-func (s *AuthService) GenerateJWT(user models.UserModel) (string, error) {
-	jwtSecret := os.Getenv("JWT_SECRET")
-	if jwtSecret == "" {
-		jwtSecret = "your-secret-key"
-	}
-
-	claims := models.JWTClaims{
-		UserID: user.ID,
-		Email:  user.Email,
-		Role:   "user",
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			Issuer:    "cvilo-api",
-			Subject:   fmt.Sprintf("%d", user.ID),
-		},
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(jwtSecret))
-	if err != nil {
-		return "", err
+// claimsRole returns the role to put in the tokens of a user, users without a stored role being plain users
+func claimsRole(user models.UserModel) string {
+	if user.Role == "" {
+		return models.RoleUser
 	}
+	return user.Role
+}
 
-	return tokenString, nil
+// GenerateJWT generates a JWT token for a user, valid for 24 hours and not bound to a session
+func (s *AuthService) GenerateJWT(user models.UserModel) (string, error) {
+	return s.signClaims(user, "", 24*time.Hour)
 }
 
-// GenerateTokenPair generates both access and refresh tokens for a user
-func (s *AuthService) GenerateTokenPair(user models.UserModel) (*TokenPair, error) {
+// signClaims signs an access token for the user, bound to sessionID when it is set
+func (s *AuthService) signClaims(user models.UserModel, sessionID string, ttl time.Duration) (string, error) {
 	jwtSecret := os.Getenv("JWT_SECRET")
 	if jwtSecret == "" {
 		jwtSecret = "your-secret-key"
 	}
 
-	// Generate access token (short-lived, 15 minutes)
-	accessClaims := models.JWTClaims{
-		UserID: user.ID,
-		Email:  user.Email,
-		Role:   "user",
+	now := time.Now()
+	claims := models.JWTClaims{
+		UserID:    user.ID,
+		Email:     user.Email,
+		Role:      claimsRole(user),
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 			Issuer:    "cvilo-api",
 			Subject:   fmt.Sprintf("%d", user.ID),
 		},
 	}
 
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessTokenString, err := accessToken.SignedString([]byte(jwtSecret))
-	if err != nil {
-		return nil, err
-	}
-
-	// Generate refresh token (long-lived, 7 days)
-	refreshClaims := models.JWTClaims{
-		UserID: user.ID,
-		Email:  user.Email,
-		Role:   "user",
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(7 * 24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			Issuer:    "cvilo-api",
-			Subject:   fmt.Sprintf("%d", user.ID),
-		},
-	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(jwtSecret))
+}
 
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshTokenString, err := refreshToken.SignedString([]byte(jwtSecret))
+// GenerateTokenPair opens a session for the user: a short-lived access token (15 minutes) and a single-use
+// refresh token (7 days), stored hashed and exchanged with RefreshTokens
+func (s *AuthService) GenerateTokenPair(user models.UserModel, client ClientInfo) (*TokenPair, error) {
+	sessionID, err := newOpaqueToken()
 	if err != nil {
 		return nil, err
 	}
-
-	return &TokenPair{
-		AccessToken:  accessTokenString,
-		RefreshToken: refreshTokenString,
-		ExpiresAt:    time.Now().Add(15 * time.Minute),
-	}, nil
+	return s.issueTokens(user, client, sessionID, time.Now(), nil)
 }
 
 // ValidateJWT validates a JWT token and returns the claims
@@ -144,20 +112,32 @@ func (s *AuthService) ValidateJWT(tokenString string) (*models.JWTClaims, error)
 	return nil, errors.New("invalid token")
 }
 
-// AuthenticateUser authenticates a user with email and password
+var (
+	// ErrInvalidCredentials is returned for unknown emails and wrong passwords alike
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrAccountDeactivated is returned for the correct password of a deactivated account
+	ErrAccountDeactivated = errors.New("account is deactivated")
+)
+
+// dummyPasswordHash is compared against for unknown emails, so they take as long as wrong passwords
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("cvilo-dummy-password"), bcrypt.DefaultCost)
+
+// AuthenticateUser authenticates a user with email and password. Whether the email exists is not revealed, by the
+// error nor by the time taken; only the correct password of a deactivated account returns ErrAccountDeactivated.
 func (s *AuthService) AuthenticateUser(email, password string) (*models.UserModel, error) {
 	var user models.UserModel
 
 	if err := user.GetUserByEmail(email); err != nil {
-		return nil, errors.New("invalid credentials")
+		s.CheckPassword(password, string(dummyPasswordHash))
+		return nil, ErrInvalidCredentials
 	}
 
-	if !user.IsActive {
-		return nil, errors.New("account is deactivated")
+	if !s.CheckPassword(password, user.Password) {
+		return nil, ErrInvalidCredentials
 	}
 
-	if !s.CheckPassword(password, user.Password) {
-		return nil, errors.New("invalid credentials")
+	if !user.IsActive {
+		return nil, ErrAccountDeactivated
 	}
 
 	return &user, nil
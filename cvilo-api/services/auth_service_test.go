@@ -0,0 +1,51 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/smhnaqvi/cvilo/models"
+)
+
+func TestTokensCarryUserRole(t *testing.T) {
+	service := NewAuthService()
+
+	tests := []struct {
+		name     string
+		role     string
+		expected string
+	}{
+		{"Admin", models.RoleAdmin, models.RoleAdmin},
+		{"Recruiter", models.RoleRecruiter, models.RoleRecruiter},
+		{"No stored role", "", models.RoleUser},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := models.UserModel{ID: 7, Email: "jane@example.com", Role: tt.role}
+
+			token, err := service.GenerateJWT(user)
+			if err != nil {
+				t.Fatalf("GenerateJWT() error: %v", err)
+			}
+			sessionToken, err := service.signClaims(user, "session-1", accessTokenTTL)
+			if err != nil {
+				t.Fatalf("signClaims() error: %v", err)
+			}
+
+			for _, tokenString := range []string{token, sessionToken} {
+				claims, err := service.ValidateJWT(tokenString)
+				if err != nil {
+					t.Fatalf("ValidateJWT() error: %v", err)
+				}
+				if claims.Role != tt.expected {
+					t.Errorf("ValidateJWT().Role = %s, want %s", claims.Role, tt.expected)
+				}
+			}
+
+			claims, _ := service.ValidateJWT(sessionToken)
+			if claims.SessionID != "session-1" {
+				t.Errorf("ValidateJWT().SessionID = %q, want %q", claims.SessionID, "session-1")
+			}
+		})
+	}
+}
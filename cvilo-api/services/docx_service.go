@@ -0,0 +1,235 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/smhnaqvi/cvilo/models"
+)
+
+// DOCXService generates Word (Office Open XML) documents from resumes
+type DOCXService struct{}
+
+func NewDOCXService() *DOCXService {
+	return &DOCXService{}
+}
+
+// GenerateResumeDOCX builds a .docx file for the resume.
+// Headings and bullets use the built-in Word styles (Title, Heading 1, Heading 2, List Bullet)
+// so the document stays editable.
+func (ds *DOCXService) GenerateResumeDOCX(resume *models.ResumeModel) ([]byte, error) {
+	view, err := BuildResumeView(resume)
+	if err != nil {
+		return nil, err
+	}
+
+	// [Content_Types].xml must be the first entry of the package
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", docxContentTypes},
+		{"_rels/.rels", docxRootRels},
+		{"docProps/core.xml", docxCoreProperties(view.Name, resume.Title, resume.CreatedAt, resume.UpdatedAt)},
+		{"word/_rels/document.xml.rels", docxDocumentRels},
+		{"word/styles.xml", docxStyles},
+		{"word/numbering.xml", docxNumbering},
+		{"word/document.xml", docxDocument(view)},
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, part := range parts {
+		w, err := zw.Create(part.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s: %v", part.name, err)
+		}
+		if _, err := w.Write([]byte(part.content)); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %v", part.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write DOCX: %v", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// docxBuilder accumulates the paragraphs of word/document.xml
+type docxBuilder struct {
+	body strings.Builder
+}
+
+// paragraph adds a paragraph with the given style; an empty style uses Normal
+func (b *docxBuilder) paragraph(style string, text string) {
+	b.body.WriteString("<w:p>")
+	if style != "" {
+		fmt.Fprintf(&b.body, `<w:pPr><w:pStyle w:val="%s"/></w:pPr>`, style)
+	}
+	b.run(text)
+	b.body.WriteString("</w:p>")
+}
+
+// run adds a text run, preserving line breaks
+func (b *docxBuilder) run(text string) {
+	b.body.WriteString("<w:r>")
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			b.body.WriteString("<w:br/>")
+		}
+		b.body.WriteString(`<w:t xml:space="preserve">`)
+		xml.EscapeText(&b.body, []byte(line))
+		b.body.WriteString("</w:t>")
+	}
+	b.body.WriteString("</w:r>")
+}
+
+// bullets adds one List Bullet paragraph per point
+func (b *docxBuilder) bullets(points []string) {
+	for _, point := range points {
+		b.paragraph("ListBullet", point)
+	}
+}
+
+// entries adds a section of dated entries
+func (b *docxBuilder) entries(heading string, entries []ResumeEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	b.paragraph("Heading1", heading)
+	for _, entry := range entries {
+		b.paragraph("Heading2", entryTitle(entry))
+		if meta := entryMeta(entry); meta != "" {
+			b.paragraph("EntryMeta", meta)
+		}
+		b.bullets(entry.Points)
+	}
+}
+
+// items adds a section of single-line items as a bullet list
+func (b *docxBuilder) items(heading string, items []ResumeItem) {
+	if len(items) == 0 {
+		return
+	}
+	b.paragraph("Heading1", heading)
+	var lines []string
+	for _, item := range items {
+		lines = append(lines, formatResumeItem(item))
+	}
+	b.bullets(lines)
+}
+
+// formatResumeItem formats an item as "Name, Detail (Date)"
+func formatResumeItem(item ResumeItem) string {
+	line := item.Name
+	if item.Detail != "" {
+		line += ", " + item.Detail
+	}
+	if item.Date != "" {
+		line += " (" + item.Date + ")"
+	}
+	return line
+}
+
+// docxDocument renders word/document.xml
+func docxDocument(view *ResumeView) string {
+	b := &docxBuilder{}
+
+	b.paragraph("Title", view.Name)
+	if view.Headline != "" {
+		b.paragraph("Subtitle", view.Headline)
+	}
+	if contact := contactLine(view); contact != "" {
+		b.paragraph("", contact)
+	}
+
+	if view.Summary != "" {
+		b.paragraph("Heading1", "Professional Summary")
+		b.paragraph("", view.Summary)
+	}
+	b.entries("Experience", view.Experience)
+	b.entries("Education", view.Education)
+	if len(view.Skills) > 0 {
+		b.paragraph("Heading1", "Skills")
+		b.paragraph("", strings.Join(view.Skills, ", "))
+	}
+	b.entries("Projects", view.Projects)
+	b.items("Certifications", view.Certifications)
+	b.items("Languages", view.Languages)
+	b.items("Awards", view.Awards)
+	if view.Interests != "" {
+		b.paragraph("Heading1", "Interests")
+		b.paragraph("", view.Interests)
+	}
+	if view.References != "" {
+		b.paragraph("Heading1", "References")
+		b.paragraph("", view.References)
+	}
+
+	return xml.Header + `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		"<w:body>" + b.body.String() +
+		`<w:sectPr><w:pgMar w:top="1080" w:right="1080" w:bottom="1080" w:left="1080" w:header="720" w:footer="720" w:gutter="0"/></w:sectPr>` +
+		"</w:body></w:document>"
+}
+
+// docxCoreProperties renders docProps/core.xml with the document title, author and resume timestamps
+func docxCoreProperties(author string, title string, created time.Time, modified time.Time) string {
+	var escapedAuthor, escapedTitle bytes.Buffer
+	xml.EscapeText(&escapedAuthor, []byte(author))
+	xml.EscapeText(&escapedTitle, []byte(title))
+
+	return xml.Header + `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">` +
+		"<dc:title>" + escapedTitle.String() + "</dc:title>" +
+		"<dc:creator>" + escapedAuthor.String() + "</dc:creator>" +
+		`<dcterms:created xsi:type="dcterms:W3CDTF">` + created.UTC().Format(time.RFC3339) + "</dcterms:created>" +
+		`<dcterms:modified xsi:type="dcterms:W3CDTF">` + modified.UTC().Format(time.RFC3339) + "</dcterms:modified>" +
+		"</cp:coreProperties>"
+}
+
+const docxContentTypes = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
+<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>
+<Override PartName="/word/numbering.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.numbering+xml"/>
+<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>
+</Types>`
+
+const docxRootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>
+</Relationships>`
+
+const docxDocumentRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/numbering" Target="numbering.xml"/>
+</Relationships>`
+
+// docxStyles declares the built-in styles used by the document.
+// Style IDs match Word's own so recruiters see "Heading 1", "List Bullet", etc. in the style gallery.
+const docxStyles = xml.Header + `<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:docDefaults>
+<w:rPrDefault><w:rPr><w:rFonts w:ascii="Calibri" w:hAnsi="Calibri" w:eastAsia="Calibri" w:cs="Calibri"/><w:sz w:val="22"/><w:szCs w:val="22"/><w:lang w:val="en-US"/></w:rPr></w:rPrDefault>
+<w:pPrDefault><w:pPr><w:spacing w:after="80" w:line="264" w:lineRule="auto"/></w:pPr></w:pPrDefault>
+</w:docDefaults>
+<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/><w:qFormat/></w:style>
+<w:style w:type="paragraph" w:styleId="Title"><w:name w:val="Title"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:spacing w:after="40"/></w:pPr><w:rPr><w:b/><w:sz w:val="48"/><w:szCs w:val="48"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Subtitle"><w:name w:val="Subtitle"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:rPr><w:color w:val="595959"/><w:sz w:val="26"/><w:szCs w:val="26"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:uiPriority w:val="9"/><w:qFormat/><w:pPr><w:keepNext/><w:keepLines/><w:pBdr><w:bottom w:val="single" w:sz="6" w:space="1" w:color="1F4E79"/></w:pBdr><w:spacing w:before="240" w:after="80"/><w:outlineLvl w:val="0"/></w:pPr><w:rPr><w:b/><w:caps/><w:color w:val="1F4E79"/><w:sz w:val="26"/><w:szCs w:val="26"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading2"><w:name w:val="heading 2"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:uiPriority w:val="9"/><w:unhideWhenUsed/><w:qFormat/><w:pPr><w:keepNext/><w:keepLines/><w:spacing w:before="160" w:after="0"/><w:outlineLvl w:val="1"/></w:pPr><w:rPr><w:b/><w:sz w:val="23"/><w:szCs w:val="23"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:customStyle="1" w:styleId="EntryMeta"><w:name w:val="Entry Meta"/><w:basedOn w:val="Normal"/><w:next w:val="ListBullet"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:after="40"/></w:pPr><w:rPr><w:i/><w:color w:val="595959"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="ListBullet"><w:name w:val="List Bullet"/><w:basedOn w:val="Normal"/><w:uiPriority w:val="9"/><w:unhideWhenUsed/><w:qFormat/><w:pPr><w:numPr><w:numId w:val="1"/></w:numPr><w:spacing w:after="40"/><w:contextualSpacing/></w:pPr></w:style>
+</w:styles>`
+
+// docxNumbering defines the bullet list attached to the List Bullet style
+const docxNumbering = xml.Header + `<w:numbering xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:abstractNum w:abstractNumId="0">
+<w:multiLevelType w:val="singleLevel"/>
+<w:lvl w:ilvl="0"><w:start w:val="1"/><w:numFmt w:val="bullet"/><w:pStyle w:val="ListBullet"/><w:lvlText w:val="•"/><w:lvlJc w:val="left"/><w:pPr><w:ind w:left="360" w:hanging="360"/></w:pPr></w:lvl>
+</w:abstractNum>
+<w:num w:numId="1"><w:abstractNumId w:val="0"/></w:num>
+</w:numbering>`
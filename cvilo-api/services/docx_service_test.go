@@ -0,0 +1,77 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/smhnaqvi/cvilo/models"
+)
+
+func TestGenerateResumeDOCX(t *testing.T) {
+	resume := &models.ResumeModel{
+		Title:      "Backend <Resume>",
+		FullName:   "Jane & Co",
+		Experience: `[{"company":"Acme","position":"Engineer","start_date":"2020-01-01T00:00:00Z","is_current":true,"description":"Built APIs\nShipped features"}]`,
+		Education:  `[{"institution":"MIT","degree":"BSc","field_of_study":"Computer Science","start_date":"2015-09-01T00:00:00Z"}]`,
+		Skills:     `[{"name":"Go"},{"name":"SQL"}]`,
+	}
+
+	docx, err := NewDOCXService().GenerateResumeDOCX(resume)
+	if err != nil {
+		t.Fatalf("GenerateResumeDOCX() error: %v", err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(docx), int64(len(docx)))
+	if err != nil {
+		t.Fatalf("DOCX is not a valid zip archive: %v", err)
+	}
+	if reader.File[0].Name != "[Content_Types].xml" {
+		t.Errorf("first entry = %s, want [Content_Types].xml", reader.File[0].Name)
+	}
+
+	parts := make(map[string]string)
+	for _, file := range reader.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", file.Name, err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+
+		// Every part must be well-formed XML
+		decoder := xml.NewDecoder(bytes.NewReader(content))
+		for {
+			if _, err := decoder.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s is not well-formed XML: %v", file.Name, err)
+			}
+		}
+		parts[file.Name] = string(content)
+	}
+
+	tests := []struct {
+		name string
+		part string
+		want string
+	}{
+		{name: "Escaped name", part: "word/document.xml", want: "Jane &amp; Co"},
+		{name: "Section heading style", part: "word/document.xml", want: `<w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t xml:space="preserve">Experience</w:t>`},
+		{name: "Entry heading style", part: "word/document.xml", want: `<w:pStyle w:val="Heading2"/></w:pPr><w:r><w:t xml:space="preserve">Engineer, Acme</w:t>`},
+		{name: "Bullet style", part: "word/document.xml", want: `<w:pStyle w:val="ListBullet"/></w:pPr><w:r><w:t xml:space="preserve">Shipped features</w:t>`},
+		{name: "Bullet numbering", part: "word/styles.xml", want: `<w:numId w:val="1"/>`},
+		{name: "Escaped title", part: "docProps/core.xml", want: "Backend &lt;Resume&gt;"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(parts[tt.part], tt.want) {
+				t.Errorf("%s does not contain %s", tt.part, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/smhnaqvi/cvilo/models"
+)
+
+// Supported export formats
+const (
+	ExportFormatText       = "txt"
+	ExportFormatMarkdown   = "md"
+	ExportFormatJSONResume = "jsonresume"
+)
+
+// Wrap width limits for text exports, 0 disables wrapping
+const (
+	DefaultExportWidth = 80
+	MinExportWidth     = 40
+	MaxExportWidth     = 200
+)
+
+var ErrUnsupportedExportFormat = errors.New("unsupported export format")
+
+// ExportOptions selects the export format and the line width.
+// Callers validate Width against MinExportWidth and MaxExportWidth, 0 disables wrapping.
+type ExportOptions struct {
+	Format string
+	Width  int
+}
+
+// ExportedResume is an exported resume ready to be sent to the client
+type ExportedResume struct {
+	Content     []byte
+	ContentType string
+	Extension   string
+}
+
+// ExportService exports resumes to plain-text formats that survive job portals and ATS parsers,
+// and to the JSON Resume schema for use with other resume tools
+type ExportService struct{}
+
+func NewExportService() *ExportService {
+	return &ExportService{}
+}
+
+// Export renders the resume in the requested format.
+// Sections always appear in the same order: contact details, summary, experience, education,
+// skills, projects, certifications, languages, awards, interests and references.
+// JSON Resume exports ignore the width.
+func (es *ExportService) Export(resume *models.ResumeModel, opts ExportOptions) (*ExportedResume, error) {
+	if opts.Format == ExportFormatJSONResume {
+		doc, err := ToJSONResume(resume)
+		if err != nil {
+			return nil, err
+		}
+		content, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode JSON Resume: %v", err)
+		}
+		return &ExportedResume{
+			Content:     append(content, '\n'),
+			ContentType: "application/json; charset=utf-8",
+			Extension:   "json",
+		}, nil
+	}
+
+	view, err := BuildResumeView(resume)
+	if err != nil {
+		return nil, err
+	}
+
+	switch opts.Format {
+	case ExportFormatText:
+		return &ExportedResume{
+			Content:     []byte(renderResumeText(view, opts.Width)),
+			ContentType: "text/plain; charset=utf-8",
+			Extension:   "txt",
+		}, nil
+	case ExportFormatMarkdown:
+		return &ExportedResume{
+			Content:     []byte(renderResumeMarkdown(view, opts.Width)),
+			ContentType: "text/markdown; charset=utf-8",
+			Extension:   "md",
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedExportFormat, opts.Format)
+	}
+}
+
+// textWriter writes wrapped lines for the text and Markdown exports
+type textWriter struct {
+	sb    strings.Builder
+	width int
+}
+
+// line writes a single unwrapped line
+func (w *textWriter) line(text string) {
+	w.sb.WriteString(strings.TrimRight(text, " "))
+	w.sb.WriteString("\n")
+}
+
+// blank writes an empty line, never more than one in a row
+func (w *textWriter) blank() {
+	if w.sb.Len() > 0 && !strings.HasSuffix(w.sb.String(), "\n\n") {
+		w.sb.WriteString("\n")
+	}
+}
+
+// wrapped writes text wrapped to the width, prefixing the first line with prefix
+// and indenting the following lines to align with it
+func (w *textWriter) wrapped(prefix string, text string) {
+	indent := strings.Repeat(" ", utf8.RuneCountInString(prefix))
+	for i, line := range wrapText(text, w.width-utf8.RuneCountInString(prefix)) {
+		if i == 0 {
+			w.line(prefix + line)
+		} else {
+			w.line(indent + line)
+		}
+	}
+}
+
+func (w *textWriter) String() string {
+	return strings.TrimRight(w.sb.String(), "\n") + "\n"
+}
+
+// wrapText splits text into lines of at most width runes, breaking on spaces.
+// Words longer than the width are kept whole. A width of 0 or less disables wrapping.
+func wrapText(text string, width int) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []string{""}
+	}
+	if width <= 0 {
+		return []string{strings.Join(words, " ")}
+	}
+
+	var lines []string
+	current := words[0]
+	for _, word := range words[1:] {
+		if utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) > width {
+			lines = append(lines, current)
+			current = word
+			continue
+		}
+		current += " " + word
+	}
+	return append(lines, current)
+}
+
+// contactLine joins the non-empty contact details of the resume
+func contactLine(view *ResumeView) string {
+	var contact []string
+	for _, value := range []string{view.Email, view.Phone, view.Address, view.Website, view.LinkedIn, view.GitHub} {
+		if value != "" {
+			contact = append(contact, value)
+		}
+	}
+	return strings.Join(contact, " | ")
+}
+
+// entryMeta joins the period and location of an entry
+func entryMeta(entry ResumeEntry) string {
+	var meta []string
+	for _, value := range []string{entry.Period, entry.Location} {
+		if value != "" {
+			meta = append(meta, value)
+		}
+	}
+	return strings.Join(meta, " | ")
+}
+
+// entryTitle formats an entry heading such as "Senior Engineer, Acme"
+func entryTitle(entry ResumeEntry) string {
+	if entry.Title != "" && entry.Subtitle != "" {
+		return entry.Title + ", " + entry.Subtitle
+	}
+	if entry.Title != "" {
+		return entry.Title
+	}
+	return entry.Subtitle
+}
+
+// renderResumeText renders the resume as plain text with upper-case section titles and dash bullets
+func renderResumeText(view *ResumeView, width int) string {
+	w := &textWriter{width: width}
+
+	w.wrapped("", strings.ToUpper(view.Name))
+	if view.Headline != "" {
+		w.wrapped("", view.Headline)
+	}
+	if contact := contactLine(view); contact != "" {
+		w.wrapped("", contact)
+	}
+
+	section := func(title string) {
+		w.blank()
+		w.line(strings.ToUpper(title))
+		w.line(strings.Repeat("-", utf8.RuneCountInString(title)))
+	}
+	paragraph := func(title string, text string) {
+		if text == "" {
+			return
+		}
+		section(title)
+		for _, line := range strings.Split(text, "\n") {
+			if strings.TrimSpace(line) != "" {
+				w.wrapped("", line)
+			}
+		}
+	}
+	entries := func(title string, entries []ResumeEntry) {
+		if len(entries) == 0 {
+			return
+		}
+		section(title)
+		for i, entry := range entries {
+			if i > 0 {
+				w.blank()
+			}
+			w.wrapped("", entryTitle(entry))
+			if meta := entryMeta(entry); meta != "" {
+				w.wrapped("", meta)
+			}
+			for _, point := range entry.Points {
+				w.wrapped("- ", point)
+			}
+		}
+	}
+	items := func(title string, items []ResumeItem) {
+		if len(items) == 0 {
+			return
+		}
+		section(title)
+		for _, item := range items {
+			w.wrapped("- ", formatResumeItem(item))
+		}
+	}
+
+	paragraph("Summary", view.Summary)
+	entries("Experience", view.Experience)
+	entries("Education", view.Education)
+	paragraph("Skills", strings.Join(view.Skills, ", "))
+	entries("Projects", view.Projects)
+	items("Certifications", view.Certifications)
+	items("Languages", view.Languages)
+	items("Awards", view.Awards)
+	paragraph("Interests", view.Interests)
+	paragraph("References", view.References)
+
+	return w.String()
+}
+
+// markdownEscaper escapes characters that would otherwise be read as Markdown syntax
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "#", `\#`, "<", `\<`, ">", `\>`, "|", `\|`,
+)
+
+// renderResumeMarkdown renders the resume as Markdown with one heading level per section and entry
+func renderResumeMarkdown(view *ResumeView, width int) string {
+	w := &textWriter{width: width}
+	esc := markdownEscaper.Replace
+
+	w.line("# " + esc(view.Name))
+	if view.Headline != "" {
+		w.blank()
+		w.wrapped("", "**"+esc(view.Headline)+"**")
+	}
+	if contact := contactLine(view); contact != "" {
+		w.blank()
+		w.wrapped("", esc(contact))
+	}
+
+	paragraph := func(title string, text string) {
+		if text == "" {
+			return
+		}
+		w.blank()
+		w.line("## " + title)
+		for _, line := range strings.Split(text, "\n") {
+			if strings.TrimSpace(line) != "" {
+				w.blank()
+				w.wrapped("", esc(line))
+			}
+		}
+	}
+	entries := func(title string, entries []ResumeEntry) {
+		if len(entries) == 0 {
+			return
+		}
+		w.blank()
+		w.line("## " + title)
+		for _, entry := range entries {
+			w.blank()
+			w.line("### " + esc(entryTitle(entry)))
+			if meta := entryMeta(entry); meta != "" {
+				w.blank()
+				w.wrapped("", "*"+esc(meta)+"*")
+			}
+			if len(entry.Points) > 0 {
+				w.blank()
+				for _, point := range entry.Points {
+					w.wrapped("- ", esc(point))
+				}
+			}
+		}
+	}
+	items := func(title string, items []ResumeItem) {
+		if len(items) == 0 {
+			return
+		}
+		w.blank()
+		w.line("## " + title)
+		w.blank()
+		for _, item := range items {
+			w.wrapped("- ", esc(formatResumeItem(item)))
+		}
+	}
+
+	paragraph("Summary", view.Summary)
+	entries("Experience", view.Experience)
+	entries("Education", view.Education)
+	paragraph("Skills", strings.Join(view.Skills, ", "))
+	entries("Projects", view.Projects)
+	items("Certifications", view.Certifications)
+	items("Languages", view.Languages)
+	items("Awards", view.Awards)
+	paragraph("Interests", view.Interests)
+	paragraph("References", view.References)
+
+	return w.String()
+}
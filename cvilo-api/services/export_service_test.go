@@ -0,0 +1,95 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/smhnaqvi/cvilo/models"
+)
+
+func TestWrapText(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		width    int
+		expected []string
+	}{
+		{name: "Fits on one line", text: "Built APIs in Go", width: 40, expected: []string{"Built APIs in Go"}},
+		{name: "Wraps on spaces", text: "Built APIs in Go", width: 10, expected: []string{"Built APIs", "in Go"}},
+		{name: "Keeps long words whole", text: "internationalization rocks", width: 10, expected: []string{"internationalization", "rocks"}},
+		{name: "Collapses whitespace", text: "  Built   APIs  ", width: 0, expected: []string{"Built APIs"}},
+		{name: "Empty text", text: "", width: 10, expected: []string{""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := wrapText(tt.text, tt.width)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("wrapText(%q, %d) = %q, want %q", tt.text, tt.width, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExportServiceExport(t *testing.T) {
+	resume := &models.ResumeModel{
+		FullName: "Jane Doe",
+		Email:    "jane@example.com",
+		Summary:  "Backend engineer building reliable distributed systems for fintech companies",
+		Experience: `[
+			{"company":"Initech","position":"Engineer","start_date":"2018-01-01T00:00:00Z","end_date":"2020-12-01T00:00:00Z","description":"Maintained *legacy* reports"},
+			{"company":"Acme","position":"Senior Engineer","start_date":"2021-03-01T00:00:00Z","is_current":true,"description":"Led the migration of the payments platform to Go and PostgreSQL"}
+		]`,
+		Skills: `[{"name":"Go"},{"name":"PostgreSQL"}]`,
+	}
+	service := NewExportService()
+
+	text, err := service.Export(resume, ExportOptions{Format: ExportFormatText, Width: 40})
+	if err != nil {
+		t.Fatalf("Export(txt) error: %v", err)
+	}
+	expectedText := `JANE DOE
+Senior Engineer
+jane@example.com
+
+SUMMARY
+-------
+Backend engineer building reliable
+distributed systems for fintech
+companies
+
+EXPERIENCE
+----------
+Senior Engineer, Acme
+Mar 2021 – Present
+- Led the migration of the payments
+  platform to Go and PostgreSQL
+
+Engineer, Initech
+Jan 2018 – Dec 2020
+- Maintained *legacy* reports
+
+SKILLS
+------
+Go, PostgreSQL
+`
+	if string(text.Content) != expectedText {
+		t.Errorf("Export(txt) =\n%s\nwant\n%s", text.Content, expectedText)
+	}
+
+	markdown, err := service.Export(resume, ExportOptions{Format: ExportFormatMarkdown})
+	if err != nil {
+		t.Fatalf("Export(md) error: %v", err)
+	}
+	for _, want := range []string{"# Jane Doe\n", "## Experience\n\n### Senior Engineer, Acme\n\n*Mar 2021 – Present*\n", `- Maintained \*legacy\* reports`} {
+		if !strings.Contains(string(markdown.Content), want) {
+			t.Errorf("Export(md) does not contain %q:\n%s", want, markdown.Content)
+		}
+	}
+
+	if _, err := service.Export(resume, ExportOptions{Format: "pdf"}); !errors.Is(err, ErrUnsupportedExportFormat) {
+		t.Errorf("Export(pdf) error = %v, want %v", err, ErrUnsupportedExportFormat)
+	}
+}
@@ -0,0 +1,615 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ledongthuc/pdf"
+	"github.com/smhnaqvi/cvilo/models"
+)
+
+// Supported upload formats
+const (
+	ImportFormatPDF  = "pdf"
+	ImportFormatDOCX = "docx"
+)
+
+// Import methods
+const (
+	ImportMethodHeuristic = "heuristic"
+	ImportMethodAI        = "ai"
+)
+
+// MaxImportFileSize is the largest resume upload accepted, in bytes
+const MaxImportFileSize = 10 << 20
+
+var (
+	ErrUnsupportedImportFormat = errors.New("unsupported file format, upload a PDF or DOCX resume")
+	ErrNoResumeText            = errors.New("no text found in the uploaded file, scanned resumes are not supported")
+)
+
+// DetectedSection is a resume section found in the extracted text
+type DetectedSection struct {
+	Name    string `json:"name"`
+	Heading string `json:"heading"`
+	Lines   int    `json:"lines"`
+}
+
+// ResumeImport is the result of parsing an uploaded resume
+type ResumeImport struct {
+	Format   string            `json:"format"`
+	Method   string            `json:"method"`
+	Provider string            `json:"provider,omitempty"`
+	Text     string            `json:"text"`
+	Sections []DetectedSection `json:"sections"`
+	Resume   *AIResumeResponse `json:"parsed_resume"`
+	Warnings []string          `json:"warnings"`
+}
+
+// ImportService turns uploaded PDF and DOCX resumes into structured resumes
+type ImportService struct {
+	aiService *AIService
+}
+
+func NewImportService(aiService *AIService) *ImportService {
+	return &ImportService{aiService: aiService}
+}
+
+// Import extracts the text of an uploaded resume and parses it.
+// When useAI is set and the AI service is configured the text is parsed by the AI,
+// falling back to heuristic section detection if the AI fails.
+func (is *ImportService) Import(ctx context.Context, filename string, data []byte, useAI bool) (*ResumeImport, error) {
+	format, err := detectImportFormat(filename, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var text string
+	switch format {
+	case ImportFormatPDF:
+		text, err = ExtractPDFText(data)
+	case ImportFormatDOCX:
+		text, err = ExtractDOCXText(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, ErrNoResumeText
+	}
+
+	sections := splitResumeText(text)
+	result := &ResumeImport{
+		Format:   format,
+		Method:   ImportMethodHeuristic,
+		Text:     text,
+		Warnings: []string{},
+	}
+	for _, section := range sections {
+		if section.name != "" {
+			result.Sections = append(result.Sections, DetectedSection{Name: section.name, Heading: section.heading, Lines: len(section.lines)})
+		}
+	}
+
+	if useAI && is.aiService.IsConfigured() {
+		parsed, provider, err := is.aiService.ParseResumeText(ctx, text)
+		if err == nil {
+			result.Method = ImportMethodAI
+			result.Provider = provider
+			result.Resume = parsed
+			return result, nil
+		}
+		result.Warnings = append(result.Warnings, "AI parsing failed, sections were detected heuristically: "+err.Error())
+	}
+
+	parsed, warnings := parseResumeSections(sections)
+	result.Resume = parsed
+	result.Warnings = append(result.Warnings, warnings...)
+	return result, nil
+}
+
+// detectImportFormat identifies PDF and DOCX uploads by their content, using the extension only to tell
+// DOCX apart from other zip files
+func detectImportFormat(filename string, data []byte) (string, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte("%PDF-")):
+		return ImportFormatPDF, nil
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")) && strings.EqualFold(filepath.Ext(filename), ".docx"):
+		return ImportFormatDOCX, nil
+	}
+	return "", ErrUnsupportedImportFormat
+}
+
+// ExtractPDFText extracts the text of a PDF, one line per line of text on the page
+func ExtractPDFText(data []byte) (text string, err error) {
+	// The PDF reader panics on malformed files
+	defer func() {
+		if r := recover(); r != nil {
+			text, err = "", fmt.Errorf("failed to read PDF: %v", r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("failed to read PDF: %v", err)
+	}
+
+	var sb strings.Builder
+	for i := 1; i <= reader.NumPage(); i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		for _, line := range pdfTextLines(page.Content().Text) {
+			sb.WriteString(line)
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// pdfTextLines groups the glyphs of a page into lines, top to bottom, inserting spaces at visible gaps
+func pdfTextLines(glyphs []pdf.Text) []string {
+	sort.SliceStable(glyphs, func(i, j int) bool {
+		return glyphs[i].Y > glyphs[j].Y
+	})
+
+	var lines [][]pdf.Text
+	for _, glyph := range glyphs {
+		tolerance := glyph.FontSize / 2
+		if n := len(lines); n > 0 && lines[n-1][0].Y-glyph.Y <= tolerance {
+			lines[n-1] = append(lines[n-1], glyph)
+			continue
+		}
+		lines = append(lines, []pdf.Text{glyph})
+	}
+
+	var result []string
+	for _, line := range lines {
+		sort.SliceStable(line, func(i, j int) bool { return line[i].X < line[j].X })
+
+		var sb strings.Builder
+		var end float64
+		for i, glyph := range line {
+			width := glyph.W
+			if width == 0 {
+				// Fonts without widths, assume an average glyph width
+				width = glyph.FontSize / 2
+			}
+			if i > 0 && glyph.X-end > glyph.FontSize/4 && !strings.HasSuffix(sb.String(), " ") && glyph.S != " " {
+				sb.WriteString(" ")
+			}
+			sb.WriteString(glyph.S)
+			end = glyph.X + width
+		}
+		if text := strings.Join(strings.Fields(sb.String()), " "); text != "" {
+			result = append(result, text)
+		}
+	}
+	return result
+}
+
+// ExtractDOCXText extracts the paragraphs of a DOCX document, one per line.
+// List paragraphs are prefixed with "- " so bullets survive section detection.
+func ExtractDOCXText(data []byte) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("failed to read DOCX: %v", err)
+	}
+
+	var document *zip.File
+	for _, f := range archive.File {
+		if f.Name == "word/document.xml" {
+			document = f
+			break
+		}
+	}
+	if document == nil {
+		return "", fmt.Errorf("failed to read DOCX: word/document.xml is missing")
+	}
+
+	rc, err := document.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to read DOCX: %v", err)
+	}
+	defer rc.Close()
+
+	var sb, paragraph strings.Builder
+	isListItem := false
+	decoder := xml.NewDecoder(io.LimitReader(rc, MaxImportFileSize*4))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read DOCX: %v", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				paragraph.Reset()
+				isListItem = false
+			case "numPr":
+				isListItem = true
+			case "pStyle":
+				// Bullets are often numbered through the paragraph style, such as ListBullet
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "val" && strings.HasPrefix(attr.Value, "List") {
+						isListItem = true
+					}
+				}
+			case "tab":
+				paragraph.WriteString("\t")
+			case "br", "cr":
+				paragraph.WriteString("\n")
+			case "t":
+				var value string
+				if err := decoder.DecodeElement(&value, &t); err != nil {
+					return "", fmt.Errorf("failed to read DOCX: %v", err)
+				}
+				paragraph.WriteString(value)
+			}
+		case xml.EndElement:
+			if t.Name.Local == "p" {
+				text := strings.TrimSpace(paragraph.String())
+				if text == "" {
+					continue
+				}
+				if isListItem {
+					text = "- " + text
+				}
+				sb.WriteString(text)
+				sb.WriteString("\n")
+			}
+		}
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// resumeSectionHeadings maps the headings commonly used in resumes to section names
+var resumeSectionHeadings = map[string]string{
+	"summary":                   "summary",
+	"profile":                   "summary",
+	"professional summary":      "summary",
+	"about":                     "summary",
+	"about me":                  "summary",
+	"objective":                 "summary",
+	"experience":                "experience",
+	"work experience":           "experience",
+	"professional experience":   "experience",
+	"employment":                "experience",
+	"employment history":        "experience",
+	"work history":              "experience",
+	"career history":            "experience",
+	"education":                 "education",
+	"academic background":       "education",
+	"skills":                    "skills",
+	"technical skills":          "skills",
+	"core competencies":         "skills",
+	"competencies":              "skills",
+	"expertise":                 "skills",
+	"projects":                  "projects",
+	"certifications":            "certifications",
+	"certificates":              "certifications",
+	"licenses & certifications": "certifications",
+	"languages":                 "languages",
+	"awards":                    "awards",
+	"honors":                    "awards",
+	"honors & awards":           "awards",
+	"achievements":              "awards",
+	"interests":                 "interests",
+	"hobbies":                   "interests",
+	"references":                "references",
+}
+
+// resumeTextSection is a block of lines under a heading, the first block being the unnamed header
+type resumeTextSection struct {
+	name    string
+	heading string
+	lines   []string
+}
+
+// splitResumeText splits extracted text into sections at recognised headings
+func splitResumeText(text string) []resumeTextSection {
+	sections := []resumeTextSection{{}}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || isUnderline(line) {
+			continue
+		}
+		key := strings.ToLower(strings.TrimRight(strings.TrimLeft(line, "#* "), ":* "))
+		if name, ok := resumeSectionHeadings[key]; ok {
+			sections = append(sections, resumeTextSection{name: name, heading: line})
+			continue
+		}
+		sections[len(sections)-1].lines = append(sections[len(sections)-1].lines, line)
+	}
+	return sections
+}
+
+// isUnderline reports whether a line only underlines the previous one, as in plain-text resumes
+func isUnderline(line string) bool {
+	return strings.Trim(line, "-=_") == ""
+}
+
+var (
+	emailPattern    = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	phonePattern    = regexp.MustCompile(`\+?\d[\d\s().\-]{7,}\d`)
+	urlPattern      = regexp.MustCompile(`(?i)(?:https?://)?(?:www\.)?[a-z0-9\-]+(?:\.[a-z0-9\-]+)*\.[a-z]{2,}(?:/[^\s|,]*)?`)
+	dateRangeRegexp = regexp.MustCompile(`(?i)((?:jan|feb|mar|apr|may|jun|jul|aug|sep|sept|oct|nov|dec)[a-z]*\.?\s+\d{4}|\d{1,2}/\d{4}|\d{4})\s*(?:-|–|—|to)\s*((?:jan|feb|mar|apr|may|jun|jul|aug|sep|sept|oct|nov|dec)[a-z]*\.?\s+\d{4}|\d{1,2}/\d{4}|\d{4}|present|current|now|today)`)
+	bulletPattern   = regexp.MustCompile(`^[-•*▪◦·]\s*`)
+	headerSplitter  = regexp.MustCompile(`\s+(?:at|@|\||—|–|-)\s+|,\s+`)
+)
+
+// parseResumeSections builds a resume from the detected sections.
+// Experience and education entries are recognised by their date ranges, skills by separators.
+// Sections that cannot be parsed reliably are reported as warnings.
+func parseResumeSections(sections []resumeTextSection) (*AIResumeResponse, []string) {
+	resume := &AIResumeResponse{Template: DefaultResumeTemplate, Theme: "blue"}
+	var warnings []string
+
+	parseResumeHeader(resume, sections[0].lines)
+
+	for _, section := range sections[1:] {
+		switch section.name {
+		case "summary":
+			resume.Summary = strings.Join(section.lines, " ")
+		case "experience":
+			for _, entry := range parseDatedEntries(section.lines) {
+				exp := models.WorkExperience{
+					StartDate:   entry.start,
+					EndDate:     entry.end,
+					IsCurrent:   entry.current,
+					Location:    entry.location,
+					Description: strings.Join(entry.points, "\n"),
+				}
+				exp.Position, exp.Company = splitEntryHeader(entry.header)
+				resume.Experience = append(resume.Experience, exp)
+			}
+			if len(resume.Experience) == 0 && len(section.lines) > 0 {
+				warnings = append(warnings, "experience: no date ranges found, positions could not be separated")
+			}
+		case "education":
+			for _, entry := range parseDatedEntries(section.lines) {
+				edu := models.Education{
+					StartDate:   entry.start,
+					EndDate:     entry.end,
+					Location:    entry.location,
+					Description: strings.Join(entry.points, "\n"),
+				}
+				degree, institution := splitEntryHeader(entry.header)
+				if isInstitution(degree) && !isInstitution(institution) {
+					degree, institution = institution, degree
+				}
+				edu.Institution = institution
+				edu.Degree, edu.FieldOfStudy = degree, ""
+				if parts := strings.SplitN(degree, " in ", 2); len(parts) == 2 {
+					edu.Degree, edu.FieldOfStudy = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+				}
+				resume.Education = append(resume.Education, edu)
+			}
+			if len(resume.Education) == 0 && len(section.lines) > 0 {
+				warnings = append(warnings, "education: no date ranges found, degrees could not be separated")
+			}
+		case "skills":
+			resume.Skills = append(resume.Skills, parseSkillLines(section.lines)...)
+		case "languages":
+			for _, item := range splitListItems(section.lines) {
+				language := models.Language{Name: item}
+				if name, proficiency, ok := strings.Cut(item, "("); ok {
+					language = models.Language{Name: strings.TrimSpace(name), Proficiency: strings.TrimSpace(strings.TrimSuffix(proficiency, ")"))}
+				} else if name, proficiency, ok := strings.Cut(item, ":"); ok {
+					language = models.Language{Name: strings.TrimSpace(name), Proficiency: strings.TrimSpace(proficiency)}
+				}
+				resume.Languages = append(resume.Languages, language)
+			}
+		case "awards":
+			resume.Awards = joinPoints(section.lines)
+		case "interests":
+			resume.Interests = strings.Join(splitListItems(section.lines), ", ")
+		case "references":
+			resume.References = strings.Join(section.lines, "\n")
+		default:
+			warnings = append(warnings, fmt.Sprintf("%s: section detected but not imported, add it after reviewing the resume", section.name))
+		}
+	}
+
+	return resume, warnings
+}
+
+// parseResumeHeader reads the name and contact details from the lines above the first section
+func parseResumeHeader(resume *AIResumeResponse, lines []string) {
+	for i, line := range lines {
+		if i == 0 && !emailPattern.MatchString(line) {
+			resume.FullName = line
+			continue
+		}
+
+		if email := emailPattern.FindString(line); email != "" && resume.Email == "" {
+			resume.Email = email
+		}
+		rest := emailPattern.ReplaceAllString(line, " ")
+		for _, url := range urlPattern.FindAllString(rest, -1) {
+			switch lower := strings.ToLower(url); {
+			case strings.Contains(lower, "linkedin.com"):
+				resume.LinkedIn = url
+			case strings.Contains(lower, "github.com"):
+				resume.GitHub = url
+			case resume.Website == "":
+				resume.Website = url
+			}
+		}
+		rest = urlPattern.ReplaceAllString(rest, " ")
+		if phone := phonePattern.FindString(rest); phone != "" && resume.Phone == "" {
+			resume.Phone = strings.TrimSpace(phone)
+		}
+	}
+}
+
+// datedEntry is an experience or education entry recognised by its date range
+type datedEntry struct {
+	header   string
+	location string
+	start    time.Time
+	end      *time.Time
+	current  bool
+	points   []string
+}
+
+// parseDatedEntries splits section lines into entries. Every line with a date range starts an entry,
+// with the non-bullet lines right above it (at most two) as its header. Other lines are description points.
+func parseDatedEntries(lines []string) []datedEntry {
+	var entries []datedEntry
+	var pending []string
+
+	addPoints := func(points ...string) {
+		if len(entries) == 0 {
+			return
+		}
+		last := &entries[len(entries)-1]
+		for _, point := range points {
+			last.points = append(last.points, bulletPattern.ReplaceAllString(point, ""))
+		}
+	}
+
+	for _, line := range lines {
+		match := dateRangeRegexp.FindStringSubmatchIndex(line)
+		if match == nil {
+			if bulletPattern.MatchString(line) {
+				// Lines above a bullet belong to the description, not to the next header
+				addPoints(pending...)
+				addPoints(line)
+				pending = nil
+				continue
+			}
+			pending = append(pending, line)
+			continue
+		}
+
+		if len(pending) > 2 {
+			addPoints(pending[:len(pending)-2]...)
+			pending = pending[len(pending)-2:]
+		}
+
+		entry := datedEntry{}
+		entry.start = parseResumeTextDate(line[match[2]:match[3]])
+		endText := strings.ToLower(line[match[4]:match[5]])
+		switch endText {
+		case "present", "current", "now", "today":
+			entry.current = true
+		default:
+			if end := parseResumeTextDate(endText); !end.IsZero() {
+				entry.end = &end
+			}
+		}
+
+		var rest []string
+		for _, part := range strings.Split(line[:match[0]]+"|"+line[match[1]:], "|") {
+			if part = strings.Trim(part, " ,()–—-"); part != "" {
+				rest = append(rest, part)
+			}
+		}
+		header := pending
+		if len(pending) == 0 && len(rest) > 0 {
+			// Title and dates on the same line
+			header, rest = rest[:1], rest[1:]
+		}
+		entry.header = strings.Join(header, ", ")
+		entry.location = strings.Join(rest, ", ")
+		pending = nil
+		entries = append(entries, entry)
+	}
+
+	addPoints(pending...)
+	return entries
+}
+
+// parseResumeTextDate parses dates such as "Mar 2021", "March 2021", "03/2021" or "2021"
+func parseResumeTextDate(value string) time.Time {
+	value = strings.Join(strings.Fields(strings.ReplaceAll(value, ".", "")), " ")
+	if strings.HasPrefix(strings.ToLower(value), "sept ") {
+		value = "Sep" + value[4:]
+	}
+	// Month names are matched case-insensitively
+	for _, layout := range []string{"Jan 2006", "January 2006", "01/2006", "1/2006", "2006"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// splitEntryHeader splits a header such as "Senior Engineer, Acme" or "Senior Engineer at Acme" in two
+func splitEntryHeader(header string) (string, string) {
+	parts := headerSplitter.Split(header, 2)
+	if len(parts) == 1 {
+		return strings.TrimSpace(parts[0]), ""
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+}
+
+// isInstitution reports whether a name looks like a school rather than a degree
+func isInstitution(name string) bool {
+	lower := strings.ToLower(name)
+	for _, word := range []string{"university", "college", "school", "institute", "academy", "polytechnic"} {
+		if strings.Contains(lower, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSkillLines reads skills separated by commas, semicolons, pipes or bullets.
+// Lines such as "Backend: Go, SQL" set the category of their skills.
+func parseSkillLines(lines []string) []models.Skill {
+	var skills []models.Skill
+	for _, line := range lines {
+		category := ""
+		line = bulletPattern.ReplaceAllString(line, "")
+		if name, rest, ok := strings.Cut(line, ":"); ok && len(strings.Fields(name)) <= 3 {
+			category, line = strings.TrimSpace(name), rest
+		}
+		for _, item := range splitListItems([]string{line}) {
+			skills = append(skills, models.Skill{Name: item, Category: category})
+		}
+	}
+	return skills
+}
+
+// splitListItems splits lines into items on commas, semicolons, pipes and bullets
+func splitListItems(lines []string) []string {
+	var items []string
+	for _, line := range lines {
+		for _, item := range strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || r == ';' || r == '|' || r == '•' || r == '·'
+		}) {
+			if item = strings.TrimSpace(bulletPattern.ReplaceAllString(strings.TrimSpace(item), "")); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+// joinPoints joins lines into a newline separated list without bullet characters
+func joinPoints(lines []string) string {
+	var points []string
+	for _, line := range lines {
+		points = append(points, bulletPattern.ReplaceAllString(line, ""))
+	}
+	return strings.Join(points, "\n")
+}
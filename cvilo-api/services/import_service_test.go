@@ -0,0 +1,215 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smhnaqvi/cvilo/models"
+)
+
+func TestParseResumeTextDate(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{value: "Mar 2021", expected: "2021-03"},
+		{value: "march 2021", expected: "2021-03"},
+		{value: "Sept. 2019", expected: "2019-09"},
+		{value: "September 2019", expected: "2019-09"},
+		{value: "03/2021", expected: "2021-03"},
+		{value: "2018", expected: "2018-01"},
+		{value: "spring", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			result := ""
+			if date := parseResumeTextDate(tt.value); !date.IsZero() {
+				result = date.Format("2006-01")
+			}
+			if result != tt.expected {
+				t.Errorf("parseResumeTextDate(%q) = %q, want %q", tt.value, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseResumeSections(t *testing.T) {
+	text := `Jane Doe
+jane@example.com | +49 30 1234567 | linkedin.com/in/janedoe | https://janedoe.dev
+
+SUMMARY
+-------
+Backend engineer building payment systems.
+
+Work Experience
+Senior Engineer, Acme
+Mar 2021 – Present | Berlin
+- Led the migration to Go
+- Owned billing
+Engineer at Initech 01/2018 - 12/2020
+Maintained reports
+
+Education
+BSc in Computer Science, TU Berlin University
+2014 - 2018
+
+Skills:
+Backend: Go, PostgreSQL
+Docker | Kubernetes
+
+Projects
+Cvilo, resume builder`
+
+	resume, warnings := parseResumeSections(splitResumeText(text))
+
+	if resume.FullName != "Jane Doe" || resume.Email != "jane@example.com" || resume.Phone != "+49 30 1234567" {
+		t.Errorf("parseResumeSections() contact = %q/%q/%q", resume.FullName, resume.Email, resume.Phone)
+	}
+	if resume.LinkedIn != "linkedin.com/in/janedoe" || resume.Website != "https://janedoe.dev" {
+		t.Errorf("parseResumeSections() links = %q/%q", resume.LinkedIn, resume.Website)
+	}
+	if resume.Summary != "Backend engineer building payment systems." {
+		t.Errorf("parseResumeSections() summary = %q", resume.Summary)
+	}
+
+	end := time.Date(2020, 12, 1, 0, 0, 0, 0, time.UTC)
+	expectedExperience := []models.WorkExperience{
+		{Position: "Senior Engineer", Company: "Acme", Location: "Berlin", StartDate: time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC), IsCurrent: true, Description: "Led the migration to Go\nOwned billing"},
+		{Position: "Engineer", Company: "Initech", StartDate: time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC), EndDate: &end, Description: "Maintained reports"},
+	}
+	if !reflect.DeepEqual(resume.Experience, expectedExperience) {
+		t.Errorf("parseResumeSections() experience = %+v, want %+v", resume.Experience, expectedExperience)
+	}
+
+	if len(resume.Education) != 1 {
+		t.Fatalf("parseResumeSections() education = %d entries, want 1", len(resume.Education))
+	}
+	edu := resume.Education[0]
+	if edu.Degree != "BSc" || edu.FieldOfStudy != "Computer Science" || edu.Institution != "TU Berlin University" {
+		t.Errorf("parseResumeSections() education = %+v", edu)
+	}
+
+	expectedSkills := []models.Skill{
+		{Name: "Go", Category: "Backend"},
+		{Name: "PostgreSQL", Category: "Backend"},
+		{Name: "Docker"},
+		{Name: "Kubernetes"},
+	}
+	if !reflect.DeepEqual(resume.Skills, expectedSkills) {
+		t.Errorf("parseResumeSections() skills = %+v, want %+v", resume.Skills, expectedSkills)
+	}
+
+	if len(warnings) != 1 || !strings.HasPrefix(warnings[0], "projects:") {
+		t.Errorf("parseResumeSections() warnings = %q, want a projects warning", warnings)
+	}
+}
+
+func TestExtractDOCXText(t *testing.T) {
+	resume := &models.ResumeModel{
+		FullName:   "Jane Doe",
+		Email:      "jane@example.com",
+		Experience: `[{"company":"Acme","position":"Senior Engineer","start_date":"2021-03-01T00:00:00Z","is_current":true,"description":"Led the migration to Go"}]`,
+		Skills:     `[{"name":"Go"},{"name":"SQL"}]`,
+	}
+	docx, err := NewDOCXService().GenerateResumeDOCX(resume)
+	if err != nil {
+		t.Fatalf("GenerateResumeDOCX() error: %v", err)
+	}
+
+	text, err := ExtractDOCXText(docx)
+	if err != nil {
+		t.Fatalf("ExtractDOCXText() error: %v", err)
+	}
+	for _, expected := range []string{"Jane Doe\n", "Experience\nSenior Engineer, Acme\n", "- Led the migration to Go\n", "Skills\n"} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("ExtractDOCXText() = %q, want it to contain %q", text, expected)
+		}
+	}
+
+	parsed, _ := parseResumeSections(splitResumeText(text))
+	if len(parsed.Experience) != 1 || parsed.Experience[0].Company != "Acme" {
+		t.Errorf("parseResumeSections() of a DOCX export experience = %+v", parsed.Experience)
+	}
+}
+
+// minimalPDF builds an uncompressed single-page PDF drawing each line with a Courier-width font
+func minimalPDF(lines []string) []byte {
+	var content strings.Builder
+	content.WriteString("BT /F1 12 Tf 72 720 Td\n")
+	for i, line := range lines {
+		if i > 0 {
+			content.WriteString("0 -16 Td\n")
+		}
+		fmt.Fprintf(&content, "(%s) Tj\n", line)
+	}
+	content.WriteString("ET")
+
+	widths := strings.TrimSpace(strings.Repeat("600 ", 95))
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 5 0 R >> >> /Contents 4 0 R >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+		fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /FirstChar 32 /LastChar 126 /Widths [%s] >>", widths),
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func TestExtractPDFText(t *testing.T) {
+	lines := []string{"Jane Doe", "jane@example.com", "Skills", "Go, PostgreSQL"}
+
+	text, err := ExtractPDFText(minimalPDF(lines))
+	if err != nil {
+		t.Fatalf("ExtractPDFText() error: %v", err)
+	}
+	if expected := strings.Join(lines, "\n"); text != expected {
+		t.Errorf("ExtractPDFText() = %q, want %q", text, expected)
+	}
+
+	if _, err := ExtractPDFText([]byte("%PDF-1.4 truncated")); err == nil {
+		t.Errorf("ExtractPDFText() of a truncated file returned no error")
+	}
+}
+
+func TestDetectImportFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		data     string
+		expected string
+	}{
+		{name: "PDF", filename: "cv.pdf", data: "%PDF-1.7", expected: ImportFormatPDF},
+		{name: "PDF without extension", filename: "cv", data: "%PDF-1.7", expected: ImportFormatPDF},
+		{name: "DOCX", filename: "CV.DOCX", data: "PK\x03\x04", expected: ImportFormatDOCX},
+		{name: "Other zip file", filename: "cv.xlsx", data: "PK\x03\x04", expected: ""},
+		{name: "Plain text", filename: "cv.txt", data: "Jane Doe", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _ := detectImportFormat(tt.filename, []byte(tt.data))
+			if result != tt.expected {
+				t.Errorf("detectImportFormat(%s) = %q, want %q", tt.filename, result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// maxJobDescriptionLength limits the text of a job description sent to the model, in bytes
+const maxJobDescriptionLength = 20000
+
+var (
+	ErrInvalidJobDescriptionURL = errors.New("job description URL must be an absolute http or https URL")
+	ErrPrivateJobDescriptionURL = errors.New("job description URL points to a private or local address")
+	ErrNoJobDescriptionText     = errors.New("no text found in the job description")
+)
+
+// FetchedJobDescription is the text of a job posting downloaded from its page
+type FetchedJobDescription struct {
+	URL   string `json:"url"`
+	Title string `json:"title"` // title of the page
+	Text  string `json:"text"`
+}
+
+// JobDescriptionFetcher downloads job postings from the server itself, without a third-party service. Pages on
+// private and local addresses are refused unless allowed, so the fetcher cannot reach internal services.
+type JobDescriptionFetcher struct {
+	client   *http.Client
+	maxBytes int64
+}
+
+// NewJobDescriptionFetcher creates the fetcher configured by the environment: JOB_FETCH_TIMEOUT (default 15s),
+// JOB_FETCH_MAX_BYTES (default 2 MB) and JOB_FETCH_ALLOW_PRIVATE=true to fetch from private addresses, e.g. in
+// development
+func NewJobDescriptionFetcher() *JobDescriptionFetcher {
+	return newJobDescriptionFetcher(
+		envDuration("JOB_FETCH_TIMEOUT", 15*time.Second),
+		int64(envInt("JOB_FETCH_MAX_BYTES", 2<<20)),
+		envBool("JOB_FETCH_ALLOW_PRIVATE", false),
+	)
+}
+
+func newJobDescriptionFetcher(timeout time.Duration, maxBytes int64, allowPrivate bool) *JobDescriptionFetcher {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		// Checked on the resolved address of every connection, redirects included
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip, err := netip.ParseAddr(host); err != nil || !isPublicIP(ip) {
+				return ErrPrivateJobDescriptionURL
+			}
+			return nil
+		}
+	}
+	return &JobDescriptionFetcher{
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: timeout},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 5 {
+					return errors.New("too many redirects")
+				}
+				return checkJobDescriptionURL(req.URL)
+			},
+		},
+		maxBytes: maxBytes,
+	}
+}
+
+// nonPublicPrefixes are the special-purpose ranges of the IANA registries that are not reachable on the internet or
+// that lead to other addresses, such as NAT64 and 6to4 which embed an IPv4 address
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // this network
+	netip.MustParsePrefix("10.0.0.0/8"),      // private
+	netip.MustParsePrefix("100.64.0.0/10"),   // carrier-grade NAT
+	netip.MustParsePrefix("127.0.0.0/8"),     // loopback
+	netip.MustParsePrefix("169.254.0.0/16"),  // link local, including cloud metadata services
+	netip.MustParsePrefix("172.16.0.0/12"),   // private
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // documentation
+	netip.MustParsePrefix("192.88.99.0/24"),  // 6to4 relay anycast
+	netip.MustParsePrefix("192.168.0.0/16"),  // private
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // documentation
+	netip.MustParsePrefix("224.0.0.0/4"),     // multicast
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved and broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // local NAT64
+	netip.MustParsePrefix("100::/64"),        // discard
+	netip.MustParsePrefix("2001::/23"),       // IETF protocol assignments, including Teredo
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+	netip.MustParsePrefix("2002::/16"),       // 6to4
+	netip.MustParsePrefix("fc00::/7"),        // unique local
+	netip.MustParsePrefix("fe80::/10"),       // link local
+}
+
+// isPublicIP reports whether ip is a global unicast address outside nonPublicPrefixes. IPv4-mapped IPv6 addresses
+// are checked as IPv4.
+func isPublicIP(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func checkJobDescriptionURL(u *url.URL) error {
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidJobDescriptionURL
+	}
+	return nil
+}
+
+// Fetch downloads a job posting and returns its text. HTML pages are reduced to their visible text; plain text is
+// returned as it is.
+func (f *JobDescriptionFetcher) Fetch(ctx context.Context, rawURL string) (*FetchedJobDescription, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, ErrInvalidJobDescriptionURL
+	}
+	if err := checkJobDescriptionURL(u); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, ErrInvalidJobDescriptionURL
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,text/plain;q=0.9")
+	req.Header.Set("User-Agent", "cvilo/1.0 (job description fetcher)")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		// Refused addresses and redirects are reported without the details of the request
+		for _, refused := range []error{ErrPrivateJobDescriptionURL, ErrInvalidJobDescriptionURL} {
+			if errors.Is(err, refused) {
+				return nil, refused
+			}
+		}
+		return nil, fmt.Errorf("failed to fetch job description: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch job description: %s answered %s", u.Host, resp.Status)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "" && mediaType != "text/html" && mediaType != "application/xhtml+xml" && mediaType != "text/plain" {
+		return nil, fmt.Errorf("failed to fetch job description: unsupported content type %s, link an HTML page", mediaType)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch job description: %v", err)
+	}
+	if int64(len(data)) > f.maxBytes {
+		return nil, fmt.Errorf("failed to fetch job description: the page is larger than %d bytes", f.maxBytes)
+	}
+
+	fetched := &FetchedJobDescription{URL: resp.Request.URL.String()}
+	if mediaType == "text/plain" {
+		fetched.Text = strings.TrimSpace(string(data))
+	} else {
+		fetched.Title, fetched.Text = ExtractHTMLText(data)
+	}
+	if fetched.Text == "" {
+		return nil, ErrNoJobDescriptionText
+	}
+	return fetched, nil
+}
+
+// skippedHTMLElements hold no visible text or only the navigation around the content of a page
+var skippedHTMLElements = map[atom.Atom]bool{
+	atom.Head: true, atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Svg: true, atom.Iframe: true, atom.Nav: true, atom.Footer: true, atom.Form: true, atom.Button: true,
+}
+
+// blockHTMLElements start a new line of text
+var blockHTMLElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Br: true, atom.Li: true, atom.Ul: true, atom.Ol: true, atom.Tr: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Section: true, atom.Article: true, atom.Main: true, atom.Header: true, atom.Blockquote: true,
+	atom.Dt: true, atom.Dd: true, atom.Pre: true, atom.Table: true,
+}
+
+// ExtractHTMLText returns the title and the visible text of an HTML page, one line per paragraph, heading or list
+// item. List items start with "- ".
+func ExtractHTMLText(data []byte) (title string, text string) {
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return "", ""
+	}
+
+	var lines []string
+	var line strings.Builder
+	endLine := func() {
+		if content := strings.Join(strings.Fields(line.String()), " "); content != "" && content != "-" {
+			lines = append(lines, content)
+		}
+		line.Reset()
+	}
+
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode {
+			if skippedHTMLElements[node.DataAtom] {
+				return
+			}
+			if blockHTMLElements[node.DataAtom] {
+				endLine()
+				if node.DataAtom == atom.Li {
+					line.WriteString("- ")
+				}
+				defer endLine()
+			}
+		}
+		if node.Type == html.TextNode {
+			line.WriteString(node.Data)
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	// The title is in the skipped head
+	if titleNode := findHTMLElement(doc, atom.Title); titleNode != nil && titleNode.FirstChild != nil {
+		title = strings.Join(strings.Fields(titleNode.FirstChild.Data), " ")
+	}
+	walk(doc)
+	endLine()
+	return title, strings.Join(lines, "\n")
+}
+
+func findHTMLElement(node *html.Node, a atom.Atom) *html.Node {
+	if node.Type == html.ElementNode && node.DataAtom == a {
+		return node
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if found := findHTMLElement(child, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// truncateJobDescription shortens a job description to the length sent to the model, at a line break if possible
+func truncateJobDescription(text string) string {
+	if len(text) <= maxJobDescriptionLength {
+		return text
+	}
+	text = text[:maxJobDescriptionLength]
+	if i := strings.LastIndex(text, "\n"); i > maxJobDescriptionLength/2 {
+		return text[:i]
+	}
+	return strings.ToValidUTF8(text, "")
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testJobPosting = `<!DOCTYPE html>
+<html>
+<head><title> Backend Engineer - Acme </title><style>p { color: red }</style></head>
+<body>
+<nav><a href="/">Jobs</a> <a href="/about">About</a></nav>
+<main>
+  <h1>Backend   Engineer</h1>
+  <p>We build <b>payment</b> systems in Go.</p>
+  <h2>Requirements</h2>
+  <ul><li>5 years of Go</li><li>PostgreSQL<br>or MySQL</li><li></li></ul>
+  <script>track("view")</script>
+</main>
+<footer>© Acme</footer>
+</body>
+</html>`
+
+func TestExtractHTMLText(t *testing.T) {
+	title, text := ExtractHTMLText([]byte(testJobPosting))
+
+	if title != "Backend Engineer - Acme" {
+		t.Errorf("title = %q, want Backend Engineer - Acme", title)
+	}
+	expected := "Backend Engineer\nWe build payment systems in Go.\nRequirements\n- 5 years of Go\n- PostgreSQL\nor MySQL"
+	if text != expected {
+		t.Errorf("text = %q, want %q", text, expected)
+	}
+}
+
+func TestJobDescriptionFetcher(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/job":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte(testJobPosting))
+		case "/job.txt":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("  Go developer wanted\n"))
+		case "/moved":
+			http.Redirect(w, r, "/job", http.StatusFound)
+		case "/job.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write([]byte("%PDF-1.4"))
+		case "/empty":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html><body><script>render()</script></body></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	fetcher := newJobDescriptionFetcher(time.Second, 1<<20, true)
+	tests := []struct {
+		url     string
+		title   string
+		text    string
+		errText string
+	}{
+		{server.URL + "/job", "Backend Engineer - Acme", "Backend Engineer\nWe build payment systems in Go.", ""},
+		{server.URL + "/moved", "Backend Engineer - Acme", "Backend Engineer", ""},
+		{server.URL + "/job.txt", "", "Go developer wanted", ""},
+		{server.URL + "/job.pdf", "", "", "unsupported content type application/pdf"},
+		{server.URL + "/missing", "", "", "404 Not Found"},
+		{server.URL + "/empty", "", "", ErrNoJobDescriptionText.Error()},
+		{"ftp://example.com/job", "", "", ErrInvalidJobDescriptionURL.Error()},
+		{"/job", "", "", ErrInvalidJobDescriptionURL.Error()},
+	}
+
+	for _, tt := range tests {
+		fetched, err := fetcher.Fetch(context.Background(), tt.url)
+		if tt.errText != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.errText) {
+				t.Errorf("Fetch(%s) error = %v, want %s", tt.url, err, tt.errText)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Fetch(%s) unexpected error %v", tt.url, err)
+			continue
+		}
+		if fetched.Title != tt.title || !strings.HasPrefix(fetched.Text, tt.text) || fetched.URL != strings.Replace(tt.url, "/moved", "/job", 1) {
+			t.Errorf("Fetch(%s) = %+v, want title %q and text starting with %q", tt.url, fetched, tt.title, tt.text)
+		}
+	}
+
+	// Without allowing private addresses the local test server is refused, also behind a redirect
+	public := newJobDescriptionFetcher(time.Second, 1<<20, false)
+	if _, err := public.Fetch(context.Background(), server.URL+"/job"); !errors.Is(err, ErrPrivateJobDescriptionURL) {
+		t.Errorf("Fetch() of a local address error = %v, want %v", err, ErrPrivateJobDescriptionURL)
+	}
+
+	small := newJobDescriptionFetcher(time.Second, 100, true)
+	if _, err := small.Fetch(context.Background(), server.URL+"/job"); err == nil || !strings.Contains(err.Error(), "larger than 100 bytes") {
+		t.Errorf("Fetch() of a large page error = %v, want the size limit", err)
+	}
+}
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"8.8.8.8", true},
+		{"2606:4700:4700::1111", true},
+		{"::ffff:93.184.216.34", true},
+		{"0.0.0.0", false},
+		{"0.1.2.3", false},
+		{"10.1.2.3", false},
+		{"100.64.0.1", false},
+		{"100.127.255.254", false},
+		{"127.0.0.1", false},
+		{"169.254.169.254", false},
+		{"172.16.0.1", false},
+		{"192.0.0.8", false},
+		{"192.0.2.1", false},
+		{"192.168.1.1", false},
+		{"198.18.0.1", false},
+		{"198.19.255.254", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		{"::", false},
+		{"::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+		{"64:ff9b::a00:1", false},
+		{"64:ff9b::5db8:d822", false},
+		{"2001:db8::1", false},
+		{"2002:a00:1::1", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+		{"ff02::1", false},
+	}
+
+	for _, tt := range tests {
+		if got := isPublicIP(netip.MustParseAddr(tt.ip)); got != tt.want {
+			t.Errorf("isPublicIP(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
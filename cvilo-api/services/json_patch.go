@@ -0,0 +1,289 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// JSONPatchOperation is an operation of a JSON Patch (RFC 6902)
+type JSONPatchOperation struct {
+	Op    string          `json:"op" jsonschema:"enum=add|remove|replace|move|copy|test"`
+	Path  string          `json:"path" jsonschema_description:"JSON Pointer to the value, e.g. /summary or /experience/0/description; /experience/- appends"`
+	From  string          `json:"from,omitempty" jsonschema_description:"JSON Pointer to the source of move and copy"`
+	Value json.RawMessage `json:"value,omitempty" jsonschema_description:"The new value of add, replace and test"`
+}
+
+// ApplyJSONPatch applies the operations of a JSON Patch to a JSON document in order. The patch is atomic: when an
+// operation fails, the error names it and no patched document is returned.
+func ApplyJSONPatch(document []byte, patch []JSONPatchOperation) ([]byte, error) {
+	doc, err := decodeJSONValue(document)
+	if err != nil {
+		return nil, fmt.Errorf("invalid document: %v", err)
+	}
+	for i, operation := range patch {
+		doc, err = applyJSONPatchOperation(doc, operation)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %v", i+1, operation.Op, operation.Path, err)
+		}
+	}
+	return json.Marshal(doc)
+}
+
+func applyJSONPatchOperation(doc interface{}, operation JSONPatchOperation) (interface{}, error) {
+	path, err := parseJSONPointer(operation.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch operation.Op {
+	case "add", "replace", "test":
+		if len(operation.Value) == 0 {
+			return nil, errors.New("value is missing")
+		}
+		value, err := decodeJSONValue(operation.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value: %v", err)
+		}
+		switch operation.Op {
+		case "add":
+			return addJSONValue(doc, path, value)
+		case "replace":
+			return replaceJSONValue(doc, path, value)
+		}
+		current, err := getJSONValue(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !equalJSONValues(current, value) {
+			return nil, errors.New("test failed, the value differs")
+		}
+		return doc, nil
+	case "remove":
+		doc, _, err := removeJSONValue(doc, path)
+		return doc, err
+	case "move", "copy":
+		from, err := parseJSONPointer(operation.From)
+		if err != nil {
+			return nil, fmt.Errorf("from: %v", err)
+		}
+		if operation.Op == "move" {
+			if isJSONPointerPrefix(from, path) && len(from) < len(path) {
+				return nil, errors.New("cannot move a value into itself")
+			}
+			doc, value, err := removeJSONValue(doc, from)
+			if err != nil {
+				return nil, fmt.Errorf("from: %v", err)
+			}
+			return addJSONValue(doc, path, value)
+		}
+		value, err := getJSONValue(doc, from)
+		if err != nil {
+			return nil, fmt.Errorf("from: %v", err)
+		}
+		copied, _ := decodeJSONValue(mustMarshalJSON(value))
+		return addJSONValue(doc, path, copied)
+	}
+	return nil, fmt.Errorf("unknown op %q", operation.Op)
+}
+
+// parseJSONPointer splits a JSON Pointer (RFC 6901) into its unescaped reference tokens
+func parseJSONPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("path %q must start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func isJSONPointerPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func getJSONValue(doc interface{}, path []string) (interface{}, error) {
+	for i, token := range path {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%s does not exist", formatJSONPointer(path[:i+1]))
+			}
+			doc = value
+		case []interface{}:
+			index, err := jsonArrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", formatJSONPointer(path[:i+1]), err)
+			}
+			doc = node[index]
+		default:
+			return nil, fmt.Errorf("%s is not an object or array", formatJSONPointer(path[:i]))
+		}
+	}
+	return doc, nil
+}
+
+// updateJSONParent calls update with the container holding the last token of path and that token, and stores the
+// container it returns, as adding to or removing from an array creates a new slice
+func updateJSONParent(doc interface{}, path []string, update func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return update(doc, path[0])
+	}
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		child, ok := node[path[0]]
+		if !ok {
+			return nil, fmt.Errorf("/%s does not exist", path[0])
+		}
+		updated, err := updateJSONParent(child, path[1:], update)
+		if err != nil {
+			return nil, err
+		}
+		node[path[0]] = updated
+		return node, nil
+	case []interface{}:
+		index, err := jsonArrayIndex(path[0], len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := updateJSONParent(node[index], path[1:], update)
+		if err != nil {
+			return nil, err
+		}
+		node[index] = updated
+		return node, nil
+	}
+	return nil, fmt.Errorf("/%s: not an object or array", path[0])
+}
+
+func addJSONValue(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return updateJSONParent(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			node[token] = value
+			return node, nil
+		case []interface{}:
+			index := len(node)
+			if token != "-" {
+				var err error
+				if index, err = jsonArrayIndex(token, len(node)); err != nil {
+					return nil, err
+				}
+			}
+			node = append(node, nil)
+			copy(node[index+1:], node[index:])
+			node[index] = value
+			return node, nil
+		}
+		return nil, fmt.Errorf("cannot add %s to a value that is not an object or array", token)
+	})
+}
+
+func replaceJSONValue(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	if _, err := getJSONValue(doc, path); err != nil {
+		return nil, err
+	}
+	return updateJSONParent(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			node[token] = value
+			return node, nil
+		case []interface{}:
+			index, _ := jsonArrayIndex(token, len(node)-1)
+			node[index] = value
+			return node, nil
+		}
+		return parent, nil
+	})
+}
+
+func removeJSONValue(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, errors.New("cannot remove the whole document")
+	}
+	removed, err := getJSONValue(doc, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	doc, err = updateJSONParent(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			delete(node, token)
+			return node, nil
+		case []interface{}:
+			index, _ := jsonArrayIndex(token, len(node)-1)
+			return append(node[:index:index], node[index+1:]...), nil
+		}
+		return parent, nil
+	})
+	return doc, removed, err
+}
+
+// jsonArrayIndex parses an array index token, which must be between 0 and max
+func jsonArrayIndex(token string, max int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%q is not an array index", token)
+	}
+	if index > max {
+		return 0, fmt.Errorf("index %d is out of range, the array has %d entries", index, max+1)
+	}
+	return index, nil
+}
+
+func formatJSONPointer(path []string) string {
+	var pointer strings.Builder
+	for _, token := range path {
+		pointer.WriteString("/" + strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1"))
+	}
+	return pointer.String()
+}
+
+// decodeJSONValue decodes a JSON value keeping numbers as written
+func decodeJSONValue(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("text after the value")
+	}
+	return value, nil
+}
+
+func mustMarshalJSON(value interface{}) []byte {
+	data, err := json.Marshal(value)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// equalJSONValues compares two decoded values; json.Marshal sorts object keys, so equal values encode the same
+func equalJSONValues(a, b interface{}) bool {
+	return bytes.Equal(mustMarshalJSON(a), mustMarshalJSON(b))
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestApplyJSONPatch(t *testing.T) {
+	document := `{"summary": "Go developer", "skills": [{"name": "Go"}, {"name": "SQL"}], "a/b": {"~c": 1}}`
+
+	tests := []struct {
+		name     string
+		patch    string
+		expected string
+		errText  string
+	}{
+		{
+			name:     "replace a field",
+			patch:    `[{"op": "replace", "path": "/summary", "value": "Senior Go developer"}]`,
+			expected: `{"a/b":{"~c":1},"skills":[{"name":"Go"},{"name":"SQL"}],"summary":"Senior Go developer"}`,
+		},
+		{
+			name:     "add to an array",
+			patch:    `[{"op": "add", "path": "/skills/1", "value": {"name": "Rust"}}, {"op": "add", "path": "/skills/-", "value": {"name": "Docker"}}]`,
+			expected: `{"a/b":{"~c":1},"skills":[{"name":"Go"},{"name":"Rust"},{"name":"SQL"},{"name":"Docker"}],"summary":"Go developer"}`,
+		},
+		{
+			name:     "remove, move and copy",
+			patch:    `[{"op": "remove", "path": "/a~1b/~0c"}, {"op": "move", "from": "/skills/1", "path": "/skills/0"}, {"op": "copy", "from": "/summary", "path": "/objective"}]`,
+			expected: `{"a/b":{},"objective":"Go developer","skills":[{"name":"SQL"},{"name":"Go"}],"summary":"Go developer"}`,
+		},
+		{
+			name:     "passing test",
+			patch:    `[{"op": "test", "path": "/skills/0", "value": {"name": "Go"}}, {"op": "remove", "path": "/skills/0"}]`,
+			expected: `{"a/b":{"~c":1},"skills":[{"name":"SQL"}],"summary":"Go developer"}`,
+		},
+		{
+			name:    "failing test",
+			patch:   `[{"op": "remove", "path": "/summary"}, {"op": "test", "path": "/skills/0/name", "value": "SQL"}]`,
+			errText: "operation 2 (test /skills/0/name): test failed",
+		},
+		{
+			name:    "index out of range",
+			patch:   `[{"op": "replace", "path": "/skills/2", "value": {"name": "Go"}}]`,
+			errText: "operation 1 (replace /skills/2): /skills/2: index 2 is out of range, the array has 2 entries",
+		},
+		{
+			name:    "replace a missing field",
+			patch:   `[{"op": "replace", "path": "/objective", "value": "Lead"}]`,
+			errText: "/objective does not exist",
+		},
+		{
+			name:    "move into itself",
+			patch:   `[{"op": "move", "from": "/skills", "path": "/skills/0"}]`,
+			errText: "cannot move a value into itself",
+		},
+		{
+			name:    "unknown op",
+			patch:   `[{"op": "merge", "path": "/summary", "value": "x"}]`,
+			errText: `unknown op "merge"`,
+		},
+		{
+			name:    "missing value",
+			patch:   `[{"op": "add", "path": "/objective"}]`,
+			errText: "value is missing",
+		},
+	}
+
+	for _, tt := range tests {
+		var patch []JSONPatchOperation
+		if err := json.Unmarshal([]byte(tt.patch), &patch); err != nil {
+			t.Fatalf("%s: invalid patch: %v", tt.name, err)
+		}
+		patched, err := ApplyJSONPatch([]byte(document), patch)
+		if tt.errText != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.errText) {
+				t.Errorf("%s: error = %v, want %s", tt.name, err, tt.errText)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+		if string(patched) != tt.expected {
+			t.Errorf("%s: patched = %s, want %s", tt.name, patched, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,382 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSchemaProblems limits the problems a validation reports, enough for the model to correct its answer
+const maxSchemaProblems = 20
+
+// JSONSchema is the subset of JSON Schema the answers of the models are described with. Objects are closed and
+// list all their properties as required, as structured outputs in strict mode demand; optional values are nullable
+// instead. Validation still accepts answers without the properties Go would omit when empty.
+type JSONSchema struct {
+	// Title names the schema in response formats and tools, e.g. "resume"
+	Title       string
+	Description string
+	// Type is a JSON type such as "object" or "string"; empty for any value
+	Type string
+	// Nullable allows null, used for pointers
+	Nullable bool
+	// Format is "date-time" for timestamps
+	Format           string
+	Enum             []string
+	Minimum, Maximum *float64
+	// Properties of an object, in the order the model should write them
+	Properties []JSONSchemaProperty
+	// Items of an array
+	Items *JSONSchema
+}
+
+// JSONSchemaProperty is a property of an object schema
+type JSONSchemaProperty struct {
+	Name   string
+	Schema *JSONSchema
+	// OmitEmpty marks a property tagged omitempty, which may be missing
+	OmitEmpty bool
+}
+
+// SchemaViolationError lists the problems of an answer that does not match its schema
+type SchemaViolationError struct {
+	Problems []string
+}
+
+func (e *SchemaViolationError) Error() string {
+	return "answer does not match the schema: " + strings.Join(e.Problems, "; ")
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// NewJSONSchema describes the JSON encoding of a Go value by reflection. Properties are named by their json tag and
+// documented by the jsonschema_description tag; the jsonschema tag sets bounds and allowed values, e.g.
+// `jsonschema:"minimum=1,maximum=5"` or `jsonschema:"enum=add|remove"`. A json.RawMessage may hold any value.
+func NewJSONSchema(value interface{}) *JSONSchema {
+	return schemaOf(reflect.TypeOf(value))
+}
+
+func schemaOf(t reflect.Type) *JSONSchema {
+	if t.Kind() == reflect.Ptr {
+		schema := schemaOf(t.Elem())
+		schema.Nullable = true
+		return schema
+	}
+	switch t {
+	case timeType:
+		return &JSONSchema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return &JSONSchema{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &JSONSchema{Type: "array", Items: schemaOf(t.Elem())}
+	case reflect.Struct:
+		schema := &JSONSchema{Type: "object"}
+		schema.addFields(t)
+		return schema
+	}
+	panic(fmt.Sprintf("json schema: unsupported type %s", t))
+}
+
+// addFields adds the encoded fields of a struct as properties, inlining embedded structs like encoding/json does
+func (s *JSONSchema) addFields(t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			s.addFields(field.Type)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := schemaOf(field.Type)
+		property.Description = field.Tag.Get("jsonschema_description")
+		for _, option := range strings.Split(field.Tag.Get("jsonschema"), ",") {
+			key, value, _ := strings.Cut(option, "=")
+			if key == "enum" {
+				property.Enum = strings.Split(value, "|")
+				continue
+			}
+			bound, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			switch key {
+			case "minimum":
+				property.Minimum = &bound
+			case "maximum":
+				property.Maximum = &bound
+			}
+		}
+		s.Properties = append(s.Properties, JSONSchemaProperty{
+			Name:      name,
+			Schema:    property,
+			OmitEmpty: strings.Contains(","+options+",", ",omitempty,"),
+		})
+	}
+}
+
+// MarshalJSON writes the schema with its properties in order
+func (s *JSONSchema) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	write := func(key string, value interface{}) error {
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(strconv.Quote(key) + ":")
+		buf.Write(encoded)
+		return nil
+	}
+
+	var err error
+	if s.Title != "" {
+		err = write("title", s.Title)
+	}
+	if err == nil && s.Description != "" {
+		err = write("description", s.Description)
+	}
+	if err == nil && s.Type != "" {
+		if s.Nullable {
+			err = write("type", []string{s.Type, "null"})
+		} else {
+			err = write("type", s.Type)
+		}
+	}
+	if err == nil && s.Format != "" {
+		err = write("format", s.Format)
+	}
+	if err == nil && len(s.Enum) > 0 {
+		err = write("enum", s.Enum)
+	}
+	if err == nil && s.Minimum != nil {
+		err = write("minimum", *s.Minimum)
+	}
+	if err == nil && s.Maximum != nil {
+		err = write("maximum", *s.Maximum)
+	}
+	if err == nil && s.Type == "object" {
+		err = s.writeProperties(&buf)
+	}
+	if err == nil && s.Items != nil {
+		err = write("items", s.Items)
+	}
+	if err != nil {
+		return nil, err
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (s *JSONSchema) writeProperties(buf *bytes.Buffer) error {
+	required := make([]string, 0, len(s.Properties))
+	buf.WriteString(`,"properties":{`)
+	for i, property := range s.Properties {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		encoded, err := json.Marshal(property.Schema)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(strconv.Quote(property.Name) + ":")
+		buf.Write(encoded)
+		required = append(required, property.Name)
+	}
+	encoded, _ := json.Marshal(required)
+	buf.WriteString(`},"required":`)
+	buf.Write(encoded)
+	buf.WriteString(`,"additionalProperties":false`)
+	return nil
+}
+
+// Strict reports whether the schema can be enforced by structured outputs in strict mode, which need a type for
+// every value
+func (s *JSONSchema) Strict() bool {
+	if s.Type == "" {
+		return false
+	}
+	for _, property := range s.Properties {
+		if !property.Schema.Strict() {
+			return false
+		}
+	}
+	return s.Items == nil || s.Items.Strict()
+}
+
+// String returns the schema as JSON for prompts
+func (s *JSONSchema) String() string {
+	encoded, _ := json.Marshal(s)
+	return string(encoded)
+}
+
+// Validate checks a JSON document against the schema and returns a *SchemaViolationError listing its problems
+func (s *JSONSchema) Validate(data []byte) error {
+	value, err := decodeJSONValue(data)
+	if err != nil {
+		return &SchemaViolationError{Problems: []string{"not valid JSON: " + err.Error()}}
+	}
+
+	var problems []string
+	s.validate("", value, &problems)
+	if len(problems) > maxSchemaProblems {
+		problems = append(problems[:maxSchemaProblems], "more problems omitted")
+	}
+	if len(problems) > 0 {
+		return &SchemaViolationError{Problems: problems}
+	}
+	return nil
+}
+
+func (s *JSONSchema) validate(path string, value interface{}, problems *[]string) {
+	if len(*problems) >= maxSchemaProblems {
+		return
+	}
+	report := func(format string, args ...interface{}) {
+		at := path
+		if at == "" {
+			at = "answer"
+		}
+		*problems = append(*problems, at+": "+fmt.Sprintf(format, args...))
+	}
+
+	if s.Type == "" {
+		return
+	}
+	if value == nil {
+		if !s.Nullable {
+			report("must not be null, expected %s", s.Type)
+		}
+		return
+	}
+
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			report("expected an object")
+			return
+		}
+		// Unknown properties first, they often explain the missing ones, like "Full Name" for "full_name"
+		known := make(map[string]bool, len(s.Properties))
+		for _, property := range s.Properties {
+			known[property.Name] = true
+		}
+		var unknown []string
+		for name := range object {
+			if !known[name] {
+				unknown = append(unknown, name)
+			}
+		}
+		sort.Strings(unknown)
+		for _, name := range unknown {
+			*problems = append(*problems, joinSchemaPath(path, name)+": unknown property")
+		}
+		for _, property := range s.Properties {
+			propertyValue, present := object[property.Name]
+			if !present {
+				if !property.OmitEmpty {
+					*problems = append(*problems, joinSchemaPath(path, property.Name)+": missing")
+				}
+				continue
+			}
+			property.Schema.validate(joinSchemaPath(path, property.Name), propertyValue, problems)
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			report("expected an array")
+			return
+		}
+		for i, item := range items {
+			s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, problems)
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			report("expected a string")
+			return
+		}
+		if len(s.Enum) > 0 && !containsString(s.Enum, str) {
+			report("%q is not one of %s", str, strings.Join(s.Enum, ", "))
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				report("%q is not an RFC 3339 date-time such as 2020-01-01T00:00:00Z", str)
+			}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			report("expected a boolean")
+		}
+	case "integer", "number":
+		number, ok := value.(json.Number)
+		if !ok && s.Type == "integer" {
+			report("expected an integer")
+			return
+		}
+		if !ok {
+			report("expected a number")
+			return
+		}
+		if s.Type == "integer" {
+			if _, err := number.Int64(); err != nil {
+				report("%s is not an integer", number)
+				return
+			}
+		}
+		f, _ := number.Float64()
+		if s.Minimum != nil && f < *s.Minimum {
+			report("%s is less than the minimum %v", number, *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			report("%s is greater than the maximum %v", number, *s.Maximum)
+		}
+	}
+}
+
+func joinSchemaPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// extractJSON returns the JSON object in the answer of a model, without markdown code fences or text around it
+func extractJSON(content string) string {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end < start {
+		return strings.TrimSpace(content)
+	}
+	return content[start : end+1]
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewJSONSchema(t *testing.T) {
+	type period struct {
+		Start time.Time  `json:"start"`
+		End   *time.Time `json:"end,omitempty" jsonschema_description:"null while ongoing"`
+	}
+	type entry struct {
+		period
+		Name     string   `json:"name"`
+		Level    int      `json:"level" jsonschema:"minimum=1,maximum=5"`
+		Tags     []string `json:"tags"`
+		Internal string   `json:"-"`
+		hidden   string
+	}
+
+	encoded, err := json.Marshal(NewJSONSchema(entry{}))
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	expected := `{"type":"object","properties":{` +
+		`"start":{"type":"string","format":"date-time"},` +
+		`"end":{"description":"null while ongoing","type":["string","null"],"format":"date-time"},` +
+		`"name":{"type":"string"},` +
+		`"level":{"type":"integer","minimum":1,"maximum":5},` +
+		`"tags":{"type":"array","items":{"type":"string"}}},` +
+		`"required":["start","end","name","level","tags"],"additionalProperties":false}`
+	if string(encoded) != expected {
+		t.Errorf("schema = %s, want %s", encoded, expected)
+	}
+}
+
+func TestJSONSchemaValidate(t *testing.T) {
+	var resume map[string]interface{}
+	json.Unmarshal([]byte(testResume("Jane Doe")), &resume)
+	with := func(change func(resume map[string]interface{})) string {
+		copied := map[string]interface{}{}
+		for key, value := range resume {
+			copied[key] = value
+		}
+		change(copied)
+		encoded, _ := json.Marshal(copied)
+		return string(encoded)
+	}
+
+	tests := []struct {
+		name     string
+		document string
+		problems []string
+	}{
+		{"valid", testResume("Jane Doe"), nil},
+		{"not JSON", `{"full_name": `, []string{"not valid JSON"}},
+		{"missing property", with(func(r map[string]interface{}) { delete(r, "summary") }), []string{"summary: missing"}},
+		{"unknown property", with(func(r map[string]interface{}) { r["Full Name"] = "Jane" }), []string{"Full Name: unknown property"}},
+		{"wrong type", with(func(r map[string]interface{}) { r["skills"] = "Go, SQL" }), []string{"skills: expected an array"}},
+		{"null array", with(func(r map[string]interface{}) { r["projects"] = nil }), []string{"projects: must not be null"}},
+		{"nested problems", `{"full_name": "Jane", "experience": [{"start_date": "January 2020", "end_date": null}], "skills": [{"level": 7.5}]}`, []string{
+			`experience[0].start_date: "January 2020" is not an RFC 3339 date-time`,
+			"experience[0].position: missing",
+			"skills[0].level: 7.5 is not an integer",
+		}},
+	}
+
+	for _, tt := range tests {
+		err := resumeSchema.Validate([]byte(tt.document))
+		if tt.problems == nil {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s: error = nil, want %v", tt.name, tt.problems)
+			continue
+		}
+		for _, problem := range tt.problems {
+			if !strings.Contains(err.Error(), problem) {
+				t.Errorf("%s: error = %v, want it to contain %q", tt.name, err, problem)
+			}
+		}
+	}
+}
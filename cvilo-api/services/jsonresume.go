@@ -0,0 +1,672 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/smhnaqvi/cvilo/models"
+)
+
+// JSONResumeSchema is the schema URL written to exported JSON Resume documents
+const JSONResumeSchema = "https://raw.githubusercontent.com/jsonresume/resume-schema/v1.0.0/schema.json"
+
+// JSONResume is a resume in the jsonresume.org schema.
+// Only the sections that map onto ResumeModel are declared, see jsonResumeKnownFields.
+type JSONResume struct {
+	Schema       string                  `json:"$schema,omitempty"`
+	Basics       JSONResumeBasics        `json:"basics"`
+	Work         []JSONResumeWork        `json:"work,omitempty"`
+	Education    []JSONResumeEducation   `json:"education,omitempty"`
+	Skills       []JSONResumeSkill       `json:"skills,omitempty"`
+	Languages    []JSONResumeLanguage    `json:"languages,omitempty"`
+	Certificates []JSONResumeCertificate `json:"certificates,omitempty"`
+	Projects     []JSONResumeProject     `json:"projects,omitempty"`
+	Awards       []JSONResumeAward       `json:"awards,omitempty"`
+	Interests    []JSONResumeInterest    `json:"interests,omitempty"`
+	References   []JSONResumeReference   `json:"references,omitempty"`
+	Meta         *JSONResumeMeta         `json:"meta,omitempty"`
+}
+
+type JSONResumeBasics struct {
+	Name     string              `json:"name,omitempty"`
+	Label    string              `json:"label,omitempty"`
+	Email    string              `json:"email,omitempty"`
+	Phone    string              `json:"phone,omitempty"`
+	URL      string              `json:"url,omitempty"`
+	Summary  string              `json:"summary,omitempty"`
+	Location *JSONResumeLocation `json:"location,omitempty"`
+	Profiles []JSONResumeProfile `json:"profiles,omitempty"`
+}
+
+type JSONResumeLocation struct {
+	Address     string `json:"address,omitempty"`
+	PostalCode  string `json:"postalCode,omitempty"`
+	City        string `json:"city,omitempty"`
+	Region      string `json:"region,omitempty"`
+	CountryCode string `json:"countryCode,omitempty"`
+}
+
+type JSONResumeProfile struct {
+	Network  string `json:"network,omitempty"`
+	Username string `json:"username,omitempty"`
+	URL      string `json:"url,omitempty"`
+}
+
+type JSONResumeWork struct {
+	Name       string   `json:"name,omitempty"`
+	Position   string   `json:"position,omitempty"`
+	Location   string   `json:"location,omitempty"`
+	StartDate  string   `json:"startDate,omitempty"`
+	EndDate    string   `json:"endDate,omitempty"`
+	Summary    string   `json:"summary,omitempty"`
+	Highlights []string `json:"highlights,omitempty"`
+}
+
+type JSONResumeEducation struct {
+	Institution string   `json:"institution,omitempty"`
+	Area        string   `json:"area,omitempty"`
+	StudyType   string   `json:"studyType,omitempty"`
+	StartDate   string   `json:"startDate,omitempty"`
+	EndDate     string   `json:"endDate,omitempty"`
+	Score       string   `json:"score,omitempty"`
+	Courses     []string `json:"courses,omitempty"`
+}
+
+type JSONResumeSkill struct {
+	Name     string   `json:"name,omitempty"`
+	Level    string   `json:"level,omitempty"`
+	Keywords []string `json:"keywords,omitempty"`
+}
+
+type JSONResumeLanguage struct {
+	Language string `json:"language,omitempty"`
+	Fluency  string `json:"fluency,omitempty"`
+}
+
+type JSONResumeCertificate struct {
+	Name   string `json:"name,omitempty"`
+	Date   string `json:"date,omitempty"`
+	Issuer string `json:"issuer,omitempty"`
+	URL    string `json:"url,omitempty"`
+}
+
+type JSONResumeProject struct {
+	Name        string   `json:"name,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Highlights  []string `json:"highlights,omitempty"`
+	Keywords    []string `json:"keywords,omitempty"`
+	StartDate   string   `json:"startDate,omitempty"`
+	EndDate     string   `json:"endDate,omitempty"`
+	URL         string   `json:"url,omitempty"`
+}
+
+type JSONResumeAward struct {
+	Title   string `json:"title,omitempty"`
+	Date    string `json:"date,omitempty"`
+	Awarder string `json:"awarder,omitempty"`
+	Summary string `json:"summary,omitempty"`
+}
+
+type JSONResumeInterest struct {
+	Name     string   `json:"name,omitempty"`
+	Keywords []string `json:"keywords,omitempty"`
+}
+
+type JSONResumeReference struct {
+	Name      string `json:"name,omitempty"`
+	Reference string `json:"reference,omitempty"`
+}
+
+type JSONResumeMeta struct {
+	Version      string `json:"version,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// UnmappedField is a JSON Resume value that could not be stored on the resume
+type UnmappedField struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// jsonResumeKnownFields lists, per object path, the JSON Resume keys that are mapped onto ResumeModel.
+// Array elements share the path of their array.
+var jsonResumeKnownFields = map[string][]string{
+	"":                {"$schema", "basics", "work", "education", "skills", "languages", "certificates", "projects", "awards", "interests", "references"},
+	"basics":          {"name", "label", "email", "phone", "url", "summary", "location", "profiles"},
+	"basics.location": {"address", "postalCode", "city", "region", "countryCode"},
+	"basics.profiles": {"network", "username", "url"},
+	"work":            {"name", "position", "location", "startDate", "endDate", "summary", "highlights"},
+	"education":       {"institution", "area", "studyType", "startDate", "endDate", "score", "courses"},
+	"skills":          {"name", "level", "keywords"},
+	"languages":       {"language", "fluency"},
+	"certificates":    {"name", "date", "issuer", "url"},
+	"projects":        {"name", "description", "highlights", "keywords", "startDate", "endDate", "url"},
+	"awards":          {"title", "date", "awarder", "summary"},
+	"interests":       {"name", "keywords"},
+	"references":      {"name", "reference"},
+}
+
+// skillLevelNames maps ResumeModel skill levels (1-5) to JSON Resume level names
+var skillLevelNames = []string{"", "Beginner", "Elementary", "Intermediate", "Advanced", "Expert"}
+
+// skillLevels maps JSON Resume level names, including common synonyms, to ResumeModel skill levels
+var skillLevels = map[string]int{
+	"beginner": 1, "novice": 1, "basic": 1,
+	"elementary":   2,
+	"intermediate": 3, "competent": 3,
+	"advanced": 4, "proficient": 4,
+	"expert": 5, "master": 5,
+}
+
+// technologiesPrefix marks the highlight that carries the technologies of a position
+const technologiesPrefix = "Technologies:"
+
+// ToJSONResume converts a stored resume to the JSON Resume schema
+func ToJSONResume(resume *models.ResumeModel) (*JSONResume, error) {
+	sections, err := resume.DecodeSections()
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &JSONResume{
+		Schema: JSONResumeSchema,
+		Basics: JSONResumeBasics{
+			Name:    resume.FullName,
+			Label:   resume.Title,
+			Email:   resume.Email,
+			Phone:   resume.Phone,
+			URL:     resume.Website,
+			Summary: resume.Summary,
+		},
+	}
+	if doc.Basics.Summary == "" {
+		doc.Basics.Summary = resume.Objective
+	}
+	if resume.Address != "" {
+		doc.Basics.Location = &JSONResumeLocation{Address: resume.Address}
+	}
+	if resume.LinkedIn != "" {
+		doc.Basics.Profiles = append(doc.Basics.Profiles, JSONResumeProfile{Network: "LinkedIn", URL: resume.LinkedIn})
+	}
+	if resume.GitHub != "" {
+		doc.Basics.Profiles = append(doc.Basics.Profiles, JSONResumeProfile{Network: "GitHub", URL: resume.GitHub})
+	}
+	if !resume.UpdatedAt.IsZero() {
+		doc.Meta = &JSONResumeMeta{Version: "v1.0.0", LastModified: resume.UpdatedAt.UTC().Format(time.RFC3339)}
+	}
+
+	for _, exp := range sections.Experience {
+		work := JSONResumeWork{
+			Name:      exp.Company,
+			Position:  exp.Position,
+			Location:  exp.Location,
+			StartDate: formatJSONResumeDate(exp.StartDate),
+		}
+		if exp.EndDate != nil && !exp.IsCurrent {
+			work.EndDate = formatJSONResumeDate(*exp.EndDate)
+		}
+		work.Summary, work.Highlights = splitSummary(exp.Description)
+		if len(exp.Technologies) > 0 {
+			work.Highlights = append(work.Highlights, technologiesPrefix+" "+strings.Join(exp.Technologies, ", "))
+		}
+		doc.Work = append(doc.Work, work)
+	}
+
+	for _, edu := range sections.Education {
+		education := JSONResumeEducation{
+			Institution: edu.Institution,
+			Area:        edu.FieldOfStudy,
+			StudyType:   edu.Degree,
+			StartDate:   formatJSONResumeDate(edu.StartDate),
+			Score:       edu.GPA,
+			Courses:     splitResumePoints(edu.Description),
+		}
+		if edu.EndDate != nil {
+			education.EndDate = formatJSONResumeDate(*edu.EndDate)
+		}
+		doc.Education = append(doc.Education, education)
+	}
+
+	doc.Skills = groupSkills(sections.Skills)
+
+	for _, lang := range sections.Languages {
+		doc.Languages = append(doc.Languages, JSONResumeLanguage{Language: lang.Name, Fluency: lang.Proficiency})
+	}
+
+	for _, cert := range sections.Certifications {
+		doc.Certificates = append(doc.Certificates, JSONResumeCertificate{
+			Name:   cert.Name,
+			Date:   formatJSONResumeDate(cert.IssueDate),
+			Issuer: cert.Issuer,
+			URL:    cert.URL,
+		})
+	}
+
+	for _, project := range sections.Projects {
+		jsonProject := JSONResumeProject{
+			Name:        project.Name,
+			Description: project.Description,
+			Keywords:    project.Technologies,
+			StartDate:   formatJSONResumeDate(project.StartDate),
+			URL:         project.URL,
+		}
+		if jsonProject.URL == "" {
+			jsonProject.URL = project.GitHub
+		}
+		if project.EndDate != nil {
+			jsonProject.EndDate = formatJSONResumeDate(*project.EndDate)
+		}
+		doc.Projects = append(doc.Projects, jsonProject)
+	}
+
+	for _, award := range sections.Awards {
+		jsonAward := JSONResumeAward{Title: award.Name, Awarder: award.Issuer, Summary: award.Description}
+		if award.IssueDate != nil {
+			jsonAward.Date = formatJSONResumeDate(*award.IssueDate)
+		}
+		doc.Awards = append(doc.Awards, jsonAward)
+	}
+
+	for _, interest := range strings.FieldsFunc(resume.Interests, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if interest = strings.TrimSpace(interest); interest != "" {
+			doc.Interests = append(doc.Interests, JSONResumeInterest{Name: interest})
+		}
+	}
+
+	if references := strings.TrimSpace(resume.References); references != "" {
+		doc.References = append(doc.References, JSONResumeReference{Reference: references})
+	}
+
+	return doc, nil
+}
+
+// splitSummary splits a free-text description into a summary and highlights.
+// Single-line descriptions become the summary, multi-line ones become highlights.
+func splitSummary(description string) (string, []string) {
+	points := splitResumePoints(description)
+	if len(points) == 1 {
+		return points[0], nil
+	}
+	return "", points
+}
+
+// groupSkills groups skills by category into JSON Resume skills with keywords.
+// Uncategorised skills are exported on their own with their level.
+func groupSkills(skills []models.Skill) []JSONResumeSkill {
+	var result []JSONResumeSkill
+	groups := make(map[string]int)
+
+	for _, skill := range skills {
+		if strings.TrimSpace(skill.Name) == "" {
+			continue
+		}
+		if skill.Category == "" {
+			result = append(result, JSONResumeSkill{Name: skill.Name, Level: skillLevelName(skill.Level)})
+			continue
+		}
+
+		index, ok := groups[skill.Category]
+		if !ok {
+			groups[skill.Category] = len(result)
+			result = append(result, JSONResumeSkill{Name: skill.Category, Level: skillLevelName(skill.Level)})
+			index = len(result) - 1
+		} else if result[index].Level != skillLevelName(skill.Level) {
+			// Levels differ within the group, a single group level would be misleading
+			result[index].Level = ""
+		}
+		result[index].Keywords = append(result[index].Keywords, skill.Name)
+	}
+
+	return result
+}
+
+// skillLevelName returns the JSON Resume name of a 1-5 skill level
+func skillLevelName(level int) string {
+	if level < 1 || level >= len(skillLevelNames) {
+		return ""
+	}
+	return skillLevelNames[level]
+}
+
+// formatJSONResumeDate formats a date as YYYY-MM-DD, or returns an empty string for zero dates
+func formatJSONResumeDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+// parseJSONResumeDate parses the YYYY-MM-DD, YYYY-MM and YYYY dates allowed by the schema
+func parseJSONResumeDate(value string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", "2006-01", "2006"} {
+		if t, err := time.Parse(layout, strings.TrimSpace(value)); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+// jsonResumeImporter converts a JSON Resume document and records what could not be mapped
+type jsonResumeImporter struct {
+	unmapped []UnmappedField
+}
+
+func (ji *jsonResumeImporter) report(path string, reason string) {
+	ji.unmapped = append(ji.unmapped, UnmappedField{Path: path, Reason: reason})
+}
+
+// date parses a date, reporting it as unmapped when it is invalid
+func (ji *jsonResumeImporter) date(path string, value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	t, err := parseJSONResumeDate(value)
+	if err != nil {
+		ji.report(path, "invalid date, expected YYYY-MM-DD, YYYY-MM or YYYY")
+	}
+	return t
+}
+
+// optionalDate parses a date that may be absent
+func (ji *jsonResumeImporter) optionalDate(path string, value string) *time.Time {
+	t := ji.date(path, value)
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// ParseJSONResume converts a JSON Resume document into a resume for userID.
+// Fields that have no place in ResumeModel, or whose values cannot be converted, are returned as unmapped.
+func ParseJSONResume(data []byte, userID uint, title string) (*models.ResumeModel, []UnmappedField, error) {
+	var doc JSONResume
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("invalid JSON Resume document: %v", err)
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, nil, fmt.Errorf("invalid JSON Resume document: %v", err)
+	}
+
+	ji := &jsonResumeImporter{}
+	ji.findUnknownFields("", "", raw)
+
+	if title == "" {
+		title = doc.Basics.Label
+	}
+	if title == "" {
+		title = strings.TrimSpace(doc.Basics.Name + " Resume")
+	}
+
+	resume := &models.ResumeModel{
+		UserID:   userID,
+		Title:    title,
+		FullName: doc.Basics.Name,
+		Email:    doc.Basics.Email,
+		Phone:    doc.Basics.Phone,
+		Website:  doc.Basics.URL,
+		Summary:  doc.Basics.Summary,
+		Template: DefaultResumeTemplate,
+		IsActive: true,
+	}
+
+	if location := doc.Basics.Location; location != nil {
+		var parts []string
+		for _, part := range []string{location.Address, location.City, location.Region, location.PostalCode, location.CountryCode} {
+			if part = strings.TrimSpace(part); part != "" {
+				parts = append(parts, part)
+			}
+		}
+		resume.Address = strings.Join(parts, ", ")
+	}
+
+	for i, profile := range doc.Basics.Profiles {
+		url := profile.URL
+		switch strings.ToLower(profile.Network) {
+		case "linkedin":
+			if url == "" && profile.Username != "" {
+				url = "https://www.linkedin.com/in/" + profile.Username
+			}
+			if resume.LinkedIn == "" {
+				resume.LinkedIn = url
+				continue
+			}
+		case "github":
+			if url == "" && profile.Username != "" {
+				url = "https://github.com/" + profile.Username
+			}
+			if resume.GitHub == "" {
+				resume.GitHub = url
+				continue
+			}
+		}
+		ji.report(fmt.Sprintf("basics.profiles[%d]", i), "only one LinkedIn and one GitHub profile can be stored")
+	}
+
+	var experience []models.WorkExperience
+	for i, work := range doc.Work {
+		path := fmt.Sprintf("work[%d]", i)
+		exp := models.WorkExperience{
+			Company:   work.Name,
+			Position:  work.Position,
+			Location:  work.Location,
+			StartDate: ji.date(path+".startDate", work.StartDate),
+			EndDate:   ji.optionalDate(path+".endDate", work.EndDate),
+		}
+		exp.IsCurrent = work.EndDate == "" && !exp.StartDate.IsZero()
+
+		var lines []string
+		if work.Summary != "" {
+			lines = append(lines, work.Summary)
+		}
+		for _, highlight := range work.Highlights {
+			if strings.HasPrefix(highlight, technologiesPrefix) {
+				for _, tech := range strings.Split(strings.TrimPrefix(highlight, technologiesPrefix), ",") {
+					if tech = strings.TrimSpace(tech); tech != "" {
+						exp.Technologies = append(exp.Technologies, tech)
+					}
+				}
+				continue
+			}
+			lines = append(lines, highlight)
+		}
+		exp.Description = strings.Join(lines, "\n")
+		experience = append(experience, exp)
+	}
+
+	var education []models.Education
+	for i, edu := range doc.Education {
+		path := fmt.Sprintf("education[%d]", i)
+		education = append(education, models.Education{
+			Institution:  edu.Institution,
+			Degree:       edu.StudyType,
+			FieldOfStudy: edu.Area,
+			StartDate:    ji.date(path+".startDate", edu.StartDate),
+			EndDate:      ji.optionalDate(path+".endDate", edu.EndDate),
+			GPA:          edu.Score,
+			Description:  strings.Join(edu.Courses, "\n"),
+		})
+	}
+
+	var skills []models.Skill
+	for i, skill := range doc.Skills {
+		level := 0
+		if skill.Level != "" {
+			var ok bool
+			if level, ok = parseSkillLevel(skill.Level); !ok {
+				ji.report(fmt.Sprintf("skills[%d].level", i), "unrecognised level, expected 1-5 or a name such as Intermediate")
+			}
+		}
+		if len(skill.Keywords) == 0 {
+			skills = append(skills, models.Skill{Name: skill.Name, Level: level})
+			continue
+		}
+		for _, keyword := range skill.Keywords {
+			skills = append(skills, models.Skill{Name: keyword, Category: skill.Name, Level: level})
+		}
+	}
+
+	var languages []models.Language
+	for _, lang := range doc.Languages {
+		languages = append(languages, models.Language{Name: lang.Language, Proficiency: lang.Fluency})
+	}
+
+	var certifications []models.Certification
+	for i, cert := range doc.Certificates {
+		certifications = append(certifications, models.Certification{
+			Name:      cert.Name,
+			Issuer:    cert.Issuer,
+			IssueDate: ji.date(fmt.Sprintf("certificates[%d].date", i), cert.Date),
+			URL:       cert.URL,
+		})
+	}
+
+	var projects []models.Project
+	for i, project := range doc.Projects {
+		path := fmt.Sprintf("projects[%d]", i)
+		lines := []string{}
+		if project.Description != "" {
+			lines = append(lines, project.Description)
+		}
+		lines = append(lines, project.Highlights...)
+		projects = append(projects, models.Project{
+			Name:         project.Name,
+			Description:  strings.Join(lines, "\n"),
+			Technologies: project.Keywords,
+			StartDate:    ji.date(path+".startDate", project.StartDate),
+			EndDate:      ji.optionalDate(path+".endDate", project.EndDate),
+			URL:          project.URL,
+		})
+	}
+
+	var awards []models.Award
+	for i, award := range doc.Awards {
+		awards = append(awards, models.Award{
+			Name:        award.Title,
+			Issuer:      award.Awarder,
+			Description: award.Summary,
+			IssueDate:   ji.optionalDate(fmt.Sprintf("awards[%d].date", i), award.Date),
+		})
+	}
+
+	var interests []string
+	for _, interest := range doc.Interests {
+		if len(interest.Keywords) > 0 {
+			interests = append(interests, fmt.Sprintf("%s (%s)", interest.Name, strings.Join(interest.Keywords, ", ")))
+		} else if interest.Name != "" {
+			interests = append(interests, interest.Name)
+		}
+	}
+	resume.Interests = strings.Join(interests, ", ")
+
+	var references []string
+	for _, reference := range doc.References {
+		if reference.Name != "" {
+			references = append(references, reference.Name+": "+reference.Reference)
+		} else if reference.Reference != "" {
+			references = append(references, reference.Reference)
+		}
+	}
+	resume.References = strings.Join(references, "\n")
+
+	for _, section := range []struct {
+		target *string
+		value  interface{}
+		empty  bool
+	}{
+		{&resume.Experience, experience, len(experience) == 0},
+		{&resume.Education, education, len(education) == 0},
+		{&resume.Skills, skills, len(skills) == 0},
+		{&resume.Languages, languages, len(languages) == 0},
+		{&resume.Certifications, certifications, len(certifications) == 0},
+		{&resume.Projects, projects, len(projects) == 0},
+		{&resume.Awards, awards, len(awards) == 0},
+	} {
+		if section.empty {
+			*section.target = "[]"
+			continue
+		}
+		encoded, err := json.Marshal(section.value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encode section: %v", err)
+		}
+		*section.target = string(encoded)
+	}
+
+	sort.SliceStable(ji.unmapped, func(i, j int) bool { return ji.unmapped[i].Path < ji.unmapped[j].Path })
+	return resume, ji.unmapped, nil
+}
+
+// parseSkillLevel converts a JSON Resume level such as "Advanced" or "4" to a 1-5 level
+func parseSkillLevel(value string) (int, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if level, err := strconv.Atoi(value); err == nil && level >= 1 && level <= 5 {
+		return level, true
+	}
+	level, ok := skillLevels[value]
+	return level, ok
+}
+
+// findUnknownFields reports non-empty values whose keys are not in jsonResumeKnownFields.
+// schemaPath identifies the object type (without array indices), path the concrete location.
+func (ji *jsonResumeImporter) findUnknownFields(schemaPath string, path string, value interface{}) {
+	switch v := value.(type) {
+	case []interface{}:
+		for i, item := range v {
+			ji.findUnknownFields(schemaPath, fmt.Sprintf("%s[%d]", path, i), item)
+		}
+	case map[string]interface{}:
+		known, ok := jsonResumeKnownFields[schemaPath]
+		if !ok {
+			return
+		}
+		for key, child := range v {
+			childSchema := key
+			childPath := key
+			if schemaPath != "" {
+				childSchema = schemaPath + "." + key
+				childPath = path + "." + key
+			}
+			if !containsString(known, key) {
+				if !isEmptyJSONValue(child) {
+					ji.report(childPath, "no matching resume field")
+				}
+				continue
+			}
+			ji.findUnknownFields(childSchema, childPath, child)
+		}
+	}
+}
+
+// isEmptyJSONValue reports whether a decoded JSON value carries no information
+func isEmptyJSONValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		for _, child := range v {
+			if !isEmptyJSONValue(child) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
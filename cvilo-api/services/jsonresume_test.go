@@ -0,0 +1,165 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/smhnaqvi/cvilo/models"
+)
+
+func TestParseJSONResume(t *testing.T) {
+	doc := `{
+		"basics": {
+			"name": "Jane Doe",
+			"label": "Backend Engineer",
+			"email": "jane@example.com",
+			"image": "https://example.com/jane.png",
+			"location": {"city": "Berlin", "countryCode": "DE"},
+			"profiles": [
+				{"network": "LinkedIn", "username": "janedoe"},
+				{"network": "Twitter", "username": "jane"}
+			]
+		},
+		"work": [{
+			"name": "Acme",
+			"position": "Senior Engineer",
+			"url": "https://acme.example",
+			"startDate": "2021-03",
+			"summary": "Payments platform",
+			"highlights": ["Led the Go migration", "Technologies: Go, PostgreSQL"]
+		}],
+		"education": [{"institution": "TU Berlin", "area": "Computer Science", "studyType": "BSc", "startDate": "2014", "endDate": "spring"}],
+		"skills": [{"name": "Backend", "level": "Advanced", "keywords": ["Go", "SQL"]}, {"name": "Docker", "level": "wizard"}],
+		"awards": [{"title": "Hackathon winner", "date": "2019-05-01", "awarder": "Acme"}],
+		"volunteer": [{"organization": "Code Club"}],
+		"publications": [],
+		"meta": {"canonical": "https://example.com/resume.json", "version": "v1.0.0"}
+	}`
+
+	resume, unmapped, err := ParseJSONResume([]byte(doc), 7, "")
+	if err != nil {
+		t.Fatalf("ParseJSONResume() error: %v", err)
+	}
+
+	if resume.UserID != 7 || resume.Title != "Backend Engineer" || resume.FullName != "Jane Doe" {
+		t.Errorf("ParseJSONResume() basics = %d/%q/%q, want 7/Backend Engineer/Jane Doe", resume.UserID, resume.Title, resume.FullName)
+	}
+	if resume.Address != "Berlin, DE" {
+		t.Errorf("ParseJSONResume() address = %q, want %q", resume.Address, "Berlin, DE")
+	}
+	if resume.LinkedIn != "https://www.linkedin.com/in/janedoe" {
+		t.Errorf("ParseJSONResume() linkedin = %q, want the profile URL", resume.LinkedIn)
+	}
+
+	sections, err := resume.DecodeSections()
+	if err != nil {
+		t.Fatalf("DecodeSections() error: %v", err)
+	}
+	if len(sections.Experience) != 1 {
+		t.Fatalf("ParseJSONResume() experience = %d entries, want 1", len(sections.Experience))
+	}
+	exp := sections.Experience[0]
+	if !exp.IsCurrent || exp.Description != "Payments platform\nLed the Go migration" || !reflect.DeepEqual(exp.Technologies, []string{"Go", "PostgreSQL"}) {
+		t.Errorf("ParseJSONResume() experience = %+v", exp)
+	}
+	expectedSkills := []models.Skill{{Name: "Go", Category: "Backend", Level: 4}, {Name: "SQL", Category: "Backend", Level: 4}, {Name: "Docker"}}
+	if !reflect.DeepEqual(sections.Skills, expectedSkills) {
+		t.Errorf("ParseJSONResume() skills = %+v, want %+v", sections.Skills, expectedSkills)
+	}
+	if len(sections.Awards) != 1 || sections.Awards[0].IssueDate == nil {
+		t.Errorf("ParseJSONResume() awards = %+v, want one dated award", sections.Awards)
+	}
+
+	var paths []string
+	for _, field := range unmapped {
+		paths = append(paths, field.Path)
+	}
+	expectedPaths := []string{
+		"basics.image",
+		"basics.profiles[1]",
+		"education[0].endDate",
+		"meta",
+		"skills[1].level",
+		"volunteer",
+		"work[0].url",
+	}
+	if !reflect.DeepEqual(paths, expectedPaths) {
+		t.Errorf("ParseJSONResume() unmapped = %q, want %q", paths, expectedPaths)
+	}
+}
+
+func TestJSONResumeRoundTrip(t *testing.T) {
+	resume := &models.ResumeModel{
+		Title:      "Backend Engineer",
+		FullName:   "Jane Doe",
+		Email:      "jane@example.com",
+		GitHub:     "https://github.com/janedoe",
+		Summary:    "Backend engineer",
+		Experience: `[{"company":"Acme","position":"Senior Engineer","start_date":"2021-03-01T00:00:00Z","is_current":true,"description":"Led the Go migration\nOwned billing","technologies":["Go"]}]`,
+		Skills:     `[{"name":"Go","category":"Backend","level":4},{"name":"SQL","category":"Backend","level":3},{"name":"Docker","level":2}]`,
+		Languages:  `[{"name":"German","proficiency":"Native"}]`,
+		Awards:     "Hackathon winner",
+	}
+
+	doc, err := ToJSONResume(resume)
+	if err != nil {
+		t.Fatalf("ToJSONResume() error: %v", err)
+	}
+
+	expectedSkills := []JSONResumeSkill{
+		{Name: "Backend", Keywords: []string{"Go", "SQL"}},
+		{Name: "Docker", Level: "Elementary"},
+	}
+	if !reflect.DeepEqual(doc.Skills, expectedSkills) {
+		t.Errorf("ToJSONResume() skills = %+v, want %+v", doc.Skills, expectedSkills)
+	}
+	expectedWork := JSONResumeWork{
+		Name:       "Acme",
+		Position:   "Senior Engineer",
+		StartDate:  "2021-03-01",
+		Highlights: []string{"Led the Go migration", "Owned billing", "Technologies: Go"},
+	}
+	if len(doc.Work) != 1 || !reflect.DeepEqual(doc.Work[0], expectedWork) {
+		t.Errorf("ToJSONResume() work = %+v, want %+v", doc.Work, expectedWork)
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("json.Marshal() error: %v", err)
+	}
+	imported, unmapped, err := ParseJSONResume(data, 1, "")
+	if err != nil {
+		t.Fatalf("ParseJSONResume() error: %v", err)
+	}
+	if len(unmapped) != 0 {
+		t.Errorf("ParseJSONResume() of an export reported unmapped fields %+v", unmapped)
+	}
+
+	tests := []struct {
+		name     string
+		got      string
+		expected string
+	}{
+		{name: "Title", got: imported.Title, expected: resume.Title},
+		{name: "GitHub", got: imported.GitHub, expected: resume.GitHub},
+		{name: "Summary", got: imported.Summary, expected: resume.Summary},
+		{name: "Languages", got: imported.Languages, expected: `[{"name":"German","proficiency":"Native"}]`},
+		{name: "Awards", got: imported.Awards, expected: `[{"name":"Hackathon winner","issuer":""}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.expected {
+				t.Errorf("round trip %s = %s, want %s", tt.name, tt.got, tt.expected)
+			}
+		})
+	}
+
+	sections, err := imported.DecodeSections()
+	if err != nil {
+		t.Fatalf("DecodeSections() error: %v", err)
+	}
+	if exp := sections.Experience[0]; !exp.IsCurrent || exp.Description != "Led the Go migration\nOwned billing" || !reflect.DeepEqual(exp.Technologies, []string{"Go"}) {
+		t.Errorf("round trip experience = %+v", exp)
+	}
+}
@@ -517,7 +517,7 @@ func (s *LinkedInService) SyncUserProfile(userID uint) error {
 
 	// Set user ID and create resume
 	resume.UserID = userID
-	if err := resume.Create(); err != nil {
+	if err := resume.CreateWithChange(models.ResumeChange{AuthorID: userID, Source: models.VersionSourceLinkedIn}); err != nil {
 		return fmt.Errorf("failed to create resume from LinkedIn data: %w", err)
 	}
 
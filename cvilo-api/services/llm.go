@@ -0,0 +1,344 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LLM provider names, as used in LLM_PROVIDERS and recorded in the chat prompt history
+const (
+	LLMProviderOpenAI           = "openai"
+	LLMProviderGitHubModels     = "github_models"
+	LLMProviderOpenAICompatible = "openai_compatible"
+	LLMProviderAnthropic        = "anthropic"
+)
+
+// defaultLLMTimeout bounds a single provider attempt unless LLM_TIMEOUT or LLM_TIMEOUT_<PROVIDER> is set
+const defaultLLMTimeout = 60 * time.Second
+
+// maxSchemaRepairs is how often a provider is asked to correct an answer that does not match the schema of the
+// request before the next provider is tried
+const maxSchemaRepairs = 1
+
+// ErrLLMNotConfigured is returned when no provider of the chain is configured
+var ErrLLMNotConfigured = errors.New("no AI provider is configured - check LLM_PROVIDERS and the provider API keys")
+
+// LLMRequest is a single-turn chat completion
+type LLMRequest struct {
+	System      string
+	Prompt      string
+	Temperature float32
+	MaxTokens   int
+	// Schema is the JSON schema the answer must match, nil for free text. Providers with structured outputs
+	// enforce it; the chain validates every answer against it.
+	Schema *JSONSchema
+}
+
+// ResumeLLM is a chat completion API resumes are generated with
+type ResumeLLM interface {
+	// Name identifies the provider in LLM_PROVIDERS and the chat prompt history
+	Name() string
+	// IsConfigured reports whether the provider has the settings it needs, unconfigured providers are skipped
+	IsConfigured() bool
+	// Complete returns the text the model answers the request with
+	Complete(ctx context.Context, request LLMRequest) (string, error)
+}
+
+// StreamingLLM is a provider that can stream its answer. Providers without streaming answer streamed requests
+// in one piece.
+type StreamingLLM interface {
+	ResumeLLM
+	// Stream calls onDelta with every piece of the answer as it arrives and returns the whole answer
+	Stream(ctx context.Context, request LLMRequest, onDelta func(delta string)) (string, error)
+}
+
+// LLMStreamHandler receives the progress of a streamed chain request
+type LLMStreamHandler struct {
+	// OnAttempt is called when a provider is asked. Pieces received before belong to a failed attempt.
+	OnAttempt func(provider string)
+	// OnDelta is called with every piece of the answer
+	OnDelta func(delta string)
+}
+
+// errLLMIdle cancels a streamed attempt that stopped receiving the answer
+var errLLMIdle = errors.New("LLM stream idle")
+
+// llmRegistry creates the known providers from the environment by name
+var llmRegistry = map[string]func() ResumeLLM{
+	LLMProviderOpenAI:           newOpenAILLMFromEnv,
+	LLMProviderGitHubModels:     newGitHubModelsLLMFromEnv,
+	LLMProviderOpenAICompatible: newOpenAICompatibleLLMFromEnv,
+	LLMProviderAnthropic:        newAnthropicLLMFromEnv,
+}
+
+// RegisterLLMProvider makes a provider available to LLM_PROVIDERS under name
+func RegisterLLMProvider(name string, factory func() ResumeLLM) {
+	llmRegistry[name] = factory
+}
+
+// LLMChain tries its providers in order until one answers. Every attempt is bounded by the timeout of its
+// provider; a provider that fails, times out or answers with a response the caller rejects hands over to the next.
+type LLMChain struct {
+	entries []llmChainEntry
+}
+
+type llmChainEntry struct {
+	llm     ResumeLLM
+	timeout time.Duration
+	// structured sends the schema of requests to the provider; without it the provider only gets the prompt
+	structured bool
+}
+
+// LLMProviderStatus describes a provider of the chain for the AI status endpoint
+type LLMProviderStatus struct {
+	Name             string `json:"name"`
+	Configured       bool   `json:"configured"`
+	Timeout          string `json:"timeout"`
+	StructuredOutput bool   `json:"structured_output"`
+}
+
+// NewLLMChain creates a chain of providers, tried in the given order with the same timeout and structured outputs
+func NewLLMChain(timeout time.Duration, llms ...ResumeLLM) *LLMChain {
+	chain := &LLMChain{}
+	for _, llm := range llms {
+		chain.entries = append(chain.entries, llmChainEntry{llm: llm, timeout: timeout, structured: true})
+	}
+	return chain
+}
+
+// NewLLMChainFromEnv creates the chain configured by the environment. LLM_PROVIDERS lists the providers in order,
+// e.g. "github_models,openai"; without it the chain is GitHub Models then OpenAI when USE_GITHUB_MODELS=true and
+// OpenAI alone otherwise. Attempts time out after LLM_TIMEOUT_<PROVIDER>, LLM_TIMEOUT or 60s. Structured outputs
+// are used unless LLM_STRUCTURED_OUTPUT_<PROVIDER> or LLM_STRUCTURED_OUTPUT is false, for models without them.
+func NewLLMChainFromEnv() *LLMChain {
+	names := os.Getenv("LLM_PROVIDERS")
+	if names == "" {
+		names = LLMProviderOpenAI
+		if os.Getenv("USE_GITHUB_MODELS") == "true" {
+			names = LLMProviderGitHubModels + "," + LLMProviderOpenAI
+		}
+	}
+
+	timeout := envDuration("LLM_TIMEOUT", defaultLLMTimeout)
+	structured := envBool("LLM_STRUCTURED_OUTPUT", true)
+	chain := &LLMChain{}
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		factory, ok := llmRegistry[name]
+		if !ok {
+			log.Printf("LLM: unknown provider %q in LLM_PROVIDERS, skipping it", name)
+			continue
+		}
+		chain.entries = append(chain.entries, llmChainEntry{
+			llm:        factory(),
+			timeout:    envDuration("LLM_TIMEOUT_"+strings.ToUpper(name), timeout),
+			structured: envBool("LLM_STRUCTURED_OUTPUT_"+strings.ToUpper(name), structured),
+		})
+	}
+	if !chain.IsConfigured() {
+		log.Printf("Warning: none of the AI providers %s is configured", names)
+	}
+	return chain
+}
+
+// envDuration reads a duration environment variable such as "90s" with a default
+func envDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return defaultValue
+}
+
+// envBool reads a boolean environment variable such as "false" with a default
+func envBool(key string, defaultValue bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// IsConfigured returns true if at least one provider of the chain can be used
+func (c *LLMChain) IsConfigured() bool {
+	for _, entry := range c.entries {
+		if entry.llm.IsConfigured() {
+			return true
+		}
+	}
+	return false
+}
+
+// Active returns the name of the provider tried first, empty when none is configured
+func (c *LLMChain) Active() string {
+	for _, entry := range c.entries {
+		if entry.llm.IsConfigured() {
+			return entry.llm.Name()
+		}
+	}
+	return ""
+}
+
+// Status describes the providers of the chain in order
+func (c *LLMChain) Status() []LLMProviderStatus {
+	status := make([]LLMProviderStatus, 0, len(c.entries))
+	for _, entry := range c.entries {
+		status = append(status, LLMProviderStatus{
+			Name:             entry.llm.Name(),
+			Configured:       entry.llm.IsConfigured(),
+			Timeout:          entry.timeout.String(),
+			StructuredOutput: entry.structured,
+		})
+	}
+	return status
+}
+
+// Complete sends the request to the configured providers in order and returns the name of the provider whose
+// answer accept took. An answer that does not match the schema of the request, or that accept rejects with a
+// *SchemaViolationError, is sent back to its provider for correction, see maxSchemaRepairs. Any other error from
+// accept, like an unparsable answer, moves on to the next provider. When ctx ends, the chain stops without trying
+// further providers.
+func (c *LLMChain) Complete(ctx context.Context, request LLMRequest, accept func(content string) error) (string, error) {
+	return c.run(ctx, request, accept, func(entry llmChainEntry, request LLMRequest) (string, error) {
+		return c.attempt(ctx, entry, request)
+	})
+}
+
+// Stream works like Complete but streams the answers of the providers to handler. The timeout of a provider
+// bounds the wait for the start of its answer and every pause within it rather than the whole answer, so long
+// answers are not cut off. A correction of an answer is streamed as a new attempt.
+func (c *LLMChain) Stream(ctx context.Context, request LLMRequest, handler LLMStreamHandler, accept func(content string) error) (string, error) {
+	return c.run(ctx, request, accept, func(entry llmChainEntry, request LLMRequest) (string, error) {
+		if handler.OnAttempt != nil {
+			handler.OnAttempt(entry.llm.Name())
+		}
+		return c.attemptStream(ctx, entry, request, handler.OnDelta)
+	})
+}
+
+// run asks the configured providers in order until one gives an answer accept takes and returns the name of that
+// provider
+func (c *LLMChain) run(ctx context.Context, request LLMRequest, accept func(string) error, ask func(llmChainEntry, LLMRequest) (string, error)) (string, error) {
+	var failures []string
+	for _, entry := range c.entries {
+		if !entry.llm.IsConfigured() {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
+		name := entry.llm.Name()
+		err := c.answer(entry, request, accept, ask)
+		if err == nil {
+			return name, nil
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		log.Printf("LLM: %s failed: %v", name, err)
+		failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+	}
+
+	if len(failures) == 0 {
+		return "", ErrLLMNotConfigured
+	}
+	return "", fmt.Errorf("all AI providers failed: %s", strings.Join(failures, "; "))
+}
+
+// answer asks one provider and checks its answer against the schema of the request, asking it to correct an
+// answer that does not match
+func (c *LLMChain) answer(entry llmChainEntry, request LLMRequest, accept func(string) error, ask func(llmChainEntry, LLMRequest) (string, error)) error {
+	schema := request.Schema
+	if !entry.structured {
+		request.Schema = nil
+	}
+
+	for repairs := 0; ; repairs++ {
+		content, err := ask(entry, request)
+		if err != nil {
+			return err
+		}
+		if schema != nil {
+			err = schema.Validate([]byte(extractJSON(content)))
+		}
+		if err == nil && accept != nil {
+			err = accept(content)
+		}
+
+		var violation *SchemaViolationError
+		if err == nil || !errors.As(err, &violation) || repairs >= maxSchemaRepairs {
+			return err
+		}
+		log.Printf("LLM: %s %v, asking for a correction", entry.llm.Name(), err)
+		request = request.repair(content, err)
+	}
+}
+
+// repair builds the request asking the model to correct its answer
+func (r LLMRequest) repair(content string, violation error) LLMRequest {
+	r.Prompt = fmt.Sprintf(`%s
+
+Your previous answer was:
+%s
+
+It was rejected because the %v
+
+Answer again with the corrected JSON object only.`, r.Prompt, content, violation)
+	return r
+}
+
+// attemptStream streams the answer of one provider, cancelling it when no piece arrives within its timeout.
+// Providers that cannot stream are asked with attempt and deliver their answer as a single piece.
+func (c *LLMChain) attemptStream(ctx context.Context, entry llmChainEntry, request LLMRequest, onDelta func(string)) (string, error) {
+	if onDelta == nil {
+		onDelta = func(string) {}
+	}
+	streamer, ok := entry.llm.(StreamingLLM)
+	if !ok {
+		content, err := c.attempt(ctx, entry, request)
+		if err == nil {
+			onDelta(content)
+		}
+		return content, err
+	}
+
+	attemptCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	idle := time.AfterFunc(entry.timeout, func() { cancel(errLLMIdle) })
+	defer idle.Stop()
+
+	content, err := streamer.Stream(attemptCtx, request, func(delta string) {
+		idle.Reset(entry.timeout)
+		onDelta(delta)
+	})
+	if err != nil {
+		if errors.Is(context.Cause(attemptCtx), errLLMIdle) && ctx.Err() == nil {
+			return "", fmt.Errorf("no response for %s", entry.timeout)
+		}
+		return "", err
+	}
+	return content, nil
+}
+
+// attempt asks one provider, bounded by its timeout
+func (c *LLMChain) attempt(ctx context.Context, entry llmChainEntry, request LLMRequest) (string, error) {
+	attemptCtx, cancel := context.WithTimeout(ctx, entry.timeout)
+	defer cancel()
+
+	content, err := entry.llm.Complete(attemptCtx, request)
+	if err != nil {
+		if errors.Is(attemptCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+			return "", fmt.Errorf("timed out after %s", entry.timeout)
+		}
+		return "", err
+	}
+	return content, nil
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// anthropicVersion is the Messages API version the requests are written for
+const anthropicVersion = "2023-06-01"
+
+// anthropicLLM talks to the Anthropic Messages API
+type anthropicLLM struct {
+	client *http.Client
+	apiURL string
+	apiKey string
+	model  string
+}
+
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	MaxTokens   int                `json:"max_tokens"`
+	Temperature float32            `json:"temperature"`
+	Stream      bool               `json:"stream,omitempty"`
+	Tools       []anthropicTool    `json:"tools,omitempty"`
+	ToolChoice  *anthropicChoice   `json:"tool_choice,omitempty"`
+}
+
+// anthropicTool is a tool the model can call; the schema of a request is sent as the only tool, which the model
+// must call with the answer as its input
+type anthropicTool struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	InputSchema *JSONSchema `json:"input_schema"`
+}
+
+type anthropicChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type  string          `json:"type"`
+		Text  string          `json:"text"`
+		Input json.RawMessage `json:"input"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+}
+
+// anthropicStreamEvent is the data of a streamed event; only text and tool input deltas and errors are used
+type anthropicStreamEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+	} `json:"delta"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type anthropicError struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// newAnthropicLLMFromEnv creates the Anthropic provider from ANTHROPIC_API_KEY, ANTHROPIC_MODEL and the optional
+// ANTHROPIC_URL
+func newAnthropicLLMFromEnv() ResumeLLM {
+	return &anthropicLLM{
+		client: &http.Client{},
+		apiURL: envString("ANTHROPIC_URL", "https://api.anthropic.com"),
+		apiKey: os.Getenv("ANTHROPIC_API_KEY"),
+		model:  envString("ANTHROPIC_MODEL", "claude-3-5-sonnet-latest"),
+	}
+}
+
+func (a *anthropicLLM) Name() string {
+	return LLMProviderAnthropic
+}
+
+func (a *anthropicLLM) IsConfigured() bool {
+	return a.apiKey != ""
+}
+
+// send posts a request to the Messages API and returns the response of a successful request
+func (a *anthropicLLM) send(ctx context.Context, request LLMRequest, stream bool) (*http.Response, error) {
+	// The Messages API requires max_tokens
+	maxTokens := request.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = 4000
+	}
+	message := anthropicRequest{
+		Model:       a.model,
+		System:      request.System,
+		Messages:    []anthropicMessage{{Role: "user", Content: request.Prompt}},
+		MaxTokens:   maxTokens,
+		Temperature: request.Temperature,
+		Stream:      stream,
+	}
+	if request.Schema != nil {
+		message.Tools = []anthropicTool{{
+			Name:        request.Schema.Title,
+			Description: request.Schema.Description,
+			InputSchema: request.Schema,
+		}}
+		message.ToolChoice = &anthropicChoice{Type: "tool", Name: request.Schema.Title}
+	}
+	body, err := json.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(a.apiURL, "/")+"/v1/messages", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", a.apiKey)
+	req.Header.Set("anthropic-version", anthropicVersion)
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("API request failed: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		var apiErr anthropicError
+		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Error.Message != "" {
+			return nil, fmt.Errorf("API error: %d - %s: %s", resp.StatusCode, apiErr.Error.Type, apiErr.Error.Message)
+		}
+		return nil, fmt.Errorf("API error: %d - %s", resp.StatusCode, string(respBody))
+	}
+	return resp, nil
+}
+
+// Complete sends the request as a system prompt and a user message. Requests without max_tokens are limited to
+// 4000 tokens. With a schema the answer is the input of the tool call.
+func (a *anthropicLLM) Complete(ctx context.Context, request LLMRequest) (string, error) {
+	resp, err := a.send(ctx, request, false)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var message anthropicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&message); err != nil {
+		return "", fmt.Errorf("failed to decode response: %v", err)
+	}
+
+	var content strings.Builder
+	for _, block := range message.Content {
+		switch block.Type {
+		case "text":
+			content.WriteString(block.Text)
+		case "tool_use":
+			return string(block.Input), nil
+		}
+	}
+	if content.Len() == 0 {
+		return "", fmt.Errorf("no response from anthropic")
+	}
+	return content.String(), nil
+}
+
+// Stream requests the message as server-sent events and passes on its text deltas, or the pieces of the tool input
+// with a schema
+func (a *anthropicLLM) Stream(ctx context.Context, request LLMRequest, onDelta func(delta string)) (string, error) {
+	resp, err := a.send(ctx, request, true)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var content strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		var event anthropicStreamEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &event); err != nil {
+			return "", fmt.Errorf("failed to decode stream event: %v", err)
+		}
+		switch event.Type {
+		case "content_block_delta":
+			delta := event.Delta.Text
+			if event.Delta.Type == "input_json_delta" {
+				delta = event.Delta.PartialJSON
+			}
+			if delta != "" {
+				content.WriteString(delta)
+				onDelta(delta)
+			}
+		case "error":
+			return "", fmt.Errorf("stream error: %s: %s", event.Error.Type, event.Error.Message)
+		case "message_stop":
+			if content.Len() == 0 {
+				return "", fmt.Errorf("no response from anthropic")
+			}
+			return content.String(), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("stream error: %v", err)
+	}
+	return "", fmt.Errorf("stream ended before the message was complete")
+}
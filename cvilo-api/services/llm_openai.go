@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// openAICompatibleLLM talks to the OpenAI chat completions API or a server implementing it, like GitHub Models,
+// Ollama or the llama.cpp server
+type openAICompatibleLLM struct {
+	name       string
+	client     *openai.Client
+	model      string
+	configured bool
+}
+
+// newOpenAICompatibleLLM creates a provider for the API at baseURL. Local servers usually need no API key.
+func newOpenAICompatibleLLM(name, baseURL, apiKey, model string, configured bool) *openAICompatibleLLM {
+	config := openai.DefaultConfig(apiKey)
+	if baseURL != "" {
+		config.BaseURL = baseURL
+	}
+	return &openAICompatibleLLM{
+		name:       name,
+		client:     openai.NewClientWithConfig(config),
+		model:      model,
+		configured: configured,
+	}
+}
+
+// newOpenAILLMFromEnv creates the OpenAI provider from OPENAI_API_KEY and OPENAI_MODEL (gpt-4o by default,
+// which supports structured outputs)
+func newOpenAILLMFromEnv() ResumeLLM {
+	apiKey := os.Getenv("OPENAI_API_KEY")
+	return newOpenAICompatibleLLM(LLMProviderOpenAI, os.Getenv("OPENAI_BASE_URL"), apiKey,
+		envString("OPENAI_MODEL", openai.GPT4o), apiKey != "")
+}
+
+// newGitHubModelsLLMFromEnv creates the GitHub Models provider from AI_TOKEN, AI_URL and AI_MODEL
+func newGitHubModelsLLMFromEnv() ResumeLLM {
+	apiKey := os.Getenv("AI_TOKEN")
+	return newOpenAICompatibleLLM(LLMProviderGitHubModels, envString("AI_URL", "https://models.github.ai/inference"),
+		apiKey, envString("AI_MODEL", "openai/gpt-4o"), apiKey != "")
+}
+
+// newOpenAICompatibleLLMFromEnv creates the provider of a self-hosted OpenAI compatible server from
+// LLM_COMPATIBLE_URL (e.g. http://localhost:11434/v1 for Ollama), LLM_COMPATIBLE_MODEL and the optional
+// LLM_COMPATIBLE_API_KEY
+func newOpenAICompatibleLLMFromEnv() ResumeLLM {
+	baseURL := os.Getenv("LLM_COMPATIBLE_URL")
+	model := os.Getenv("LLM_COMPATIBLE_MODEL")
+	return newOpenAICompatibleLLM(LLMProviderOpenAICompatible, baseURL, os.Getenv("LLM_COMPATIBLE_API_KEY"),
+		model, baseURL != "" && model != "")
+}
+
+// envString reads an environment variable with a default
+func envString(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func (o *openAICompatibleLLM) Name() string {
+	return o.name
+}
+
+func (o *openAICompatibleLLM) IsConfigured() bool {
+	return o.configured
+}
+
+// chatRequest builds the chat completion of a request as a system and a user message. The schema of the request
+// is sent as a JSON schema response format, strict when the schema allows it.
+func (o *openAICompatibleLLM) chatRequest(request LLMRequest) openai.ChatCompletionRequest {
+	var responseFormat *openai.ChatCompletionResponseFormat
+	if request.Schema != nil {
+		responseFormat = &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+			JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+				Name:        request.Schema.Title,
+				Description: request.Schema.Description,
+				Schema:      request.Schema,
+				Strict:      request.Schema.Strict(),
+			},
+		}
+	}
+	return openai.ChatCompletionRequest{
+		Model: o.model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: request.System,
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: request.Prompt,
+			},
+		},
+		Temperature:    request.Temperature,
+		MaxTokens:      request.MaxTokens,
+		ResponseFormat: responseFormat,
+	}
+}
+
+// Complete asks for the whole answer at once
+func (o *openAICompatibleLLM) Complete(ctx context.Context, request LLMRequest) (string, error) {
+	resp, err := o.client.CreateChatCompletion(ctx, o.chatRequest(request))
+	if err != nil {
+		return "", fmt.Errorf("API error: %v", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no response from %s", o.name)
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+// Stream requests the completion as server-sent events
+func (o *openAICompatibleLLM) Stream(ctx context.Context, request LLMRequest, onDelta func(delta string)) (string, error) {
+	chatRequest := o.chatRequest(request)
+	chatRequest.Stream = true
+	stream, err := o.client.CreateChatCompletionStream(ctx, chatRequest)
+	if err != nil {
+		return "", fmt.Errorf("API error: %v", err)
+	}
+	defer stream.Close()
+
+	var content strings.Builder
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("stream error: %v", err)
+		}
+		if len(resp.Choices) == 0 || resp.Choices[0].Delta.Content == "" {
+			continue
+		}
+		delta := resp.Choices[0].Delta.Content
+		content.WriteString(delta)
+		onDelta(delta)
+	}
+	if content.Len() == 0 {
+		return "", fmt.Errorf("no response from %s", o.name)
+	}
+	return content.String(), nil
+}
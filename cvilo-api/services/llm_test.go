@@ -0,0 +1,500 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smhnaqvi/cvilo/models"
+)
+
+// testResume returns an answer matching resumeSchema
+func testResume(fullName string) string {
+	resume, _ := json.Marshal(AIResumeResponse{
+		FullName:       fullName,
+		Experience:     []models.WorkExperience{{Position: "Engineer", StartDate: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), IsCurrent: true}},
+		Education:      []models.Education{},
+		Skills:         []models.Skill{{Name: "Go", Level: 4}},
+		Languages:      []models.Language{},
+		Certifications: []models.Certification{},
+		Projects:       []models.Project{},
+	})
+	return string(resume)
+}
+
+// stubLLM answers with a fixed content or error, optionally after a delay. The first answers can be set apart.
+type stubLLM struct {
+	name       string
+	configured bool
+	content    string
+	err        error
+	delay      time.Duration
+	calls      int
+	answers    []string
+	requests   []LLMRequest
+}
+
+func (s *stubLLM) Name() string       { return s.name }
+func (s *stubLLM) IsConfigured() bool { return s.configured }
+
+func (s *stubLLM) Complete(ctx context.Context, request LLMRequest) (string, error) {
+	s.calls++
+	s.requests = append(s.requests, request)
+	if s.delay > 0 {
+		select {
+		case <-time.After(s.delay):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	if len(s.answers) > 0 {
+		answer := s.answers[0]
+		s.answers = s.answers[1:]
+		return answer, nil
+	}
+	return s.content, s.err
+}
+
+func TestLLMChainFallback(t *testing.T) {
+	resume := testResume("Jane Doe")
+
+	tests := []struct {
+		name     string
+		llms     []*stubLLM
+		provider string
+		errText  string
+	}{
+		{
+			name:     "first provider answers",
+			llms:     []*stubLLM{{name: "a", configured: true, content: resume}, {name: "b", configured: true, content: resume}},
+			provider: "a",
+		},
+		{
+			name:     "unconfigured provider is skipped",
+			llms:     []*stubLLM{{name: "a", content: resume}, {name: "b", configured: true, content: resume}},
+			provider: "b",
+		},
+		{
+			name:     "error falls back",
+			llms:     []*stubLLM{{name: "a", configured: true, err: errors.New("API error: 500")}, {name: "b", configured: true, content: resume}},
+			provider: "b",
+		},
+		{
+			name:     "rejected answer falls back",
+			llms:     []*stubLLM{{name: "a", configured: true, content: "Sorry, I can't"}, {name: "b", configured: true, content: resume}},
+			provider: "b",
+		},
+		{
+			name:     "timeout falls back",
+			llms:     []*stubLLM{{name: "a", configured: true, content: resume, delay: time.Second}, {name: "b", configured: true, content: resume}},
+			provider: "b",
+		},
+		{
+			name:    "all providers fail",
+			llms:    []*stubLLM{{name: "a", configured: true, err: errors.New("API error: 500")}, {name: "b", configured: true, content: "{"}},
+			errText: "all AI providers failed: a: API error: 500; b: answer does not match the schema: not valid JSON",
+		},
+		{
+			name:    "nothing configured",
+			llms:    []*stubLLM{{name: "a"}},
+			errText: ErrLLMNotConfigured.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		llms := make([]ResumeLLM, len(tt.llms))
+		for i, llm := range tt.llms {
+			llms[i] = llm
+		}
+		ai := NewAIServiceWithChain(NewLLMChain(50*time.Millisecond, llms...))
+
+		resp, provider, err := ai.ParseResumeText(context.Background(), "Jane Doe")
+		if tt.errText != "" {
+			if err == nil || !strings.HasPrefix(err.Error(), tt.errText) {
+				t.Errorf("%s: error = %v, want %s", tt.name, err, tt.errText)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+		if provider != tt.provider || resp.FullName != "Jane Doe" {
+			t.Errorf("%s: provider = %s, full name = %s, want %s, Jane Doe", tt.name, provider, resp.FullName, tt.provider)
+		}
+	}
+}
+
+func TestLLMChainRepairsSchemaViolations(t *testing.T) {
+	drifting := `{"Full Name": "Jane Doe"}`
+
+	tests := []struct {
+		name     string
+		llms     []*stubLLM
+		provider string
+		calls    []int
+	}{
+		{
+			name:     "corrected answer",
+			llms:     []*stubLLM{{name: "a", configured: true, answers: []string{drifting}, content: testResume("Jane Doe")}},
+			provider: "a",
+			calls:    []int{2},
+		},
+		{
+			name:     "fenced answer needs no correction",
+			llms:     []*stubLLM{{name: "a", configured: true, content: "```json\n" + testResume("Jane Doe") + "\n```"}},
+			provider: "a",
+			calls:    []int{1},
+		},
+		{
+			name:     "uncorrected answer falls back",
+			llms:     []*stubLLM{{name: "a", configured: true, content: drifting}, {name: "b", configured: true, content: testResume("Jane Doe")}},
+			provider: "b",
+			calls:    []int{1 + maxSchemaRepairs, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		llms := make([]ResumeLLM, len(tt.llms))
+		for i, llm := range tt.llms {
+			llms[i] = llm
+		}
+		ai := NewAIServiceWithChain(NewLLMChain(time.Second, llms...))
+
+		resp, provider, err := ai.ParseResumeText(context.Background(), "Jane Doe")
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+		if provider != tt.provider || resp.FullName != "Jane Doe" {
+			t.Errorf("%s: provider = %s, full name = %s, want %s, Jane Doe", tt.name, provider, resp.FullName, tt.provider)
+		}
+		for i, llm := range tt.llms {
+			if llm.calls != tt.calls[i] {
+				t.Errorf("%s: %s called %d times, want %d", tt.name, llm.name, llm.calls, tt.calls[i])
+			}
+		}
+		first := tt.llms[0]
+		if first.calls > 1 {
+			repair := first.requests[1].Prompt
+			if !strings.Contains(repair, drifting) || !strings.Contains(repair, `Full Name: unknown property`) {
+				t.Errorf("%s: repair prompt = %q, want the answer and its problems", tt.name, repair)
+			}
+		}
+	}
+}
+
+func TestLLMChainStructuredOutput(t *testing.T) {
+	structured := &stubLLM{name: "structured", configured: true, content: testResume("Jane Doe")}
+	plain := &stubLLM{name: "plain", configured: true, content: testResume("Jane Doe")}
+	chain := &LLMChain{entries: []llmChainEntry{
+		{llm: structured, timeout: time.Second, structured: true},
+		{llm: plain, timeout: time.Second},
+	}}
+
+	for _, llm := range []*stubLLM{structured, plain} {
+		if _, err := chain.Complete(context.Background(), LLMRequest{Schema: resumeSchema}, nil); err != nil {
+			t.Fatalf("Complete() error = %v", err)
+		}
+		structured.configured = false
+		if got := llm.requests[0].Schema != nil; got != (llm == structured) {
+			t.Errorf("%s: schema sent = %v, want %v", llm.name, got, llm == structured)
+		}
+	}
+
+	// Answers of providers without structured outputs are still validated
+	plain.content = `{"Full Name": "Jane Doe"}`
+	if _, err := chain.Complete(context.Background(), LLMRequest{Schema: resumeSchema}, nil); err == nil || !strings.Contains(err.Error(), "unknown property") {
+		t.Errorf("Complete() error = %v, want the schema violation", err)
+	}
+}
+
+func TestLLMChainStopsWhenCancelled(t *testing.T) {
+	first := &stubLLM{name: "a", configured: true, delay: time.Second}
+	second := &stubLLM{name: "b", configured: true, content: "{}"}
+	chain := NewLLMChain(time.Minute, first, second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := chain.Complete(ctx, LLMRequest{}, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Complete() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if second.calls != 0 {
+		t.Errorf("second provider called %d times after the request ended, want 0", second.calls)
+	}
+}
+
+func TestNewLLMChainFromEnv(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "sk-test")
+	t.Setenv("AI_TOKEN", "")
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	t.Setenv("LLM_COMPATIBLE_URL", "")
+	t.Setenv("USE_GITHUB_MODELS", "true")
+	t.Setenv("LLM_TIMEOUT", "45s")
+	t.Setenv("LLM_TIMEOUT_GITHUB_MODELS", "20s")
+	t.Setenv("LLM_STRUCTURED_OUTPUT", "")
+	t.Setenv("LLM_STRUCTURED_OUTPUT_OPENAI", "false")
+
+	tests := []struct {
+		providers string
+		expected  []LLMProviderStatus
+	}{
+		{"", []LLMProviderStatus{
+			{Name: LLMProviderGitHubModels, Configured: false, Timeout: "20s", StructuredOutput: true},
+			{Name: LLMProviderOpenAI, Configured: true, Timeout: "45s", StructuredOutput: false},
+		}},
+		{"anthropic, openai_compatible,unknown", []LLMProviderStatus{
+			{Name: LLMProviderAnthropic, Configured: false, Timeout: "45s", StructuredOutput: true},
+			{Name: LLMProviderOpenAICompatible, Configured: false, Timeout: "45s", StructuredOutput: true},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("LLM_PROVIDERS", tt.providers)
+		status := NewLLMChainFromEnv().Status()
+		if len(status) != len(tt.expected) {
+			t.Errorf("LLM_PROVIDERS=%q: status = %+v, want %+v", tt.providers, status, tt.expected)
+			continue
+		}
+		for i := range status {
+			if status[i] != tt.expected[i] {
+				t.Errorf("LLM_PROVIDERS=%q: provider %d = %+v, want %+v", tt.providers, i, status[i], tt.expected[i])
+			}
+		}
+	}
+}
+
+func TestOpenAICompatibleLLM(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("path = %s, want /v1/chat/completions", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "{}"}}]}`))
+	}))
+	defer server.Close()
+
+	llm := newOpenAICompatibleLLM(LLMProviderOpenAICompatible, server.URL+"/v1", "", "llama3.1", true)
+	content, err := llm.Complete(context.Background(), LLMRequest{System: "system", Prompt: "prompt", MaxTokens: 100})
+	if err != nil || content != "{}" {
+		t.Fatalf("Complete() = %q, %v, want {}", content, err)
+	}
+	if body["model"] != "llama3.1" {
+		t.Errorf("model = %v, want llama3.1", body["model"])
+	}
+	messages, _ := body["messages"].([]interface{})
+	if len(messages) != 2 {
+		t.Errorf("messages = %v, want a system and a user message", body["messages"])
+	}
+	if _, ok := body["response_format"]; ok {
+		t.Errorf("response_format = %v, want none without a schema", body["response_format"])
+	}
+
+	if _, err := llm.Complete(context.Background(), LLMRequest{Prompt: "prompt", Schema: resumeSchema}); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	format, _ := body["response_format"].(map[string]interface{})
+	jsonSchema, _ := format["json_schema"].(map[string]interface{})
+	schema, _ := jsonSchema["schema"].(map[string]interface{})
+	if format["type"] != "json_schema" || jsonSchema["name"] != "resume" || jsonSchema["strict"] != true || schema["additionalProperties"] != false {
+		t.Errorf("response_format = %v, want the strict resume schema", body["response_format"])
+	}
+}
+
+func TestAnthropicLLM(t *testing.T) {
+	var body anthropicRequest
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("path = %s, want /v1/messages", r.URL.Path)
+		}
+		headers = r.Header
+		body = anthropicRequest{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if len(body.Tools) > 0 {
+			w.Write([]byte(`{"content": [{"type": "tool_use", "name": "resume", "input": {"full_name": "Jane"}}], "stop_reason": "tool_use"}`))
+			return
+		}
+		if body.Model == "overloaded" {
+			w.WriteHeader(529)
+			w.Write([]byte(`{"type": "error", "error": {"type": "overloaded_error", "message": "Overloaded"}}`))
+			return
+		}
+		w.Write([]byte(`{"content": [{"type": "text", "text": "{\"full_name\":"}, {"type": "text", "text": " \"Jane\"}"}], "stop_reason": "end_turn"}`))
+	}))
+	defer server.Close()
+
+	llm := &anthropicLLM{client: server.Client(), apiURL: server.URL, apiKey: "key", model: "claude-test"}
+	content, err := llm.Complete(context.Background(), LLMRequest{System: "system", Prompt: "prompt"})
+	if err != nil || content != `{"full_name": "Jane"}` {
+		t.Fatalf("Complete() = %q, %v, want the joined text blocks", content, err)
+	}
+	if headers.Get("x-api-key") != "key" || headers.Get("anthropic-version") != anthropicVersion {
+		t.Errorf("headers = %v, want x-api-key and anthropic-version", headers)
+	}
+	if body.System != "system" || len(body.Messages) != 1 || body.Messages[0].Content != "prompt" || body.MaxTokens != 4000 {
+		t.Errorf("request = %+v, want the system prompt, one user message and max_tokens 4000", body)
+	}
+
+	content, err = llm.Complete(context.Background(), LLMRequest{Prompt: "prompt", Schema: resumeSchema})
+	if err != nil || content != `{"full_name": "Jane"}` {
+		t.Fatalf("Complete() = %q, %v, want the tool input", content, err)
+	}
+	if len(body.Tools) != 1 || body.Tools[0].Name != "resume" || body.ToolChoice == nil || body.ToolChoice.Name != "resume" {
+		t.Errorf("tools = %+v, tool choice = %+v, want the resume tool forced", body.Tools, body.ToolChoice)
+	}
+
+	llm.model = "overloaded"
+	if _, err := llm.Complete(context.Background(), LLMRequest{}); err == nil || !strings.Contains(err.Error(), "529 - overloaded_error: Overloaded") {
+		t.Errorf("Complete() error = %v, want the API error", err)
+	}
+}
+
+func TestParseAIResumeContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		fullName string
+		position string
+	}{
+		{"plain JSON", `{"full_name": "Jane", "experience": [{"position": "Engineer"}]}`, "Jane", "Engineer"},
+		{"markdown fence", "```json\n{\"full_name\": \"Jane\"}\n```", "Jane", ""},
+		{"text around the object", "Here is the resume:\n{\"full_name\": \"Jane\"}\nLet me know!", "Jane", ""},
+	}
+
+	for _, tt := range tests {
+		resp, err := parseAIResumeContent(tt.content)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+		position := ""
+		if len(resp.Experience) > 0 {
+			position = resp.Experience[0].Position
+		}
+		if resp.FullName != tt.fullName || position != tt.position {
+			t.Errorf("%s: full name = %s, position = %s, want %s, %s", tt.name, resp.FullName, position, tt.fullName, tt.position)
+		}
+	}
+}
+
+// streamingStubLLM streams its content in pieces, pausing before each
+type streamingStubLLM struct {
+	stubLLM
+	pieces []string
+}
+
+func (s *streamingStubLLM) Stream(ctx context.Context, request LLMRequest, onDelta func(string)) (string, error) {
+	s.calls++
+	var content strings.Builder
+	for _, piece := range s.pieces {
+		select {
+		case <-time.After(s.delay):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+		content.WriteString(piece)
+		onDelta(piece)
+	}
+	return content.String(), s.err
+}
+
+func TestLLMChainStream(t *testing.T) {
+	resume := testResume("Jane Doe")
+	third := len(resume) / 3
+	var allSections []string
+	for _, property := range resumeSchema.Properties {
+		allSections = append(allSections, property.Name)
+	}
+
+	// Pauses of 20ms are within the 50ms timeout although the whole answer takes longer
+	slow := &streamingStubLLM{
+		stubLLM: stubLLM{name: "slow", configured: true, delay: 20 * time.Millisecond},
+		pieces:  []string{resume[:third], resume[third : 2*third], resume[2*third:]},
+	}
+	stalled := &streamingStubLLM{
+		stubLLM: stubLLM{name: "stalled", configured: true, delay: time.Second},
+		pieces:  []string{`{}`},
+	}
+	oneShot := &stubLLM{name: "one_shot", configured: true, content: resume}
+
+	tests := []struct {
+		name     string
+		llms     []ResumeLLM
+		provider string
+		attempts []string
+		sections []string
+	}{
+		{"pauses within timeout", []ResumeLLM{slow}, "slow", []string{"slow"}, allSections},
+		{"stalled stream falls back", []ResumeLLM{stalled, slow}, "slow", []string{"stalled", "slow"}, allSections},
+		{"provider without streaming", []ResumeLLM{oneShot}, "one_shot", []string{"one_shot"}, allSections},
+	}
+
+	for _, tt := range tests {
+		ai := NewAIServiceWithChain(NewLLMChain(50*time.Millisecond, tt.llms...))
+		var attempts, sections []string
+		_, provider, err := ai.GenerateResumeFromPromptStream(context.Background(), AIResumeRequest{Prompt: "engineer"}, AIStreamHandler{
+			OnProvider: func(provider string) { attempts = append(attempts, provider) },
+			OnSection:  func(name string, value json.RawMessage) { sections = append(sections, name) },
+		})
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+		if provider != tt.provider || strings.Join(attempts, ",") != strings.Join(tt.attempts, ",") {
+			t.Errorf("%s: provider = %s, attempts = %v, want %s, %v", tt.name, provider, attempts, tt.provider, tt.attempts)
+		}
+		if strings.Join(sections, ",") != strings.Join(tt.sections, ",") {
+			t.Errorf("%s: sections = %v, want %v", tt.name, sections, tt.sections)
+		}
+	}
+}
+
+func TestOpenAICompatibleLLMStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, piece := range []string{`{\"full_name\":`, ` \"Jane\"}`} {
+			w.Write([]byte(`data: {"choices": [{"index": 0, "delta": {"content": "` + piece + `"}}]}` + "\n\n"))
+		}
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	llm := newOpenAICompatibleLLM(LLMProviderOpenAICompatible, server.URL+"/v1", "", "llama3.1", true)
+	var deltas []string
+	content, err := llm.Stream(context.Background(), LLMRequest{}, func(delta string) { deltas = append(deltas, delta) })
+	if err != nil || content != `{"full_name": "Jane"}` || len(deltas) != 2 {
+		t.Errorf("Stream() = %q, %v with deltas %q, want the joined deltas", content, err, deltas)
+	}
+}
+
+func TestAnthropicLLMStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body anthropicRequest
+		json.NewDecoder(r.Body).Decode(&body)
+		if !body.Stream {
+			t.Errorf("stream = false, want true")
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("event: message_start\ndata: {\"type\": \"message_start\"}\n\n" +
+			"event: content_block_delta\ndata: {\"type\": \"content_block_delta\", \"index\": 0, \"delta\": {\"type\": \"text_delta\", \"text\": \"{\"}}\n\n" +
+			"event: content_block_delta\ndata: {\"type\": \"content_block_delta\", \"index\": 0, \"delta\": {\"type\": \"input_json_delta\", \"partial_json\": \"}\"}}\n\n" +
+			"event: ping\ndata: {\"type\": \"ping\"}\n\n" +
+			"event: message_stop\ndata: {\"type\": \"message_stop\"}\n\n"))
+	}))
+	defer server.Close()
+
+	llm := &anthropicLLM{client: server.Client(), apiURL: server.URL, apiKey: "key", model: "claude-test"}
+	var deltas []string
+	content, err := llm.Stream(context.Background(), LLMRequest{}, func(delta string) { deltas = append(deltas, delta) })
+	if err != nil || content != "{}" || len(deltas) != 2 {
+		t.Errorf("Stream() = %q, %v with deltas %q, want {}", content, err, deltas)
+	}
+}
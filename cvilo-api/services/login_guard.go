@@ -0,0 +1,236 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/smhnaqvi/cvilo/models"
+)
+
+// loginThrottlePolicy is the exponential backoff of failed logins. The first freeAttempts failures are free, each
+// further failure blocks logins for baseDelay doubling up to maxDelay, and lockAfter failures block them for lockFor.
+// Failures are forgotten after resetAfter without a failure.
+type loginThrottlePolicy struct {
+	freeAttempts int
+	baseDelay    time.Duration
+	maxDelay     time.Duration
+	lockAfter    int
+	lockFor      time.Duration
+	resetAfter   time.Duration
+}
+
+var (
+	// accountThrottle protects one account against password guessing
+	accountThrottle = loginThrottlePolicy{
+		freeAttempts: 3,
+		baseDelay:    time.Second,
+		maxDelay:     5 * time.Minute,
+		lockAfter:    10,
+		lockFor:      30 * time.Minute,
+		resetAfter:   time.Hour,
+	}
+	// ipThrottle slows down one client trying many accounts; it is looser since offices share an IP
+	ipThrottle = loginThrottlePolicy{
+		freeAttempts: 10,
+		baseDelay:    time.Second,
+		maxDelay:     5 * time.Minute,
+		lockAfter:    50,
+		lockFor:      time.Hour,
+		resetAfter:   time.Hour,
+	}
+)
+
+// LoginThrottledError is returned while logins of an account or IP are blocked after failures
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// ErrAccountNotLocked is returned when unlocking an account without failed logins
+var ErrAccountNotLocked = errors.New("account has no failed logins")
+
+// recordFailure counts a failure at now and blocks the key as the policy says. It reports whether the failure
+// locked the key.
+func (p loginThrottlePolicy) recordFailure(t *models.LoginThrottleModel, now time.Time) bool {
+	expiredLock := t.Locked && t.BlockedUntil != nil && !now.Before(*t.BlockedUntil)
+	idle := t.LastFailureAt != nil && now.Sub(*t.LastFailureAt) > p.resetAfter
+	if expiredLock || idle {
+		t.Failures = 0
+		t.Locked = false
+	}
+
+	t.Failures++
+	t.LastFailureAt = &now
+
+	var delay time.Duration
+	switch {
+	case t.Failures >= p.lockAfter:
+		delay = p.lockFor
+		if !t.Locked {
+			t.Locked = true
+			blockedUntil := now.Add(delay)
+			t.BlockedUntil = &blockedUntil
+			return true
+		}
+	case t.Failures > p.freeAttempts:
+		delay = p.baseDelay << (t.Failures - p.freeAttempts - 1)
+		if delay > p.maxDelay || delay <= 0 {
+			delay = p.maxDelay
+		}
+	default:
+		return false
+	}
+
+	blockedUntil := now.Add(delay)
+	t.BlockedUntil = &blockedUntil
+	return false
+}
+
+// accountThrottleKey and ipThrottleKey name the login throttles
+func accountThrottleKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipThrottleKey(ip string) string {
+	return "ip:" + ip
+}
+
+// CheckLoginAllowed returns a *LoginThrottledError while logins for the email or from the client IP are blocked.
+// Unknown emails are throttled like existing ones.
+func (s *AuthService) CheckLoginAllowed(email string, client ClientInfo) error {
+	var throttle models.LoginThrottleModel
+	throttles, err := throttle.GetByKeys(accountThrottleKey(email), ipThrottleKey(client.IPAddress))
+	if err != nil {
+		return err
+	}
+
+	var retryAfter time.Duration
+	now := time.Now()
+	for _, t := range throttles {
+		if wait, blocked := t.Blocked(now); blocked && wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+	if retryAfter > 0 {
+		return &LoginThrottledError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// RecordLoginFailure counts a failed login for the email and the client IP, writing an audit event when either
+// gets locked
+func (s *AuthService) RecordLoginFailure(email string, client ClientInfo) {
+	now := time.Now()
+
+	var account models.LoginThrottleModel
+	accountLocked := false
+	err := account.RecordFailure(accountThrottleKey(email), func(t *models.LoginThrottleModel) {
+		accountLocked = accountThrottle.recordFailure(t, now)
+	})
+	if err != nil {
+		log.Printf("LoginGuard: failed to record login failure for account: %v", err)
+	} else if accountLocked {
+		event := AuditEvent{
+			Action:     models.AuditActionAccountLocked,
+			TargetType: models.AuditTargetAccount,
+			TargetID:   strings.ToLower(strings.TrimSpace(email)),
+			Client:     client,
+			Details: map[string]interface{}{
+				"failures":     account.Failures,
+				"locked_until": account.BlockedUntil,
+			},
+		}
+		var user models.UserModel
+		if user.GetUserByEmail(email) == nil {
+			event.TargetType = models.AuditTargetUser
+			event.TargetID = fmt.Sprintf("%d", user.ID)
+			event.Details["email"] = user.Email
+		}
+		RecordAudit(event)
+	}
+
+	var ip models.LoginThrottleModel
+	ipLocked := false
+	err = ip.RecordFailure(ipThrottleKey(client.IPAddress), func(t *models.LoginThrottleModel) {
+		ipLocked = ipThrottle.recordFailure(t, now)
+	})
+	if err != nil {
+		log.Printf("LoginGuard: failed to record login failure for IP %s: %v", client.IPAddress, err)
+	} else if ipLocked {
+		RecordAudit(AuditEvent{
+			Action:     models.AuditActionIPBlocked,
+			TargetType: models.AuditTargetIP,
+			TargetID:   client.IPAddress,
+			Client:     client,
+			Details: map[string]interface{}{
+				"failures":      ip.Failures,
+				"blocked_until": ip.BlockedUntil,
+			},
+		})
+	}
+}
+
+// RecordLoginSuccess forgets the failed logins of the account once a login is complete, after the second factor
+// when the user has one. Failures of the IP are kept, so an attacker cannot reset them by logging in to an account
+// of their own.
+func (s *AuthService) RecordLoginSuccess(email string) {
+	var throttle models.LoginThrottleModel
+	if err := throttle.Clear(accountThrottleKey(email)); err != nil {
+		log.Printf("LoginGuard: failed to clear login failures: %v", err)
+	}
+}
+
+// loginRecorder records the outcome of login attempts for the throttling, implemented by AuthService
+type loginRecorder interface {
+	RecordLoginFailure(email string, client ClientInfo)
+	RecordLoginSuccess(email string)
+}
+
+// recordMFAOutcome records the second factor of a login: a wrong code, or one sent after too many wrong codes, is a
+// failed login of the account, and a right one completes the login. Other errors are not the user's guess.
+func recordMFAOutcome(r loginRecorder, email string, client ClientInfo, err error) {
+	switch {
+	case err == nil:
+		r.RecordLoginSuccess(email)
+	case errors.Is(err, ErrInvalidMFACode), errors.Is(err, ErrTooManyMFAAttempts):
+		r.RecordLoginFailure(email, client)
+	}
+}
+
+// UnlockAccount forgets the failed logins of a user, ending a lock or backoff, and writes an audit event
+func (s *AuthService) UnlockAccount(user models.UserModel, adminID uint, client ClientInfo) error {
+	key := accountThrottleKey(user.Email)
+
+	var throttle models.LoginThrottleModel
+	throttles, err := throttle.GetByKeys(key)
+	if err != nil {
+		return err
+	}
+	if len(throttles) == 0 {
+		return ErrAccountNotLocked
+	}
+	if err := throttle.Clear(key); err != nil {
+		return err
+	}
+
+	RecordAudit(AuditEvent{
+		ActorID:    &adminID,
+		Action:     models.AuditActionAccountUnlocked,
+		TargetType: models.AuditTargetUser,
+		TargetID:   fmt.Sprintf("%d", user.ID),
+		Client:     client,
+		Details: map[string]interface{}{
+			"email":         user.Email,
+			"failures":      throttles[0].Failures,
+			"was_locked":    throttles[0].Locked,
+			"blocked_until": throttles[0].BlockedUntil,
+		},
+	})
+	return nil
+}
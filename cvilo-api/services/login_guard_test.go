@@ -0,0 +1,123 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/smhnaqvi/cvilo/models"
+)
+
+func TestLoginThrottlePolicy(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	throttle := &models.LoginThrottleModel{Key: accountThrottleKey("Jane@Example.com ")}
+
+	tests := []struct {
+		failure int
+		delay   time.Duration
+		locks   bool
+	}{
+		{1, 0, false},
+		{2, 0, false},
+		{3, 0, false},
+		{4, time.Second, false},
+		{5, 2 * time.Second, false},
+		{6, 4 * time.Second, false},
+		{9, 32 * time.Second, false},
+		{10, 30 * time.Minute, true},
+	}
+
+	now := start
+	for _, tt := range tests {
+		for throttle.Failures < tt.failure {
+			now = now.Add(time.Second)
+			locked := accountThrottle.recordFailure(throttle, now)
+			if throttle.Failures == tt.failure && locked != tt.locks {
+				t.Errorf("recordFailure() #%d locked = %v, want %v", tt.failure, locked, tt.locks)
+			}
+		}
+		wait, blocked := throttle.Blocked(now)
+		if wait != tt.delay || blocked != (tt.delay > 0) {
+			t.Errorf("Blocked() after %d failures = %s, %v, want %s", tt.failure, wait, blocked, tt.delay)
+		}
+	}
+
+	// The lock expires and the failures start over
+	now = now.Add(31 * time.Minute)
+	if _, blocked := throttle.Blocked(now); blocked {
+		t.Errorf("Blocked() after the lock = true, want false")
+	}
+	if accountThrottle.recordFailure(throttle, now); throttle.Failures != 1 || throttle.Locked {
+		t.Errorf("recordFailure() after the lock = %d failures, locked %v, want 1 failure", throttle.Failures, throttle.Locked)
+	}
+
+	// Failures are forgotten after an idle hour
+	accountThrottle.recordFailure(throttle, now.Add(time.Second))
+	accountThrottle.recordFailure(throttle, now.Add(2*time.Hour))
+	if throttle.Failures != 1 {
+		t.Errorf("recordFailure() after an idle hour = %d failures, want 1", throttle.Failures)
+	}
+}
+
+func TestLoginThrottleBackoffIsCapped(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	throttle := &models.LoginThrottleModel{}
+	for i := 0; i < ipThrottle.lockAfter-1; i++ {
+		ipThrottle.recordFailure(throttle, now)
+	}
+
+	if wait, _ := throttle.Blocked(now); wait != ipThrottle.maxDelay {
+		t.Errorf("Blocked() after %d failures = %s, want %s", throttle.Failures, wait, ipThrottle.maxDelay)
+	}
+}
+
+func TestThrottleKeys(t *testing.T) {
+	if key := accountThrottleKey(" Jane@Example.com"); key != "account:jane@example.com" {
+		t.Errorf("accountThrottleKey() = %s, want account:jane@example.com", key)
+	}
+	if key := ipThrottleKey("203.0.113.7"); key != "ip:203.0.113.7" {
+		t.Errorf("ipThrottleKey() = %s, want ip:203.0.113.7", key)
+	}
+}
+
+type recordedLogins struct {
+	failures  []string
+	successes []string
+}
+
+func (r *recordedLogins) RecordLoginFailure(email string, client ClientInfo) {
+	r.failures = append(r.failures, email)
+}
+
+func (r *recordedLogins) RecordLoginSuccess(email string) {
+	r.successes = append(r.successes, email)
+}
+
+func TestRecordMFAOutcome(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		failures int
+		success  bool
+	}{
+		{"Right code", nil, 0, true},
+		{"Wrong code", ErrInvalidMFACode, 1, false},
+		{"Code after too many wrong codes", ErrTooManyMFAAttempts, 1, false},
+		{"Invalid challenge", models.ErrMFAChallengeInvalid, 0, false},
+		{"Database error", errors.New("connection refused"), 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &recordedLogins{}
+			recordMFAOutcome(recorder, "jane@example.com", ClientInfo{IPAddress: "203.0.113.7"}, tt.err)
+
+			if len(recorder.failures) != tt.failures {
+				t.Errorf("recordMFAOutcome(%v) failures = %v, want %d", tt.err, recorder.failures, tt.failures)
+			}
+			if (len(recorder.successes) == 1) != tt.success {
+				t.Errorf("recordMFAOutcome(%v) successes = %v, want success %v", tt.err, recorder.successes, tt.success)
+			}
+		})
+	}
+}
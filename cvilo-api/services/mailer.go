@@ -0,0 +1,179 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	netmail "net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mail is a plain text email
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers emails
+type Mailer interface {
+	Send(mail Mail) error
+}
+
+// SMTPMailer delivers emails through an SMTP server, using STARTTLS when the server offers it
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// Send delivers the mail, authenticating when a username is configured
+func (m *SMTPMailer) Send(mail Mail) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+	// The envelope sender is the bare address of From
+	sender := m.From
+	if address, err := netmail.ParseAddress(m.From); err == nil {
+		sender = address.Address
+	}
+	addr := m.Host + ":" + m.Port
+	if err := smtp.SendMail(addr, auth, sender, []string{mail.To}, formatMail(m.From, mail, time.Now())); err != nil {
+		return fmt.Errorf("failed to send mail to %s via %s: %v", mail.To, addr, err)
+	}
+	return nil
+}
+
+// FileMailer writes emails to .eml files in Dir instead of sending them, for local development and tests. With an
+// empty Dir only the recipient and subject are logged: bodies hold password reset and verification tokens.
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+// Send writes the mail to a new file in Dir, or logs that it was dropped
+func (m *FileMailer) Send(mail Mail) error {
+	if m.Dir == "" {
+		log.Printf("Mailer: not sending mail %q to %s, MAIL_DIR is not set", mail.Subject, mail.To)
+		return nil
+	}
+
+	now := time.Now()
+	message := formatMail(m.From, mail, now)
+
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create mail directory: %v", err)
+	}
+	name := fmt.Sprintf("%s-%s.eml", now.Format("20060102-150405.000000000"), sanitizeFileName(mail.To))
+	path := filepath.Join(m.Dir, name)
+	if err := os.WriteFile(path, message, 0o600); err != nil {
+		return fmt.Errorf("failed to write mail: %v", err)
+	}
+	log.Printf("Mailer: wrote mail %q to %s", mail.Subject, path)
+	return nil
+}
+
+// formatMail renders the mail as an RFC 5322 message. Line breaks are removed from header values so a recipient or
+// subject cannot add headers.
+func formatMail(from string, mail Mail, date time.Time) []byte {
+	header := strings.NewReplacer("\r", "", "\n", "")
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", header.Replace(from))
+	fmt.Fprintf(&buf, "To: %s\r\n", header.Replace(mail.To))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", header.Replace(mail.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(strings.ReplaceAll(mail.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return buf.Bytes()
+}
+
+// sanitizeFileName keeps letters, digits, dots and dashes of s
+func sanitizeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// ErrMailerNotConfigured is returned in release mode when emails would not be delivered
+var ErrMailerNotConfigured = errors.New("mailer is not configured: MAIL_DRIVER=smtp and SMTP_HOST are required in release mode")
+
+// unconfiguredMailer refuses every mail, so a misconfigured server fails instead of dropping emails
+type unconfiguredMailer struct {
+	err error
+}
+
+func (m *unconfiguredMailer) Send(mail Mail) error {
+	return m.err
+}
+
+var (
+	defaultMailer     Mailer
+	defaultMailerErr  error
+	defaultMailerOnce sync.Once
+)
+
+// DefaultMailer returns the mailer configured by the environment: MAIL_DRIVER=smtp sends through SMTP_HOST,
+// otherwise emails are written to MAIL_DIR or dropped. In release mode (GIN_MODE=release) SMTP is required and
+// without it every Send fails, see CheckMailer.
+func DefaultMailer() Mailer {
+	defaultMailerOnce.Do(func() {
+		defaultMailer, defaultMailerErr = newMailerFromEnv()
+		if defaultMailerErr != nil {
+			defaultMailer = &unconfiguredMailer{err: defaultMailerErr}
+		}
+	})
+	return defaultMailer
+}
+
+// CheckMailer returns the configuration error of the default mailer, so the server can refuse to start
+func CheckMailer() error {
+	DefaultMailer()
+	return defaultMailerErr
+}
+
+func newMailerFromEnv() (Mailer, error) {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "Cvilo <no-reply@cvilo.com>"
+	}
+
+	if os.Getenv("MAIL_DRIVER") == "smtp" {
+		host := os.Getenv("SMTP_HOST")
+		if host != "" {
+			port := os.Getenv("SMTP_PORT")
+			if port == "" {
+				port = "587"
+			}
+			return &SMTPMailer{
+				Host:     host,
+				Port:     port,
+				Username: os.Getenv("SMTP_USERNAME"),
+				Password: os.Getenv("SMTP_PASSWORD"),
+				From:     from,
+			}, nil
+		}
+		if os.Getenv("GIN_MODE") != "release" {
+			log.Println("Mailer: MAIL_DRIVER is smtp but SMTP_HOST is not set, falling back to the file mailer")
+		}
+	}
+	if os.Getenv("GIN_MODE") == "release" {
+		return nil, ErrMailerNotConfigured
+	}
+	return &FileMailer{Dir: os.Getenv("MAIL_DIR"), From: from}, nil
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatMail(t *testing.T) {
+	date := time.Date(2025, 1, 10, 9, 0, 0, 0, time.UTC)
+	message := string(formatMail("Cvilo <no-reply@cvilo.com>", Mail{
+		To:      "jane@example.com\r\nBcc: eve@example.com",
+		Subject: "Reset your password",
+		Body:    "Hi Jane,\n\nOpen the link.",
+	}, date))
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"From", "From: Cvilo <no-reply@cvilo.com>\r\n"},
+		{"To without injected header", "To: jane@example.comBcc: eve@example.com\r\n"},
+		{"Subject", "Subject: Reset your password\r\n"},
+		{"Date", "Date: Fri, 10 Jan 2025 09:00:00 +0000\r\n"},
+		{"Body with CRLF line endings", "\r\n\r\nHi Jane,\r\n\r\nOpen the link."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(message, tt.expected) {
+				t.Errorf("formatMail() = %q, want it to contain %q", message, tt.expected)
+			}
+		})
+	}
+	if strings.Contains(message, "\r\nBcc:") {
+		t.Errorf("formatMail() = %q, a recipient added a header", message)
+	}
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	mailer := &FileMailer{Dir: filepath.Join(dir, "mail"), From: "no-reply@cvilo.com"}
+
+	if err := mailer.Send(Mail{To: "jane@example.com", Subject: "Welcome", Body: "Hello"}); err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "mail", "*-jane_example.com.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("Send() wrote %v, want one .eml file for the recipient", files)
+	}
+	content, _ := os.ReadFile(files[0])
+	if !strings.Contains(string(content), "Subject: Welcome\r\n") || !strings.HasSuffix(string(content), "\r\n\r\nHello") {
+		t.Errorf("Send() wrote %q, want the formatted mail", content)
+	}
+}
+
+func TestFileMailerWithoutDir(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	mailer := &FileMailer{From: "no-reply@cvilo.com"}
+	if err := mailer.Send(Mail{To: "jane@example.com", Subject: "Reset your password", Body: "token=secret-token"}); err != nil {
+		t.Fatalf("Send() error: %v", err)
+	}
+	if !strings.Contains(logs.String(), "jane@example.com") || strings.Contains(logs.String(), "secret-token") {
+		t.Errorf("Send() logged %q, want the recipient without the body", logs.String())
+	}
+}
+
+func TestNewMailerFromEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		driver   string
+		host     string
+		expected string
+		err      error
+	}{
+		{name: "Development without SMTP", expected: "*services.FileMailer"},
+		{name: "Development with SMTP", driver: "smtp", host: "smtp.example.com", expected: "*services.SMTPMailer"},
+		{name: "Release with SMTP", mode: "release", driver: "smtp", host: "smtp.example.com", expected: "*services.SMTPMailer"},
+		{name: "Release without SMTP", mode: "release", err: ErrMailerNotConfigured},
+		{name: "Release without SMTP host", mode: "release", driver: "smtp", err: ErrMailerNotConfigured},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GIN_MODE", tt.mode)
+			t.Setenv("MAIL_DRIVER", tt.driver)
+			t.Setenv("SMTP_HOST", tt.host)
+
+			mailer, err := newMailerFromEnv()
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("newMailerFromEnv() error = %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && fmt.Sprintf("%T", mailer) != tt.expected {
+				t.Errorf("newMailerFromEnv() = %T, want %s", mailer, tt.expected)
+			}
+		})
+	}
+}
+
+func TestClientAreaLink(t *testing.T) {
+	tests := []struct {
+		base     string
+		expected string
+	}{
+		{"", "http://localhost:3009/auth/reset-password?token=a%2Bb"},
+		{"https://app.cvilo.com/", "https://app.cvilo.com/auth/reset-password?token=a%2Bb"},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("CLIENTAREA_URL", tt.base)
+		if link := clientAreaLink("/auth/reset-password", "a+b"); link != tt.expected {
+			t.Errorf("clientAreaLink(%q) = %s, want %s", tt.base, link, tt.expected)
+		}
+	}
+}
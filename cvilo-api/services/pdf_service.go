@@ -2,79 +2,76 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
+	"sync"
 	"time"
 
-	"github.com/chromedp/cdproto/page"
-	"github.com/chromedp/cdproto/runtime"
 	"github.com/chromedp/chromedp"
+	"github.com/smhnaqvi/cvilo/pdfrender"
 )
 
-type PDFService struct{}
+type PDFService struct {
+	pool *pdfrender.BrowserPool
+}
 
-func NewPDFService() *PDFService {
-	return &PDFService{}
+var (
+	browserPool     *pdfrender.BrowserPool
+	browserPoolOnce sync.Once
+)
+
+// GetBrowserPool returns the process-wide browser pool, configured from the environment on first use
+func GetBrowserPool() *pdfrender.BrowserPool {
+	browserPoolOnce.Do(func() {
+		browserPool = pdfrender.NewBrowserPool(
+			envInt("PDF_MAX_TABS", 4),
+			envInt("PDF_MAX_QUEUE", 32),
+			time.Duration(envInt("PDF_RENDER_TIMEOUT_SECONDS", 30))*time.Second,
+		)
+	})
+	return browserPool
 }
 
-// GeneratePDFFromURL generates a PDF from a given URL
-func (ps *PDFService) GeneratePDFFromURL(url string, filename string) ([]byte, error) {
-	// Create a new Chrome context
-	ctx, cancel := chromedp.NewContext(context.Background())
-	defer cancel()
+// envInt reads an integer environment variable with a default
+func envInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
 
-	// Set timeout
-	ctx, cancel = context.WithTimeout(ctx, 30*time.Second)
-	defer cancel()
+func NewPDFService() *PDFService {
+	return &PDFService{
+		pool: GetBrowserPool(),
+	}
+}
 
+// GeneratePDFFromURL generates a PDF from a given URL with the page layout in opts.
+// Rendering is cancelled as soon as ctx is done, e.g. when the HTTP client disconnects.
+func (ps *PDFService) GeneratePDFFromURL(ctx context.Context, url string, filename string, opts pdfrender.PDFOptions) ([]byte, error) {
 	var pdfBuffer []byte
 
-	// Navigate to the URL and generate PDF
-	err := chromedp.Run(ctx,
+	// Navigate to the URL and generate PDF in a pooled tab
+	err := ps.pool.Run(ctx,
 		chromedp.Navigate(url),
 		// Wait for the page to be ready
 		chromedp.WaitReady("body", chromedp.ByQuery),
-		// Wait for any dynamic content to load
-		chromedp.Sleep(3*time.Second),
-		// Wait for page to be completely loaded
-		chromedp.ActionFunc(func(ctx context.Context) error {
-			// Wait for the page to be fully loaded
-			_, _, err := runtime.Evaluate(`document.readyState`).Do(ctx)
-			if err != nil {
-				return err
-			}
-
-			// Wait for all images to load
-			_, _, err = runtime.Evaluate(`
-				Promise.all(
-					Array.from(document.images).map(img => {
-						if (img.complete) return Promise.resolve();
-						return new Promise(resolve => {
-							img.onload = resolve;
-							img.onerror = resolve;
-						});
-					})
-				)
-			`).Do(ctx)
-			return err
-		}),
+		// Wait for fonts, images and window.__READY__
+		pdfrender.WaitUntilReady(),
 		// Generate PDF
 		chromedp.ActionFunc(func(ctx context.Context) error {
 			var err error
-			pdfBuffer, _, err = page.PrintToPDF().
-				WithPrintBackground(true).
-				WithPaperWidth(8.27).   // A4 width in inches
-				WithPaperHeight(11.69). // A4 height in inches
-				WithMarginTop(0.4).     // 10mm margin
-				WithMarginBottom(0.4).
-				WithMarginLeft(0.4).
-				WithMarginRight(0.4).
-				Do(ctx)
+			pdfBuffer, _, err = opts.PrintParams().Do(ctx)
 			return err
 		}),
 	)
 
 	if err != nil {
+		if errors.Is(err, pdfrender.ErrRenderQueueFull) || errors.Is(err, context.Canceled) {
+			return nil, err
+		}
 		return nil, fmt.Errorf("failed to generate PDF: %v", err)
 	}
 
@@ -82,7 +79,7 @@ func (ps *PDFService) GeneratePDFFromURL(url string, filename string) ([]byte, e
 }
 
 // GeneratePDFFromHTML generates a PDF from HTML content
-func (ps *PDFService) GeneratePDFFromHTML(htmlContent string, filename string) ([]byte, error) {
+func (ps *PDFService) GeneratePDFFromHTML(ctx context.Context, htmlContent string, filename string, opts pdfrender.PDFOptions) ([]byte, error) {
 	// Create a temporary HTML file
 	tempFile, err := os.CreateTemp("", "resume-*.html")
 	if err != nil {
@@ -102,7 +99,7 @@ func (ps *PDFService) GeneratePDFFromHTML(htmlContent string, filename string) (
 	fileURL := fmt.Sprintf("file://%s", tempFile.Name())
 
 	// Generate PDF from the temporary file
-	return ps.GeneratePDFFromURL(fileURL, filename)
+	return ps.GeneratePDFFromURL(ctx, fileURL, filename, opts)
 }
 
 // SavePDFToFile saves PDF buffer to a file
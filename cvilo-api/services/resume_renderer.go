@@ -0,0 +1,296 @@
+package services
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/smhnaqvi/cvilo/models"
+)
+
+//go:embed templates/resume/*.html
+var resumeTemplateFS embed.FS
+
+// DefaultResumeTemplate is used when a resume names a template that does not exist
+const DefaultResumeTemplate = "modern"
+
+// ResumeTheme holds the colours a resume template is rendered with
+type ResumeTheme struct {
+	Accent        string
+	Text          string
+	Muted         string
+	Background    string
+	BackgroundAlt string
+}
+
+// resumeTemplateThemes lists the themes of every template, the first one being the default
+var resumeTemplateThemes = map[string][]string{
+	"modern":  {"green", "blue", "purple"},
+	"classic": {"blue", "green", "black"},
+}
+
+var resumeThemes = map[string]map[string]ResumeTheme{
+	"modern": {
+		"green":  {Accent: "#27ae60", Text: "#2d3a4a", Muted: "#5b6b7a", Background: "#667eea", BackgroundAlt: "#764ba2"},
+		"blue":   {Accent: "#2f80ed", Text: "#1f2d3d", Muted: "#5b6b7a", Background: "#56ccf2", BackgroundAlt: "#2f80ed"},
+		"purple": {Accent: "#8e44ad", Text: "#2d2a4a", Muted: "#6b5b7a", Background: "#c471f5", BackgroundAlt: "#fa71cd"},
+	},
+	"classic": {
+		"blue":  {Accent: "#1f4e79", Text: "#222222", Muted: "#555555", Background: "#ffffff", BackgroundAlt: "#ffffff"},
+		"green": {Accent: "#2e6b3f", Text: "#222222", Muted: "#555555", Background: "#ffffff", BackgroundAlt: "#ffffff"},
+		"black": {Accent: "#000000", Text: "#111111", Muted: "#444444", Background: "#ffffff", BackgroundAlt: "#ffffff"},
+	},
+}
+
+// ResumeEntry is a dated entry of a resume section, such as a position or a degree
+type ResumeEntry struct {
+	Title    string
+	Subtitle string
+	Location string
+	Period   string
+	Points   []string
+}
+
+// ResumeItem is a single-line entry such as a certification or a language
+type ResumeItem struct {
+	Name   string
+	Detail string
+	Date   string
+}
+
+// ResumeView is a resume prepared for display, with sections decoded, ordered and dates formatted
+type ResumeView struct {
+	Name     string
+	Headline string
+	Email    string
+	Phone    string
+	Address  string
+	Website  string
+	LinkedIn string
+	GitHub   string
+	Summary  string
+
+	Experience     []ResumeEntry
+	Education      []ResumeEntry
+	Skills         []string
+	Projects       []ResumeEntry
+	Certifications []ResumeItem
+	Languages      []ResumeItem
+	Awards         []ResumeItem
+	Interests      string
+	References     string
+
+	Theme ResumeTheme
+}
+
+// ResumeRenderer renders stored resumes to HTML with the embedded templates
+type ResumeRenderer struct {
+	templates map[string]*template.Template
+}
+
+// NewResumeRenderer parses the embedded resume templates
+func NewResumeRenderer() (*ResumeRenderer, error) {
+	templates := make(map[string]*template.Template)
+	for name := range resumeTemplateThemes {
+		tmpl, err := template.ParseFS(resumeTemplateFS, "templates/resume/"+name+".html")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse resume template %s: %v", name, err)
+		}
+		templates[name] = tmpl
+	}
+	return &ResumeRenderer{templates: templates}, nil
+}
+
+// RenderHTML renders the resume with its stored template and theme.
+// Unknown templates fall back to the default template, unknown themes to the template's default theme.
+func (rr *ResumeRenderer) RenderHTML(resume *models.ResumeModel) (string, error) {
+	view, err := BuildResumeView(resume)
+	if err != nil {
+		return "", err
+	}
+
+	templateName := resume.Template
+	if _, ok := rr.templates[templateName]; !ok {
+		templateName = DefaultResumeTemplate
+	}
+	view.Theme = resolveResumeTheme(templateName, resume.Theme)
+
+	var buf bytes.Buffer
+	if err := rr.templates[templateName].Execute(&buf, view); err != nil {
+		return "", fmt.Errorf("failed to render resume template %s: %v", templateName, err)
+	}
+	return buf.String(), nil
+}
+
+// resolveResumeTheme returns the named theme of a template, or its default theme
+func resolveResumeTheme(templateName string, themeName string) ResumeTheme {
+	if theme, ok := resumeThemes[templateName][themeName]; ok {
+		return theme
+	}
+	return resumeThemes[templateName][resumeTemplateThemes[templateName][0]]
+}
+
+// BuildResumeView decodes the resume sections and prepares them for display.
+// Entries are ordered most recent first and dates are formatted as "Jan 2006".
+func BuildResumeView(resume *models.ResumeModel) (*ResumeView, error) {
+	sections, err := resume.DecodeSections()
+	if err != nil {
+		return nil, err
+	}
+
+	view := &ResumeView{
+		Name:       resume.FullName,
+		Email:      resume.Email,
+		Phone:      resume.Phone,
+		Address:    resume.Address,
+		Website:    resume.Website,
+		LinkedIn:   resume.LinkedIn,
+		GitHub:     resume.GitHub,
+		Summary:    resume.Summary,
+		Interests:  strings.TrimSpace(resume.Interests),
+		References: strings.TrimSpace(resume.References),
+	}
+	if view.Summary == "" {
+		view.Summary = resume.Objective
+	}
+
+	experience := sections.Experience
+	sort.SliceStable(experience, func(i, j int) bool {
+		if experience[i].IsCurrent != experience[j].IsCurrent {
+			return experience[i].IsCurrent
+		}
+		return experience[i].StartDate.After(experience[j].StartDate)
+	})
+	for _, exp := range experience {
+		points := splitResumePoints(exp.Description)
+		if len(exp.Technologies) > 0 {
+			points = append(points, "Technologies: "+strings.Join(exp.Technologies, ", "))
+		}
+		view.Experience = append(view.Experience, ResumeEntry{
+			Title:    exp.Position,
+			Subtitle: exp.Company,
+			Location: exp.Location,
+			Period:   formatResumePeriod(exp.StartDate, exp.EndDate, exp.IsCurrent || exp.EndDate == nil),
+			Points:   points,
+		})
+	}
+	if len(experience) > 0 {
+		view.Headline = experience[0].Position
+	}
+
+	education := sections.Education
+	sort.SliceStable(education, func(i, j int) bool {
+		return education[i].StartDate.After(education[j].StartDate)
+	})
+	for _, edu := range education {
+		degree := edu.Degree
+		if edu.FieldOfStudy != "" && edu.FieldOfStudy != edu.Degree {
+			degree = strings.TrimSpace(degree + " in " + edu.FieldOfStudy)
+		}
+		var points []string
+		if edu.GPA != "" {
+			points = append(points, "GPA: "+edu.GPA)
+		}
+		points = append(points, splitResumePoints(edu.Description)...)
+		view.Education = append(view.Education, ResumeEntry{
+			Title:    degree,
+			Subtitle: edu.Institution,
+			Location: edu.Location,
+			Period:   formatResumePeriod(edu.StartDate, edu.EndDate, false),
+			Points:   points,
+		})
+	}
+
+	for _, skill := range sections.Skills {
+		if name := strings.TrimSpace(skill.Name); name != "" {
+			view.Skills = append(view.Skills, name)
+		}
+	}
+
+	projects := sections.Projects
+	sort.SliceStable(projects, func(i, j int) bool {
+		return projects[i].StartDate.After(projects[j].StartDate)
+	})
+	for _, project := range projects {
+		points := splitResumePoints(project.Description)
+		if len(project.Technologies) > 0 {
+			points = append(points, "Technologies: "+strings.Join(project.Technologies, ", "))
+		}
+		for _, link := range []string{project.URL, project.GitHub} {
+			if link != "" {
+				points = append(points, link)
+			}
+		}
+		view.Projects = append(view.Projects, ResumeEntry{
+			Title:  project.Name,
+			Period: formatResumePeriod(project.StartDate, project.EndDate, false),
+			Points: points,
+		})
+	}
+
+	for _, cert := range sections.Certifications {
+		view.Certifications = append(view.Certifications, ResumeItem{
+			Name:   cert.Name,
+			Detail: cert.Issuer,
+			Date:   formatResumeDate(cert.IssueDate),
+		})
+	}
+
+	for _, lang := range sections.Languages {
+		view.Languages = append(view.Languages, ResumeItem{Name: lang.Name, Detail: lang.Proficiency})
+	}
+
+	for _, award := range sections.Awards {
+		date := ""
+		if award.IssueDate != nil {
+			date = formatResumeDate(*award.IssueDate)
+		}
+		view.Awards = append(view.Awards, ResumeItem{Name: award.Name, Detail: award.Issuer, Date: date})
+	}
+
+	return view, nil
+}
+
+// splitResumePoints turns a free-text description into bullet points
+func splitResumePoints(text string) []string {
+	var points []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "•-*"))
+		if line != "" {
+			points = append(points, line)
+		}
+	}
+	return points
+}
+
+// formatResumeDate formats a date as "Jan 2006", or returns an empty string for zero dates
+func formatResumeDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("Jan 2006")
+}
+
+// formatResumePeriod formats a date range such as "Jun 2023 – Present"
+func formatResumePeriod(start time.Time, end *time.Time, isCurrent bool) string {
+	from := formatResumeDate(start)
+	to := ""
+	if isCurrent {
+		to = "Present"
+	} else if end != nil {
+		to = formatResumeDate(*end)
+	}
+
+	switch {
+	case from == "":
+		return to
+	case to == "":
+		return from
+	default:
+		return from + " – " + to
+	}
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/smhnaqvi/cvilo/models"
+)
+
+func TestFormatResumePeriod(t *testing.T) {
+	start := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2023, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		start     time.Time
+		end       *time.Time
+		isCurrent bool
+		expected  string
+	}{
+		{name: "Closed period", start: start, end: &end, expected: "Mar 2021 – Jun 2023"},
+		{name: "Current period", start: start, isCurrent: true, expected: "Mar 2021 – Present"},
+		{name: "Start only", start: start, expected: "Mar 2021"},
+		{name: "End only", end: &end, expected: "Jun 2023"},
+		{name: "No dates", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := formatResumePeriod(tt.start, tt.end, tt.isCurrent)
+			if result != tt.expected {
+				t.Errorf("formatResumePeriod() = %s, want %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResumeRendererRenderHTML(t *testing.T) {
+	renderer, err := NewResumeRenderer()
+	if err != nil {
+		t.Fatalf("NewResumeRenderer() error: %v", err)
+	}
+
+	resume := &models.ResumeModel{
+		FullName:   "Jane Doe",
+		Email:      "jane@example.com",
+		Summary:    "Backend engineer",
+		Experience: `[{"company":"Acme","position":"Senior Engineer","start_date":"2021-03-01T00:00:00Z","is_current":true,"description":"Built APIs\n- Led migrations"}]`,
+		Skills:     `[{"name":"Go","category":"Technical","level":5}]`,
+		Awards:     "Employee of the year",
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		theme    string
+		accent   string
+	}{
+		{name: "Modern default theme", template: "modern", theme: "", accent: "#27ae60"},
+		{name: "Classic named theme", template: "classic", theme: "black", accent: "#000000"},
+		{name: "Unknown template falls back", template: "light", theme: "light", accent: "#27ae60"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resume.Template = tt.template
+			resume.Theme = tt.theme
+
+			html, err := renderer.RenderHTML(resume)
+			if err != nil {
+				t.Fatalf("RenderHTML() error: %v", err)
+			}
+			for _, want := range []string{"Jane Doe", "Senior Engineer", "Led migrations", "Mar 2021 – Present", "Employee of the year", tt.accent} {
+				if !strings.Contains(html, want) {
+					t.Errorf("RenderHTML() output does not contain %q", want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/smhnaqvi/cvilo/models"
+)
+
+const (
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 7 * 24 * time.Hour
+)
+
+// ErrInvalidRefreshToken is returned for unknown, expired or revoked refresh tokens
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+// ClientInfo identifies the client a session is used from
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+	RequestID string // ID of the request, see utils.ResponseMiddleware
+}
+
+// Session is an open login session of a user
+type Session struct {
+	ID         string    `json:"id"`
+	Device     string    `json:"device"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	StartedAt  time.Time `json:"started_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// newOpaqueToken returns a random URL-safe token
+func newOpaqueToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken returns the hex SHA-256 of a token, which is what the database stores
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// issueTokens signs an access token bound to the session and stores a new refresh token in the family
+func (s *AuthService) issueTokens(user models.UserModel, client ClientInfo, familyID string, startedAt time.Time, rotated *models.RefreshTokenModel) (*TokenPair, error) {
+	refreshToken, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	record := &models.RefreshTokenModel{
+		UserID:           user.ID,
+		FamilyID:         familyID,
+		TokenHash:        hashToken(refreshToken),
+		UserAgent:        client.UserAgent,
+		IPAddress:        client.IPAddress,
+		SessionStartedAt: startedAt,
+		ExpiresAt:        now.Add(refreshTokenTTL),
+	}
+	if rotated != nil {
+		err = rotated.Rotate(record)
+	} else {
+		err = record.Create()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.signClaims(user, familyID, accessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresAt:    now.Add(accessTokenTTL),
+	}, nil
+}
+
+// RefreshTokens exchanges a refresh token for a new token pair. The refresh token is revoked and replaced by a
+// new one in the same session. Presenting a token that was already exchanged means it leaked, so the whole session
+// is revoked and models.ErrRefreshTokenReused returned.
+func (s *AuthService) RefreshTokens(refreshToken string, client ClientInfo) (*TokenPair, *models.UserModel, error) {
+	var current models.RefreshTokenModel
+	if err := current.GetByHash(hashToken(refreshToken)); err != nil {
+		if errors.Is(err, models.ErrRefreshTokenNotFound) {
+			return nil, nil, ErrInvalidRefreshToken
+		}
+		return nil, nil, err
+	}
+
+	if current.ReplacedByID != nil {
+		return nil, nil, s.revokeReusedFamily(&current)
+	}
+	if !current.Active(time.Now()) {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	var user models.UserModel
+	if err := user.GetUserByID(current.UserID); err != nil || !user.IsActive {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	tokenPair, err := s.issueTokens(user, client, current.FamilyID, current.SessionStartedAt, &current)
+	if errors.Is(err, models.ErrRefreshTokenReused) {
+		// Exchanged concurrently by another client holding the same token
+		return nil, nil, s.revokeReusedFamily(&current)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return tokenPair, &user, nil
+}
+
+// revokeReusedFamily ends the session of a reused refresh token
+func (s *AuthService) revokeReusedFamily(token *models.RefreshTokenModel) error {
+	if _, err := token.RevokeFamily(token.UserID, token.FamilyID); err != nil {
+		return err
+	}
+	return models.ErrRefreshTokenReused
+}
+
+// Logout ends a session of the user. It reports whether the session was open.
+func (s *AuthService) Logout(userID uint, sessionID string) (bool, error) {
+	var tokens models.RefreshTokenModel
+	revoked, err := tokens.RevokeFamily(userID, sessionID)
+	return revoked > 0, err
+}
+
+// LogoutAll ends every session of the user and returns how many were open
+func (s *AuthService) LogoutAll(userID uint) (int, error) {
+	sessions, err := s.GetSessions(userID, "")
+	if err != nil {
+		return 0, err
+	}
+	var tokens models.RefreshTokenModel
+	if _, err := tokens.RevokeAllForUser(userID); err != nil {
+		return 0, err
+	}
+	return len(sessions), nil
+}
+
+// GetSessions lists the open sessions of the user, marking the one with currentSessionID
+func (s *AuthService) GetSessions(userID uint, currentSessionID string) ([]Session, error) {
+	var tokens models.RefreshTokenModel
+	active, err := tokens.GetActiveSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]Session, 0, len(active))
+	for _, token := range active {
+		sessions = append(sessions, Session{
+			ID:         token.FamilyID,
+			Device:     DescribeDevice(token.UserAgent),
+			UserAgent:  token.UserAgent,
+			IPAddress:  token.IPAddress,
+			StartedAt:  token.SessionStartedAt,
+			LastUsedAt: token.CreatedAt,
+			ExpiresAt:  token.ExpiresAt,
+			Current:    currentSessionID != "" && token.FamilyID == currentSessionID,
+		})
+	}
+	return sessions, nil
+}
+
+// userAgentBrowsers and userAgentSystems are matched in order, so more specific markers come first
+var (
+	userAgentBrowsers = []struct{ marker, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	}
+	userAgentSystems = []struct{ marker, name string }{
+		{"Android", "Android"},
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	}
+)
+
+// DescribeDevice summarises a user agent as "Browser on System", or the client name for non-browser clients
+func DescribeDevice(userAgent string) string {
+	if strings.TrimSpace(userAgent) == "" {
+		return "Unknown device"
+	}
+
+	browser, system := "", ""
+	for _, b := range userAgentBrowsers {
+		if strings.Contains(userAgent, b.marker) {
+			browser = b.name
+			break
+		}
+	}
+	for _, o := range userAgentSystems {
+		if strings.Contains(userAgent, o.marker) {
+			system = o.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	}
+	// Non-browser clients such as curl/8.4.0 or okhttp/4.12.0
+	name, _, _ := strings.Cut(strings.Fields(userAgent)[0], "/")
+	return name
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"testing"
+)
+
+func TestOpaqueTokenHash(t *testing.T) {
+	first, err := newOpaqueToken()
+	if err != nil {
+		t.Fatalf("newOpaqueToken() error: %v", err)
+	}
+	second, _ := newOpaqueToken()
+	if first == second || len(first) != 43 {
+		t.Errorf("newOpaqueToken() = %q, %q, want two distinct 43 character tokens", first, second)
+	}
+
+	if hashToken(first) != hashToken(first) || hashToken(first) == hashToken(second) {
+		t.Errorf("hashToken() is not a stable hash of the token")
+	}
+	if len(hashToken(first)) != 64 {
+		t.Errorf("hashToken() = %q, want 64 hex characters", hashToken(first))
+	}
+}
+
+func TestDescribeDevice(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		expected  string
+	}{
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36", "Chrome on macOS"},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36 Edg/126.0.0.0", "Edge on Windows"},
+		{"Mozilla/5.0 (X11; Linux x86_64; rv:127.0) Gecko/20100101 Firefox/127.0", "Firefox on Linux"},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1", "Safari on iOS"},
+		{"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Mobile Safari/537.36", "Chrome on Android"},
+		{"curl/8.4.0", "curl"},
+		{"", "Unknown device"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			result := DescribeDevice(tt.userAgent)
+			if result != tt.expected {
+				t.Errorf("DescribeDevice(%s) = %s, want %s", tt.userAgent, result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// rfc6238Secret is the SHA-1 key of the RFC 6238 test vectors, "12345678901234567890", in base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 appendix B, truncated to 6 digits
+	tests := []struct {
+		unix     int64
+		expected string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		code, err := totpCode(rfc6238Secret, totpStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("totpCode() error: %v", err)
+		}
+		if code != tt.expected {
+			t.Errorf("totpCode(%d) = %s, want %s", tt.unix, code, tt.expected)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := totpStep(now)
+	previous, _ := totpCode(rfc6238Secret, step-1)
+
+	tests := []struct {
+		name         string
+		code         string
+		lastUsedStep int64
+		valid        bool
+	}{
+		{"Current code", "050471", 0, true},
+		{"Code with a space", "050 471", 0, true},
+		{"Previous step for clock drift", previous, 0, true},
+		{"Replayed code", "050471", step, false},
+		{"Wrong code", "123456", 0, false},
+		{"Too short", "05047", 0, false},
+		{"Code from long ago", "287082", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, valid := validateTOTP(rfc6238Secret, tt.code, now, tt.lastUsedStep); valid != tt.valid {
+				t.Errorf("validateTOTP(%s) = %v, want %v", tt.code, valid, tt.valid)
+			}
+		})
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := totpProvisioningURI("jane@example.com", rfc6238Secret)
+
+	expected := "otpauth://totp/Cvilo:jane@example.com?algorithm=SHA1&digits=6&issuer=Cvilo&period=30&secret=" + rfc6238Secret
+	if uri != expected {
+		t.Errorf("totpProvisioningURI() = %s, want %s", uri, expected)
+	}
+}
+
+func TestSealTOTPSecret(t *testing.T) {
+	t.Setenv("TOTP_ENCRYPTION_KEY", "first-key")
+
+	sealed, err := sealTOTPSecret(rfc6238Secret)
+	if err != nil {
+		t.Fatalf("sealTOTPSecret() error: %v", err)
+	}
+	if strings.Contains(sealed, rfc6238Secret) {
+		t.Errorf("sealTOTPSecret() = %s, contains the secret in clear", sealed)
+	}
+	if opened, err := openTOTPSecret(sealed); err != nil || opened != rfc6238Secret {
+		t.Errorf("openTOTPSecret() = %s, %v, want %s", opened, err, rfc6238Secret)
+	}
+
+	t.Setenv("TOTP_ENCRYPTION_KEY", "second-key")
+	if _, err := openTOTPSecret(sealed); err == nil {
+		t.Errorf("openTOTPSecret() with another key succeeded, want an error")
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		t.Fatalf("newRecoveryCodes() error: %v", err)
+	}
+	if len(codes) != recoveryCodeCount || len(hashes) != recoveryCodeCount {
+		t.Fatalf("newRecoveryCodes() returned %d codes, want %d", len(codes), recoveryCodeCount)
+	}
+
+	code := codes[0]
+	if len(code) != 11 || code[5] != '-' || !isRecoveryCode(code) {
+		t.Errorf("newRecoveryCode() = %s, want xxxxx-xxxxx", code)
+	}
+	typed := strings.ToUpper(strings.Replace(code, "-", " ", 1))
+	if hashToken(normalizeRecoveryCode(typed)) != hashes[0] {
+		t.Errorf("normalizeRecoveryCode(%s) does not match the stored hash of %s", typed, code)
+	}
+	if isRecoveryCode("123 456") {
+		t.Errorf("isRecoveryCode(123 456) = true, want a TOTP code")
+	}
+}
+
+func TestMFAChallengeIsNotAnAccessToken(t *testing.T) {
+	claims := mfaChallengeClaims{
+		UserID:           7,
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(mfaChallengeTTL))},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(mfaChallengeKey())
+	if err != nil {
+		t.Fatalf("SignedString() error: %v", err)
+	}
+
+	if _, err := NewAuthService().ValidateJWT(token); err == nil {
+		t.Errorf("ValidateJWT() accepted an MFA challenge token")
+	}
+}
@@ -0,0 +1,312 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/smhnaqvi/cvilo/models"
+)
+
+const (
+	mfaChallengeTTL   = 5 * time.Minute
+	maxMFAAttempts    = 5
+	recoveryCodeCount = 10
+)
+
+var (
+	// ErrTwoFactorAlreadyEnabled is returned when enrolling a user whose logins already require a second factor
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	// ErrTwoFactorNotEnabled is returned for operations requiring an enabled second factor
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled")
+	// ErrIncorrectPassword is returned when the password confirming a two-factor change is wrong
+	ErrIncorrectPassword = errors.New("password is incorrect")
+	// ErrInvalidMFACode is returned for wrong TOTP and recovery codes
+	ErrInvalidMFACode = errors.New("invalid authentication code")
+	// ErrTooManyMFAAttempts is returned once a login challenge had maxMFAAttempts wrong codes
+	ErrTooManyMFAAttempts = errors.New("too many invalid codes, please log in again")
+)
+
+// TwoFactorSetup is the secret of a new enrollment, shown once for the user to add it to an authenticator app
+type TwoFactorSetup struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"` // render as a QR code
+}
+
+// MFAChallenge is returned by a password login of a user with two-factor authentication, in place of the tokens
+type MFAChallenge struct {
+	Token     string    `json:"mfa_token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// mfaChallengeClaims identify the user of a login challenge. They are signed with a key of their own, so a challenge
+// token is never accepted as an access token.
+type mfaChallengeClaims struct {
+	UserID uint `json:"user_id"`
+	jwt.RegisteredClaims
+}
+
+func mfaChallengeKey() []byte {
+	jwtSecret := os.Getenv("JWT_SECRET")
+	if jwtSecret == "" {
+		jwtSecret = "your-secret-key"
+	}
+	return []byte(jwtSecret + ":mfa-challenge")
+}
+
+// SetupTwoFactor starts an enrollment with a new secret. Logins keep working with the password alone until the
+// enrollment is confirmed with EnableTwoFactor.
+func (s *AuthService) SetupTwoFactor(user models.UserModel) (*TwoFactorSetup, error) {
+	var settings models.TwoFactorModel
+	err := settings.GetByUserID(user.ID)
+	if err == nil && settings.Enabled() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if err != nil && !errors.Is(err, models.ErrTwoFactorNotFound) {
+		return nil, err
+	}
+
+	secret, err := newTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := sealTOTPSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+	enrollment := models.TwoFactorModel{UserID: user.ID, Secret: sealed}
+	if err := enrollment.StartEnrollment(); err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorSetup{
+		Secret:     secret,
+		OTPAuthURI: totpProvisioningURI(user.Email, secret),
+	}, nil
+}
+
+// EnableTwoFactor completes the enrollment with a code from the authenticator app and returns the recovery codes,
+// which are not stored in clear and cannot be shown again
+func (s *AuthService) EnableTwoFactor(userID uint, code string) ([]string, error) {
+	var settings models.TwoFactorModel
+	if err := settings.GetByUserID(userID); err != nil {
+		return nil, err
+	}
+	if settings.Enabled() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := openTOTPSecret(settings.Secret)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := validateTOTP(secret, code, time.Now(), settings.LastUsedStep)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := settings.Enable(step, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTwoFactor turns off the second factor, requiring the password and a TOTP or recovery code
+func (s *AuthService) DisableTwoFactor(userID uint, password, code string) error {
+	settings, err := s.enabledTwoFactor(userID, password)
+	if err != nil {
+		return err
+	}
+	if _, err := s.checkSecondFactor(settings, code); err != nil {
+		return err
+	}
+	return settings.Disable()
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user, requiring the password and a TOTP code
+func (s *AuthService) RegenerateRecoveryCodes(userID uint, password, code string) ([]string, error) {
+	settings, err := s.enabledTwoFactor(userID, password)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkTOTP(settings, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := settings.ReplaceRecoveryCodes(hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// enabledTwoFactor checks the password of the user and loads their enabled two-factor settings
+func (s *AuthService) enabledTwoFactor(userID uint, password string) (*models.TwoFactorModel, error) {
+	var user models.UserModel
+	if err := user.GetUserByID(userID); err != nil || !s.CheckPassword(password, user.Password) {
+		return nil, ErrIncorrectPassword
+	}
+
+	var settings models.TwoFactorModel
+	if err := settings.GetByUserID(userID); err != nil || !settings.Enabled() {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	return &settings, nil
+}
+
+// NewMFAChallenge starts the second step of a login for a user whose password was checked. Only the latest
+// challenge of a user can be completed.
+func (s *AuthService) NewMFAChallenge(user models.UserModel) (*MFAChallenge, error) {
+	var settings models.TwoFactorModel
+	if err := settings.GetByUserID(user.ID); err != nil || !settings.Enabled() {
+		return nil, ErrTwoFactorNotEnabled
+	}
+
+	challengeID, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := settings.StartChallenge(challengeID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(mfaChallengeTTL)
+	claims := mfaChallengeClaims{
+		UserID: user.ID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        challengeID,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    "cvilo-api",
+			Subject:   fmt.Sprintf("%d", user.ID),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(mfaChallengeKey())
+	if err != nil {
+		return nil, err
+	}
+	return &MFAChallenge{Token: token, ExpiresAt: expiresAt}, nil
+}
+
+// VerifyMFAChallenge completes a login challenge with a TOTP or recovery code and returns the user. usedRecoveryCode
+// reports whether a recovery code was used. After maxMFAAttempts wrong codes the challenge is void. Wrong codes count
+// as failed logins of the account and a right one completes the login, see recordMFAOutcome.
+func (s *AuthService) VerifyMFAChallenge(challengeToken, code string, client ClientInfo) (user *models.UserModel, usedRecoveryCode bool, err error) {
+	claims := &mfaChallengeClaims{}
+	_, err = jwt.ParseWithClaims(challengeToken, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return mfaChallengeKey(), nil
+	})
+	if err != nil {
+		return nil, false, models.ErrMFAChallengeInvalid
+	}
+
+	var settings models.TwoFactorModel
+	if err := settings.GetByUserID(claims.UserID); err != nil || !settings.Enabled() {
+		return nil, false, models.ErrMFAChallengeInvalid
+	}
+	if settings.ChallengeID == "" || settings.ChallengeID != claims.ID {
+		return nil, false, models.ErrMFAChallengeInvalid
+	}
+	user = &models.UserModel{}
+	if err := user.GetUserByID(claims.UserID); err != nil || !user.IsActive {
+		return nil, false, models.ErrMFAChallengeInvalid
+	}
+
+	usedRecoveryCode, err = s.checkChallengeCode(&settings, claims.ID, code)
+	recordMFAOutcome(s, user.Email, client, err)
+	if err != nil {
+		return nil, false, err
+	}
+	return user, usedRecoveryCode, nil
+}
+
+// checkChallengeCode checks the code of a login challenge and ends the challenge once a code is right. Every
+// attempt is counted before the code is checked, so parallel requests share the maxMFAAttempts of the challenge.
+func (s *AuthService) checkChallengeCode(settings *models.TwoFactorModel, challengeID string, code string) (bool, error) {
+	reserved, err := settings.ReserveAttempt(challengeID, maxMFAAttempts)
+	if err != nil {
+		return false, err
+	}
+	if !reserved {
+		return false, ErrTooManyMFAAttempts
+	}
+
+	usedRecoveryCode, err := s.checkSecondFactor(settings, code)
+	if err != nil {
+		return false, err
+	}
+	if err := settings.CompleteChallenge(challengeID); err != nil {
+		return false, err
+	}
+	return usedRecoveryCode, nil
+}
+
+// RemainingRecoveryCodes counts the unused recovery codes of the user
+func (s *AuthService) RemainingRecoveryCodes(userID uint) (int64, error) {
+	settings := models.TwoFactorModel{UserID: userID}
+	return settings.RemainingRecoveryCodes()
+}
+
+// checkSecondFactor accepts a TOTP code or an unused recovery code, reporting whether a recovery code was used
+func (s *AuthService) checkSecondFactor(settings *models.TwoFactorModel, code string) (bool, error) {
+	if isRecoveryCode(code) {
+		err := settings.UseRecoveryCode(hashToken(normalizeRecoveryCode(code)))
+		if errors.Is(err, models.ErrRecoveryCodeInvalid) {
+			return false, ErrInvalidMFACode
+		}
+		return err == nil, err
+	}
+	return false, s.checkTOTP(settings, code)
+}
+
+// checkTOTP accepts a TOTP code that was not used before
+func (s *AuthService) checkTOTP(settings *models.TwoFactorModel, code string) error {
+	secret, err := openTOTPSecret(settings.Secret)
+	if err != nil {
+		return err
+	}
+	step, ok := validateTOTP(secret, code, time.Now(), settings.LastUsedStep)
+	if !ok {
+		return ErrInvalidMFACode
+	}
+	if err := settings.MarkStepUsed(step); errors.Is(err, models.ErrMFAChallengeInvalid) {
+		// Accepted concurrently
+		return ErrInvalidMFACode
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// newRecoveryCodes returns recoveryCodeCount recovery codes and their hashes
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for len(codes) < recoveryCodeCount {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, hashToken(normalizeRecoveryCode(code)))
+	}
+	return codes, hashes, nil
+}
+
+// isRecoveryCode reports whether code is meant as a recovery code rather than a TOTP code
+func isRecoveryCode(code string) bool {
+	return len(normalizeRecoveryCode(code)) != totpDigits
+}
@@ -23,7 +23,7 @@ func testTokenGeneration() {
 	// Test 1: Generate token pair
 	fmt.Println("\n1. Testing GenerateTokenPair...")
 	authService := services.NewAuthService()
-	tokenPair, err := authService.GenerateTokenPair(user)
+	tokenPair, err := authService.GenerateTokenPair(user, services.ClientInfo{UserAgent: "token-test"})
 	if err != nil {
 		log.Fatal("Failed to generate token pair:", err)
 	}
@@ -62,7 +62,7 @@ func testTokenGeneration() {
 
 	// Test 5: Generate tokens using utility function
 	fmt.Println("\n5. Testing GenerateTokensForUser utility...")
-	tokenPair2, err := utils.GenerateTokensForUser(user)
+	tokenPair2, err := utils.GenerateTokensForUser(user, services.ClientInfo{UserAgent: "token-test"})
 	if err != nil {
 		log.Fatal("Failed to generate tokens using utility:", err)
 	}
@@ -73,8 +73,8 @@ func testTokenGeneration() {
 
 	fmt.Println("\n✅ All token generation tests passed!")
 	fmt.Println("\nYou can now use these functions in your application:")
-	fmt.Println("- authService.GenerateTokenPair(user) - Generate access + refresh tokens")
-	fmt.Println("- utils.GenerateTokensForUser(user) - Utility function for token pair")
+	fmt.Println("- authService.GenerateTokenPair(user, client) - Open a session with access + refresh tokens")
+	fmt.Println("- utils.GenerateTokensForUser(user, client) - Utility function for token pair")
 	fmt.Println("- utils.GenerateAccessTokenForUser(user) - Generate access token only")
 	fmt.Println("- utils.ValidateUserToken(token) - Validate a token")
 	fmt.Println("- utils.GetUserFromToken(token) - Get user from token")
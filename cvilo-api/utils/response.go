@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"net/http"
 	"time"
 
@@ -21,16 +23,18 @@ const (
 type ResponseCode int
 
 const (
-	CodeSuccess        ResponseCode = 200
-	CodeCreated        ResponseCode = 201
-	CodeBadRequest     ResponseCode = 400
-	CodeUnauthorized   ResponseCode = 401
-	CodeForbidden      ResponseCode = 403
-	CodeNotFound       ResponseCode = 404
-	CodeConflict       ResponseCode = 409
-	CodeValidation     ResponseCode = 422
-	CodeInternalError  ResponseCode = 500
-	CodeNotImplemented ResponseCode = 501
+	CodeSuccess            ResponseCode = 200
+	CodeCreated            ResponseCode = 201
+	CodeBadRequest         ResponseCode = 400
+	CodeUnauthorized       ResponseCode = 401
+	CodeForbidden          ResponseCode = 403
+	CodeNotFound           ResponseCode = 404
+	CodeConflict           ResponseCode = 409
+	CodeValidation         ResponseCode = 422
+	CodeTooManyRequests    ResponseCode = 429
+	CodeInternalError      ResponseCode = 500
+	CodeNotImplemented     ResponseCode = 501
+	CodeServiceUnavailable ResponseCode = 503
 )
 
 // APIResponse represents a standardized API response structure
@@ -264,28 +268,47 @@ func CreatePaginationInfo(currentPage, perPage int, total int64) PaginationInfo
 	}
 }
 
-// Response middleware for adding common headers and request ID
+// Response middleware for adding common headers and request ID. A request ID sent by the client or a proxy in
+// X-Request-ID is kept when it looks like one, so a request can be traced across services; otherwise a random
+// one is generated.
 func ResponseMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Add request ID to context
 		requestID := c.GetHeader("X-Request-ID")
-		if requestID == "" {
+		if !validRequestID(requestID) {
 			requestID = generateRequestID()
 		}
 		c.Set("request_id", requestID)
 		c.Header("X-Request-ID", requestID)
 
-		// Add common headers
-		c.Header("Content-Type", "application/json")
+		// Add common headers; the content type is left to the handler, which may send files
 		c.Header("X-API-Version", "1.0")
 
 		c.Next()
 	}
 }
 
-// generateRequestID generates a simple request ID (you might want to use a proper UUID library)
+// validRequestID reports whether an incoming request ID is short and made of safe characters, so it can be
+// logged, stored and echoed in a header as is
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// generateRequestID generates a random request ID
 func generateRequestID() string {
-	return time.Now().Format("20060102150405") + "-" + time.Now().Format("000000000")
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return time.Now().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(buf)
 }
 
 // Legacy response functions for backward compatibility
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"time"
 
@@ -194,6 +195,23 @@ func SeedDatabase(db *gorm.DB) error {
 	return nil
 }
 
+// PromoteUser gives the user with the email a role, admin by default. It backs the --promote command,
+// which is how the first admin is created.
+func PromoteUser(email string, role string) (*models.UserModel, error) {
+	if role == "" {
+		role = models.RoleAdmin
+	}
+
+	var user models.UserModel
+	if err := user.GetUserByEmail(email); err != nil {
+		return nil, fmt.Errorf("no user with email %s", email)
+	}
+	if err := user.SetRole(role); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 // GetSampleData returns sample data structures for reference
 func GetSampleData() map[string]interface{} {
 	return map[string]interface{}{
@@ -5,11 +5,11 @@ import (
 	"github.com/smhnaqvi/cvilo/services"
 )
 
-// GenerateTokensForUser is a utility function to generate access and refresh tokens for a user
+// GenerateTokensForUser is a utility function to open a session with access and refresh tokens for a user
 // This function can be used anywhere in the codebase where you need to generate tokens
-func GenerateTokensForUser(user models.UserModel) (*services.TokenPair, error) {
+func GenerateTokensForUser(user models.UserModel, client services.ClientInfo) (*services.TokenPair, error) {
 	authService := services.NewAuthService()
-	return authService.GenerateTokenPair(user)
+	return authService.GenerateTokenPair(user, client)
 }
 
 // GenerateAccessTokenForUser is a utility function to generate only an access token for a user
@@ -1,19 +1,22 @@
 module cvilo-export
 
-go 1.21
+go 1.23.0
 
 require (
-	github.com/chromedp/chromedp v0.9.3
+	github.com/chromedp/chromedp v0.13.7
 	github.com/gorilla/mux v1.8.1
+	github.com/smhnaqvi/cvilo v0.0.0
 )
 
 require (
-	github.com/chromedp/cdproto v0.0.0-20231011050154-1d073bb38998 // indirect
-	github.com/chromedp/sysutil v1.0.0 // indirect
+	github.com/chromedp/cdproto v0.0.0-20250403032234-65de8f5d025b // indirect
+	github.com/chromedp/sysutil v1.1.0 // indirect
+	github.com/go-json-experiment/json v0.0.0-20250211171154-1ae217ad3535 // indirect
 	github.com/gobwas/httphead v0.1.0 // indirect
 	github.com/gobwas/pool v0.2.1 // indirect
-	github.com/gobwas/ws v1.3.0 // indirect
-	github.com/josharian/intern v1.0.0 // indirect
-	github.com/mailru/easyjson v0.7.7 // indirect
-	golang.org/x/sys v0.13.0 // indirect
+	github.com/gobwas/ws v1.4.0 // indirect
+	golang.org/x/sys v0.33.0 // indirect
 )
+
+// The PDF renderer is shared with the API
+replace github.com/smhnaqvi/cvilo => ../cvilo-api
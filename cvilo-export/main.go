@@ -1,19 +1,22 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
+	"io"
 	"log"
 	"net/http"
 	"os"
+	"sort"
+	"strconv"
 	"time"
 
-	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/chromedp"
 	"github.com/gorilla/mux"
+	"github.com/smhnaqvi/cvilo/pdfrender"
 )
 
 // ResumeData represents the structure of resume data
@@ -32,6 +35,10 @@ type ResumeData struct {
 	Languages      []Language      `json:"languages,omitempty"`
 	Volunteer      []Volunteer     `json:"volunteer,omitempty"`
 	Awards         []Award         `json:"awards,omitempty"`
+
+	// Rendering preferences, overridable with the template and theme query parameters
+	Template string `json:"template,omitempty"`
+	Theme    string `json:"theme,omitempty"`
 }
 
 type Experience struct {
@@ -81,38 +88,51 @@ type Award struct {
 
 // PDFGenerator handles PDF generation
 type PDFGenerator struct {
-	template    *template.Template
-	pdfTemplate *template.Template
+	template  *template.Template
+	templates *TemplateRegistry
+	browsers  *pdfrender.BrowserPool
+}
+
+// RenderOptions selects how a resume is rendered
+type RenderOptions struct {
+	Template string
+	Theme    string
+	Page     pdfrender.PDFOptions
+	// Warnings explain the template or theme used instead of an unknown one
+	Warnings []string
 }
 
 // NewPDFGenerator creates a new PDF generator
-func NewPDFGenerator(templatePath string) (*PDFGenerator, error) {
+func NewPDFGenerator(templatePath string, templates *TemplateRegistry, browsers *pdfrender.BrowserPool) (*PDFGenerator, error) {
 	tmpl, err := template.ParseFiles(templatePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse template: %v", err)
 	}
 
-	pdfTmpl, err := template.ParseFiles("cv-pdf.html")
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse PDF template: %v", err)
-	}
-
 	return &PDFGenerator{
-		template:    tmpl,
-		pdfTemplate: pdfTmpl,
+		template:  tmpl,
+		templates: templates,
+		browsers:  browsers,
 	}, nil
 }
 
-// GeneratePDF generates a PDF from resume data
-func (pg *PDFGenerator) GeneratePDF(data ResumeData) ([]byte, error) {
-	// Execute template with data
-	var buf bytes.Buffer
-	err := pg.pdfTemplate.Execute(&buf, data)
+// RenderHTML renders resume data with the template and theme selected in opts
+func (pg *PDFGenerator) RenderHTML(data ResumeData, opts RenderOptions) (string, error) {
+	rt, err := pg.templates.Get(opts.Template)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute template: %v", err)
+		return "", err
 	}
+	return rt.Render(data, opts.Theme)
+}
 
-	htmlContent := buf.String()
+// GeneratePDF generates a PDF from resume data.
+// Rendering stops as soon as ctx is done, e.g. when the client disconnects.
+func (pg *PDFGenerator) GeneratePDF(ctx context.Context, data ResumeData, opts RenderOptions) ([]byte, error) {
+	// Execute template with data
+	htmlContent, err := pg.RenderHTML(data, opts)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create a temporary HTML file
 	tmpFile, err := os.CreateTemp("", "resume-*.html")
@@ -127,36 +147,19 @@ func (pg *PDFGenerator) GeneratePDF(data ResumeData) ([]byte, error) {
 	}
 	tmpFile.Close()
 
-	// Create Chrome context
-	ctx, cancel := chromedp.NewContext(context.Background())
-	defer cancel()
-	ctx, cancel = context.WithTimeout(ctx, 30*time.Second)
-	defer cancel()
-
 	var pdfBuffer []byte
 
-	// Generate PDF using Chrome
-	err = chromedp.Run(ctx,
+	// Generate PDF in a pooled Chrome tab
+	err = pg.browsers.Run(ctx,
 		chromedp.Navigate("file://"+tmpFile.Name()),
 		chromedp.WaitReady("body"),
-		chromedp.ActionFunc(func(ctx context.Context) error {
-			// Wait for any dynamic content to load
-			time.Sleep(2 * time.Second)
-			return nil
-		}),
+		// Wait for fonts, images and window.__READY__
+		pdfrender.WaitUntilReady(),
 		chromedp.ActionFunc(func(ctx context.Context) error {
 			// Generate PDF using Chrome DevTools API
 			var result []byte
 			var err error
-			result, _, err = page.PrintToPDF().
-				WithPrintBackground(true).
-				WithPaperWidth(8.27).
-				WithPaperHeight(11.69).
-				WithMarginTop(0).
-				WithMarginBottom(0).
-				WithMarginLeft(0).
-				WithMarginRight(0).
-				Do(ctx)
+			result, _, err = opts.Page.PrintParams().Do(ctx)
 
 			if err != nil {
 				return err
@@ -168,6 +171,9 @@ func (pg *PDFGenerator) GeneratePDF(data ResumeData) ([]byte, error) {
 		}),
 	)
 
+	if errors.Is(err, pdfrender.ErrRenderQueueFull) || errors.Is(err, context.Canceled) {
+		return nil, err
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate PDF: %v", err)
 	}
@@ -204,15 +210,51 @@ func (s *Server) generatePDFHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Load sample data from JSON file instead of parsing request body
-	resumeData, err := loadSampleData()
+	// Read the resume from the request body
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBodySize))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeJSONError(w, http.StatusRequestEntityTooLarge, "Request body too large", nil)
+		return
+	}
 	if err != nil {
-		http.Error(w, "Failed to load sample resume data", http.StatusInternalServerError)
+		writeJSONError(w, http.StatusBadRequest, "Failed to read request body", nil)
 		return
 	}
 
+	resumeData, err := decodeResumePayload(body)
+	if err == nil {
+		err = resumeData.Validate()
+	}
+	if err != nil {
+		if ve, ok := err.(*ValidationError); ok {
+			writeJSONError(w, http.StatusUnprocessableEntity, "Validation failed", ve.Errors)
+			return
+		}
+		writeJSONError(w, http.StatusBadRequest, "Invalid resume data", nil)
+		return
+	}
+
+	opts, fieldErrors := s.resolveRenderOptions(r, resumeData)
+	if len(fieldErrors) > 0 {
+		writeJSONError(w, http.StatusUnprocessableEntity, "Validation failed", fieldErrors)
+		return
+	}
+	for _, warning := range opts.Warnings {
+		w.Header().Add("X-Render-Warning", warning)
+	}
+
 	// Generate PDF
-	pdfBytes, err := s.pdfGenerator.GeneratePDF(resumeData)
+	pdfBytes, err := s.pdfGenerator.GeneratePDF(r.Context(), resumeData, opts)
+	if errors.Is(err, context.Canceled) {
+		// The client went away, nobody is waiting for the PDF
+		return
+	}
+	if errors.Is(err, pdfrender.ErrRenderQueueFull) {
+		w.Header().Set("Retry-After", "5")
+		writeJSONError(w, http.StatusServiceUnavailable, "PDF renderer is busy, please try again shortly", nil)
+		return
+	}
 	if err != nil {
 		log.Printf("Error generating PDF: %v", err)
 		http.Error(w, "Failed to generate PDF", http.StatusInternalServerError)
@@ -229,6 +271,138 @@ func (s *Server) generatePDFHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write(pdfBytes)
 }
 
+// defaultPDFOptions returns A4 portrait without margins, the templates draw their own padding
+func defaultPDFOptions() pdfrender.PDFOptions {
+	return pdfrender.PDFOptions{
+		PaperSize: "a4",
+		Scale:     1,
+	}
+}
+
+// resolveRenderOptions picks the template and theme from the query string or the resume data.
+// Like the API's renderer, an unknown template falls back to the default template and an unknown
+// theme to the template's default theme, with a warning, so resumes saved with other values still render.
+func (s *Server) resolveRenderOptions(r *http.Request, data ResumeData) (RenderOptions, []FieldError) {
+	opts := RenderOptions{Template: data.Template, Theme: data.Theme}
+
+	page, err := pdfrender.ParsePDFOptions(r.URL.Query(), defaultPDFOptions())
+	if err != nil {
+		var fieldErrors []FieldError
+		for _, fe := range err.(*pdfrender.PDFOptionsError).Errors {
+			fieldErrors = append(fieldErrors, FieldError{Field: fe.Field, Message: fe.Message, Code: fe.Code})
+		}
+		return opts, fieldErrors
+	}
+	opts.Page = page
+
+	if name := r.URL.Query().Get("template"); name != "" {
+		opts.Template = name
+	}
+	if theme := r.URL.Query().Get("theme"); theme != "" {
+		opts.Theme = theme
+	}
+
+	rt, err := s.pdfGenerator.templates.Get(opts.Template)
+	if err != nil {
+		if rt, err = s.pdfGenerator.templates.Get(""); err != nil {
+			return opts, []FieldError{{Field: "template", Message: err.Error(), Code: "unknown_template"}}
+		}
+		opts.Warnings = append(opts.Warnings, fmt.Sprintf("unknown template %q, rendered with %s", opts.Template, rt.Meta.Name))
+		opts.Template = rt.Meta.Name
+	}
+	if _, err := rt.Theme(opts.Theme); err != nil {
+		opts.Warnings = append(opts.Warnings, fmt.Sprintf("unknown theme %q, rendered with %s", opts.Theme, rt.Meta.DefaultTheme))
+		opts.Theme = rt.Meta.DefaultTheme
+	}
+
+	return opts, nil
+}
+
+// listTemplatesHandler lists the available templates with their themes and thumbnails
+func (s *Server) listTemplatesHandler(w http.ResponseWriter, r *http.Request) {
+	type themeInfo struct {
+		Name   string `json:"name"`
+		Accent string `json:"accent"`
+	}
+	type templateInfo struct {
+		Name         string      `json:"name"`
+		DisplayName  string      `json:"display_name"`
+		Description  string      `json:"description"`
+		DefaultTheme string      `json:"default_theme"`
+		Themes       []themeInfo `json:"themes"`
+		ThumbnailURL string      `json:"thumbnail_url,omitempty"`
+		PreviewURL   string      `json:"preview_url"`
+	}
+
+	metas := s.pdfGenerator.templates.List()
+	templates := make([]templateInfo, 0, len(metas))
+	for _, meta := range metas {
+		info := templateInfo{
+			Name:         meta.Name,
+			DisplayName:  meta.DisplayName,
+			Description:  meta.Description,
+			DefaultTheme: meta.DefaultTheme,
+			PreviewURL:   "/templates/" + meta.Name + "/preview",
+		}
+		if meta.Thumbnail != "" {
+			info.ThumbnailURL = "/templates/" + meta.Name + "/thumbnail"
+		}
+		for name, theme := range meta.Themes {
+			info.Themes = append(info.Themes, themeInfo{Name: name, Accent: theme.Accent})
+		}
+		sort.Slice(info.Themes, func(i, j int) bool { return info.Themes[i].Name < info.Themes[j].Name })
+		templates = append(templates, info)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"templates": templates,
+	})
+}
+
+// templateThumbnailHandler serves the thumbnail image of a template
+func (s *Server) templateThumbnailHandler(w http.ResponseWriter, r *http.Request) {
+	rt, err := s.pdfGenerator.templates.Get(mux.Vars(r)["name"])
+	if err != nil || rt.ThumbnailPath() == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	http.ServeFile(w, r, rt.ThumbnailPath())
+}
+
+// templatePreviewHandler renders a template as HTML using the sample resume data
+func (s *Server) templatePreviewHandler(w http.ResponseWriter, r *http.Request) {
+	sampleData, err := loadSampleData()
+	if err != nil {
+		log.Printf("Error loading sample data: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	htmlContent, err := s.pdfGenerator.RenderHTML(sampleData, RenderOptions{
+		Template: mux.Vars(r)["name"],
+		Theme:    r.URL.Query().Get("theme"),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(htmlContent))
+}
+
+// writeJSONError writes a JSON error response with optional field-level errors
+func writeJSONError(w http.ResponseWriter, status int, message string, fields []FieldError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":  message,
+		"fields": fields,
+	})
+}
+
 // serveTemplateHandler serves the HTML template
 func (s *Server) serveTemplateHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -282,9 +456,40 @@ func (s *Server) healthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("OK"))
 }
 
+// getEnvInt reads an integer environment variable with a default
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
 func main() {
+	// Load resume templates, reloading them on change in development
+	templatesDir := os.Getenv("TEMPLATES_DIR")
+	if templatesDir == "" {
+		templatesDir = "templates"
+	}
+	defaultTemplate := os.Getenv("DEFAULT_TEMPLATE")
+	if defaultTemplate == "" {
+		defaultTemplate = "modern"
+	}
+	hotReload := os.Getenv("TEMPLATES_HOT_RELOAD") == "true"
+
+	templates, err := NewTemplateRegistry(templatesDir, defaultTemplate, hotReload)
+	if err != nil {
+		log.Fatalf("Failed to load templates: %v", err)
+	}
+
+	// Keep one Chrome process alive and share it between requests
+	browsers := pdfrender.NewBrowserPool(
+		getEnvInt("PDF_MAX_TABS", 4),
+		getEnvInt("PDF_MAX_QUEUE", 32),
+		time.Duration(getEnvInt("PDF_RENDER_TIMEOUT_SECONDS", 30))*time.Second,
+	)
+
 	// Initialize PDF generator
-	pdfGenerator, err := NewPDFGenerator("cv.html")
+	pdfGenerator, err := NewPDFGenerator("cv.html", templates, browsers)
 	if err != nil {
 		log.Fatalf("Failed to initialize PDF generator: %v", err)
 	}
@@ -299,6 +504,9 @@ func main() {
 	router.HandleFunc("/generate-pdf", server.generatePDFHandler).Methods("POST")
 	router.HandleFunc("/health", server.healthCheckHandler).Methods("GET")
 	router.HandleFunc("/template", server.serveTemplateHandler).Methods("GET")
+	router.HandleFunc("/templates", server.listTemplatesHandler).Methods("GET")
+	router.HandleFunc("/templates/{name}/thumbnail", server.templateThumbnailHandler).Methods("GET")
+	router.HandleFunc("/templates/{name}/preview", server.templatePreviewHandler).Methods("GET")
 
 	// Serve static files (for testing)
 	router.PathPrefix("/static/").Handler(http.StripPrefix("/static/", http.FileServer(http.Dir("."))))
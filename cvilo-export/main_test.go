@@ -0,0 +1,87 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResolveRenderOptions(t *testing.T) {
+	registry, err := NewTemplateRegistry("templates", "modern", false)
+	if err != nil {
+		t.Fatalf("NewTemplateRegistry() error: %v", err)
+	}
+	modern, _ := registry.Get("modern")
+	server := NewServer(&PDFGenerator{templates: registry})
+
+	tests := []struct {
+		name     string
+		query    string
+		data     ResumeData
+		template string
+		theme    string
+		warnings int
+	}{
+		{name: "Stored template and theme", data: ResumeData{Template: "classic", Theme: "green"}, template: "classic", theme: "green"},
+		{name: "Query overrides stored values", query: "?template=classic&theme=blue", data: ResumeData{Template: "modern"}, template: "classic", theme: "blue"},
+		{name: "Defaults", template: "", theme: ""},
+		{name: "Unknown stored template", data: ResumeData{Template: "professional", Theme: "navy"}, template: "modern", theme: modern.Meta.DefaultTheme, warnings: 2},
+		{name: "Unknown theme", query: "?theme=rainbow", data: ResumeData{Template: "modern"}, template: "modern", theme: modern.Meta.DefaultTheme, warnings: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, fieldErrors := server.resolveRenderOptions(httptest.NewRequest("POST", "/generate-pdf"+tt.query, nil), tt.data)
+			if len(fieldErrors) > 0 {
+				t.Fatalf("resolveRenderOptions() field errors: %+v", fieldErrors)
+			}
+			if opts.Template != tt.template || opts.Theme != tt.theme || len(opts.Warnings) != tt.warnings {
+				t.Errorf("resolveRenderOptions() = %s/%s with warnings %v, want %s/%s with %d warnings",
+					opts.Template, opts.Theme, opts.Warnings, tt.template, tt.theme, tt.warnings)
+			}
+			if _, err := server.pdfGenerator.RenderHTML(sampleResumeData(t), opts); err != nil {
+				t.Errorf("RenderHTML() error: %v", err)
+			}
+		})
+	}
+}
+
+func sampleResumeData(t *testing.T) ResumeData {
+	data, err := loadSampleData()
+	if err != nil {
+		t.Fatalf("loadSampleData() error: %v", err)
+	}
+	return data
+}
+
+// failingReader fails like the body of a client that disconnected
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, io.ErrUnexpectedEOF
+}
+
+func TestGeneratePDFHandlerBodyErrors(t *testing.T) {
+	server := NewServer(&PDFGenerator{})
+
+	tests := []struct {
+		name     string
+		body     io.Reader
+		expected int
+	}{
+		{"Body too large", strings.NewReader(strings.Repeat("x", maxRequestBodySize+1)), http.StatusRequestEntityTooLarge},
+		{"Truncated body", failingReader{}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			server.generatePDFHandler(w, httptest.NewRequest(http.MethodPost, "/generate-pdf", tt.body))
+			if w.Code != tt.expected {
+				t.Errorf("generatePDFHandler() = %d, want %d", w.Code, tt.expected)
+			}
+		})
+	}
+}
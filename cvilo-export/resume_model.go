@@ -0,0 +1,352 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// APIResume mirrors the cvilo-api models.ResumeModel JSON shape.
+// Section fields are stored by the API as JSON-encoded strings, but plain
+// JSON arrays are accepted as well.
+type APIResume struct {
+	ID             uint            `json:"id"`
+	UserID         uint            `json:"user_id"`
+	Title          string          `json:"title"`
+	FullName       string          `json:"full_name"`
+	Email          string          `json:"email"`
+	Phone          string          `json:"phone"`
+	Address        string          `json:"address"`
+	Website        string          `json:"website"`
+	LinkedIn       string          `json:"linkedin"`
+	GitHub         string          `json:"github"`
+	Summary        string          `json:"summary"`
+	Objective      string          `json:"objective"`
+	Experience     json.RawMessage `json:"experience"`
+	Education      json.RawMessage `json:"education"`
+	Skills         json.RawMessage `json:"skills"`
+	Languages      json.RawMessage `json:"languages"`
+	Certifications json.RawMessage `json:"certifications"`
+	Projects       json.RawMessage `json:"projects"`
+	Awards         json.RawMessage `json:"awards"`
+	Interests      string          `json:"interests"`
+	References     string          `json:"references"`
+	Template       string          `json:"template"`
+	Theme          string          `json:"theme"`
+}
+
+// Section structs matching cvilo-api models
+type apiWorkExperience struct {
+	Company      string     `json:"company"`
+	Position     string     `json:"position"`
+	Location     string     `json:"location"`
+	StartDate    time.Time  `json:"start_date"`
+	EndDate      *time.Time `json:"end_date,omitempty"`
+	IsCurrent    bool       `json:"is_current"`
+	Description  string     `json:"description"`
+	Technologies []string   `json:"technologies,omitempty"`
+}
+
+type apiEducation struct {
+	Institution  string     `json:"institution"`
+	Degree       string     `json:"degree"`
+	FieldOfStudy string     `json:"field_of_study"`
+	Location     string     `json:"location"`
+	StartDate    time.Time  `json:"start_date"`
+	EndDate      *time.Time `json:"end_date,omitempty"`
+	GPA          string     `json:"gpa,omitempty"`
+	Description  string     `json:"description,omitempty"`
+}
+
+type apiSkill struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	Level    int    `json:"level"`
+}
+
+type apiLanguage struct {
+	Name        string `json:"name"`
+	Proficiency string `json:"proficiency"`
+}
+
+type apiCertification struct {
+	Name      string    `json:"name"`
+	Issuer    string    `json:"issuer"`
+	IssueDate time.Time `json:"issue_date"`
+}
+
+type apiProject struct {
+	Name         string     `json:"name"`
+	Description  string     `json:"description"`
+	Technologies []string   `json:"technologies"`
+	StartDate    time.Time  `json:"start_date"`
+	EndDate      *time.Time `json:"end_date,omitempty"`
+	URL          string     `json:"url,omitempty"`
+}
+
+type apiAward struct {
+	Name      string     `json:"name"`
+	Issuer    string     `json:"issuer"`
+	IssueDate *time.Time `json:"issue_date,omitempty"`
+}
+
+// isAPIResumePayload reports whether a request body uses the cvilo-api ResumeModel shape
+func isAPIResumePayload(body []byte) bool {
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(body, &probe); err != nil {
+		return false
+	}
+	_, hasFullName := probe["full_name"]
+	_, hasUserID := probe["user_id"]
+	return hasFullName || hasUserID
+}
+
+// decodeResumePayload decodes a request body in either the ResumeData or
+// the cvilo-api ResumeModel shape. Malformed fields are reported as a *ValidationError.
+func decodeResumePayload(body []byte) (ResumeData, error) {
+	if !isAPIResumePayload(body) {
+		var data ResumeData
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		if err := decoder.Decode(&data); err != nil {
+			return data, jsonFieldError(err)
+		}
+		return data, nil
+	}
+
+	var resume APIResume
+	if err := json.Unmarshal(body, &resume); err != nil {
+		return ResumeData{}, jsonFieldError(err)
+	}
+	return resume.ToResumeData()
+}
+
+// jsonFieldError converts a JSON decoding error into a field-level validation error
+func jsonFieldError(err error) error {
+	ve := &ValidationError{}
+	if typeErr, ok := err.(*json.UnmarshalTypeError); ok && typeErr.Field != "" {
+		ve.add(typeErr.Field, "invalid_type", fmt.Sprintf("must be of type %s", typeErr.Type))
+		return ve
+	}
+	ve.add("body", "invalid_json", "request body must be valid JSON: "+err.Error())
+	return ve
+}
+
+// decodeSection decodes a section stored either as a JSON string or as a raw JSON value
+func decodeSection(raw json.RawMessage, v interface{}) error {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil
+	}
+
+	if raw[0] == '"' {
+		var encoded string
+		if err := json.Unmarshal(raw, &encoded); err != nil {
+			return err
+		}
+		encoded = strings.TrimSpace(encoded)
+		if encoded == "" || encoded == "null" {
+			return nil
+		}
+		raw = json.RawMessage(encoded)
+	}
+
+	return json.Unmarshal(raw, v)
+}
+
+// ToResumeData converts the API resume into the template data structure
+func (r APIResume) ToResumeData() (ResumeData, error) {
+	ve := &ValidationError{}
+
+	var experience []apiWorkExperience
+	if err := decodeSection(r.Experience, &experience); err != nil {
+		ve.add("experience", "invalid_section", "must be a JSON array of work experience entries")
+	}
+
+	var education []apiEducation
+	if err := decodeSection(r.Education, &education); err != nil {
+		ve.add("education", "invalid_section", "must be a JSON array of education entries")
+	}
+
+	var skills []apiSkill
+	if err := decodeSection(r.Skills, &skills); err != nil {
+		ve.add("skills", "invalid_section", "must be a JSON array of skills")
+	}
+
+	var languages []apiLanguage
+	if err := decodeSection(r.Languages, &languages); err != nil {
+		ve.add("languages", "invalid_section", "must be a JSON array of languages")
+	}
+
+	var certifications []apiCertification
+	if err := decodeSection(r.Certifications, &certifications); err != nil {
+		ve.add("certifications", "invalid_section", "must be a JSON array of certifications")
+	}
+
+	var projects []apiProject
+	if err := decodeSection(r.Projects, &projects); err != nil {
+		ve.add("projects", "invalid_section", "must be a JSON array of projects")
+	}
+
+	// Awards are free text in most resumes, structured only when imported from LinkedIn
+	var awards []apiAward
+	if err := decodeSection(r.Awards, &awards); err != nil {
+		awards = nil
+		var text string
+		if json.Unmarshal(r.Awards, &text) == nil && strings.TrimSpace(text) != "" {
+			for _, line := range splitPoints(text) {
+				awards = append(awards, apiAward{Name: line})
+			}
+		}
+	}
+
+	if len(ve.Errors) > 0 {
+		return ResumeData{}, ve
+	}
+
+	data := ResumeData{
+		Name:     r.FullName,
+		Email:    r.Email,
+		Phone:    r.Phone,
+		LinkedIn: r.LinkedIn,
+		Summary:  r.Summary,
+		Template: r.Template,
+		Theme:    r.Theme,
+	}
+	if data.Summary == "" {
+		data.Summary = r.Objective
+	}
+
+	// Most recent positions first
+	sort.SliceStable(experience, func(i, j int) bool {
+		return experience[i].StartDate.After(experience[j].StartDate)
+	})
+	for _, exp := range experience {
+		if data.Title == "" && exp.IsCurrent {
+			data.Title = exp.Position
+		}
+		points := splitPoints(exp.Description)
+		if len(exp.Technologies) > 0 {
+			points = append(points, "Technologies: "+strings.Join(exp.Technologies, ", "))
+		}
+		data.Experience = append(data.Experience, Experience{
+			Role:     exp.Position,
+			Company:  exp.Company,
+			Duration: formatPeriod(exp.StartDate, exp.EndDate, exp.IsCurrent),
+			Points:   points,
+		})
+	}
+	if data.Title == "" && len(experience) > 0 {
+		data.Title = experience[0].Position
+	}
+
+	// The templates render a single education entry, use the most recent one
+	if len(education) > 0 {
+		sort.SliceStable(education, func(i, j int) bool {
+			return education[i].StartDate.After(education[j].StartDate)
+		})
+		edu := education[0]
+		degree := edu.Degree
+		if edu.FieldOfStudy != "" && edu.FieldOfStudy != edu.Degree {
+			degree = strings.TrimSpace(degree + " in " + edu.FieldOfStudy)
+		}
+		var details []string
+		if edu.GPA != "" {
+			details = append(details, "GPA: "+edu.GPA)
+		}
+		if edu.Description != "" {
+			details = append(details, edu.Description)
+		}
+		year := ""
+		if edu.EndDate != nil {
+			year = formatYear(*edu.EndDate)
+		} else if !edu.StartDate.IsZero() {
+			year = formatYear(edu.StartDate) + " – Present"
+		}
+		data.Education = Education{
+			Degree:  degree,
+			School:  edu.Institution,
+			Year:    year,
+			Details: strings.Join(details, " • "),
+		}
+	}
+
+	for _, skill := range skills {
+		if strings.TrimSpace(skill.Name) != "" {
+			data.Skills = append(data.Skills, skill.Name)
+		}
+	}
+
+	for _, lang := range languages {
+		data.Languages = append(data.Languages, Language{Name: lang.Name, Proficiency: lang.Proficiency})
+	}
+
+	for _, cert := range certifications {
+		data.Certifications = append(data.Certifications, Certification{
+			Name:   cert.Name,
+			Issuer: cert.Issuer,
+			Year:   formatYear(cert.IssueDate),
+		})
+	}
+
+	for _, project := range projects {
+		points := splitPoints(project.Description)
+		if len(project.Technologies) > 0 {
+			points = append(points, "Technologies: "+strings.Join(project.Technologies, ", "))
+		}
+		if project.URL != "" {
+			points = append(points, project.URL)
+		}
+		data.Projects = append(data.Projects, Project{
+			Name:   project.Name,
+			Type:   "Project",
+			Year:   formatYear(project.StartDate),
+			Points: points,
+		})
+	}
+
+	for _, award := range awards {
+		year := ""
+		if award.IssueDate != nil {
+			year = formatYear(*award.IssueDate)
+		}
+		data.Awards = append(data.Awards, Award{Name: award.Name, Issuer: award.Issuer, Year: year})
+	}
+
+	return data, nil
+}
+
+// splitPoints turns a free-text description into bullet points
+func splitPoints(text string) []string {
+	var points []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimLeft(line, "•-* ")
+		if line != "" {
+			points = append(points, line)
+		}
+	}
+	return points
+}
+
+// formatPeriod formats a date range such as "June 2023 – Present"
+func formatPeriod(start time.Time, end *time.Time, isCurrent bool) string {
+	if start.IsZero() {
+		return ""
+	}
+	from := start.Format("January 2006")
+	if isCurrent || end == nil {
+		return from + " – Present"
+	}
+	return from + " – " + end.Format("January 2006")
+}
+
+// formatYear formats a date as a year, or returns an empty string for zero dates
+func formatYear(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006")
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestDecodeResumePayload(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		expectedName string
+		expectedRole string
+		wantErr      bool
+	}{
+		{
+			name:         "ResumeData shape",
+			body:         `{"name": "John Doe", "experience": [{"role": "Developer", "company": "Acme"}]}`,
+			expectedName: "John Doe",
+			expectedRole: "Developer",
+		},
+		{
+			name:         "ResumeModel shape with JSON string sections",
+			body:         `{"user_id": 1, "full_name": "Jane Smith", "experience": "[{\"company\": \"Acme\", \"position\": \"Engineer\", \"start_date\": \"2021-01-01T00:00:00Z\", \"is_current\": true}]"}`,
+			expectedName: "Jane Smith",
+			expectedRole: "Engineer",
+		},
+		{
+			name:         "ResumeModel shape with array sections",
+			body:         `{"full_name": "Jane Smith", "experience": [{"company": "Acme", "position": "Engineer"}]}`,
+			expectedName: "Jane Smith",
+			expectedRole: "Engineer",
+		},
+		{
+			name:    "ResumeModel shape with malformed section",
+			body:    `{"full_name": "Jane Smith", "experience": "not json"}`,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid JSON",
+			body:    `{"name": `,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := decodeResumePayload([]byte(tt.body))
+			if tt.wantErr {
+				if _, ok := err.(*ValidationError); !ok {
+					t.Fatalf("decodeResumePayload() error = %v, want *ValidationError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeResumePayload() unexpected error: %v", err)
+			}
+			if data.Name != tt.expectedName {
+				t.Errorf("Name = %s, want %s", data.Name, tt.expectedName)
+			}
+			if len(data.Experience) != 1 || data.Experience[0].Role != tt.expectedRole {
+				t.Errorf("Experience = %+v, want role %s", data.Experience, tt.expectedRole)
+			}
+		})
+	}
+}
+
+func TestResumeDataValidate(t *testing.T) {
+	tests := []struct {
+		name           string
+		data           ResumeData
+		expectedFields []string
+	}{
+		{
+			name: "Valid resume",
+			data: ResumeData{Name: "John Doe", Email: "john@example.com"},
+		},
+		{
+			name:           "Missing name and invalid email",
+			data:           ResumeData{Email: "not-an-email"},
+			expectedFields: []string{"name", "email"},
+		},
+		{
+			name: "Experience without company",
+			data: ResumeData{
+				Name:       "John Doe",
+				Experience: []Experience{{Role: "Developer"}},
+			},
+			expectedFields: []string{"experience[0].company"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.data.Validate()
+			if len(tt.expectedFields) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() unexpected error: %v", err)
+				}
+				return
+			}
+
+			ve, ok := err.(*ValidationError)
+			if !ok {
+				t.Fatalf("Validate() error = %v, want *ValidationError", err)
+			}
+			if len(ve.Errors) != len(tt.expectedFields) {
+				t.Fatalf("Validate() returned %d errors, want %d: %v", len(ve.Errors), len(tt.expectedFields), ve)
+			}
+			for i, field := range tt.expectedFields {
+				if ve.Errors[i].Field != field {
+					t.Errorf("Errors[%d].Field = %s, want %s", i, ve.Errors[i].Field, field)
+				}
+			}
+		})
+	}
+}
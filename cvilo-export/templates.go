@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Files expected in every template directory
+const (
+	templateFileName = "template.html"
+	metaFileName     = "meta.json"
+)
+
+var themeColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{3,8}$`)
+
+// Theme holds the colours a template is rendered with
+type Theme struct {
+	Name          string `json:"name"`
+	Accent        string `json:"accent"`
+	Text          string `json:"text"`
+	Muted         string `json:"muted"`
+	Background    string `json:"background"`
+	BackgroundAlt string `json:"background_alt"`
+}
+
+// TemplateMeta describes a template as declared in its meta.json
+type TemplateMeta struct {
+	Name         string           `json:"name"`
+	DisplayName  string           `json:"display_name"`
+	Description  string           `json:"description"`
+	DefaultTheme string           `json:"default_theme"`
+	Thumbnail    string           `json:"thumbnail,omitempty"`
+	Themes       map[string]Theme `json:"themes"`
+}
+
+// ResumeTemplate is a parsed template together with its metadata
+type ResumeTemplate struct {
+	Meta     TemplateMeta
+	Dir      string
+	template *template.Template
+}
+
+// TemplateData is the value passed to resume templates
+type TemplateData struct {
+	ResumeData
+	Theme Theme
+}
+
+// Render executes the template with the given resume data and theme name.
+// An empty theme name selects the template's default theme.
+func (rt *ResumeTemplate) Render(data ResumeData, themeName string) (string, error) {
+	theme, err := rt.Theme(themeName)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := rt.template.Execute(&buf, TemplateData{ResumeData: data, Theme: theme}); err != nil {
+		return "", fmt.Errorf("failed to execute template %s: %v", rt.Meta.Name, err)
+	}
+	return buf.String(), nil
+}
+
+// Theme looks up a theme by name, falling back to the default theme when name is empty
+func (rt *ResumeTemplate) Theme(name string) (Theme, error) {
+	if name == "" {
+		name = rt.Meta.DefaultTheme
+	}
+	theme, ok := rt.Meta.Themes[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("template %s has no theme %q", rt.Meta.Name, name)
+	}
+	return theme, nil
+}
+
+// ThumbnailPath returns the path of the thumbnail file, or an empty string if there is none
+func (rt *ResumeTemplate) ThumbnailPath() string {
+	if rt.Meta.Thumbnail == "" {
+		return ""
+	}
+	return filepath.Join(rt.Dir, rt.Meta.Thumbnail)
+}
+
+// TemplateRegistry loads every template found in a directory.
+// Each template lives in its own sub-directory containing template.html and meta.json.
+type TemplateRegistry struct {
+	dir             string
+	defaultTemplate string
+	hotReload       bool
+
+	mu        sync.RWMutex
+	templates map[string]*ResumeTemplate
+	loadedAt  time.Time
+}
+
+// NewTemplateRegistry creates a registry and loads all templates from dir.
+// When hotReload is true, templates are reloaded whenever a file in dir changes.
+func NewTemplateRegistry(dir string, defaultTemplate string, hotReload bool) (*TemplateRegistry, error) {
+	registry := &TemplateRegistry{
+		dir:             dir,
+		defaultTemplate: defaultTemplate,
+		hotReload:       hotReload,
+	}
+
+	if err := registry.Load(); err != nil {
+		return nil, err
+	}
+
+	if _, ok := registry.templates[defaultTemplate]; !ok {
+		return nil, fmt.Errorf("default template %q not found in %s", defaultTemplate, dir)
+	}
+
+	return registry, nil
+}
+
+// Load (re)parses every template in the registry directory
+func (tr *TemplateRegistry) Load() error {
+	entries, err := os.ReadDir(tr.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read templates directory: %v", err)
+	}
+
+	templates := make(map[string]*ResumeTemplate)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		rt, err := loadResumeTemplate(filepath.Join(tr.dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		templates[rt.Meta.Name] = rt
+	}
+
+	if len(templates) == 0 {
+		return fmt.Errorf("no templates found in %s", tr.dir)
+	}
+
+	tr.mu.Lock()
+	tr.templates = templates
+	tr.loadedAt = time.Now()
+	tr.mu.Unlock()
+
+	log.Printf("Loaded %d templates from %s", len(templates), tr.dir)
+	return nil
+}
+
+// loadResumeTemplate parses a single template directory
+func loadResumeTemplate(dir string) (*ResumeTemplate, error) {
+	metaBytes, err := os.ReadFile(filepath.Join(dir, metaFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template metadata: %v", err)
+	}
+
+	var meta TemplateMeta
+	if err := json.Unmarshal(metaBytes, &meta); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", filepath.Join(dir, metaFileName), err)
+	}
+
+	// The directory name is the template identifier used in requests
+	meta.Name = filepath.Base(dir)
+	if meta.DisplayName == "" {
+		meta.DisplayName = meta.Name
+	}
+
+	if len(meta.Themes) == 0 {
+		return nil, fmt.Errorf("template %s declares no themes", meta.Name)
+	}
+	for name, theme := range meta.Themes {
+		theme.Name = name
+		for _, color := range []string{theme.Accent, theme.Text, theme.Muted, theme.Background, theme.BackgroundAlt} {
+			if !themeColorPattern.MatchString(color) {
+				return nil, fmt.Errorf("template %s theme %s has invalid color %q", meta.Name, name, color)
+			}
+		}
+		meta.Themes[name] = theme
+	}
+	if _, ok := meta.Themes[meta.DefaultTheme]; !ok {
+		return nil, fmt.Errorf("template %s default theme %q is not declared", meta.Name, meta.DefaultTheme)
+	}
+
+	tmpl, err := template.ParseFiles(filepath.Join(dir, templateFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %v", meta.Name, err)
+	}
+
+	return &ResumeTemplate{Meta: meta, Dir: dir, template: tmpl}, nil
+}
+
+// Get returns the named template, or the default template when name is empty
+func (tr *TemplateRegistry) Get(name string) (*ResumeTemplate, error) {
+	tr.reloadIfChanged()
+
+	if name == "" {
+		name = tr.defaultTemplate
+	}
+
+	tr.mu.RLock()
+	defer tr.mu.RUnlock()
+
+	rt, ok := tr.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("template %q not found", name)
+	}
+	return rt, nil
+}
+
+// List returns the metadata of all templates sorted by name
+func (tr *TemplateRegistry) List() []TemplateMeta {
+	tr.reloadIfChanged()
+
+	tr.mu.RLock()
+	defer tr.mu.RUnlock()
+
+	metas := make([]TemplateMeta, 0, len(tr.templates))
+	for _, rt := range tr.templates {
+		metas = append(metas, rt.Meta)
+	}
+	sort.Slice(metas, func(i, j int) bool { return metas[i].Name < metas[j].Name })
+	return metas
+}
+
+// reloadIfChanged reloads the templates in development mode when any file changed since the last load
+func (tr *TemplateRegistry) reloadIfChanged() {
+	if !tr.hotReload {
+		return
+	}
+
+	tr.mu.RLock()
+	loadedAt := tr.loadedAt
+	tr.mu.RUnlock()
+
+	changed := false
+	filepath.Walk(tr.dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.ModTime().After(loadedAt) {
+			changed = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+
+	if changed {
+		// Keep serving the previous templates if the edited ones fail to parse
+		if err := tr.Load(); err != nil {
+			log.Printf("Error reloading templates: %v", err)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTemplateRegistryRendersAllThemes(t *testing.T) {
+	registry, err := NewTemplateRegistry("templates", "modern", false)
+	if err != nil {
+		t.Fatalf("NewTemplateRegistry() error: %v", err)
+	}
+
+	data, err := loadSampleData()
+	if err != nil {
+		t.Fatalf("loadSampleData() error: %v", err)
+	}
+
+	for _, meta := range registry.List() {
+		rt, err := registry.Get(meta.Name)
+		if err != nil {
+			t.Fatalf("Get(%s) error: %v", meta.Name, err)
+		}
+		for themeName, theme := range meta.Themes {
+			t.Run(meta.Name+"/"+themeName, func(t *testing.T) {
+				html, err := rt.Render(data, themeName)
+				if err != nil {
+					t.Fatalf("Render() error: %v", err)
+				}
+				if !strings.Contains(html, data.Name) {
+					t.Errorf("rendered HTML does not contain the resume name")
+				}
+				if !strings.Contains(html, theme.Accent) {
+					t.Errorf("rendered HTML does not contain the theme accent %s", theme.Accent)
+				}
+			})
+		}
+	}
+}
+
+func TestTemplateRegistryGet(t *testing.T) {
+	registry, err := NewTemplateRegistry("templates", "modern", false)
+	if err != nil {
+		t.Fatalf("NewTemplateRegistry() error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+		wantErr  bool
+	}{
+		{name: "Default template", template: "", expected: "modern"},
+		{name: "Named template", template: "classic", expected: "classic"},
+		{name: "Unknown template", template: "missing", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt, err := registry.Get(tt.template)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Get(%s) expected error", tt.template)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Get(%s) error: %v", tt.template, err)
+			}
+			if rt.Meta.Name != tt.expected {
+				t.Errorf("Get(%s) = %s, want %s", tt.template, rt.Meta.Name, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+// Limits applied to incoming resume payloads
+const (
+	maxRequestBodySize = 1 << 20 // 1 MB
+	maxTextLength      = 5000
+	maxListLength      = 100
+)
+
+// FieldError describes a validation problem with a single field of the payload
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	Code    string `json:"code,omitempty"`
+}
+
+// ValidationError is returned when a payload fails validation
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+func (ve *ValidationError) Error() string {
+	parts := make([]string, 0, len(ve.Errors))
+	for _, fe := range ve.Errors {
+		parts = append(parts, fe.Field+": "+fe.Message)
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// add records a field error
+func (ve *ValidationError) add(field, code, message string) {
+	ve.Errors = append(ve.Errors, FieldError{Field: field, Message: message, Code: code})
+}
+
+// required records an error if value is blank
+func (ve *ValidationError) required(field, value string) {
+	if strings.TrimSpace(value) == "" {
+		ve.add(field, "required", "is required")
+	}
+}
+
+// maxLength records an error if value is longer than max characters
+func (ve *ValidationError) maxLength(field, value string, max int) {
+	if len([]rune(value)) > max {
+		ve.add(field, "too_long", fmt.Sprintf("must be at most %d characters", max))
+	}
+}
+
+// maxItems records an error if a list has more than max entries
+func (ve *ValidationError) maxItems(field string, n, max int) {
+	if n > max {
+		ve.add(field, "too_many", fmt.Sprintf("must contain at most %d entries", max))
+	}
+}
+
+// Validate checks the resume data and returns a *ValidationError listing every invalid field
+func (d ResumeData) Validate() error {
+	ve := &ValidationError{}
+
+	ve.required("name", d.Name)
+	ve.maxLength("name", d.Name, 200)
+	ve.maxLength("title", d.Title, 200)
+	ve.maxLength("phone", d.Phone, 50)
+	ve.maxLength("linkedin", d.LinkedIn, 500)
+	ve.maxLength("summary", d.Summary, maxTextLength)
+
+	if d.Email != "" {
+		if _, err := mail.ParseAddress(d.Email); err != nil {
+			ve.add("email", "invalid_email", "must be a valid email address")
+		}
+	}
+
+	ve.maxItems("skills", len(d.Skills), maxListLength)
+	for i, skill := range d.Skills {
+		field := fmt.Sprintf("skills[%d]", i)
+		ve.required(field, skill)
+		ve.maxLength(field, skill, 100)
+	}
+
+	ve.maxItems("experience", len(d.Experience), maxListLength)
+	for i, exp := range d.Experience {
+		prefix := fmt.Sprintf("experience[%d]", i)
+		ve.required(prefix+".role", exp.Role)
+		ve.required(prefix+".company", exp.Company)
+		validatePoints(ve, prefix, exp.Points)
+	}
+
+	ve.maxLength("education.details", d.Education.Details, maxTextLength)
+
+	for i, cert := range d.Certifications {
+		ve.required(fmt.Sprintf("certifications[%d].name", i), cert.Name)
+	}
+
+	ve.maxItems("projects", len(d.Projects), maxListLength)
+	for i, project := range d.Projects {
+		prefix := fmt.Sprintf("projects[%d]", i)
+		ve.required(prefix+".name", project.Name)
+		validatePoints(ve, prefix, project.Points)
+	}
+
+	for i, lang := range d.Languages {
+		ve.required(fmt.Sprintf("languages[%d].name", i), lang.Name)
+	}
+
+	for i, vol := range d.Volunteer {
+		prefix := fmt.Sprintf("volunteer[%d]", i)
+		ve.required(prefix+".role", vol.Role)
+		ve.required(prefix+".organization", vol.Organization)
+		validatePoints(ve, prefix, vol.Points)
+	}
+
+	for i, award := range d.Awards {
+		ve.required(fmt.Sprintf("awards[%d].name", i), award.Name)
+	}
+
+	if len(ve.Errors) > 0 {
+		return ve
+	}
+	return nil
+}
+
+// validatePoints checks the bullet points of an entry
+func validatePoints(ve *ValidationError, prefix string, points []string) {
+	ve.maxItems(prefix+".points", len(points), maxListLength)
+	for j, point := range points {
+		ve.maxLength(fmt.Sprintf("%s.points[%d]", prefix, j), point, maxTextLength)
+	}
+}